@@ -43,6 +43,7 @@ const (
 	metadataEncodeBase64 = "encodeBase64"
 	metadataFilePath     = "filePath"
 	metadataPresignTTL   = "presignTTL"
+	metadataPartSize     = "partSize"
 
 	metadataKey = "key"
 
@@ -73,6 +74,49 @@ type s3Metadata struct {
 	InsecureSSL    bool   `json:"insecureSSL,string"`
 	FilePath       string
 	PresignTTL     string
+	// PartSize is the size of the parts of the multipart uploads, 5 MiB by default.
+	// The objects smaller than a part are uploaded in one request.
+	PartSize int64 `json:"partSize,string"`
+	// StrictMetadata rejects the requests with unknown metadata, instead of logging a warning.
+	StrictMetadata bool `json:"strictMetadata,string"`
+}
+
+// keyRequestMetadata is the request metadata of the operations on an object.
+type keyRequestMetadata struct {
+	Key string `mapstructure:"key"`
+}
+
+type createRequestMetadata struct {
+	keyRequestMetadata `mapstructure:",squash"`
+	DecodeBase64       bool   `mapstructure:"decodeBase64"`
+	FilePath           string `mapstructure:"filePath"`
+	PresignTTL         string `mapstructure:"presignTTL"`
+	PartSize           int64  `mapstructure:"partSize"`
+}
+
+type getRequestMetadata struct {
+	keyRequestMetadata `mapstructure:",squash"`
+	EncodeBase64       bool   `mapstructure:"encodeBase64"`
+	Range              string `mapstructure:"range"`
+}
+
+type getResponseMetadata struct {
+	// ContentLength is only set by InvokeStream, when the object isn't encoded in base64.
+	ContentLength int64 `mapstructure:"contentLength"`
+}
+
+type presignRequestMetadata struct {
+	keyRequestMetadata `mapstructure:",squash"`
+	PresignTTL         string `mapstructure:"presignTTL"`
+}
+
+//nolint:gochecknoglobals
+var operationsMetadata = map[bindings.OperationKind]bindings.OperationMetadata{
+	bindings.CreateOperation: bindings.NewOperationMetadata(bindings.DataFormatBytes, createRequestMetadata{}, bindings.DataFormatJSON, nil),
+	bindings.GetOperation:    bindings.NewOperationMetadata(bindings.DataFormatNone, getRequestMetadata{}, bindings.DataFormatBytes, getResponseMetadata{}),
+	bindings.DeleteOperation: bindings.NewOperationMetadata(bindings.DataFormatNone, keyRequestMetadata{}, bindings.DataFormatNone, nil),
+	bindings.ListOperation:   bindings.NewOperationMetadata(bindings.DataFormatJSON, nil, bindings.DataFormatJSON, nil),
+	presignOperation:         bindings.NewOperationMetadata(bindings.DataFormatNone, presignRequestMetadata{}, bindings.DataFormatJSON, nil),
 }
 
 type createResponse struct {
@@ -85,15 +129,8 @@ type presignResponse struct {
 	PresignURL string `json:"presignURL"`
 }
 
-type listPayload struct {
-	Marker     string `json:"marker"`
-	Prefix     string `json:"prefix"`
-	MaxResults int32  `json:"maxResults"`
-	Delimiter  string `json:"delimiter"`
-}
-
 // NewAWSS3 returns a new AWSS3 instance.
-func NewAWSS3(logger logger.Logger) bindings.OutputBinding {
+func NewAWSS3(logger logger.Logger) bindings.StreamingOutputBinding {
 	return &AWSS3{logger: logger}
 }
 
@@ -147,6 +184,11 @@ func (s *AWSS3) Operations() []bindings.OperationKind {
 	}
 }
 
+// GetOperationsMetadata returns the description of the operations.
+func (s *AWSS3) GetOperationsMetadata() map[bindings.OperationKind]bindings.OperationMetadata {
+	return operationsMetadata
+}
+
 func (s *AWSS3) create(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
 	metadata, err := s.metadata.mergeWithRequestMetadata(req)
 	if err != nil {
@@ -165,14 +207,27 @@ func (s *AWSS3) create(ctx context.Context, req *bindings.InvokeRequest) (*bindi
 		req.Data = []byte(d)
 	}
 
-	var r io.Reader
+	res, err := s.upload(ctx, metadata, key, bytes.NewReader(req.Data))
+	if err != nil {
+		return nil, err
+	}
+
+	return &bindings.InvokeResponse{
+		Data: res,
+	}, nil
+}
+
+// upload uploads an object, in several parts if it is larger than the part size, and returns the JSON create response.
+// The object is read from the file path when it is set, instead of from data.
+func (s *AWSS3) upload(ctx context.Context, metadata s3Metadata, key string, data io.Reader) ([]byte, error) {
+	r := data
 	if metadata.FilePath != "" {
-		r, err = os.Open(metadata.FilePath)
+		f, err := os.Open(metadata.FilePath)
 		if err != nil {
 			return nil, fmt.Errorf("s3 file read error: %s", err)
 		}
-	} else {
-		r = bytes.NewReader(req.Data)
+		defer f.Close()
+		r = f
 	}
 
 	if metadata.DecodeBase64 {
@@ -183,6 +238,10 @@ func (s *AWSS3) create(ctx context.Context, req *bindings.InvokeRequest) (*bindi
 		Bucket: aws.String(metadata.Bucket),
 		Key:    aws.String(key),
 		Body:   r,
+	}, func(u *s3manager.Uploader) {
+		if metadata.PartSize > 0 {
+			u.PartSize = metadata.PartSize
+		}
 	})
 	if err != nil {
 		return nil, fmt.Errorf("s3 binding error: Uploading: %w", err)
@@ -207,9 +266,7 @@ func (s *AWSS3) create(ctx context.Context, req *bindings.InvokeRequest) (*bindi
 		return nil, fmt.Errorf("s3 binding error: Error marshalling create response: %w", err)
 	}
 
-	return &bindings.InvokeResponse{
-		Data: jsonResponse,
-	}, nil
+	return jsonResponse, nil
 }
 
 func (s *AWSS3) presign(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
@@ -276,15 +333,22 @@ func (s *AWSS3) get(ctx context.Context, req *bindings.InvokeRequest) (*bindings
 		return nil, fmt.Errorf("s3 binding error: can't read key value")
 	}
 
+	byteRange, err := bindings.ParseByteRange(req.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("s3 binding error: %w", err)
+	}
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(s.metadata.Bucket),
+		Key:    aws.String(key),
+	}
+	if byteRange != nil {
+		// The downloader gets the range in one request
+		input.Range = aws.String(byteRange.HTTPHeader())
+	}
+
 	buff := &aws.WriteAtBuffer{}
 
-	_, err = s.downloader.DownloadWithContext(ctx,
-		buff,
-		&s3.GetObjectInput{
-			Bucket: aws.String(s.metadata.Bucket),
-			Key:    aws.String(key),
-		},
-	)
+	_, err = s.downloader.DownloadWithContext(ctx, buff, input)
 	if err != nil {
 		return nil, fmt.Errorf("s3 binding error: error downloading S3 object: %w", err)
 	}
@@ -303,6 +367,98 @@ func (s *AWSS3) get(ctx context.Context, req *bindings.InvokeRequest) (*bindings
 	}, nil
 }
 
+// getStream returns the object, or its range, as it is downloaded.
+func (s *AWSS3) getStream(ctx context.Context, req *bindings.StreamInvokeRequest) (*bindings.StreamInvokeResponse, error) {
+	metadata, err := s.metadata.mergeWithRequestMetadata(&bindings.InvokeRequest{Metadata: req.Metadata})
+	if err != nil {
+		return nil, fmt.Errorf("s3 binding error. error merge metadata : %w", err)
+	}
+
+	key := req.Metadata[metadataKey]
+	if key == "" {
+		return nil, fmt.Errorf("s3 binding error: can't read key value")
+	}
+
+	byteRange, err := bindings.ParseByteRange(req.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("s3 binding error: %w", err)
+	}
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(s.metadata.Bucket),
+		Key:    aws.String(key),
+	}
+	if byteRange != nil {
+		input.Range = aws.String(byteRange.HTTPHeader())
+	}
+
+	output, err := s.s3Client.GetObjectWithContext(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("s3 binding error: error downloading S3 object: %w", err)
+	}
+
+	res := &bindings.StreamInvokeResponse{
+		Data:        output.Body,
+		Metadata:    map[string]string{},
+		ContentType: output.ContentType,
+	}
+	if metadata.EncodeBase64 {
+		pr, pw := io.Pipe()
+		go func() {
+			enc := b64.NewEncoder(b64.StdEncoding, pw)
+			_, copyErr := io.Copy(enc, output.Body)
+			if copyErr == nil {
+				copyErr = enc.Close()
+			}
+			output.Body.Close()
+			pw.CloseWithError(copyErr)
+		}()
+		res.Data = pr
+	} else if output.ContentLength != nil {
+		res.Metadata[bindings.ContentLengthMetadataKey] = strconv.FormatInt(*output.ContentLength, 10)
+	}
+
+	return res, nil
+}
+
+// InvokeStream streams the objects of the create and get operations, and buffers the data of the other operations.
+// Unlike Invoke, the create operation doesn't unquote the data.
+func (s *AWSS3) InvokeStream(ctx context.Context, req *bindings.StreamInvokeRequest) (*bindings.StreamInvokeResponse, error) {
+	switch req.Operation {
+	case bindings.CreateOperation, bindings.GetOperation:
+		err := bindings.CheckRequestMetadata(s, req.Operation, req.Metadata, s.metadata.StrictMetadata, s.logger)
+		if err != nil {
+			return nil, fmt.Errorf("s3 binding error: %w", err)
+		}
+	}
+
+	switch req.Operation {
+	case bindings.CreateOperation:
+		metadata, err := s.metadata.mergeWithRequestMetadata(&bindings.InvokeRequest{Metadata: req.Metadata})
+		if err != nil {
+			return nil, fmt.Errorf("s3 binding error: error merging metadata: %w", err)
+		}
+		key := req.Metadata[metadataKey]
+		if key == "" {
+			key = uuid.New().String()
+			s.logger.Debugf("s3 binding: key not found. generating key %s", key)
+		}
+		data := req.Data
+		if data == nil {
+			data = bytes.NewReader(nil)
+		}
+
+		res, err := s.upload(ctx, metadata, key, data)
+		if err != nil {
+			return nil, err
+		}
+		return &bindings.StreamInvokeResponse{Data: io.NopCloser(bytes.NewReader(res))}, nil
+	case bindings.GetOperation:
+		return s.getStream(ctx, req)
+	default:
+		return bindings.BufferedInvokeStream(ctx, s, req)
+	}
+}
+
 func (s *AWSS3) delete(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
 	var key string
 	if val, ok := req.Metadata[metadataKey]; ok && val != "" {
@@ -323,28 +479,48 @@ func (s *AWSS3) delete(ctx context.Context, req *bindings.InvokeRequest) (*bindi
 }
 
 func (s *AWSS3) list(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
-	var payload listPayload
-	err := json.Unmarshal(req.Data, &payload)
+	listReq, err := bindings.ParseListRequest(req.Data, maxResults)
 	if err != nil {
 		return nil, err
 	}
 
-	if payload.MaxResults == int32(0) {
-		payload.MaxResults = maxResults
-	}
-
 	result, err := s.s3Client.ListObjectsWithContext(ctx, &s3.ListObjectsInput{
 		Bucket:    aws.String(s.metadata.Bucket),
-		MaxKeys:   aws.Int64(int64(payload.MaxResults)),
-		Marker:    aws.String(payload.Marker),
-		Prefix:    aws.String(payload.Prefix),
-		Delimiter: aws.String(payload.Delimiter),
+		MaxKeys:   aws.Int64(int64(listReq.PageSize)),
+		Marker:    aws.String(listReq.ContinuationToken),
+		Prefix:    aws.String(listReq.Prefix),
+		Delimiter: aws.String(listReq.Delimiter),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("s3 binding error. list operation. cannot marshal blobs to json: %w", err)
+		return nil, fmt.Errorf("s3 binding error. list operation. cannot list objects: %w", err)
+	}
+
+	res := &bindings.ListResponse{Items: make([]bindings.ListItem, 0, len(result.Contents))}
+	last := ""
+	for _, object := range result.Contents {
+		res.Items = append(res.Items, bindings.ListItem{
+			Name:         aws.StringValue(object.Key),
+			Size:         aws.Int64Value(object.Size),
+			LastModified: object.LastModified,
+			ETag:         aws.StringValue(object.ETag),
+		})
+		last = aws.StringValue(object.Key)
+	}
+	for _, prefix := range result.CommonPrefixes {
+		res.Prefixes = append(res.Prefixes, aws.StringValue(prefix.Prefix))
+		if aws.StringValue(prefix.Prefix) > last {
+			last = aws.StringValue(prefix.Prefix)
+		}
+	}
+	if aws.BoolValue(result.IsTruncated) {
+		// S3 only returns the next marker when a delimiter is set
+		res.ContinuationToken = aws.StringValue(result.NextMarker)
+		if res.ContinuationToken == "" {
+			res.ContinuationToken = last
+		}
 	}
 
-	jsonResponse, err := json.Marshal(result)
+	jsonResponse, err := json.Marshal(res)
 	if err != nil {
 		return nil, fmt.Errorf("s3 binding error. list operation. cannot marshal blobs to json: %w", err)
 	}
@@ -355,6 +531,11 @@ func (s *AWSS3) list(ctx context.Context, req *bindings.InvokeRequest) (*binding
 }
 
 func (s *AWSS3) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	err := bindings.CheckRequestMetadata(s, req.Operation, req.Metadata, s.metadata.StrictMetadata, s.logger)
+	if err != nil {
+		return nil, fmt.Errorf("s3 binding error: %w", err)
+	}
+
 	switch req.Operation {
 	case bindings.CreateOperation:
 		return s.create(ctx, req)
@@ -415,5 +596,13 @@ func (metadata s3Metadata) mergeWithRequestMetadata(req *bindings.InvokeRequest)
 		merged.PresignTTL = val
 	}
 
+	if val, ok := req.Metadata[metadataPartSize]; ok && val != "" {
+		partSize, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return merged, fmt.Errorf("invalid %s %s: %w", metadataPartSize, val, err)
+		}
+		merged.PartSize = partSize
+	}
+
 	return merged, nil
 }
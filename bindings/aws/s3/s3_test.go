@@ -14,10 +14,23 @@ limitations under the License.
 package s3
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/JY29/components-contrib/bindings"
 	"github.com/dapr/kit/logger"
@@ -165,3 +178,250 @@ func TestDeleteOption(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+// fakeS3 is a local S3 stand-in for a bucket, supporting the requests of the uploads and the downloads.
+type fakeS3 struct {
+	lock    sync.Mutex
+	objects map[string][]byte
+	uploads map[string]map[int][]byte
+	parts   int
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/bucket"), "/")
+	query := r.URL.Query()
+	body, _ := io.ReadAll(r.Body)
+
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.uploads["upload-1"] = map[int][]byte{}
+		fmt.Fprintf(w, `<InitiateMultipartUploadResult><Bucket>bucket</Bucket><Key>%s</Key><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>`, key)
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		n, _ := strconv.Atoi(query.Get("partNumber"))
+		f.uploads[query.Get("uploadId")][n] = body
+		f.parts++
+		w.Header().Set("ETag", fmt.Sprintf(`"etag-%d"`, n))
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		parts := f.uploads[query.Get("uploadId")]
+		var object []byte
+		for i := 1; i <= len(parts); i++ {
+			object = append(object, parts[i]...)
+		}
+		f.objects[key] = object
+		delete(f.uploads, query.Get("uploadId"))
+		fmt.Fprintf(w, `<CompleteMultipartUploadResult><Location>http://%s/bucket/%s</Location><Bucket>bucket</Bucket><Key>%s</Key><ETag>"etag"</ETag></CompleteMultipartUploadResult>`, r.Host, key, key)
+	case r.Method == http.MethodPut:
+		f.objects[key] = body
+		w.Header().Set("ETag", `"etag"`)
+	case r.Method == http.MethodGet && key == "":
+		f.list(w, query)
+	case r.Method == http.MethodGet:
+		object, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchKey</Code></Error>`)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		if rng := r.Header.Get("Range"); rng != "" {
+			var start, end int
+			if _, err := fmt.Sscanf(rng, "bytes=%d-%d", &start, &end); err != nil || end >= len(object) {
+				end = len(object) - 1
+			}
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(object)))
+			w.Header().Set("Content-Length", strconv.Itoa(end-start+1))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(object[start : end+1])
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(object)))
+		w.Write(object)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+// list lists the objects in lexicographical order, without grouping them by delimiter.
+func (f *fakeS3) list(w http.ResponseWriter, query url.Values) {
+	keys := make([]string, 0, len(f.objects))
+	for k := range f.objects {
+		if strings.HasPrefix(k, query.Get("prefix")) && k > query.Get("marker") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	maxKeys, _ := strconv.Atoi(query.Get("max-keys"))
+	truncated := maxKeys > 0 && len(keys) > maxKeys
+	if truncated {
+		keys = keys[:maxKeys]
+	}
+
+	fmt.Fprintf(w, `<ListBucketResult><Name>bucket</Name><IsTruncated>%t</IsTruncated>`, truncated)
+	for _, k := range keys {
+		fmt.Fprintf(w, `<Contents><Key>%s</Key><Size>%d</Size><ETag>"etag"</ETag><LastModified>2023-01-02T03:04:05.000Z</LastModified></Contents>`, k, len(f.objects[k]))
+	}
+	fmt.Fprint(w, `</ListBucketResult>`)
+}
+
+func newFakeS3Binding(t *testing.T) (*AWSS3, *fakeS3) {
+	fake := &fakeS3{objects: map[string][]byte{}, uploads: map[string]map[int][]byte{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	s := NewAWSS3(logger.NewLogger("s3")).(*AWSS3)
+	m := bindings.Metadata{}
+	m.Properties = map[string]string{
+		"accessKey": "key", "secretKey": "secret", "region": "us-east-1", "bucket": "bucket",
+		"endpoint": server.URL, "forcePathStyle": "true", "disableSSL": "true",
+	}
+	require.NoError(t, s.Init(m))
+
+	return s, fake
+}
+
+func TestInvokeStream(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("multipart create and get", func(t *testing.T) {
+		s, fake := newFakeS3Binding(t)
+		// Larger than 2 parts of the minimum size
+		object := bytes.Repeat([]byte("0123456789"), 1200*1024)
+
+		res, err := s.InvokeStream(ctx, &bindings.StreamInvokeRequest{
+			Operation: bindings.CreateOperation,
+			Data:      bytes.NewReader(object),
+			Metadata:  map[string]string{metadataKey: "large", metadataPartSize: strconv.Itoa(5 * 1024 * 1024)},
+		})
+		require.NoError(t, err)
+		b, err := io.ReadAll(res.Data)
+		require.NoError(t, err)
+		assert.Contains(t, string(b), "/bucket/large")
+		assert.Equal(t, 3, fake.parts)
+		assert.Equal(t, object, fake.objects["large"])
+
+		res, err = s.InvokeStream(ctx, &bindings.StreamInvokeRequest{
+			Operation: bindings.GetOperation,
+			Metadata:  map[string]string{metadataKey: "large"},
+		})
+		require.NoError(t, err)
+		defer res.Data.Close()
+		b, err = io.ReadAll(res.Data)
+		require.NoError(t, err)
+		assert.Equal(t, object, b)
+		assert.Equal(t, strconv.Itoa(len(object)), res.Metadata[bindings.ContentLengthMetadataKey])
+	})
+
+	t.Run("range get", func(t *testing.T) {
+		s, fake := newFakeS3Binding(t)
+		fake.objects["small"] = []byte("hello world")
+
+		res, err := s.InvokeStream(ctx, &bindings.StreamInvokeRequest{
+			Operation: bindings.GetOperation,
+			Metadata:  map[string]string{metadataKey: "small", bindings.RangeMetadataKey: "6-10"},
+		})
+		require.NoError(t, err)
+		b, err := io.ReadAll(res.Data)
+		require.NoError(t, err)
+		res.Data.Close()
+		assert.Equal(t, "world", string(b))
+		assert.Equal(t, "5", res.Metadata[bindings.ContentLengthMetadataKey])
+
+		res, err = s.InvokeStream(ctx, &bindings.StreamInvokeRequest{
+			Operation: bindings.GetOperation,
+			Metadata:  map[string]string{metadataKey: "small", bindings.RangeMetadataKey: "0-4", metadataEncodeBase64: "true"},
+		})
+		require.NoError(t, err)
+		b, err = io.ReadAll(res.Data)
+		require.NoError(t, err)
+		res.Data.Close()
+		assert.Equal(t, "aGVsbG8=", string(b))
+
+		// The range also applies to the get operation of Invoke
+		invokeRes, err := s.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: bindings.GetOperation,
+			Metadata:  map[string]string{metadataKey: "small", bindings.RangeMetadataKey: "0-4"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(invokeRes.Data))
+	})
+
+	t.Run("small create is a single request", func(t *testing.T) {
+		s, fake := newFakeS3Binding(t)
+
+		_, err := s.InvokeStream(ctx, &bindings.StreamInvokeRequest{
+			Operation: bindings.CreateOperation,
+			Data:      strings.NewReader(`"quoted"`),
+			Metadata:  map[string]string{metadataKey: "small"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 0, fake.parts)
+		// Unlike Invoke, the data is not unquoted
+		assert.Equal(t, `"quoted"`, string(fake.objects["small"]))
+	})
+}
+
+func TestList(t *testing.T) {
+	s, fake := newFakeS3Binding(t)
+	fake.objects["a/1"] = []byte("1")
+	fake.objects["a/22"] = []byte("22")
+	fake.objects["b"] = []byte("b")
+
+	list := func(data string) bindings.ListResponse {
+		res, err := s.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: bindings.ListOperation,
+			Data:      []byte(data),
+		})
+		require.NoError(t, err)
+		var listRes bindings.ListResponse
+		require.NoError(t, json.Unmarshal(res.Data, &listRes))
+
+		return listRes
+	}
+
+	res := list(`{"prefix": "a/", "pageSize": 1}`)
+	require.Len(t, res.Items, 1)
+	assert.Equal(t, "a/1", res.Items[0].Name)
+	assert.Equal(t, int64(1), res.Items[0].Size)
+	assert.Equal(t, time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC), *res.Items[0].LastModified)
+	assert.Equal(t, "a/1", res.ContinuationToken)
+
+	res = list(`{"prefix": "a/", "pageSize": 1, "continuationToken": "a/1"}`)
+	require.Len(t, res.Items, 1)
+	assert.Equal(t, "a/22", res.Items[0].Name)
+	assert.Equal(t, int64(2), res.Items[0].Size)
+	assert.Empty(t, res.ContinuationToken)
+
+	// The previous payload is still accepted
+	res = list(`{"maxResults": 2, "marker": "a/1"}`)
+	require.Len(t, res.Items, 2)
+	assert.Equal(t, "b", res.Items[1].Name)
+	assert.Empty(t, res.ContinuationToken)
+
+	// An empty request lists all the objects
+	res = list(``)
+	assert.Len(t, res.Items, 3)
+}
+
+func TestStrictMetadata(t *testing.T) {
+	s, fake := newFakeS3Binding(t)
+	fake.objects["object"] = []byte("hello")
+	s.metadata.StrictMetadata = true
+
+	res, err := s.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: bindings.GetOperation,
+		Metadata:  map[string]string{metadataKey: "object", metadataEncodeBase64: "false"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(res.Data))
+
+	_, err = s.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: bindings.GetOperation,
+		Metadata:  map[string]string{metadataKey: "object", metadataPresignTTL: "1m"},
+	})
+	assert.ErrorIs(t, err, bindings.ErrUnknownMetadata)
+	assert.ErrorContains(t, err, metadataPresignTTL)
+}
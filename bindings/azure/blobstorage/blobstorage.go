@@ -31,7 +31,6 @@ import (
 	"github.com/JY29/components-contrib/bindings"
 	storageinternal "github.com/JY29/components-contrib/internal/component/azure/blobstorage"
 	"github.com/dapr/kit/logger"
-	"github.com/dapr/kit/ptr"
 )
 
 const (
@@ -79,11 +78,9 @@ type listInclude struct {
 	Deleted          bool `json:"deleted"`
 }
 
+// listPayload holds the options of the list operation specific to Azure Blob Storage, in addition to the ones of bindings.ListRequest.
 type listPayload struct {
-	Marker     string      `json:"marker"`
-	Prefix     string      `json:"prefix"`
-	MaxResults int32       `json:"maxResults"`
-	Include    listInclude `json:"include"`
+	Include listInclude `json:"include"`
 }
 
 // NewAzureBlobStorage returns a new Azure Blob Storage instance.
@@ -248,79 +245,122 @@ func (a *AzureBlobStorage) delete(ctx context.Context, req *bindings.InvokeReque
 }
 
 func (a *AzureBlobStorage) list(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
-	options := container.ListBlobsFlatOptions{}
-
-	hasPayload := false
+	listReq, err := bindings.ParseListRequest(req.Data, maxResults)
+	if err != nil {
+		return nil, err
+	}
 	var payload listPayload
-	if req.Data != nil {
-		err := json.Unmarshal(req.Data, &payload)
-		if err != nil {
+	if len(req.Data) > 0 {
+		if err = json.Unmarshal(req.Data, &payload); err != nil {
 			return nil, err
 		}
-		hasPayload = true
-	}
-	if hasPayload {
-		options.Include.Copy = payload.Include.Copy
-		options.Include.Metadata = payload.Include.Metadata
-		options.Include.Snapshots = payload.Include.Snapshots
-		options.Include.UncommittedBlobs = payload.Include.UncommittedBlobs
-		options.Include.Deleted = payload.Include.Deleted
 	}
 
-	if hasPayload && payload.MaxResults > 0 {
-		options.MaxResults = &payload.MaxResults
-	} else {
-		options.MaxResults = ptr.Of(maxResults) // cannot get address of constant directly
+	include := container.ListBlobsInclude{
+		Copy:             payload.Include.Copy,
+		Metadata:         payload.Include.Metadata,
+		Snapshots:        payload.Include.Snapshots,
+		UncommittedBlobs: payload.Include.UncommittedBlobs,
+		Deleted:          payload.Include.Deleted,
 	}
-
-	if hasPayload && payload.Prefix != "" {
-		options.Prefix = &payload.Prefix
+	var prefix *string
+	if listReq.Prefix != "" {
+		prefix = &listReq.Prefix
 	}
-
-	var initialMarker string
-	if hasPayload && payload.Marker != "" {
-		initialMarker = payload.Marker
-	} else {
-		initialMarker = ""
+	var marker *string
+	if listReq.ContinuationToken != "" {
+		marker = &listReq.ContinuationToken
 	}
-	options.Marker = &initialMarker
-
-	metadata := map[string]string{}
-	blobs := []*container.BlobItem{}
-	pager := a.containerClient.NewListBlobsFlatPager(&options)
 
-	for pager.More() {
+	// A single page is returned, the service may return less items than the page size with a continuation token
+	var (
+		blobs      []*container.BlobItem
+		nextMarker *string
+	)
+	res := &bindings.ListResponse{}
+	if listReq.Delimiter == "" {
+		pager := a.containerClient.NewListBlobsFlatPager(&container.ListBlobsFlatOptions{
+			Include:    include,
+			Marker:     marker,
+			MaxResults: &listReq.PageSize,
+			Prefix:     prefix,
+		})
 		resp, err := pager.NextPage(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("error listing blobs: %w", err)
 		}
-
-		blobs = append(blobs, resp.Segment.BlobItems...)
-		numBlobs := len(blobs)
-		metadata[metadataKeyNumber] = strconv.FormatInt(int64(numBlobs), 10)
-		metadata[metadataKeyMarker] = ""
-		if resp.Marker != nil {
-			metadata[metadataKeyMarker] = *resp.Marker
+		blobs = resp.Segment.BlobItems
+		nextMarker = resp.NextMarker
+	} else {
+		pager := a.containerClient.NewListBlobsHierarchyPager(listReq.Delimiter, &container.ListBlobsHierarchyOptions{
+			Include:    include,
+			Marker:     marker,
+			MaxResults: &listReq.PageSize,
+			Prefix:     prefix,
+		})
+		resp, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error listing blobs: %w", err)
 		}
-
-		if *options.MaxResults-maxResults > 0 {
-			*options.MaxResults -= maxResults
-		} else {
-			break
+		blobs = resp.Segment.BlobItems
+		for _, p := range resp.Segment.BlobPrefixes {
+			res.Prefixes = append(res.Prefixes, stringValue(p.Name))
 		}
+		nextMarker = resp.NextMarker
 	}
 
-	jsonResponse, err := json.Marshal(blobs)
+	res.Items = make([]bindings.ListItem, 0, len(blobs))
+	for _, blob := range blobs {
+		res.Items = append(res.Items, listItem(blob))
+	}
+	res.ContinuationToken = stringValue(nextMarker)
+
+	jsonResponse, err := json.Marshal(res)
 	if err != nil {
 		return nil, fmt.Errorf("cannot marshal blobs to json: %w", err)
 	}
 
 	return &bindings.InvokeResponse{
-		Data:     jsonResponse,
-		Metadata: metadata,
+		Data: jsonResponse,
+		Metadata: map[string]string{
+			metadataKeyNumber: strconv.Itoa(len(res.Items)),
+			metadataKeyMarker: res.ContinuationToken,
+		},
 	}, nil
 }
 
+func listItem(blob *container.BlobItem) bindings.ListItem {
+	item := bindings.ListItem{
+		Name: stringValue(blob.Name),
+	}
+	if props := blob.Properties; props != nil {
+		if props.ContentLength != nil {
+			item.Size = *props.ContentLength
+		}
+		item.LastModified = props.LastModified
+		item.ContentType = stringValue(props.ContentType)
+		if props.ETag != nil {
+			item.ETag = string(*props.ETag)
+		}
+	}
+	if len(blob.Metadata) > 0 {
+		item.Metadata = make(map[string]string, len(blob.Metadata))
+		for k, v := range blob.Metadata {
+			item.Metadata[k] = stringValue(v)
+		}
+	}
+
+	return item
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
+
 func (a *AzureBlobStorage) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
 	switch req.Operation {
 	case bindings.CreateOperation:
@@ -15,36 +15,103 @@ package cron
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/benbjohnson/clock"
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 
 	cron "github.com/dapr/kit/cron"
 
 	"github.com/JY29/components-contrib/bindings"
+	"github.com/JY29/components-contrib/lock"
+	"github.com/JY29/components-contrib/metadata"
+	"github.com/JY29/components-contrib/state"
 	"github.com/dapr/kit/logger"
 )
 
+const (
+	defaultMaxCatchUpRuns = 10
+	defaultLockTTL        = time.Minute
+
+	// Metadata of the events.
+	scheduledTimeMetadataKey = "scheduledTime"
+	catchUpMetadataKey       = "catchUp"
+)
+
 // Binding represents Cron input binding.
 type Binding struct {
 	logger   logger.Logger
 	name     string
 	schedule string
+	metadata cronMetadata
+	sched    cron.Schedule
+	location *time.Location
 	parser   cron.Parser
 	clk      clock.Clock
+	opts     Options
+	// owner identifies the binding in the locks of the runs.
+	owner string
+
+	lock    sync.Mutex
+	lastRun time.Time
+}
+
+type cronMetadata struct {
+	Schedule string
+	// Timezone is the IANA name of the timezone of the schedule, the local timezone by default.
+	Timezone string
+	// Jitter is the maximum random delay of each run.
+	Jitter time.Duration
+	// CatchUp replays the runs missed since the last run persisted in the state store when the binding starts.
+	CatchUp bool
+	// MaxCatchUpRuns is the maximum number of missed runs replayed, the most recent ones.
+	MaxCatchUpRuns int
+	// StateKey is the key of the last run in the state store, derived from the name of the component by default.
+	StateKey string
+	// LockTTL is how long the lock of a run is held, which must be longer than the jitter and the clock skew of the replicas.
+	LockTTL time.Duration
+	// StateStoreName is the name of the state store persisting the last run, resolved with Options.StateStores.
+	StateStoreName string
+	// LockStoreName is the name of the lock store electing the replica firing each run, resolved with Options.LockStores.
+	LockStoreName string
+}
+
+// Options holds the optional dependencies of the binding.
+// The stores are either given directly, or named by the stateStoreName and lockStoreName metadata and resolved at Init.
+type Options struct {
+	// StateStore persists the time of the last run, which is required to catch up with the missed runs.
+	StateStore state.Store
+	// LockStore elects the replica firing each run, when several replicas share the same schedule.
+	LockStore lock.Store
+	// StateStores resolves the state store named by the stateStoreName metadata.
+	StateStores state.StoreResolver
+	// LockStores resolves the lock store named by the lockStoreName metadata.
+	LockStores lock.StoreResolver
 }
 
 // NewCron returns a new Cron event input binding.
+// Catching up and electing a replica need stores, which are given with NewCronWithOptions.
 func NewCron(logger logger.Logger) bindings.InputBinding {
 	return NewCronWithClock(logger, clock.New())
 }
 
 func NewCronWithClock(logger logger.Logger, clk clock.Clock) bindings.InputBinding {
+	return NewCronWithOptions(logger, clk, Options{})
+}
+
+// NewCronWithOptions returns a new Cron event input binding using the given stores.
+func NewCronWithOptions(logger logger.Logger, clk clock.Clock, opts Options) bindings.InputBinding {
 	return &Binding{
 		logger: logger,
 		clk:    clk,
+		opts:   opts,
+		owner:  uuid.New().String(),
 		parser: cron.NewParser(
 			cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
 		),
@@ -56,39 +123,91 @@ func NewCronWithClock(logger logger.Logger, clk clock.Clock) bindings.InputBindi
 //
 //	"15 * * * * *" - Every 15 sec
 //	"0 30 * * * *" - Every 30 min
-func (b *Binding) Init(metadata bindings.Metadata) error {
-	b.name = metadata.Name
-	s, f := metadata.Properties["schedule"]
-	if !f || s == "" {
+func (b *Binding) Init(meta bindings.Metadata) error {
+	b.name = meta.Name
+	m := cronMetadata{
+		MaxCatchUpRuns: defaultMaxCatchUpRuns,
+		LockTTL:        defaultLockTTL,
+	}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return err
+	}
+
+	if m.Schedule == "" {
 		return fmt.Errorf("schedule not set")
 	}
-	_, err := b.parser.Parse(s)
+	b.sched, err = b.parser.Parse(m.Schedule)
 	if err != nil {
-		return errors.Wrapf(err, "invalid schedule format: %s", s)
+		return errors.Wrapf(err, "invalid schedule format: %s", m.Schedule)
+	}
+	b.schedule = m.Schedule
+
+	b.location = time.Local
+	if m.Timezone != "" {
+		b.location, err = time.LoadLocation(m.Timezone)
+		if err != nil {
+			return errors.Wrapf(err, "invalid timezone: %s", m.Timezone)
+		}
+	}
+
+	if m.Jitter < 0 {
+		return fmt.Errorf("invalid jitter: %v", m.Jitter)
+	}
+	if m.StateStoreName != "" {
+		if b.opts.StateStores == nil {
+			return fmt.Errorf("stateStoreName requires a state store resolver")
+		}
+		var ok bool
+		b.opts.StateStore, ok = b.opts.StateStores(m.StateStoreName)
+		if !ok {
+			return fmt.Errorf("state store %s not found", m.StateStoreName)
+		}
+	}
+	if m.LockStoreName != "" {
+		if b.opts.LockStores == nil {
+			return fmt.Errorf("lockStoreName requires a lock store resolver")
+		}
+		var ok bool
+		b.opts.LockStore, ok = b.opts.LockStores(m.LockStoreName)
+		if !ok {
+			return fmt.Errorf("lock store %s not found", m.LockStoreName)
+		}
+	}
+	if m.CatchUp && b.opts.StateStore == nil {
+		return fmt.Errorf("catchUp requires a state store")
+	}
+	if m.StateKey == "" {
+		m.StateKey = "cron-" + b.name + "-last-run"
+	}
+	if b.opts.LockStore != nil && m.LockTTL <= m.Jitter {
+		return fmt.Errorf("lockTTL %v must be longer than the jitter %v", m.LockTTL, m.Jitter)
 	}
-	b.schedule = s
+	b.metadata = m
 
 	return nil
 }
 
 // Read triggers the Cron scheduler.
+// When catching up is enabled, the missed runs are replayed while the scheduler runs.
 func (b *Binding) Read(ctx context.Context, handler bindings.Handler) error {
-	c := cron.New(cron.WithParser(b.parser), cron.WithClock(b.clk))
+	c := cron.New(cron.WithParser(b.parser), cron.WithClock(b.clk), cron.WithLocation(b.location))
 	id, err := c.AddFunc(b.schedule, func() {
-		b.logger.Debugf("name: %s, schedule fired: %v", b.name, time.Now())
-		handler(ctx, &bindings.ReadResponse{
-			Metadata: map[string]string{
-				"timeZone":    c.Location().String(),
-				"readTimeUTC": time.Now().UTC().String(),
-			},
-		})
+		// The runs are on whole seconds, and the scheduler fires them as soon as they are due
+		b.fire(ctx, handler, b.clk.Now().In(b.location).Truncate(time.Second), false)
 	})
 	if err != nil {
 		return errors.Wrapf(err, "name: %s, error scheduling %s", b.name, b.schedule)
 	}
+
+	start := b.clk.Now()
 	c.Start()
 	b.logger.Debugf("name: %s, next run: %v", b.name, time.Until(c.Entry(id).Next))
 
+	if b.metadata.CatchUp {
+		go b.catchUp(ctx, handler, start)
+	}
+
 	go func() {
 		// Wait for context to be canceled
 		<-ctx.Done()
@@ -98,3 +217,111 @@ func (b *Binding) Read(ctx context.Context, handler bindings.Handler) error {
 
 	return nil
 }
+
+// catchUp replays the runs scheduled between the last persisted run and the start of the scheduler.
+func (b *Binding) catchUp(ctx context.Context, handler bindings.Handler, start time.Time) {
+	res, err := b.opts.StateStore.Get(ctx, &state.GetRequest{Key: b.metadata.StateKey})
+	if err != nil {
+		b.logger.Errorf("name: %s, error reading the last run: %v", b.name, err)
+		return
+	}
+	if res == nil || len(res.Data) == 0 {
+		// First start, nothing was missed
+		return
+	}
+	var last time.Time
+	if err = json.Unmarshal(res.Data, &last); err != nil {
+		b.logger.Errorf("name: %s, error decoding the last run: %v", b.name, err)
+		return
+	}
+
+	var missed []time.Time
+	skipped := 0
+	for t := b.sched.Next(last.In(b.location)); !t.IsZero() && !t.After(start); t = b.sched.Next(t) {
+		missed = append(missed, t)
+		if len(missed) > b.metadata.MaxCatchUpRuns {
+			missed = missed[1:]
+			skipped++
+		}
+	}
+	if skipped > 0 {
+		b.logger.Warnf("name: %s, skipping %d missed runs, more than %d", b.name, skipped, b.metadata.MaxCatchUpRuns)
+	}
+
+	for _, t := range missed {
+		if ctx.Err() != nil {
+			return
+		}
+		b.logger.Debugf("name: %s, replaying missed run: %v", b.name, t)
+		b.fire(ctx, handler, t, true)
+	}
+}
+
+// fire triggers the handler for a run, after the jitter, if the binding is elected for the run.
+func (b *Binding) fire(ctx context.Context, handler bindings.Handler, scheduled time.Time, catchUp bool) {
+	if b.metadata.Jitter > 0 && !catchUp {
+		//nolint:gosec
+		delay := time.Duration(rand.Int63n(int64(b.metadata.Jitter)))
+		select {
+		case <-b.clk.After(delay):
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	if b.opts.LockStore != nil {
+		res, err := b.opts.LockStore.TryLock(ctx, &lock.TryLockRequest{
+			ResourceID:      fmt.Sprintf("cron-%s-%d", b.name, scheduled.Unix()),
+			LockOwner:       b.owner,
+			ExpiryInSeconds: int32((b.metadata.LockTTL + time.Second - 1) / time.Second),
+		})
+		if err != nil {
+			b.logger.Errorf("name: %s, error locking the run %v: %v", b.name, scheduled, err)
+			return
+		}
+		if !res.Success {
+			b.logger.Debugf("name: %s, run %v fired by another replica", b.name, scheduled)
+			return
+		}
+	}
+
+	b.logger.Debugf("name: %s, schedule fired: %v", b.name, time.Now())
+	md := map[string]string{
+		"timeZone":               b.location.String(),
+		"readTimeUTC":            time.Now().UTC().String(),
+		scheduledTimeMetadataKey: scheduled.Format(time.RFC3339),
+	}
+	if catchUp {
+		md[catchUpMetadataKey] = strconv.FormatBool(true)
+	}
+	handler(ctx, &bindings.ReadResponse{
+		Metadata: md,
+	})
+
+	if b.opts.StateStore != nil {
+		b.saveLastRun(ctx, scheduled)
+	}
+}
+
+// saveLastRun persists the time of a run, unless a later run was persisted.
+func (b *Binding) saveLastRun(ctx context.Context, scheduled time.Time) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !scheduled.After(b.lastRun) {
+		return
+	}
+	data, err := json.Marshal(scheduled)
+	if err != nil {
+		return
+	}
+	err = b.opts.StateStore.Set(ctx, &state.SetRequest{
+		Key:   b.metadata.StateKey,
+		Value: data,
+	})
+	if err != nil {
+		b.logger.Errorf("name: %s, error saving the last run: %v", b.name, err)
+		return
+	}
+	b.lastRun = scheduled
+}
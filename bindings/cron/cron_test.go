@@ -15,14 +15,20 @@ package cron
 
 import (
 	"context"
+	"encoding/json"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/benbjohnson/clock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/JY29/components-contrib/bindings"
+	"github.com/JY29/components-contrib/lock"
+	"github.com/JY29/components-contrib/state"
+	inmemory "github.com/JY29/components-contrib/state/in-memory"
 	"github.com/dapr/kit/logger"
 )
 
@@ -145,3 +151,267 @@ func TestCronReadWithContextCancellation(t *testing.T) {
 	assert.Equal(t, expectedCount, observedCount, "Cron did not trigger expected number of times, expected %d, got %d", expectedCount, observedCount)
 	assert.NoErrorf(t, err, "error on read")
 }
+
+func TestCronInitOptions(t *testing.T) {
+	initTests := []struct {
+		name          string
+		properties    map[string]string
+		opts          Options
+		errorExpected bool
+	}{
+		{
+			name:       "timezone",
+			properties: map[string]string{"schedule": "0 0 * * *", "timezone": "America/New_York"},
+		},
+		{
+			name:          "invalid timezone",
+			properties:    map[string]string{"schedule": "0 0 * * *", "timezone": "Mars/Olympus_Mons"},
+			errorExpected: true,
+		},
+		{
+			name:          "negative jitter",
+			properties:    map[string]string{"schedule": "@every 1s", "jitter": "-1s"},
+			errorExpected: true,
+		},
+		{
+			name:          "catch up without state store",
+			properties:    map[string]string{"schedule": "@every 1s", "catchUp": "true"},
+			errorExpected: true,
+		},
+		{
+			name:          "lock TTL shorter than the jitter",
+			properties:    map[string]string{"schedule": "@every 1s", "jitter": "2m"},
+			opts:          Options{LockStore: newFakeLockStore()},
+			errorExpected: true,
+		},
+		{
+			name:       "lock TTL longer than the jitter",
+			properties: map[string]string{"schedule": "@every 1s", "jitter": "2m", "lockTTL": "5m"},
+			opts:       Options{LockStore: newFakeLockStore()},
+		},
+		{
+			name:       "catch up with a named state store",
+			properties: map[string]string{"schedule": "@every 1s", "catchUp": "true", "stateStoreName": "statestore"},
+			opts: Options{StateStores: func(name string) (state.Store, bool) {
+				if name != "statestore" {
+					return nil, false
+				}
+				return inmemory.NewInMemoryStateStore(logger.NewLogger("test")), true
+			}},
+		},
+		{
+			name:          "unknown state store",
+			properties:    map[string]string{"schedule": "@every 1s", "catchUp": "true", "stateStoreName": "other"},
+			opts:          Options{StateStores: func(name string) (state.Store, bool) { return nil, false }},
+			errorExpected: true,
+		},
+		{
+			name:          "named state store without resolver",
+			properties:    map[string]string{"schedule": "@every 1s", "stateStoreName": "statestore"},
+			errorExpected: true,
+		},
+		{
+			name:       "named lock store",
+			properties: map[string]string{"schedule": "@every 1s", "lockStoreName": "lockstore"},
+			opts:       Options{LockStores: func(name string) (lock.Store, bool) { return newFakeLockStore(), name == "lockstore" }},
+		},
+		{
+			name:          "named lock store without resolver",
+			properties:    map[string]string{"schedule": "@every 1s", "lockStoreName": "lockstore"},
+			errorExpected: true,
+		},
+	}
+
+	for _, test := range initTests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewCronWithOptions(logger.NewLogger("cron"), clock.New(), test.opts)
+			m := bindings.Metadata{}
+			m.Properties = test.properties
+			err := c.Init(m)
+			if test.errorExpected {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCronReadTimezone(t *testing.T) {
+	clk := clock.NewMock()
+	c := getNewCronWithClock(clk)
+	m := getTestMetadata("0 0 * * * *")
+	m.Properties["timezone"] = "Asia/Kolkata"
+	require.NoError(t, c.Init(m))
+
+	events := make(chan *bindings.ReadResponse, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, c.Read(ctx, func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+		events <- res
+		return nil, nil
+	}))
+
+	// Kolkata is 5:30 ahead of UTC, so the first run is at 00:30 UTC
+	clk.Add(29 * time.Minute)
+	time.Sleep(10 * time.Millisecond)
+	assert.Empty(t, events)
+	clk.Add(time.Minute)
+	select {
+	case res := <-events:
+		assert.Equal(t, "Asia/Kolkata", res.Metadata["timeZone"])
+		assert.Equal(t, "1970-01-01T06:00:00+05:30", res.Metadata[scheduledTimeMetadataKey])
+	case <-time.After(time.Second):
+		t.Fatal("the run wasn't fired")
+	}
+}
+
+func TestCronReadJitter(t *testing.T) {
+	clk := clock.NewMock()
+	c := getNewCronWithClock(clk)
+	m := getTestMetadata("0 * * * * *")
+	m.Properties["jitter"] = "10s"
+	require.NoError(t, c.Init(m))
+
+	events := make(chan *bindings.ReadResponse, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, c.Read(ctx, func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+		events <- res
+		return nil, nil
+	}))
+
+	clk.Add(time.Minute)
+	time.Sleep(10 * time.Millisecond)
+	clk.Add(10 * time.Second)
+	select {
+	case res := <-events:
+		// The scheduled time doesn't include the jitter
+		assert.Equal(t, "1970-01-01T00:01:00Z", res.Metadata[scheduledTimeMetadataKey])
+	case <-time.After(time.Second):
+		t.Fatal("the run wasn't fired")
+	}
+}
+
+func TestCronReadCatchUp(t *testing.T) {
+	store := inmemory.NewInMemoryStateStore(logger.NewLogger("test"))
+	require.NoError(t, store.Init(state.Metadata{}))
+	clk := clock.NewMock()
+	clk.Add(time.Hour)
+
+	// The last run was 5 seconds ago
+	data, err := json.Marshal(clk.Now().Add(-5 * time.Second))
+	require.NoError(t, err)
+	require.NoError(t, store.Set(context.Background(), &state.SetRequest{Key: "cron-test-last-run", Value: data}))
+
+	c := NewCronWithOptions(logger.NewLogger("cron"), clk, Options{StateStore: store})
+	m := getTestMetadata("@every 1s")
+	m.Name = "test"
+	m.Properties["catchUp"] = "true"
+	m.Properties["maxCatchUpRuns"] = "3"
+	require.NoError(t, c.Init(m))
+
+	var (
+		lock     sync.Mutex
+		replayed []string
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, c.Read(ctx, func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		if res.Metadata[catchUpMetadataKey] == "true" {
+			replayed = append(replayed, res.Metadata[scheduledTimeMetadataKey])
+		}
+		return nil, nil
+	}))
+
+	// Only the 3 most recent missed runs are replayed
+	assert.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(replayed) == 3
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"1970-01-01T00:59:58Z", "1970-01-01T00:59:59Z", "1970-01-01T01:00:00Z"}, replayed)
+
+	// The last run is persisted
+	res, err := store.Get(context.Background(), &state.GetRequest{Key: "cron-test-last-run"})
+	require.NoError(t, err)
+	var last time.Time
+	require.NoError(t, json.Unmarshal(res.Data, &last))
+	assert.True(t, last.Equal(clk.Now()))
+}
+
+func TestCronReadLock(t *testing.T) {
+	clk := clock.NewMock()
+	lockStore := newFakeLockStore()
+
+	var (
+		lock  sync.Mutex
+		fired = map[string]int{}
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		c := NewCronWithOptions(logger.NewLogger("cron"), clk, Options{LockStore: lockStore})
+		m := getTestMetadata("@every 1s")
+		m.Name = "test"
+		require.NoError(t, c.Init(m))
+		require.NoError(t, c.Read(ctx, func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			fired[res.Metadata[scheduledTimeMetadataKey]]++
+			return nil, nil
+		}))
+	}
+
+	for i := 0; i < 3; i++ {
+		clk.Add(time.Second)
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// Each run is fired by a single replica
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, map[string]int{
+		"1970-01-01T00:00:01Z": 1,
+		"1970-01-01T00:00:02Z": 1,
+		"1970-01-01T00:00:03Z": 1,
+	}, fired)
+}
+
+// fakeLockStore is a lock store whose locks never expire.
+type fakeLockStore struct {
+	lock   sync.Mutex
+	owners map[string]string
+}
+
+func newFakeLockStore() *fakeLockStore {
+	return &fakeLockStore{owners: map[string]string{}}
+}
+
+func (f *fakeLockStore) InitLockStore(metadata lock.Metadata) error {
+	return nil
+}
+
+func (f *fakeLockStore) TryLock(ctx context.Context, req *lock.TryLockRequest) (*lock.TryLockResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if _, ok := f.owners[req.ResourceID]; ok {
+		return &lock.TryLockResponse{Success: false}, nil
+	}
+	f.owners[req.ResourceID] = req.LockOwner
+
+	return &lock.TryLockResponse{Success: true}, nil
+}
+
+func (f *fakeLockStore) Unlock(ctx context.Context, req *lock.UnlockRequest) (*lock.UnlockResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	delete(f.owners, req.ResourceID)
+
+	return &lock.UnlockResponse{Status: lock.Success}, nil
+}
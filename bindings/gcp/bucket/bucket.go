@@ -67,12 +67,6 @@ type gcpMetadata struct {
 	EncodeBase64        bool   `json:"encodeBase64,string"`
 }
 
-type listPayload struct {
-	Prefix     string `json:"prefix"`
-	MaxResults int32  `json:"maxResults"`
-	Delimiter  string `json:"delimiter"`
-}
-
 type createResponse struct {
 	ObjectURL string `json:"objectURL"`
 }
@@ -245,34 +239,47 @@ func (g *GCPStorage) delete(ctx context.Context, req *bindings.InvokeRequest) (*
 }
 
 func (g *GCPStorage) list(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
-	var payload listPayload
-	err := json.Unmarshal(req.Data, &payload)
+	listReq, err := bindings.ParseListRequest(req.Data, maxResults)
 	if err != nil {
 		return nil, err
 	}
 
-	if payload.MaxResults == int32(0) {
-		payload.MaxResults = maxResults
-	}
-
 	input := &storage.Query{
-		Prefix:    payload.Prefix,
-		Delimiter: payload.Delimiter,
+		Prefix:    listReq.Prefix,
+		Delimiter: listReq.Delimiter,
 	}
 
-	var result []storage.ObjectAttrs
+	var result []*storage.ObjectAttrs
 	it := g.client.Bucket(g.metadata.Bucket).Objects(ctx, input)
-	for {
-		attrs, errIt := it.Next()
-		if errIt == iterator.Done || len(result) == int(payload.MaxResults) {
-			break
+	token, err := iterator.NewPager(it, int(listReq.PageSize), listReq.ContinuationToken).NextPage(&result)
+	if err != nil {
+		return nil, fmt.Errorf("gcp bucket binding error. list operation. cannot list objects: %w", err)
+	}
+
+	res := &bindings.ListResponse{
+		Items:             make([]bindings.ListItem, 0, len(result)),
+		ContinuationToken: token,
+	}
+	for _, attrs := range result {
+		// The names grouped by the delimiter are only returned as prefixes
+		if attrs.Prefix != "" {
+			res.Prefixes = append(res.Prefixes, attrs.Prefix)
+			continue
 		}
-		result = append(result, *attrs)
+		updated := attrs.Updated
+		res.Items = append(res.Items, bindings.ListItem{
+			Name:         attrs.Name,
+			Size:         attrs.Size,
+			LastModified: &updated,
+			ETag:         attrs.Etag,
+			ContentType:  attrs.ContentType,
+			Metadata:     attrs.Metadata,
+		})
 	}
 
-	jsonResponse, err := json.Marshal(result)
+	jsonResponse, err := json.Marshal(res)
 	if err != nil {
-		return nil, fmt.Errorf("gcp bucketgcp bucket binding error. list operation. cannot marshal blobs to json: %w", err)
+		return nil, fmt.Errorf("gcp bucket binding error. list operation. cannot marshal blobs to json: %w", err)
 	}
 
 	return &bindings.InvokeResponse{
@@ -17,6 +17,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"regexp"
 	"strings"
 	"time"
@@ -24,6 +25,7 @@ import (
 	graphql "github.com/machinebox/graphql"
 
 	"github.com/JY29/components-contrib/bindings"
+	httpcomponent "github.com/JY29/components-contrib/internal/component/http"
 	"github.com/dapr/kit/logger"
 )
 
@@ -67,8 +69,17 @@ func (gql *GraphQL) Init(metadata bindings.Metadata) error {
 		return fmt.Errorf("GraphQL Error: Missing GraphQL URL")
 	}
 
+	policy, err := httpcomponent.ParsePolicyMetadata(p)
+	if err != nil {
+		return fmt.Errorf("GraphQL Error: %w", err)
+	}
+	transport, err := httpcomponent.NewTransport(http.DefaultTransport, policy)
+	if err != nil {
+		return fmt.Errorf("GraphQL Error: %w", err)
+	}
+
 	// Connect to GraphQL Server
-	client := graphql.NewClient(ep)
+	client := graphql.NewClient(ep, graphql.WithHTTPClient(&http.Client{Transport: transport}))
 
 	gql.client = client
 	gql.header = make(map[string]string)
@@ -168,6 +179,16 @@ func (gql *GraphQL) runRequest(ctx context.Context, requestKey string, req *bind
 		}
 	}
 
+	ctx, cancel, err := httpcomponent.WithRequestTimeout(ctx, req.Metadata, 0)
+	if err != nil {
+		return fmt.Errorf("GraphQL Error: %w", err)
+	}
+	defer cancel()
+	// The queries have no side effect, so they can be retried and cached
+	if requestKey == commandQuery {
+		ctx = httpcomponent.WithIdempotent(ctx)
+	}
+
 	if err := gql.client.Run(ctx, request, response); err != nil {
 		return fmt.Errorf("GraphQL Error: %w", err)
 	}
@@ -14,9 +14,18 @@ limitations under the License.
 package graphql
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JY29/components-contrib/bindings"
+	"github.com/JY29/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
 )
 
 func TestOperations(t *testing.T) {
@@ -29,3 +38,45 @@ func TestOperations(t *testing.T) {
 		assert.Equal(t, 2, len(l))
 	})
 }
+
+func TestRetries(t *testing.T) {
+	var count int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1)%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{"hello":"world"}}`))
+	}))
+	defer s.Close()
+
+	b := NewGraphQL(logger.NewLogger("test"))
+	err := b.Init(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+		connectionEndPointKey:  s.URL,
+		"maxRetries":           "1",
+		"retryInitialInterval": "1ms",
+	}}})
+	require.NoError(t, err)
+
+	t.Run("query is retried", func(t *testing.T) {
+		atomic.StoreInt32(&count, 0)
+		resp, err := b.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: QueryOperation,
+			Metadata:  map[string]string{commandQuery: "query { hello }"},
+		})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"hello":"world"}`, string(resp.Data))
+		assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+	})
+
+	t.Run("mutation is not retried", func(t *testing.T) {
+		atomic.StoreInt32(&count, 0)
+		_, err := b.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: MutationOperation,
+			Metadata:  map[string]string{commandMutation: "mutation { hello }"},
+		})
+		require.Error(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+	})
+}
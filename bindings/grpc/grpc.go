@@ -0,0 +1,352 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/grpcreflect"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/JY29/components-contrib/bindings"
+	"github.com/JY29/components-contrib/internal/tlsutils"
+	contribMetadata "github.com/JY29/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// invokeOperation calls a unary method.
+	invokeOperation bindings.OperationKind = "invoke"
+
+	// Keys of the request metadata.
+	methodMetadataKey  = "method"
+	timeoutMetadataKey = "timeout"
+	// Prefix of the request metadata sent as gRPC metadata, like the headers of the graphql binding.
+	headerMetadataPrefix = "header:"
+)
+
+// Binding is an output binding invoking the unary methods of a gRPC server.
+// The requests and the responses are JSON documents, mapped to the Protobuf messages of the methods
+// described by the server reflection or by a descriptor set.
+type Binding struct {
+	logger   logger.Logger
+	metadata grpcMetadata
+	conn     *grpc.ClientConn
+	// files holds the descriptors of the descriptor set, the server reflection is used without it.
+	files      *protoregistry.Files
+	reflection *grpcreflect.Client
+
+	lock    sync.RWMutex
+	methods map[string]protoreflect.MethodDescriptor
+}
+
+type grpcMetadata struct {
+	// Address is the host and port of the server.
+	Address string `mapstructure:"address"`
+	// EnableTLS connects to the server with TLS, verified with caCert, or the system roots by default.
+	EnableTLS bool `mapstructure:"enableTLS"`
+	// SkipVerify disables the verification of the certificate of the server.
+	SkipVerify bool `mapstructure:"skipVerify"`
+	// ServerName overrides the name of the server verified in its certificate.
+	ServerName string `mapstructure:"serverName"`
+	// DescriptorSetPath is the path of a serialized FileDescriptorSet describing the services.
+	DescriptorSetPath string `mapstructure:"descriptorSetPath"`
+	// Timeout is the default deadline of the calls.
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// NewGRPC returns a new gRPC output binding.
+func NewGRPC(logger logger.Logger) bindings.OutputBinding {
+	return &Binding{logger: logger, methods: map[string]protoreflect.MethodDescriptor{}}
+}
+
+// Init connects to the server.
+func (b *Binding) Init(meta bindings.Metadata) error {
+	err := contribMetadata.DecodeMetadata(meta.Properties, &b.metadata)
+	if err != nil {
+		return err
+	}
+	if b.metadata.Address == "" {
+		return errors.New("address is required")
+	}
+	if b.metadata.Timeout < 0 {
+		return fmt.Errorf("invalid timeout: %v", b.metadata.Timeout)
+	}
+
+	creds, err := b.transportCredentials(meta.Properties)
+	if err != nil {
+		return err
+	}
+
+	if b.metadata.DescriptorSetPath != "" {
+		b.files, err = loadDescriptorSet(b.metadata.DescriptorSetPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	b.conn, err = grpc.Dial(b.metadata.Address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return fmt.Errorf("error connecting to %s: %w", b.metadata.Address, err)
+	}
+	if b.files == nil {
+		b.reflection = grpcreflect.NewClientAuto(context.Background(), b.conn)
+	}
+
+	return nil
+}
+
+func (b *Binding) transportCredentials(properties map[string]string) (credentials.TransportCredentials, error) {
+	tlsProperties, err := tlsutils.TLS(properties)
+	if err != nil {
+		return nil, err
+	}
+	if !b.metadata.EnableTLS {
+		if tlsProperties != (tlsutils.TLSProperties{}) {
+			return nil, errors.New("caCert, clientCert and clientKey require enableTLS")
+		}
+		return insecure.NewCredentials(), nil
+	}
+
+	tlsConfig, err := tlsutils.ConvertTLSPropertiesToTLSConfig(tlsProperties)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.ServerName = b.metadata.ServerName
+	//nolint:gosec
+	tlsConfig.InsecureSkipVerify = b.metadata.SkipVerify
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+func loadDescriptorSet(path string) (*protoregistry.Files, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the descriptor set: %w", err)
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	if err = proto.Unmarshal(data, set); err != nil {
+		return nil, fmt.Errorf("invalid descriptor set: %w", err)
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor set: %w", err)
+	}
+
+	return files, nil
+}
+
+// Operations returns the operations of the binding.
+func (b *Binding) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{invokeOperation}
+}
+
+// Invoke calls the method of the request metadata with the request data.
+func (b *Binding) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	if req.Operation != invokeOperation {
+		return nil, fmt.Errorf("invalid operation: %s", req.Operation)
+	}
+
+	method, err := b.resolveMethod(req.Metadata[methodMetadataKey])
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := b.metadata.Timeout
+	if val := req.Metadata[timeoutMetadataKey]; val != "" {
+		timeout, err = time.ParseDuration(val)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout: %s", val)
+		}
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	md, err := outgoingMetadata(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	in := dynamicpb.NewMessage(method.Input())
+	if len(req.Data) > 0 {
+		if err = protojson.Unmarshal(req.Data, in); err != nil {
+			return nil, fmt.Errorf("the data does not match the request message %s: %w", method.Input().FullName(), err)
+		}
+	}
+	out := dynamicpb.NewMessage(method.Output())
+
+	var header, trailer metadata.MD
+	fullName := fmt.Sprintf("/%s/%s", method.Parent().FullName(), method.Name())
+	err = b.conn.Invoke(ctx, fullName, in, out, grpc.Header(&header), grpc.Trailer(&trailer))
+	if err != nil {
+		return nil, fmt.Errorf("error invoking %s: %w", fullName, err)
+	}
+
+	data, err := protojson.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing the response: %w", err)
+	}
+
+	// The values of the headers and trailers are joined with ", ", like the headers of the http binding
+	respMetadata := make(map[string]string, len(header)+len(trailer))
+	for _, m := range []metadata.MD{header, trailer} {
+		for k, values := range m {
+			respMetadata[k] = strings.Join(values, ", ")
+		}
+	}
+
+	return &bindings.InvokeResponse{
+		Data:     data,
+		Metadata: respMetadata,
+	}, nil
+}
+
+// outgoingMetadata returns the gRPC metadata of the request metadata prefixed with "header:".
+// The keys reserved by gRPC and HTTP/2 can't be set.
+func outgoingMetadata(reqMetadata map[string]string) (metadata.MD, error) {
+	md := metadata.MD{}
+	for k, v := range reqMetadata {
+		if !strings.HasPrefix(k, headerMetadataPrefix) {
+			continue
+		}
+		key := strings.ToLower(strings.TrimPrefix(k, headerMetadataPrefix))
+		if !isValidMetadataKey(key) {
+			return nil, fmt.Errorf("invalid gRPC metadata key: %s", key)
+		}
+		if key == "content-type" || key == "te" || key == "user-agent" || strings.HasPrefix(key, "grpc-") {
+			return nil, fmt.Errorf("reserved gRPC metadata key: %s", key)
+		}
+		md.Append(key, v)
+	}
+
+	return md, nil
+}
+
+// isValidMetadataKey returns whether the key only has the characters allowed in the keys of gRPC metadata, after
+// conversion to lowercase.
+func isValidMetadataKey(key string) bool {
+	if key == "" || key[0] == ':' {
+		return false
+	}
+	for _, c := range key {
+		if !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') && c != '-' && c != '_' && c != '.' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// resolveMethod returns the descriptor of a unary method named "/package.Service/Method", "package.Service/Method"
+// or "package.Service.Method".
+func (b *Binding) resolveMethod(name string) (protoreflect.MethodDescriptor, error) {
+	if name == "" {
+		return nil, fmt.Errorf("required metadata not set: %s", methodMetadataKey)
+	}
+
+	b.lock.RLock()
+	method, ok := b.methods[name]
+	b.lock.RUnlock()
+	if ok {
+		return method, nil
+	}
+
+	fullName := strings.Replace(strings.TrimPrefix(name, "/"), "/", ".", 1)
+	i := strings.LastIndex(fullName, ".")
+	if i <= 0 || i == len(fullName)-1 {
+		return nil, fmt.Errorf("invalid method name: %s", name)
+	}
+	serviceName, methodName := fullName[:i], fullName[i+1:]
+
+	service, err := b.resolveService(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	method = service.Methods().ByName(protoreflect.Name(methodName))
+	if method == nil {
+		return nil, fmt.Errorf("method %s not found in service %s", methodName, serviceName)
+	}
+	if method.IsStreamingClient() || method.IsStreamingServer() {
+		return nil, fmt.Errorf("method %s is not unary", name)
+	}
+
+	b.lock.Lock()
+	b.methods[name] = method
+	b.lock.Unlock()
+
+	return method, nil
+}
+
+func (b *Binding) resolveService(name string) (protoreflect.ServiceDescriptor, error) {
+	if b.files == nil {
+		service, err := b.reflection.ResolveService(name)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving service %s with the server reflection: %w", name, err)
+		}
+		// The file of the service and its dependencies are converted to the descriptors of the protobuf API
+		files, err := protodesc.NewFiles(desc.ToFileDescriptorSet(service.GetFile()))
+		if err != nil {
+			return nil, fmt.Errorf("invalid descriptors of service %s: %w", name, err)
+		}
+		return findService(files, name)
+	}
+
+	return findService(b.files, name)
+}
+
+func findService(files *protoregistry.Files, name string) (protoreflect.ServiceDescriptor, error) {
+	d, err := files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, fmt.Errorf("service %s not found: %w", name, err)
+	}
+	service, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", name)
+	}
+
+	return service, nil
+}
+
+// Close closes the connection.
+func (b *Binding) Close() error {
+	if b.reflection != nil {
+		b.reflection.Reset()
+	}
+	if b.conn != nil {
+		return b.conn.Close()
+	}
+
+	return nil
+}
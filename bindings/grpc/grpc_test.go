@@ -0,0 +1,313 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/JY29/components-contrib/bindings"
+	contribMetadata "github.com/JY29/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// testServer is an in-process server of the health service, recording the metadata and the deadline of the last call.
+type testServer struct {
+	address  string
+	incoming chan metadata.MD
+	deadline chan bool
+}
+
+func startServer(t *testing.T, withReflection bool, opts ...grpc.ServerOption) *testServer {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ts := &testServer{
+		address:  lis.Addr().String(),
+		incoming: make(chan metadata.MD, 1),
+		deadline: make(chan bool, 1),
+	}
+
+	opts = append(opts, grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		_, hasDeadline := ctx.Deadline()
+		if info.FullMethod == "/grpc.health.v1.Health/Check" {
+			ts.incoming <- md
+			ts.deadline <- hasDeadline
+		}
+		if len(md.Get("x-delay")) > 0 {
+			delay, _ := time.ParseDuration(md.Get("x-delay")[0])
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return nil, status.FromContextError(ctx.Err()).Err()
+			}
+		}
+		_ = grpc.SetHeader(ctx, metadata.Pairs("x-served-by", "test"))
+
+		return handler(ctx, req)
+	}))
+	s := grpc.NewServer(opts...)
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("orders", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s, healthServer)
+	if withReflection {
+		reflection.Register(s)
+	}
+
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	return ts
+}
+
+func initBinding(t *testing.T, properties map[string]string) *Binding {
+	t.Helper()
+
+	b := NewGRPC(logger.NewLogger("test")).(*Binding)
+	require.NoError(t, b.Init(bindings.Metadata{Base: contribMetadata.Base{Properties: properties}}))
+	t.Cleanup(func() { b.Close() })
+
+	return b
+}
+
+func TestInit(t *testing.T) {
+	b := NewGRPC(logger.NewLogger("test"))
+
+	t.Run("address required", func(t *testing.T) {
+		err := b.Init(bindings.Metadata{})
+		assert.Error(t, err)
+	})
+
+	t.Run("certificates require TLS", func(t *testing.T) {
+		caCert, _ := generateCertificate(t)
+		err := b.Init(bindings.Metadata{Base: contribMetadata.Base{Properties: map[string]string{
+			"address": "localhost:50051",
+			"caCert":  caCert,
+		}}})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid descriptor set", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "descriptors.pb")
+		require.NoError(t, os.WriteFile(path, []byte("invalid"), 0o600))
+		err := b.Init(bindings.Metadata{Base: contribMetadata.Base{Properties: map[string]string{
+			"address":           "localhost:50051",
+			"descriptorSetPath": path,
+		}}})
+		assert.Error(t, err)
+	})
+}
+
+func TestInvokeWithReflection(t *testing.T) {
+	ts := startServer(t, true)
+	b := initBinding(t, map[string]string{"address": ts.address})
+	ctx := context.Background()
+
+	t.Run("unary method", func(t *testing.T) {
+		res, err := b.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: invokeOperation,
+			Data:      []byte(`{"service": "orders"}`),
+			Metadata: map[string]string{
+				methodMetadataKey: "/grpc.health.v1.Health/Check",
+				"header:X-Tenant": "contoso",
+				"X-Ignored":       "true",
+			},
+		})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"status": "SERVING"}`, string(res.Data))
+		assert.Equal(t, "test", res.Metadata["x-served-by"])
+
+		md := <-ts.incoming
+		assert.Equal(t, []string{"contoso"}, md.Get("x-tenant"))
+		assert.Empty(t, md.Get(methodMetadataKey))
+		assert.Empty(t, md.Get("x-ignored"))
+		assert.False(t, <-ts.deadline)
+	})
+
+	t.Run("method names", func(t *testing.T) {
+		for _, name := range []string{"grpc.health.v1.Health/Check", "grpc.health.v1.Health.Check"} {
+			res, err := b.Invoke(ctx, &bindings.InvokeRequest{
+				Operation: invokeOperation,
+				Metadata:  map[string]string{methodMetadataKey: name},
+			})
+			require.NoError(t, err, name)
+			assert.JSONEq(t, `{"status": "SERVING"}`, string(res.Data))
+			<-ts.incoming
+			<-ts.deadline
+		}
+	})
+
+	t.Run("status error", func(t *testing.T) {
+		_, err := b.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: invokeOperation,
+			Data:      []byte(`{"service": "unknown"}`),
+			Metadata:  map[string]string{methodMetadataKey: "grpc.health.v1.Health/Check"},
+		})
+		require.Error(t, err)
+		assert.Equal(t, codes.NotFound, status.Code(errors.Unwrap(err)))
+		<-ts.incoming
+		<-ts.deadline
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		for name, req := range map[string]*bindings.InvokeRequest{
+			"operation":        {Operation: "get", Metadata: map[string]string{methodMetadataKey: "grpc.health.v1.Health/Check"}},
+			"no method":        {Operation: invokeOperation},
+			"invalid method":   {Operation: invokeOperation, Metadata: map[string]string{methodMetadataKey: "Check"}},
+			"unknown service":  {Operation: invokeOperation, Metadata: map[string]string{methodMetadataKey: "foo.Bar/Check"}},
+			"unknown method":   {Operation: invokeOperation, Metadata: map[string]string{methodMetadataKey: "grpc.health.v1.Health/Foo"}},
+			"streaming method": {Operation: invokeOperation, Metadata: map[string]string{methodMetadataKey: "grpc.health.v1.Health/Watch"}},
+			"invalid data":     {Operation: invokeOperation, Data: []byte(`{"foo": 1}`), Metadata: map[string]string{methodMetadataKey: "grpc.health.v1.Health/Check"}},
+			"invalid timeout":  {Operation: invokeOperation, Metadata: map[string]string{methodMetadataKey: "grpc.health.v1.Health/Check", timeoutMetadataKey: "soon"}},
+			"reserved header":  {Operation: invokeOperation, Metadata: map[string]string{methodMetadataKey: "grpc.health.v1.Health/Check", "header:grpc-timeout": "1S"}},
+			"invalid header":   {Operation: invokeOperation, Metadata: map[string]string{methodMetadataKey: "grpc.health.v1.Health/Check", "header:x tenant": "contoso"}},
+		} {
+			_, err := b.Invoke(ctx, req)
+			assert.Error(t, err, name)
+		}
+	})
+}
+
+func TestInvokeDeadline(t *testing.T) {
+	ts := startServer(t, true)
+	b := initBinding(t, map[string]string{"address": ts.address, "timeout": "1m"})
+	ctx := context.Background()
+
+	_, err := b.Invoke(ctx, &bindings.InvokeRequest{
+		Operation: invokeOperation,
+		Metadata:  map[string]string{methodMetadataKey: "grpc.health.v1.Health/Check"},
+	})
+	require.NoError(t, err)
+	<-ts.incoming
+	assert.True(t, <-ts.deadline)
+
+	// The timeout of the request overrides the default one
+	_, err = b.Invoke(ctx, &bindings.InvokeRequest{
+		Operation: invokeOperation,
+		Metadata: map[string]string{
+			methodMetadataKey:  "grpc.health.v1.Health/Check",
+			timeoutMetadataKey: "50ms",
+			"header:x-delay":   "1s",
+		},
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(errors.Unwrap(err)))
+}
+
+func TestInvokeWithDescriptorSet(t *testing.T) {
+	ts := startServer(t, false)
+	set := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{protodesc.ToFileDescriptorProto(healthpb.File_grpc_health_v1_health_proto)},
+	}
+	data, err := proto.Marshal(set)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "health.pb")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	b := initBinding(t, map[string]string{"address": ts.address, "descriptorSetPath": path})
+	res, err := b.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: invokeOperation,
+		Data:      []byte(`{"service": "orders"}`),
+		Metadata:  map[string]string{methodMetadataKey: "grpc.health.v1.Health/Check"},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status": "SERVING"}`, string(res.Data))
+}
+
+func TestInvokeWithTLS(t *testing.T) {
+	certPEM, keyPEM := generateCertificate(t)
+	cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	require.NoError(t, err)
+	ts := startServer(t, true, grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	})))
+
+	b := initBinding(t, map[string]string{
+		"address":    ts.address,
+		"enableTLS":  "true",
+		"caCert":     certPEM,
+		"serverName": "localhost",
+	})
+	res, err := b.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: invokeOperation,
+		Data:      []byte(`{"service": "orders"}`),
+		Metadata:  map[string]string{methodMetadataKey: "grpc.health.v1.Health/Check"},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status": "SERVING"}`, string(res.Data))
+
+	// The certificate of the server is not trusted without the CA certificate
+	b = initBinding(t, map[string]string{"address": ts.address, "enableTLS": "true"})
+	_, err = b.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: invokeOperation,
+		Metadata:  map[string]string{methodMetadataKey: "grpc.health.v1.Health/Check"},
+	})
+	assert.Error(t, err)
+}
+
+// generateCertificate returns a self-signed certificate of localhost and its key, in PEM.
+func generateCertificate(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
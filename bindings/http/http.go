@@ -16,6 +16,7 @@ package http
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net"
@@ -28,10 +29,15 @@ import (
 	"github.com/mitchellh/mapstructure"
 
 	"github.com/JY29/components-contrib/bindings"
+	"github.com/JY29/components-contrib/contenttype"
+	httpcomponent "github.com/JY29/components-contrib/internal/component/http"
 	"github.com/JY29/components-contrib/internal/utils"
+	"github.com/JY29/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 )
 
+const defaultTimeout = 30 * time.Second
+
 // HTTPSource is a binding for an http url endpoint invocation
 //
 //revive:disable-next-line
@@ -43,7 +49,8 @@ type HTTPSource struct {
 }
 
 type httpMetadata struct {
-	URL string `mapstructure:"url"`
+	URL             string `mapstructure:"url"`
+	CloudEventsMode string `mapstructure:"cloudEventsMode"`
 }
 
 // NewHTTP returns a new HTTPSource.
@@ -57,6 +64,14 @@ func (h *HTTPSource) Init(metadata bindings.Metadata) error {
 		return err
 	}
 
+	switch h.metadata.CloudEventsMode {
+	case "":
+		h.metadata.CloudEventsMode = pubsub.CloudEventsModeStructured
+	case pubsub.CloudEventsModeStructured, pubsub.CloudEventsModeBinary:
+	default:
+		return fmt.Errorf("invalid value for 'cloudEventsMode': %s", h.metadata.CloudEventsMode)
+	}
+
 	// See guidance on proper HTTP client settings here:
 	// https://medium.com/@nate510/don-t-use-go-s-default-http-client-4804cb19f779
 	dialer := &net.Dialer{
@@ -66,9 +81,17 @@ func (h *HTTPSource) Init(metadata bindings.Metadata) error {
 		Dial:                dialer.Dial,
 		TLSHandshakeTimeout: 5 * time.Second,
 	}
+	policy, err := httpcomponent.ParsePolicyMetadata(metadata.Properties)
+	if err != nil {
+		return err
+	}
+	transport, err := httpcomponent.NewTransport(netTransport, policy)
+	if err != nil {
+		return err
+	}
+	// The timeout of the requests is set by their context, as it can be overridden by the request metadata
 	h.client = &http.Client{
-		Timeout:   time.Second * 30,
-		Transport: netTransport,
+		Transport: transport,
 	}
 
 	if val, ok := metadata.Properties["errorIfNot2XX"]; ok {
@@ -119,15 +142,26 @@ func (h *HTTPSource) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*
 		req.Metadata = make(map[string]string)
 	}
 
+	ctx, cancel, err := httpcomponent.WithRequestTimeout(ctx, req.Metadata, defaultTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
 	var body io.Reader
 	method := strings.ToUpper(string(req.Operation))
 	// For backward compatibility
 	if method == "CREATE" {
 		method = "POST"
 	}
+	var ceHeaders map[string]string
 	switch method {
 	case "PUT", "POST", "PATCH":
-		body = bytes.NewBuffer(req.Data)
+		data := req.Data
+		if h.metadata.CloudEventsMode == pubsub.CloudEventsModeBinary {
+			data, ceHeaders = h.toBinaryCloudEvent(data)
+		}
+		body = bytes.NewBuffer(data)
 	case "GET", "HEAD", "DELETE", "OPTIONS", "TRACE":
 	default:
 		return nil, fmt.Errorf("invalid operation: %s", req.Operation)
@@ -157,6 +191,11 @@ func (h *HTTPSource) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*
 		}
 	}
 
+	// The attributes of binary-mode CloudEvents take precedence over the metadata
+	for k, v := range ceHeaders {
+		request.Header.Set(k, v)
+	}
+
 	// Send the question
 	resp, err := h.client.Do(request)
 	if err != nil {
@@ -182,6 +221,19 @@ func (h *HTTPSource) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*
 		metadata[key] = strings.Join(values, ", ")
 	}
 
+	// Binary-mode CloudEvents in the response are returned in structured mode
+	if h.metadata.CloudEventsMode == pubsub.CloudEventsModeBinary && pubsub.IsBinaryCloudEvent(metadata, pubsub.CloudEventsHTTPBinding) {
+		var ce map[string]interface{}
+		ce, err = pubsub.FromBinaryCloudEvent(metadata, b, pubsub.CloudEventsHTTPBinding)
+		if err == nil {
+			b, err = json.Marshal(ce)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading the CloudEvent in the response: %w", err)
+		}
+		metadata["Content-Type"] = contenttype.CloudEventContentType
+	}
+
 	// Create an error for non-200 status codes unless suppressed.
 	if errorIfNot2XX && resp.StatusCode/100 != 2 {
 		err = fmt.Errorf("received status code %d", resp.StatusCode)
@@ -192,3 +244,22 @@ func (h *HTTPSource) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*
 		Metadata: metadata,
 	}, err
 }
+
+// toBinaryCloudEvent converts a structured-mode CloudEvent to binary mode, returning its attributes as headers.
+// Payloads which are not CloudEvents are returned unchanged.
+func (h *HTTPSource) toBinaryCloudEvent(data []byte) ([]byte, map[string]string) {
+	var ce map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&ce); err != nil || ce[pubsub.SpecVersionField] == nil {
+		return data, nil
+	}
+
+	headers, payload, err := pubsub.ToBinaryCloudEvent(ce, pubsub.CloudEventsHTTPBinding)
+	if err != nil {
+		h.logger.Warnf("Error converting CloudEvent to binary mode, sending it in structured mode: %v", err)
+		return data, nil
+	}
+
+	return payload, headers
+}
@@ -20,7 +20,9 @@ import (
 	"net/http/httptest"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -364,3 +366,92 @@ func TestNon2XXErrorsSuppressed(t *testing.T) {
 		})
 	}
 }
+
+func TestCloudEventsBinaryMode(t *testing.T) {
+	var received http.Header
+	var receivedBody []byte
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		received = req.Header
+		receivedBody, _ = io.ReadAll(req.Body)
+		w.Header().Set("Ce-Specversion", "1.0")
+		w.Header().Set("Ce-Id", "reply")
+		w.Header().Set("Ce-Source", "server")
+		w.Header().Set("Ce-Type", "test.reply")
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("pong"))
+	}))
+	defer s.Close()
+
+	hs, err := InitBinding(s, map[string]string{"cloudEventsMode": "binary"})
+	require.NoError(t, err)
+
+	resp, err := hs.Invoke(context.Background(), &bindings.InvokeRequest{
+		Data:      []byte(`{"specversion":"1.0","id":"1","source":"test","type":"test.event","datacontenttype":"text/plain","data":"ping"}`),
+		Operation: "post",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "ping", string(receivedBody))
+	assert.Equal(t, "1", received.Get("Ce-Id"))
+	assert.Equal(t, "test.event", received.Get("Ce-Type"))
+	assert.Equal(t, "text/plain", received.Get("Content-Type"))
+
+	assert.JSONEq(t, `{"specversion":"1.0","id":"reply","source":"server","type":"test.reply","datacontenttype":"text/plain","data":"pong"}`, string(resp.Data))
+	assert.Equal(t, "application/cloudevents+json", resp.Metadata["Content-Type"])
+}
+
+func TestInvalidCloudEventsMode(t *testing.T) {
+	s := httptest.NewServer(NewHTTPHandler())
+	defer s.Close()
+
+	_, err := InitBinding(s, map[string]string{"cloudEventsMode": "foo"})
+	require.Error(t, err)
+}
+
+func TestRetries(t *testing.T) {
+	var count int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&count, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer s.Close()
+
+	hs, err := InitBinding(s, map[string]string{"maxRetries": "2", "retryInitialInterval": "1ms"})
+	require.NoError(t, err)
+
+	resp, err := hs.Invoke(context.Background(), &bindings.InvokeRequest{Operation: "get"})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(resp.Data))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+}
+
+func TestTimeoutMetadata(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer s.Close()
+
+	hs, err := InitBinding(s, nil)
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = hs.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: "get",
+		Metadata:  map[string]string{"timeout": "50ms"},
+	})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+
+	_, err = hs.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: "get",
+		Metadata:  map[string]string{"timeout": "soon"},
+	})
+	require.Error(t, err)
+}
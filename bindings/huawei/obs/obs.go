@@ -62,13 +62,6 @@ type uploadPayload struct {
 	SourceFile string `json:"sourceFile"`
 }
 
-type listPayload struct {
-	Marker     string `json:"marker"`
-	Prefix     string `json:"prefix"`
-	MaxResults int32  `json:"maxResults"`
-	Delimiter  string `json:"delimiter"`
-}
-
 // NewHuaweiOBS returns a new Huawei OBS instance.
 func NewHuaweiOBS(logger logger.Logger) bindings.OutputBinding {
 	return &HuaweiOBS{logger: logger}
@@ -270,30 +263,53 @@ func (o *HuaweiOBS) delete(ctx context.Context, req *bindings.InvokeRequest) (*b
 }
 
 func (o *HuaweiOBS) list(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
-	var payload listPayload
-	err := json.Unmarshal(req.Data, &payload)
+	listReq, err := bindings.ParseListRequest(req.Data, maxResults)
 	if err != nil {
 		return nil, err
 	}
 
-	if payload.MaxResults == int32(0) {
-		payload.MaxResults = maxResults
-	}
-
 	input := &obs.ListObjectsInput{}
 
 	input.Bucket = o.metadata.Bucket
-	input.MaxKeys = int(payload.MaxResults)
-	input.Marker = payload.Marker
-	input.Prefix = payload.Prefix
-	input.Delimiter = payload.Delimiter
+	input.MaxKeys = int(listReq.PageSize)
+	input.Marker = listReq.ContinuationToken
+	input.Prefix = listReq.Prefix
+	input.Delimiter = listReq.Delimiter
 
 	out, err := o.service.ListObjects(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("obs binding error. error listing obs objects: %w", err)
 	}
 
-	jsonResponse, err := json.Marshal(out)
+	res := &bindings.ListResponse{
+		Items:    make([]bindings.ListItem, 0, len(out.Contents)),
+		Prefixes: out.CommonPrefixes,
+	}
+	last := ""
+	for i := range out.Contents {
+		object := &out.Contents[i]
+		res.Items = append(res.Items, bindings.ListItem{
+			Name:         object.Key,
+			Size:         object.Size,
+			LastModified: &object.LastModified,
+			ETag:         object.ETag,
+		})
+		last = object.Key
+	}
+	for _, prefix := range out.CommonPrefixes {
+		if prefix > last {
+			last = prefix
+		}
+	}
+	if out.IsTruncated {
+		// OBS only returns the next marker when a delimiter is set
+		res.ContinuationToken = out.NextMarker
+		if res.ContinuationToken == "" {
+			res.ContinuationToken = last
+		}
+	}
+
+	jsonResponse, err := json.Marshal(res)
 	if err != nil {
 		return nil, fmt.Errorf("obs binding error. list operation. cannot marshal response to json: %w", err)
 	}
@@ -575,6 +575,48 @@ func TestListOperation(t *testing.T) {
 		assert.Nil(t, err)
 	})
 
+	t.Run("Successfully list objects with the standard list response", func(t *testing.T) {
+		var input *obs.ListObjectsInput
+		mo := &HuaweiOBS{
+			service: &MockHuaweiOBSService{
+				ListObjectsFn: func(ctx context.Context, in *obs.ListObjectsInput) (output *obs.ListObjectsOutput, err error) {
+					input = in
+					return &obs.ListObjectsOutput{
+						BaseModel: obs.BaseModel{
+							StatusCode: 200,
+						},
+						IsTruncated:    true,
+						Contents:       []obs.Content{{Key: "a/1", Size: 3, ETag: "etag"}},
+						CommonPrefixes: []string{"a/b/"},
+					}, nil
+				},
+			},
+			logger: logger.NewLogger("test"),
+			metadata: &obsMetadata{
+				Bucket: "test",
+			},
+		}
+
+		req := &bindings.InvokeRequest{
+			Operation: "list",
+			Data:      []byte(`{"prefix": "a/", "delimiter": "/", "pageSize": 2, "continuationToken": "a/0"}`),
+		}
+
+		res, err := mo.list(context.Background(), req)
+		assert.Nil(t, err)
+		assert.Equal(t, "a/", input.Prefix)
+		assert.Equal(t, "/", input.Delimiter)
+		assert.Equal(t, 2, input.MaxKeys)
+		assert.Equal(t, "a/0", input.Marker)
+
+		var listRes bindings.ListResponse
+		assert.Nil(t, json.Unmarshal(res.Data, &listRes))
+		assert.Equal(t, []string{"a/b/"}, listRes.Prefixes)
+		assert.Equal(t, "a/1", listRes.Items[0].Name)
+		assert.Equal(t, int64(3), listRes.Items[0].Size)
+		assert.Equal(t, "a/b/", listRes.ContinuationToken)
+	})
+
 	t.Run("Fail list objects with obs internal error", func(t *testing.T) {
 		mo := &HuaweiOBS{
 			service: &MockHuaweiOBSService{
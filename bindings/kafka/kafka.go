@@ -32,6 +32,7 @@ type Binding struct {
 	kafka           *kafka.Kafka
 	publishTopic    string
 	topics          []string
+	valueSchemaType string
 	logger          logger.Logger
 	subscribeCtx    context.Context
 	subscribeCancel context.CancelFunc
@@ -66,6 +67,8 @@ func (b *Binding) Init(metadata bindings.Metadata) error {
 		b.topics = strings.Split(val, ",")
 	}
 
+	b.valueSchemaType = metadata.Properties[kafka.ValueSchemaType]
+
 	return nil
 }
 
@@ -79,7 +82,17 @@ func (b *Binding) Close() (err error) {
 }
 
 func (b *Binding) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
-	err := b.kafka.Publish(ctx, b.publishTopic, req.Data, req.Metadata)
+	metadata := req.Metadata
+	// The values are serialized with the schema type of the component, unless the request sets one
+	if b.valueSchemaType != "" && metadata[kafka.ValueSchemaType] == "" {
+		metadata = make(map[string]string, len(req.Metadata)+1)
+		for k, v := range req.Metadata {
+			metadata[k] = v
+		}
+		metadata[kafka.ValueSchemaType] = b.valueSchemaType
+	}
+
+	err := b.kafka.Publish(ctx, b.publishTopic, req.Data, metadata)
 	return nil, err
 }
 
@@ -92,6 +105,7 @@ func (b *Binding) Read(ctx context.Context, handler bindings.Handler) error {
 	handlerConfig := kafka.SubscriptionHandlerConfig{
 		IsBulkSubscribe: false,
 		Handler:         adaptHandler(handler),
+		ValueSchemaType: b.valueSchemaType,
 	}
 	for _, t := range b.topics {
 		b.kafka.AddTopicHandler(t, handlerConfig)
@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bindings
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ListRequest is the data of a request of the list operation of the storage bindings.
+type ListRequest struct {
+	// Prefix restricts the listed objects to the names starting with it.
+	Prefix string `json:"prefix,omitempty"`
+	// Delimiter groups the names containing it after the prefix in the prefixes of the response, instead of listing them.
+	Delimiter string `json:"delimiter,omitempty"`
+	// PageSize is the maximum number of items and prefixes of the response.
+	PageSize int32 `json:"pageSize,omitempty"`
+	// ContinuationToken is the token returned by the previous page.
+	ContinuationToken string `json:"continuationToken,omitempty"`
+}
+
+// ListItem is an object listed by the list operation.
+type ListItem struct {
+	Name         string            `json:"name"`
+	Size         int64             `json:"size"`
+	LastModified *time.Time        `json:"lastModified,omitempty"`
+	ETag         string            `json:"etag,omitempty"`
+	ContentType  string            `json:"contentType,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+// ListResponse is the data of a response of the list operation of the storage bindings.
+type ListResponse struct {
+	Items []ListItem `json:"items"`
+	// Prefixes are the common prefixes of the names grouped by the delimiter, ending with it.
+	Prefixes []string `json:"prefixes,omitempty"`
+	// ContinuationToken is set when there are more results, to request the next page.
+	ContinuationToken string `json:"continuationToken,omitempty"`
+}
+
+// ParseListRequest decodes the data of a list request, which may be empty.
+// The marker and maxResults properties of the previous payloads of the storage bindings are accepted as
+// the continuation token and the page size.
+// The page size is set to defaultPageSize when the request doesn't set it.
+func ParseListRequest(data []byte, defaultPageSize int32) (ListRequest, error) {
+	var payload struct {
+		ListRequest
+		Marker     string `json:"marker"`
+		MaxResults int32  `json:"maxResults"`
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return ListRequest{}, fmt.Errorf("invalid list request: %w", err)
+		}
+	}
+
+	req := payload.ListRequest
+	if req.PageSize == 0 {
+		req.PageSize = payload.MaxResults
+	}
+	if req.ContinuationToken == "" {
+		req.ContinuationToken = payload.Marker
+	}
+	if req.PageSize < 0 {
+		return ListRequest{}, fmt.Errorf("invalid list request: negative page size %d", req.PageSize)
+	}
+	if req.PageSize == 0 {
+		req.PageSize = defaultPageSize
+	}
+
+	return req, nil
+}
+
+// ListPage returns a page of the items, for the bindings listing all the objects at once.
+// The items are sorted by name, and the continuation token is the last name or prefix of the previous page.
+func ListPage(req ListRequest, items []ListItem) *ListResponse {
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+
+	res := &ListResponse{Items: []ListItem{}}
+	last := ""
+	for _, item := range items {
+		if !strings.HasPrefix(item.Name, req.Prefix) {
+			continue
+		}
+
+		name := item.Name
+		isPrefix := false
+		if req.Delimiter != "" {
+			if i := strings.Index(name[len(req.Prefix):], req.Delimiter); i >= 0 {
+				name = name[:len(req.Prefix)+i+len(req.Delimiter)]
+				isPrefix = true
+			}
+		}
+		if name <= req.ContinuationToken || name == last {
+			// Returned by a previous page, or another name of the same prefix
+			continue
+		}
+
+		if req.PageSize > 0 && int32(len(res.Items)+len(res.Prefixes)) == req.PageSize {
+			res.ContinuationToken = last
+			break
+		}
+		if isPrefix {
+			res.Prefixes = append(res.Prefixes, name)
+		} else {
+			res.Items = append(res.Items, item)
+		}
+		last = name
+	}
+
+	return res
+}
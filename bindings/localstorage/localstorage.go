@@ -14,6 +14,7 @@ limitations under the License.
 package localstorage
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
@@ -23,6 +24,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	securejoin "github.com/cyphar/filepath-securejoin"
 	"github.com/google/uuid"
@@ -36,7 +38,7 @@ const (
 	fileNameMetadataKey = "fileName"
 )
 
-// LocalStorage allows saving files to disk.
+// LocalStorage allows saving files to disk, and watching the changes of the files.
 type LocalStorage struct {
 	metadata *Metadata
 	logger   logger.Logger
@@ -45,6 +47,38 @@ type LocalStorage struct {
 // Metadata defines the metadata.
 type Metadata struct {
 	RootPath string `json:"rootPath"`
+	// Patterns are the glob patterns of the files watched by the input binding.
+	// A pattern without a separator matches the base name of the files, and the other ones their path relative to RootPath.
+	Patterns []string `json:"patterns"`
+	// Debounce is how long a file must be left unchanged before its changes trigger the input binding.
+	Debounce time.Duration `json:"debounce"`
+	// ArchivePath is the directory where the input binding moves the files once they are processed.
+	ArchivePath string `json:"archivePath"`
+	// StrictMetadata rejects the requests with unknown metadata, instead of logging a warning.
+	StrictMetadata bool `json:"strictMetadata"`
+}
+
+// fileRequestMetadata is the request metadata of the operations on a file.
+type fileRequestMetadata struct {
+	FileName string `mapstructure:"fileName"`
+}
+
+type getRequestMetadata struct {
+	fileRequestMetadata `mapstructure:",squash"`
+	Range               string `mapstructure:"range"`
+}
+
+type getResponseMetadata struct {
+	// ContentLength is only set by InvokeStream.
+	ContentLength int64 `mapstructure:"contentLength"`
+}
+
+//nolint:gochecknoglobals
+var operationsMetadata = map[bindings.OperationKind]bindings.OperationMetadata{
+	bindings.CreateOperation: bindings.NewOperationMetadata(bindings.DataFormatBytes, fileRequestMetadata{}, bindings.DataFormatJSON, nil),
+	bindings.GetOperation:    bindings.NewOperationMetadata(bindings.DataFormatNone, getRequestMetadata{}, bindings.DataFormatBytes, getResponseMetadata{}),
+	bindings.ListOperation:   bindings.NewOperationMetadata(bindings.DataFormatJSON, fileRequestMetadata{}, bindings.DataFormatJSON, nil),
+	bindings.DeleteOperation: bindings.NewOperationMetadata(bindings.DataFormatNone, fileRequestMetadata{}, bindings.DataFormatNone, nil),
 }
 
 type createResponse struct {
@@ -52,7 +86,7 @@ type createResponse struct {
 }
 
 // NewLocalStorage returns a new LocalStorage instance.
-func NewLocalStorage(logger logger.Logger) bindings.OutputBinding {
+func NewLocalStorage(logger logger.Logger) bindings.InputOutputBinding {
 	return &LocalStorage{logger: logger}
 }
 
@@ -69,6 +103,13 @@ func (ls *LocalStorage) Init(metadata bindings.Metadata) error {
 		return fmt.Errorf("unable to create directory specified by 'rootPath': %s", ls.metadata.RootPath)
 	}
 
+	if ls.metadata.ArchivePath != "" {
+		err = os.MkdirAll(ls.metadata.ArchivePath, 0o777)
+		if err != nil {
+			return fmt.Errorf("unable to create directory specified by 'archivePath': %s", ls.metadata.ArchivePath)
+		}
+	}
+
 	return nil
 }
 
@@ -92,6 +133,11 @@ func (ls *LocalStorage) Operations() []bindings.OperationKind {
 	}
 }
 
+// GetOperationsMetadata returns the description of the operations.
+func (ls *LocalStorage) GetOperationsMetadata() map[bindings.OperationKind]bindings.OperationMetadata {
+	return operationsMetadata
+}
+
 func (ls *LocalStorage) create(filename string, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
 	d, err := strconv.Unquote(string(req.Data))
 	if err == nil {
@@ -103,6 +149,11 @@ func (ls *LocalStorage) create(filename string, req *bindings.InvokeRequest) (*b
 		req.Data = decoded
 	}
 
+	return ls.write(filename, bytes.NewReader(req.Data))
+}
+
+// write writes a file from a reader, and returns the response with its path relative to the root path.
+func (ls *LocalStorage) write(filename string, r io.Reader) (*bindings.InvokeResponse, error) {
 	absPath, relPath, err := getSecureAbsRelPath(ls.metadata.RootPath, filename)
 	if err != nil {
 		return nil, err
@@ -119,7 +170,7 @@ func (ls *LocalStorage) create(filename string, req *bindings.InvokeRequest) (*b
 	}
 	defer f.Close()
 
-	numBytes, err := f.Write(req.Data)
+	numBytes, err := io.Copy(f, r)
 	if err != nil {
 		return nil, err
 	}
@@ -141,30 +192,110 @@ func (ls *LocalStorage) create(filename string, req *bindings.InvokeRequest) (*b
 }
 
 func (ls *LocalStorage) get(filename string, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
-	absPath, _, err := getSecureAbsRelPath(ls.metadata.RootPath, filename)
+	r, _, err := ls.open(filename, req.Metadata)
 	if err != nil {
 		return nil, err
 	}
+	defer r.Close()
 
-	f, err := os.Open(absPath)
+	b, err := io.ReadAll(r)
 	if err != nil {
 		ls.logger.Debugf("%s", err)
 
 		return nil, err
 	}
 
-	b, err := io.ReadAll(f)
+	ls.logger.Debugf("read file: %s. size: %d bytes", filename, len(b))
+
+	return &bindings.InvokeResponse{
+		Data: b,
+	}, nil
+}
+
+// readCloser reads a range of a file, and closes the file.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// open opens a file for reading the range of the request, if any, and returns the length of the data read.
+func (ls *LocalStorage) open(filename string, md map[string]string) (io.ReadCloser, int64, error) {
+	byteRange, err := bindings.ParseByteRange(md)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	absPath, _, err := getSecureAbsRelPath(ls.metadata.RootPath, filename)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	f, err := os.Open(absPath)
 	if err != nil {
 		ls.logger.Debugf("%s", err)
 
-		return nil, err
+		return nil, 0, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	if byteRange == nil {
+		return f, fi.Size(), nil
 	}
 
-	ls.logger.Debugf("read file: %s. size: %d bytes", absPath, len(b))
+	if byteRange.Start >= fi.Size() {
+		f.Close()
+		return nil, 0, fmt.Errorf("range start %d is beyond the size %d of the file", byteRange.Start, fi.Size())
+	}
+	length := fi.Size() - byteRange.Start
+	if l := byteRange.Length(); l >= 0 && l < length {
+		length = l
+	}
 
-	return &bindings.InvokeResponse{
-		Data: b,
-	}, nil
+	return readCloser{Reader: io.NewSectionReader(f, byteRange.Start, length), Closer: f}, length, nil
+}
+
+// InvokeStream is called for output bindings with streamed data.
+// The create operation writes the data to the file as it is, without decoding it.
+func (ls *LocalStorage) InvokeStream(ctx context.Context, req *bindings.StreamInvokeRequest) (*bindings.StreamInvokeResponse, error) {
+	filename := req.Metadata[fileNameMetadataKey]
+
+	switch req.Operation {
+	case bindings.CreateOperation, bindings.GetOperation:
+		err := bindings.CheckRequestMetadata(ls, req.Operation, req.Metadata, ls.metadata.StrictMetadata, ls.logger)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch req.Operation {
+	case bindings.CreateOperation:
+		if filename == "" {
+			filename = uuid.New().String()
+		}
+		data := req.Data
+		if data == nil {
+			data = bytes.NewReader(nil)
+		}
+		res, err := ls.write(filename, data)
+		if err != nil {
+			return nil, err
+		}
+		return &bindings.StreamInvokeResponse{Data: io.NopCloser(bytes.NewReader(res.Data))}, nil
+	case bindings.GetOperation:
+		r, length, err := ls.open(filename, req.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		return &bindings.StreamInvokeResponse{
+			Data:     r,
+			Metadata: map[string]string{bindings.ContentLengthMetadataKey: strconv.FormatInt(length, 10)},
+		}, nil
+	default:
+		return bindings.BufferedInvokeStream(ctx, ls, req)
+	}
 }
 
 func (ls *LocalStorage) delete(filename string, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
@@ -183,7 +314,13 @@ func (ls *LocalStorage) delete(filename string, req *bindings.InvokeRequest) (*b
 	return nil, nil
 }
 
+// list lists the files under the root path, or under the directory set as file name, with their path relative to the root path.
 func (ls *LocalStorage) list(filename string, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	listReq, err := bindings.ParseListRequest(req.Data, 0)
+	if err != nil {
+		return nil, err
+	}
+
 	absPath, _, err := getSecureAbsRelPath(ls.metadata.RootPath, filename)
 	if err != nil {
 		return nil, err
@@ -200,12 +337,12 @@ func (ls *LocalStorage) list(filename string, req *bindings.InvokeRequest) (*bin
 		return nil, errors.New(msg)
 	}
 
-	files, err := walkPath(absPath)
+	items, err := ls.walkPath(absPath)
 	if err != nil {
 		return nil, err
 	}
 
-	b, err := json.Marshal(files)
+	b, err := json.Marshal(bindings.ListPage(listReq, items))
 	if err != nil {
 		return nil, err
 	}
@@ -228,21 +365,40 @@ func getSecureAbsRelPath(rootPath string, filename string) (absPath string, relP
 	return
 }
 
-func walkPath(root string) ([]string, error) {
-	var files []string
+func (ls *LocalStorage) walkPath(root string) ([]bindings.ListItem, error) {
+	var items []bindings.ListItem
 	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-		if !info.IsDir() {
-			files = append(files, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(ls.metadata.RootPath, path)
+		if err != nil {
+			return err
 		}
+		modTime := info.ModTime().UTC()
+		items = append(items, bindings.ListItem{
+			Name:         filepath.ToSlash(rel),
+			Size:         info.Size(),
+			LastModified: &modTime,
+		})
 
 		return nil
 	})
 
-	return files, err
+	return items, err
 }
 
 // Invoke is called for output bindings.
 func (ls *LocalStorage) Invoke(_ context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	err := bindings.CheckRequestMetadata(ls, req.Operation, req.Metadata, ls.metadata.StrictMetadata, ls.logger)
+	if err != nil {
+		return nil, err
+	}
+
 	filename := ""
 	if val, ok := req.Metadata[fileNameMetadataKey]; ok && val != "" {
 		filename = val
@@ -14,9 +14,15 @@ limitations under the License.
 package localstorage
 
 import (
+	"context"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/JY29/components-contrib/bindings"
 	"github.com/dapr/kit/logger"
@@ -30,3 +36,205 @@ func TestParseMetadata(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "/files", meta.RootPath)
 }
+
+func TestInvokeStream(t *testing.T) {
+	ls := NewLocalStorage(logger.NewLogger("test")).(*LocalStorage)
+	m := bindings.Metadata{}
+	m.Properties = map[string]string{"rootPath": t.TempDir()}
+	require.NoError(t, ls.Init(m))
+	ctx := context.Background()
+
+	// The data is written as it is, even when it looks like base64
+	res, err := ls.InvokeStream(ctx, &bindings.StreamInvokeRequest{
+		Operation: bindings.CreateOperation,
+		Data:      strings.NewReader("aGVsbG8gd29ybGQ="),
+		Metadata:  map[string]string{fileNameMetadataKey: "dir/file.txt"},
+	})
+	require.NoError(t, err)
+	b, err := io.ReadAll(res.Data)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"fileName":"dir/file.txt"}`, string(b))
+
+	t.Run("get", func(t *testing.T) {
+		res, err := ls.InvokeStream(ctx, &bindings.StreamInvokeRequest{
+			Operation: bindings.GetOperation,
+			Metadata:  map[string]string{fileNameMetadataKey: "dir/file.txt"},
+		})
+		require.NoError(t, err)
+		defer res.Data.Close()
+		b, err := io.ReadAll(res.Data)
+		require.NoError(t, err)
+		assert.Equal(t, "aGVsbG8gd29ybGQ=", string(b))
+		assert.Equal(t, "16", res.Metadata[bindings.ContentLengthMetadataKey])
+	})
+
+	t.Run("get range", func(t *testing.T) {
+		for r, expected := range map[string]string{"4-7": "bG8g", "12-": "bGQ=", "12-100": "bGQ="} {
+			res, err := ls.InvokeStream(ctx, &bindings.StreamInvokeRequest{
+				Operation: bindings.GetOperation,
+				Metadata:  map[string]string{fileNameMetadataKey: "dir/file.txt", bindings.RangeMetadataKey: r},
+			})
+			require.NoError(t, err)
+			b, err := io.ReadAll(res.Data)
+			require.NoError(t, err)
+			res.Data.Close()
+			assert.Equal(t, expected, string(b), r)
+			assert.Equal(t, strconv.Itoa(len(expected)), res.Metadata[bindings.ContentLengthMetadataKey], r)
+		}
+
+		// The range also applies to the get operation of Invoke
+		invokeRes, err := ls.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: bindings.GetOperation,
+			Metadata:  map[string]string{fileNameMetadataKey: "dir/file.txt", bindings.RangeMetadataKey: "0-3"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "aGVs", string(invokeRes.Data))
+
+		for _, r := range []string{"100-", "5-2", "abc", "-5"} {
+			_, err = ls.InvokeStream(ctx, &bindings.StreamInvokeRequest{
+				Operation: bindings.GetOperation,
+				Metadata:  map[string]string{fileNameMetadataKey: "dir/file.txt", bindings.RangeMetadataKey: r},
+			})
+			assert.Error(t, err, r)
+		}
+	})
+
+	t.Run("other operations are buffered", func(t *testing.T) {
+		res, err := bindings.InvokeStream(ctx, ls, &bindings.StreamInvokeRequest{
+			Operation: bindings.ListOperation,
+			Metadata:  map[string]string{fileNameMetadataKey: "dir"},
+		})
+		require.NoError(t, err)
+		b, err := io.ReadAll(res.Data)
+		require.NoError(t, err)
+		assert.Contains(t, string(b), "file.txt")
+	})
+}
+
+func TestList(t *testing.T) {
+	ls := NewLocalStorage(logger.NewLogger("test")).(*LocalStorage)
+	m := bindings.Metadata{}
+	m.Properties = map[string]string{"rootPath": t.TempDir()}
+	require.NoError(t, ls.Init(m))
+	ctx := context.Background()
+
+	for _, name := range []string{"a.txt", "dir/b.txt", "dir/sub/c.txt", "dir/sub/d.txt"} {
+		_, err := ls.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: bindings.CreateOperation,
+			Data:      []byte(`"` + name + `"`),
+			Metadata:  map[string]string{fileNameMetadataKey: name},
+		})
+		require.NoError(t, err)
+	}
+
+	list := func(data string, md map[string]string) bindings.ListResponse {
+		res, err := ls.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: bindings.ListOperation,
+			Data:      []byte(data),
+			Metadata:  md,
+		})
+		require.NoError(t, err)
+		var listRes bindings.ListResponse
+		require.NoError(t, json.Unmarshal(res.Data, &listRes))
+
+		return listRes
+	}
+	names := func(res bindings.ListResponse) []string {
+		var names []string
+		for _, item := range res.Items {
+			names = append(names, item.Name)
+		}
+
+		return names
+	}
+
+	res := list("", nil)
+	assert.Equal(t, []string{"a.txt", "dir/b.txt", "dir/sub/c.txt", "dir/sub/d.txt"}, names(res))
+	assert.Equal(t, int64(len("dir/b.txt")), res.Items[1].Size)
+	assert.NotNil(t, res.Items[1].LastModified)
+	assert.Empty(t, res.ContinuationToken)
+
+	t.Run("pages", func(t *testing.T) {
+		res := list(`{"pageSize": 3}`, nil)
+		assert.Equal(t, []string{"a.txt", "dir/b.txt", "dir/sub/c.txt"}, names(res))
+		require.Equal(t, "dir/sub/c.txt", res.ContinuationToken)
+
+		res = list(`{"pageSize": 3, "continuationToken": "dir/sub/c.txt"}`, nil)
+		assert.Equal(t, []string{"dir/sub/d.txt"}, names(res))
+		assert.Empty(t, res.ContinuationToken)
+	})
+
+	t.Run("prefix and delimiter", func(t *testing.T) {
+		res := list(`{"delimiter": "/"}`, nil)
+		assert.Equal(t, []string{"a.txt"}, names(res))
+		assert.Equal(t, []string{"dir/"}, res.Prefixes)
+
+		res = list(`{"prefix": "dir/", "delimiter": "/", "pageSize": 1}`, nil)
+		assert.Equal(t, []string{"dir/b.txt"}, names(res))
+		require.Equal(t, "dir/b.txt", res.ContinuationToken)
+
+		res = list(`{"prefix": "dir/", "delimiter": "/", "pageSize": 1, "continuationToken": "dir/b.txt"}`, nil)
+		assert.Empty(t, res.Items)
+		assert.Equal(t, []string{"dir/sub/"}, res.Prefixes)
+		assert.Empty(t, res.ContinuationToken)
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		res := list("", map[string]string{fileNameMetadataKey: "dir/sub"})
+		assert.Equal(t, []string{"dir/sub/c.txt", "dir/sub/d.txt"}, names(res))
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		_, err := ls.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: bindings.ListOperation,
+			Data:      []byte(`{"pageSize": -1}`),
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestOperationsMetadata(t *testing.T) {
+	ls := NewLocalStorage(logger.NewLogger("test")).(*LocalStorage)
+	ops := ls.GetOperationsMetadata()
+	for _, op := range ls.Operations() {
+		assert.Contains(t, ops, op)
+	}
+	assert.Equal(t, map[string]string{"fileName": "string", "range": "string"}, ops[bindings.GetOperation].Metadata)
+	assert.Equal(t, map[string]string{"contentLength": "int64"}, ops[bindings.GetOperation].ResponseMetadata)
+	assert.Equal(t, bindings.DataFormatBytes, ops[bindings.CreateOperation].DataFormat)
+
+	assert.Empty(t, ops[bindings.GetOperation].UnknownMetadata(map[string]string{"FILENAME": "a", "range": "0-1", "traceparent": "x"}))
+	assert.Equal(t, []string{"fileNmae", "rnage"}, ops[bindings.GetOperation].UnknownMetadata(map[string]string{"fileNmae": "a", "rnage": "0-1"}))
+
+	ctx := context.Background()
+	req := &bindings.InvokeRequest{
+		Operation: bindings.CreateOperation,
+		Data:      []byte("data"),
+		Metadata:  map[string]string{fileNameMetadataKey: "file.txt", "unknown": "value"},
+	}
+
+	t.Run("unknown metadata is ignored", func(t *testing.T) {
+		m := bindings.Metadata{}
+		m.Properties = map[string]string{"rootPath": t.TempDir()}
+		require.NoError(t, ls.Init(m))
+
+		_, err := ls.Invoke(ctx, req)
+		assert.NoError(t, err)
+	})
+
+	t.Run("unknown metadata is rejected in strict mode", func(t *testing.T) {
+		m := bindings.Metadata{}
+		m.Properties = map[string]string{"rootPath": t.TempDir(), bindings.StrictMetadataKey: "true"}
+		require.NoError(t, ls.Init(m))
+
+		_, err := ls.Invoke(ctx, req)
+		assert.ErrorIs(t, err, bindings.ErrUnknownMetadata)
+		assert.ErrorContains(t, err, "unknown")
+
+		_, err = ls.InvokeStream(ctx, &bindings.StreamInvokeRequest{
+			Operation: bindings.GetOperation,
+			Metadata:  map[string]string{fileNameMetadataKey: "file.txt", "unknown": "value"},
+		})
+		assert.ErrorIs(t, err, bindings.ErrUnknownMetadata)
+	})
+}
@@ -0,0 +1,355 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/JY29/components-contrib/bindings"
+)
+
+// Kinds of file events.
+const (
+	fileEventCreate = "create"
+	fileEventModify = "modify"
+	fileEventDelete = "delete"
+)
+
+// Metadata of the file events.
+const (
+	eventMetadataKey   = "event"
+	sizeMetadataKey    = "size"
+	modTimeMetadataKey = "modTime"
+)
+
+// fileEvent is a change of a file, which may merge several fsnotify events.
+type fileEvent struct {
+	absPath string
+	// created is set when the file was created, and stays set while the file is modified during the debounce delay.
+	created bool
+	removed bool
+	timer   *time.Timer
+}
+
+// watcher watches the files of the root path, and triggers the handler for their changes.
+type watcher struct {
+	ls      *LocalStorage
+	fsw     *fsnotify.Watcher
+	handler bindings.Handler
+
+	// dirs holds the watched directories, which are only accessed by the goroutine receiving the fsnotify events.
+	dirs map[string]struct{}
+
+	lock    sync.Mutex
+	pending map[string]*fileEvent
+	// archived holds the files moved to the archive path, whose removal is not an event.
+	archived map[string]struct{}
+
+	events chan *fileEvent
+}
+
+// Read watches the files of the root path, including the subdirectories, and triggers the handler when a file is created, modified or deleted.
+// The data of the events is the content of the file, empty for the deletions, and their metadata has the relative file name,
+// the kind of event, the size and the modification time of the file.
+// The files whose relative path or base name matches none of the patterns are ignored.
+// The changes of a file are merged until it is left unchanged for the debounce delay.
+// When an archive path is set, the created and modified files are moved there once the handler succeeded.
+func (ls *LocalStorage) Read(ctx context.Context, handler bindings.Handler) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("error creating file watcher: %w", err)
+	}
+
+	w := &watcher{
+		ls:       ls,
+		fsw:      fsw,
+		handler:  handler,
+		dirs:     map[string]struct{}{},
+		pending:  map[string]*fileEvent{},
+		archived: map[string]struct{}{},
+		events:   make(chan *fileEvent, 100),
+	}
+	if err = w.watchTree(ls.metadata.RootPath); err != nil {
+		fsw.Close()
+		return err
+	}
+
+	go w.run(ctx)
+	go w.dispatch(ctx)
+
+	return nil
+}
+
+// watchTree watches a directory and its subdirectories, except the archive path.
+func (w *watcher) watchTree(root string) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// The directory may have been removed in the meantime
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if w.isArchive(p) {
+			return filepath.SkipDir
+		}
+		if err := w.fsw.Add(p); err != nil {
+			return fmt.Errorf("error watching directory %s: %w", p, err)
+		}
+		w.dirs[p] = struct{}{}
+
+		return nil
+	})
+}
+
+func (w *watcher) isArchive(p string) bool {
+	archive := w.ls.metadata.ArchivePath
+	if archive == "" {
+		return false
+	}
+	rel, err := filepath.Rel(archive, p)
+
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// run receives the fsnotify events until ctx is canceled.
+func (w *watcher) run(ctx context.Context) {
+	defer w.fsw.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.lock.Lock()
+			for _, e := range w.pending {
+				e.timer.Stop()
+			}
+			w.lock.Unlock()
+			return
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			w.ls.logger.Errorf("error watching files: %v", err)
+		case ev, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			w.handleEvent(ctx, ev)
+		}
+	}
+}
+
+func (w *watcher) handleEvent(ctx context.Context, ev fsnotify.Event) {
+	if ev.Op == fsnotify.Chmod || w.isArchive(ev.Name) {
+		return
+	}
+
+	removed := ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename)
+	if _, ok := w.dirs[ev.Name]; ok && removed {
+		// The watch of the directory is removed with it
+		delete(w.dirs, ev.Name)
+		return
+	}
+	if !removed {
+		info, err := os.Stat(ev.Name)
+		if err != nil {
+			// Removed since, which is reported by another event
+			return
+		}
+		if info.IsDir() {
+			if ev.Has(fsnotify.Create) {
+				// The files created in the directory before it is watched are missed
+				if err = w.watchTree(ev.Name); err != nil {
+					w.ls.logger.Errorf("error watching new directory: %v", err)
+				}
+			}
+			return
+		}
+	}
+
+	if !w.matches(ev.Name) {
+		return
+	}
+
+	w.lock.Lock()
+	if _, ok := w.archived[ev.Name]; ok && removed {
+		delete(w.archived, ev.Name)
+		w.lock.Unlock()
+		return
+	}
+
+	e, ok := w.pending[ev.Name]
+	if !ok {
+		e = &fileEvent{absPath: ev.Name}
+	}
+	if ev.Has(fsnotify.Create) {
+		e.created = true
+		e.removed = false
+	}
+	if ev.Has(fsnotify.Write) {
+		e.removed = false
+	}
+	if removed {
+		e.removed = true
+	}
+
+	if w.ls.metadata.Debounce <= 0 {
+		w.lock.Unlock()
+		w.send(ctx, e)
+		return
+	}
+	defer w.lock.Unlock()
+	if ok {
+		e.timer.Reset(w.ls.metadata.Debounce)
+		return
+	}
+	w.pending[ev.Name] = e
+	e.timer = time.AfterFunc(w.ls.metadata.Debounce, func() {
+		w.lock.Lock()
+		if w.pending[e.absPath] != e {
+			// Already sent, when the timer was reset while firing
+			w.lock.Unlock()
+			return
+		}
+		delete(w.pending, e.absPath)
+		w.lock.Unlock()
+		w.send(ctx, e)
+	})
+}
+
+func (w *watcher) send(ctx context.Context, e *fileEvent) {
+	if e.created && e.removed {
+		// The file didn't exist before nor after the debounce delay
+		return
+	}
+
+	select {
+	case w.events <- e:
+	case <-ctx.Done():
+	}
+}
+
+// matches returns true if the relative path or the base name of the file matches one of the patterns, or if there is no pattern.
+func (w *watcher) matches(absPath string) bool {
+	if len(w.ls.metadata.Patterns) == 0 {
+		return true
+	}
+
+	rel, err := filepath.Rel(w.ls.metadata.RootPath, absPath)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range w.ls.metadata.Patterns {
+		pattern = strings.TrimSpace(pattern)
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(rel)); ok && !strings.Contains(pattern, "/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dispatch triggers the handler for the events, one at a time.
+func (w *watcher) dispatch(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-w.events:
+			w.trigger(ctx, e)
+		}
+	}
+}
+
+func (w *watcher) trigger(ctx context.Context, e *fileEvent) {
+	rel, err := filepath.Rel(w.ls.metadata.RootPath, e.absPath)
+	if err != nil {
+		return
+	}
+
+	res := &bindings.ReadResponse{
+		Metadata: map[string]string{
+			fileNameMetadataKey: filepath.ToSlash(rel),
+			eventMetadataKey:    fileEventDelete,
+		},
+	}
+	if !e.removed {
+		info, err := os.Stat(e.absPath)
+		if err != nil {
+			// Removed since, which is reported by another event
+			return
+		}
+		res.Data, err = os.ReadFile(e.absPath)
+		if err != nil {
+			w.ls.logger.Errorf("error reading file %s: %v", e.absPath, err)
+			return
+		}
+		res.Metadata[eventMetadataKey] = fileEventModify
+		if e.created {
+			res.Metadata[eventMetadataKey] = fileEventCreate
+		}
+		res.Metadata[sizeMetadataKey] = strconv.FormatInt(info.Size(), 10)
+		res.Metadata[modTimeMetadataKey] = info.ModTime().UTC().Format(time.RFC3339Nano)
+	}
+
+	if _, err = w.handler(ctx, res); err != nil {
+		w.ls.logger.Errorf("error handling %s event of file %s: %v", res.Metadata[eventMetadataKey], rel, err)
+		return
+	}
+
+	if !e.removed && w.ls.metadata.ArchivePath != "" {
+		if err = w.archive(e.absPath, rel); err != nil {
+			w.ls.logger.Errorf("error archiving file %s: %v", rel, err)
+		}
+	}
+}
+
+// archive moves a processed file to the archive path, keeping its relative path.
+func (w *watcher) archive(absPath string, rel string) error {
+	dest := filepath.Join(w.ls.metadata.ArchivePath, rel)
+	if err := os.MkdirAll(filepath.Dir(dest), 0o777); err != nil {
+		return err
+	}
+
+	w.lock.Lock()
+	w.archived[absPath] = struct{}{}
+	w.lock.Unlock()
+	err := os.Rename(absPath, dest)
+	if err != nil {
+		w.lock.Lock()
+		delete(w.archived, absPath)
+		w.lock.Unlock()
+	}
+
+	return err
+}
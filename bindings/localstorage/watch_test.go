@@ -0,0 +1,130 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localstorage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JY29/components-contrib/bindings"
+	"github.com/dapr/kit/logger"
+)
+
+func startReading(t *testing.T, properties map[string]string) (string, <-chan *bindings.ReadResponse) {
+	root := t.TempDir()
+	properties["rootPath"] = root
+
+	ls := NewLocalStorage(logger.NewLogger("test"))
+	m := bindings.Metadata{}
+	m.Properties = properties
+	require.NoError(t, ls.Init(m))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	events := make(chan *bindings.ReadResponse, 10)
+	require.NoError(t, ls.Read(ctx, func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+		events <- res
+		return nil, nil
+	}))
+
+	return root, events
+}
+
+func nextEvent(t *testing.T, events <-chan *bindings.ReadResponse) *bindings.ReadResponse {
+	select {
+	case res := <-events:
+		return res
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for a file event")
+		return nil
+	}
+}
+
+func TestRead(t *testing.T) {
+	t.Run("create, modify and delete events", func(t *testing.T) {
+		root, events := startReading(t, map[string]string{})
+
+		f := filepath.Join(root, "data.csv")
+		require.NoError(t, os.WriteFile(f, []byte("a,b"), 0o600))
+		res := nextEvent(t, events)
+		assert.Equal(t, fileEventCreate, res.Metadata[eventMetadataKey])
+		assert.Equal(t, "data.csv", res.Metadata[fileNameMetadataKey])
+		// The file is created empty, then written
+		res = nextEvent(t, events)
+		assert.Equal(t, fileEventModify, res.Metadata[eventMetadataKey])
+		assert.Equal(t, "3", res.Metadata[sizeMetadataKey])
+		assert.Equal(t, []byte("a,b"), res.Data)
+
+		require.NoError(t, os.Remove(f))
+		res = nextEvent(t, events)
+		assert.Equal(t, fileEventDelete, res.Metadata[eventMetadataKey])
+		assert.Empty(t, res.Data)
+	})
+
+	t.Run("patterns, debounce and archive", func(t *testing.T) {
+		archive := filepath.Join(t.TempDir(), "archive")
+		root, events := startReading(t, map[string]string{
+			"patterns":    "*.csv,reports/*.json",
+			"debounce":    "100ms",
+			"archivePath": archive,
+		})
+
+		require.NoError(t, os.WriteFile(filepath.Join(root, "ignored.txt"), []byte("x"), 0o600))
+		require.NoError(t, os.Mkdir(filepath.Join(root, "reports"), 0o700))
+		// Leave time for the new directory to be watched
+		time.Sleep(100 * time.Millisecond)
+		f, err := os.Create(filepath.Join(root, "reports", "r.json"))
+		require.NoError(t, err)
+		for _, chunk := range []string{`{"a":`, `1}`} {
+			_, err = f.WriteString(chunk)
+			require.NoError(t, err)
+			time.Sleep(20 * time.Millisecond)
+		}
+		require.NoError(t, f.Close())
+
+		// The writes are merged in the create event
+		res := nextEvent(t, events)
+		assert.Equal(t, fileEventCreate, res.Metadata[eventMetadataKey])
+		assert.Equal(t, "reports/r.json", res.Metadata[fileNameMetadataKey])
+		assert.Equal(t, `{"a":1}`, string(res.Data))
+
+		// The processed file is moved to the archive, which is not a delete event
+		assert.Eventually(t, func() bool {
+			_, err := os.Stat(filepath.Join(archive, "reports", "r.json"))
+			return err == nil
+		}, 5*time.Second, 10*time.Millisecond)
+		_, err = os.Stat(filepath.Join(root, "reports", "r.json"))
+		assert.True(t, os.IsNotExist(err))
+		select {
+		case res := <-events:
+			t.Fatalf("unexpected event %v", res.Metadata)
+		case <-time.After(300 * time.Millisecond):
+		}
+	})
+}
+
+func TestMatches(t *testing.T) {
+	w := &watcher{ls: &LocalStorage{metadata: &Metadata{RootPath: "/files", Patterns: []string{"*.csv", "in/*.json"}}}}
+	assert.True(t, w.matches("/files/a.csv"))
+	assert.True(t, w.matches("/files/sub/a.csv"))
+	assert.True(t, w.matches("/files/in/a.json"))
+	assert.False(t, w.matches("/files/a.json"))
+	assert.False(t, w.matches("/files/sub/in/a.json"))
+}
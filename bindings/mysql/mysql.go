@@ -30,6 +30,9 @@ import (
 	"github.com/go-sql-driver/mysql"
 
 	"github.com/JY29/components-contrib/bindings"
+	sqlcomponent "github.com/JY29/components-contrib/internal/component/sql"
+	"github.com/JY29/components-contrib/metadata"
+	"github.com/JY29/components-contrib/state"
 	"github.com/dapr/kit/logger"
 )
 
@@ -38,6 +41,8 @@ const (
 	execOperation  bindings.OperationKind = "exec"
 	queryOperation bindings.OperationKind = "query"
 	closeOperation bindings.OperationKind = "close"
+	// txOperation runs the statements of the request data in a transaction.
+	txOperation bindings.OperationKind = "tx"
 
 	// configurations to connect to Mysql, either a data source name represent by URL.
 	connectionURLKey = "url"
@@ -58,7 +63,8 @@ const (
 	connMaxIdleTimeKey = "connMaxIdleTime"
 
 	// keys from request's metadata.
-	commandSQLKey = "sql"
+	commandSQLKey    = sqlcomponent.SQLKey
+	commandParamsKey = sqlcomponent.ParamsKey
 
 	// keys from response's metadata.
 	respOpKey           = "operation"
@@ -69,28 +75,60 @@ const (
 	respDurationKey     = "duration"
 )
 
-// Mysql represents MySQL output bindings.
+// Mysql represents MySQL input and output bindings.
 type Mysql struct {
-	db     *sql.DB
-	logger logger.Logger
+	db       *sql.DB
+	logger   logger.Logger
+	metadata sqlcomponent.PollMetadata
+	opts     Options
 }
 
-// NewMysql returns a new MySQL output binding.
-func NewMysql(logger logger.Logger) bindings.OutputBinding {
-	return &Mysql{logger: logger}
+// Options holds the optional dependencies of the binding.
+type Options struct {
+	// StateStore persists the watermark of the polled query.
+	StateStore state.Store
+	// StateStores resolves the state store named by the stateStoreName metadata, instead of StateStore.
+	StateStores state.StoreResolver
+}
+
+// NewMysql returns a new MySQL input and output binding.
+// The watermark of the polled query is persisted in the state store named by the stateStoreName metadata,
+// which is resolved with the Options given to NewMysqlWithOptions.
+func NewMysql(logger logger.Logger) bindings.InputOutputBinding {
+	return NewMysqlWithOptions(logger, Options{})
+}
+
+// NewMysqlWithOptions returns a new MySQL input and output binding using the given state store.
+func NewMysqlWithOptions(logger logger.Logger, opts Options) bindings.InputOutputBinding {
+	return &Mysql{logger: logger, opts: opts}
 }
 
 // Init initializes the MySQL binding.
-func (m *Mysql) Init(metadata bindings.Metadata) error {
+func (m *Mysql) Init(meta bindings.Metadata) error {
 	m.logger.Debug("Initializing MySql binding")
 
-	p := metadata.Properties
+	p := meta.Properties
 	url, ok := p[connectionURLKey]
 	if !ok || url == "" {
 		return fmt.Errorf("missing MySql connection string")
 	}
 
-	db, err := initDB(url, metadata.Properties[pemPathKey])
+	err := metadata.DecodeMetadata(p, &m.metadata)
+	if err != nil {
+		return err
+	}
+	if err = m.metadata.Validate(meta.Name); err != nil {
+		return err
+	}
+	m.opts.StateStore, err = m.metadata.ResolveStateStore(m.opts.StateStore, m.opts.StateStores)
+	if err != nil {
+		return err
+	}
+	if m.metadata.PollQuery != "" && m.opts.StateStore == nil {
+		m.logger.Warn("The watermark is not persisted without a state store: the rows are delivered again from initialWatermark at each start")
+	}
+
+	db, err := initDB(url, p[pemPathKey])
 	if err != nil {
 		return err
 	}
@@ -135,44 +173,64 @@ func (m *Mysql) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindi
 		return nil, m.db.Close()
 	}
 
-	if req.Metadata == nil {
-		return nil, errors.New("metadata required")
-	}
 	m.logger.Debugf("operation: %v", req.Operation)
 
-	s, ok := req.Metadata[commandSQLKey]
-	if !ok || s == "" {
-		return nil, fmt.Errorf("required metadata not set: %s", commandSQLKey)
-	}
-
 	startTime := time.Now()
 
 	resp := &bindings.InvokeResponse{
 		Metadata: map[string]string{
 			respOpKey:        string(req.Operation),
-			respSQLKey:       s,
 			respStartTimeKey: startTime.Format(time.RFC3339Nano),
 		},
 	}
 
 	switch req.Operation { //nolint:exhaustive
-	case execOperation:
-		r, err := m.exec(ctx, s)
+	case execOperation, queryOperation:
+		statement, err := sqlcomponent.StatementFromMetadata(req.Metadata)
 		if err != nil {
 			return nil, err
 		}
-		resp.Metadata[respRowsAffectedKey] = strconv.FormatInt(r, 10)
+		args, err := statement.Args()
+		if err != nil {
+			return nil, err
+		}
+		resp.Metadata[respSQLKey] = statement.SQL
 
-	case queryOperation:
-		d, err := m.query(ctx, s)
+		if req.Operation == execOperation {
+			r, err := m.exec(ctx, statement.SQL, args...)
+			if err != nil {
+				return nil, err
+			}
+			resp.Metadata[respRowsAffectedKey] = strconv.FormatInt(r, 10)
+		} else {
+			d, err := m.query(ctx, statement.SQL, args...)
+			if err != nil {
+				return nil, err
+			}
+			resp.Data = d
+		}
+
+	case txOperation:
+		statements, err := sqlcomponent.ParseStatements(req.Data)
 		if err != nil {
 			return nil, err
 		}
-		resp.Data = d
+		rowsAffected, err := m.tx(ctx, statements)
+		if err != nil {
+			return nil, err
+		}
+		var total int64
+		for _, r := range rowsAffected {
+			total += r
+		}
+		resp.Metadata[respRowsAffectedKey] = strconv.FormatInt(total, 10)
+		if resp.Data, err = json.Marshal(rowsAffected); err != nil {
+			return nil, err
+		}
 
 	default:
-		return nil, fmt.Errorf("invalid operation type: %s. Expected %s, %s, or %s",
-			req.Operation, execOperation, queryOperation, closeOperation)
+		return nil, fmt.Errorf("invalid operation type: %s. Expected %s, %s, %s, or %s",
+			req.Operation, execOperation, queryOperation, txOperation, closeOperation)
 	}
 
 	endTime := time.Now()
@@ -182,11 +240,21 @@ func (m *Mysql) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindi
 	return resp, nil
 }
 
+// Read polls the query for new rows.
+func (m *Mysql) Read(ctx context.Context, handler bindings.Handler) error {
+	if m.metadata.PollQuery == "" {
+		return errors.New("pollQuery is required to read")
+	}
+
+	return sqlcomponent.NewPoller(m.metadata, m.queryRows, m.opts.StateStore, m.logger).Start(ctx, handler)
+}
+
 // Operations returns list of operations supported by Mysql binding.
 func (m *Mysql) Operations() []bindings.OperationKind {
 	return []bindings.OperationKind{
 		execOperation,
 		queryOperation,
+		txOperation,
 		closeOperation,
 	}
 }
@@ -200,8 +268,8 @@ func (m *Mysql) Close() error {
 	return nil
 }
 
-func (m *Mysql) query(ctx context.Context, sql string) ([]byte, error) {
-	rows, err := m.db.QueryContext(ctx, sql)
+func (m *Mysql) query(ctx context.Context, sql string, args ...interface{}) ([]byte, error) {
+	rows, err := m.db.QueryContext(ctx, sql, args...)
 	if err != nil {
 		return nil, fmt.Errorf("error executing query: %w", err)
 	}
@@ -219,10 +287,25 @@ func (m *Mysql) query(ctx context.Context, sql string) ([]byte, error) {
 	return result, nil
 }
 
-func (m *Mysql) exec(ctx context.Context, sql string) (int64, error) {
+// queryRows returns the rows as maps of the column names to their values.
+func (m *Mysql) queryRows(ctx context.Context, sql string, args ...interface{}) ([]map[string]interface{}, error) {
+	rows, err := m.db.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+		_ = rows.Err()
+	}()
+
+	return m.rowMaps(rows)
+}
+
+func (m *Mysql) exec(ctx context.Context, sql string, args ...interface{}) (int64, error) {
 	m.logger.Debugf("exec: %s", sql)
 
-	res, err := m.db.ExecContext(ctx, sql)
+	res, err := m.db.ExecContext(ctx, sql, args...)
 	if err != nil {
 		return 0, fmt.Errorf("error executing query: %w", err)
 	}
@@ -230,6 +313,39 @@ func (m *Mysql) exec(ctx context.Context, sql string) (int64, error) {
 	return res.RowsAffected()
 }
 
+// tx executes the statements in a transaction, which is rolled back if any of them fails.
+// It returns the number of rows affected by each statement.
+func (m *Mysql) tx(ctx context.Context, statements []sqlcomponent.Statement) ([]int64, error) {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+
+	rowsAffected := make([]int64, len(statements))
+	for i, statement := range statements {
+		m.logger.Debugf("tx exec: %s", statement.SQL)
+
+		args, err := statement.Args()
+		if err == nil {
+			var res sql.Result
+			res, err = tx.ExecContext(ctx, statement.SQL, args...)
+			if err == nil {
+				rowsAffected[i], err = res.RowsAffected()
+			}
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, fmt.Errorf("error executing statement %d of transaction: %w", i, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
 func propertyToInt(props map[string]string, key string, setter func(int)) error {
 	if v, ok := props[key]; ok {
 		if i, err := strconv.Atoi(v); err == nil {
@@ -286,12 +402,21 @@ func initDB(url, pemPath string) (*sql.DB, error) {
 }
 
 func (m *Mysql) jsonify(rows *sql.Rows) ([]byte, error) {
+	ret, err := m.rowMaps(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(ret)
+}
+
+func (m *Mysql) rowMaps(rows *sql.Rows) ([]map[string]interface{}, error) {
 	columnTypes, err := rows.ColumnTypes()
 	if err != nil {
 		return nil, err
 	}
 
-	var ret []interface{}
+	var ret []map[string]interface{}
 	for rows.Next() {
 		values := prepareValues(columnTypes)
 		err := rows.Scan(values...)
@@ -299,11 +424,10 @@ func (m *Mysql) jsonify(rows *sql.Rows) ([]byte, error) {
 			return nil, err
 		}
 
-		r := m.convert(columnTypes, values)
-		ret = append(ret, r)
+		ret = append(ret, m.convert(columnTypes, values))
 	}
 
-	return json.Marshal(ret)
+	return ret, rows.Err()
 }
 
 func prepareValues(columnTypes []*sql.ColumnType) []interface{} {
@@ -335,11 +459,9 @@ func (m *Mysql) convert(columnTypes []*sql.ColumnType, values []interface{}) map
 			}
 		case *sql.RawBytes:
 			// special case for sql.RawBytes, see https://github.com/go-sql-driver/mysql/blob/master/fields.go#L178
-			switch ct.DatabaseTypeName() {
-			case "VARCHAR", "CHAR", "TEXT", "LONGTEXT":
-				value = string(*v)
-			}
+			value = []byte(*v)
 		}
+		value = sqlcomponent.DecodeValue(ct.DatabaseTypeName(), value)
 
 		if value != nil {
 			r[ct.Name()] = value
@@ -53,10 +53,11 @@ func TestOperations(t *testing.T) {
 		b := NewMysql(nil)
 		assert.NotNil(t, b)
 		l := b.Operations()
-		assert.Equal(t, 3, len(l))
+		assert.Equal(t, 4, len(l))
 		assert.Contains(t, l, execOperation)
 		assert.Contains(t, l, closeOperation)
 		assert.Contains(t, l, queryOperation)
+		assert.Contains(t, l, txOperation)
 	})
 }
 
@@ -143,6 +144,42 @@ func TestMysqlIntegration(t *testing.T) {
 		t.Logf("time stamp is: %v", tt)
 	})
 
+	t.Run("Invoke select with params", func(t *testing.T) {
+		req.Operation = queryOperation
+		req.Metadata[commandSQLKey] = "SELECT v1 FROM foo WHERE id = ? AND v1 = ?"
+		req.Metadata[commandParamsKey] = `[1, "test-1"]`
+		defer delete(req.Metadata, commandParamsKey)
+		res, err := b.Invoke(context.TODO(), req)
+		assertResponse(t, res, err)
+		assert.JSONEq(t, `[{"v1":"test-1"}]`, string(res.Data))
+	})
+
+	t.Run("Invoke tx", func(t *testing.T) {
+		txReq := &bindings.InvokeRequest{
+			Operation: txOperation,
+			Data: []byte(`[
+				{"sql": "UPDATE foo SET v1 = ? WHERE id = ?", "params": ["tx-1", 1]},
+				{"sql": "UPDATE foo SET v1 = ? WHERE id < ?", "params": ["tx-2", 0]}
+			]`),
+		}
+		res, err := b.Invoke(context.TODO(), txReq)
+		assertResponse(t, res, err)
+		assert.Equal(t, "1", res.Metadata[respRowsAffectedKey])
+		assert.JSONEq(t, `[1, 0]`, string(res.Data))
+
+		// The failing statement rolls back the transaction
+		txReq.Data = []byte(`[{"sql": "UPDATE foo SET v1 = ? WHERE id = ?", "params": ["tx-3", 1]}, {"sql": "NOT SQL"}]`)
+		_, err = b.Invoke(context.TODO(), txReq)
+		assert.Error(t, err)
+
+		res, err = b.Invoke(context.TODO(), &bindings.InvokeRequest{
+			Operation: queryOperation,
+			Metadata:  map[string]string{commandSQLKey: "SELECT v1 FROM foo WHERE id = ?", commandParamsKey: `[1]`},
+		})
+		assertResponse(t, res, err)
+		assert.JSONEq(t, `[{"v1":"tx-1"}]`, string(res.Data))
+	})
+
 	t.Run("Invoke select JSON_EXTRACT", func(t *testing.T) {
 		req.Operation = queryOperation
 		req.Metadata[commandSQLKey] = testSelectJSONExtract
@@ -150,8 +187,8 @@ func TestMysqlIntegration(t *testing.T) {
 		assertResponse(t, res, err)
 		t.Logf("received result: %s", res.Data)
 
-		// verify json extract number
-		assert.Contains(t, string(res.Data), "{\"key\":\"\\\"val\\\"\"}")
+		// verify json extract is decoded as JSON
+		assert.Contains(t, string(res.Data), "{\"key\":\"val\"}")
 
 		result := make([]interface{}, 0)
 		err = json.Unmarshal(res.Data, &result)
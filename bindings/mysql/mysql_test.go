@@ -15,15 +15,21 @@ package mysql
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/JY29/components-contrib/bindings"
+	sqlcomponent "github.com/JY29/components-contrib/internal/component/sql"
+	"github.com/JY29/components-contrib/state"
+	inmemory "github.com/JY29/components-contrib/state/in-memory"
 	"github.com/dapr/kit/logger"
 )
 
@@ -80,6 +86,78 @@ func TestQuery(t *testing.T) {
 	})
 }
 
+func TestQueryTypedResults(t *testing.T) {
+	m, mock, _ := mockDatabase(t)
+	defer m.Close()
+
+	col1 := sqlmock.NewColumn("price").OfType("DECIMAL", sql.RawBytes{})
+	col2 := sqlmock.NewColumn("doc").OfType("JSON", sql.RawBytes{})
+	col3 := sqlmock.NewColumn("name").OfType("VARCHAR", sql.RawBytes{})
+	rows := sqlmock.NewRowsWithColumnDefinition(col1, col2, col3).AddRow("12.50", `{"a":[1,2]}`, "widget")
+	mock.ExpectQuery("SELECT").WithArgs(int64(7)).WillReturnRows(rows)
+
+	ret, err := m.query(context.Background(), "SELECT price, doc, name FROM items WHERE id = ?", int64(7))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `[{"price":12.50,"doc":{"a":[1,2]},"name":"widget"}]`, string(ret))
+}
+
+func TestRead(t *testing.T) {
+	m, mock, _ := mockDatabase(t)
+	defer m.Close()
+
+	t.Run("pollQuery required", func(t *testing.T) {
+		err := m.Read(context.Background(), nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("poll new rows", func(t *testing.T) {
+		store := inmemory.NewInMemoryStateStore(logger.NewLogger("test"))
+		require.NoError(t, store.Init(state.Metadata{}))
+		m.opts.StateStore = store
+		m.metadata = sqlcomponent.PollMetadata{
+			PollQuery:        "SELECT id, name FROM events WHERE id > ? ORDER BY id",
+			WatermarkColumn:  "id",
+			InitialWatermark: "1",
+		}
+		require.NoError(t, m.metadata.Validate("events"))
+		m.metadata.PollInterval = time.Hour
+
+		col1 := sqlmock.NewColumn("id").OfType("BIGINT", int64(0))
+		col2 := sqlmock.NewColumn("name").OfType("VARCHAR", "")
+		rows := sqlmock.NewRowsWithColumnDefinition(col1, col2).AddRow(2, "b").AddRow(3, "c")
+		mock.ExpectQuery("SELECT id, name FROM events").WithArgs(int64(1)).WillReturnRows(rows)
+
+		var (
+			lock     sync.Mutex
+			received []*bindings.ReadResponse
+		)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		err := m.Read(ctx, func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			received = append(received, res)
+			return nil, nil
+		})
+		require.NoError(t, err)
+
+		assert.Eventually(t, func() bool {
+			lock.Lock()
+			defer lock.Unlock()
+			return len(received) == 2
+		}, time.Second, 10*time.Millisecond)
+		lock.Lock()
+		defer lock.Unlock()
+		assert.JSONEq(t, `{"id":2,"name":"b"}`, string(received[0].Data))
+		assert.Equal(t, "3", received[1].Metadata[sqlcomponent.WatermarkMetadataKey])
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		res, err := store.Get(ctx, &state.GetRequest{Key: "events-watermark"})
+		require.NoError(t, err)
+		assert.Equal(t, "3", string(res.Data))
+	})
+}
+
 func TestExec(t *testing.T) {
 	m, mock, _ := mockDatabase(t)
 	defer m.Close()
@@ -153,6 +231,69 @@ func TestInvoke(t *testing.T) {
 		assert.NotNil(t, err)
 	})
 
+	t.Run("exec operation with params", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO foo \\(id, v1\\) VALUES \\(\\?, \\?\\)").
+			WithArgs(int64(1), "'; DROP TABLE foo; --").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		req := &bindings.InvokeRequest{
+			Metadata: map[string]string{
+				commandSQLKey:    "INSERT INTO foo (id, v1) VALUES (?, ?)",
+				commandParamsKey: `[1, "'; DROP TABLE foo; --"]`,
+			},
+			Operation: execOperation,
+		}
+		resp, err := m.Invoke(context.Background(), req)
+		assert.Nil(t, err)
+		assert.Equal(t, "1", resp.Metadata[respRowsAffectedKey])
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		req := &bindings.InvokeRequest{
+			Metadata: map[string]string{
+				commandSQLKey:    "SELECT * FROM foo WHERE id = ?",
+				commandParamsKey: `{"id": 1}`,
+			},
+			Operation: queryOperation,
+		}
+		resp, err := m.Invoke(context.Background(), req)
+		assert.Nil(t, resp)
+		assert.ErrorContains(t, err, commandParamsKey)
+	})
+
+	t.Run("tx operation succeeds", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE accounts SET balance = balance - \\? WHERE id = \\?").WithArgs(10.5, "a").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE accounts SET balance = balance \\+ \\? WHERE id = \\?").WithArgs(10.5, "b").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		req := &bindings.InvokeRequest{
+			Data: []byte(`[
+				{"sql": "UPDATE accounts SET balance = balance - ? WHERE id = ?", "params": [10.5, "a"]},
+				{"sql": "UPDATE accounts SET balance = balance + ? WHERE id = ?", "params": [10.5, "b"]}
+			]`),
+			Operation: txOperation,
+		}
+		resp, err := m.Invoke(context.Background(), req)
+		assert.Nil(t, err)
+		assert.Equal(t, "2", resp.Metadata[respRowsAffectedKey])
+		assert.JSONEq(t, `[1, 1]`, string(resp.Data))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("tx operation is rolled back", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE accounts").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE accounts").WillReturnError(errors.New("update failed"))
+		mock.ExpectRollback()
+		req := &bindings.InvokeRequest{
+			Data:      []byte(`[{"sql": "UPDATE accounts SET balance = 0"}, {"sql": "UPDATE accounts SET balance = 1"}]`),
+			Operation: txOperation,
+		}
+		resp, err := m.Invoke(context.Background(), req)
+		assert.Nil(t, resp)
+		assert.ErrorContains(t, err, "statement 1")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("close operation", func(t *testing.T) {
 		mock.ExpectClose()
 		req := &bindings.InvokeRequest{
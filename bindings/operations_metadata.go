@@ -0,0 +1,139 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bindings
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/JY29/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// Formats of the data of the requests and of the responses.
+const (
+	// DataFormatNone is the format of the operations ignoring the data.
+	DataFormatNone = "none"
+	// DataFormatJSON is the format of the data which is a JSON document.
+	DataFormatJSON = "json"
+	// DataFormatBytes is the format of the data which is passed as it is, like the content of a file.
+	DataFormatBytes = "bytes"
+)
+
+// StrictMetadataKey is the property of the components enabling the strict mode, where the requests with unknown metadata are rejected.
+const StrictMetadataKey = "strictMetadata"
+
+// ErrUnknownMetadata is returned in strict mode for the requests with metadata not accepted by their operation.
+var ErrUnknownMetadata = errors.New("unknown request metadata")
+
+// ignoredRequestMetadata holds the keys of the request metadata set by the runtime for any binding, which are never unknown.
+//
+//nolint:gochecknoglobals
+var ignoredRequestMetadata = map[string]struct{}{
+	"traceparent": {},
+	"tracestate":  {},
+}
+
+// OperationMetadata describes the request and the response of an operation of an output binding.
+type OperationMetadata struct {
+	// Metadata maps the keys of the request metadata accepted by the operation to their type.
+	Metadata map[string]string `json:"metadata"`
+	// DataFormat is the format of the data of the request.
+	DataFormat string `json:"dataFormat"`
+	// ResponseMetadata maps the keys of the response metadata to their type.
+	ResponseMetadata map[string]string `json:"responseMetadata"`
+	// ResponseDataFormat is the format of the data of the response.
+	ResponseDataFormat string `json:"responseDataFormat"`
+}
+
+// OperationsMetadataGetter is implemented by the output bindings which describe their operations.
+type OperationsMetadataGetter interface {
+	// GetOperationsMetadata returns the description of the operations, by operation.
+	GetOperationsMetadata() map[OperationKind]OperationMetadata
+}
+
+// NewOperationMetadata returns the description of an operation whose request and response metadata are the fields of structs,
+// named after their mapstructure tag like with metadata.GetMetadataInfoFromStructType.
+// The structs are nil for the operations without request or response metadata.
+func NewOperationMetadata(dataFormat string, requestMetadata interface{}, responseDataFormat string, responseMetadata interface{}) OperationMetadata {
+	return OperationMetadata{
+		Metadata:           metadataInfo(requestMetadata),
+		DataFormat:         dataFormat,
+		ResponseMetadata:   metadataInfo(responseMetadata),
+		ResponseDataFormat: responseDataFormat,
+	}
+}
+
+func metadataInfo(metadataStruct interface{}) map[string]string {
+	info := map[string]string{}
+	if metadataStruct != nil {
+		metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &info)
+	}
+
+	return info
+}
+
+// UnknownMetadata returns the keys of the request metadata which are not accepted by the operation, sorted.
+// The keys are case-insensitive, like with metadata.DecodeMetadata.
+func (o OperationMetadata) UnknownMetadata(md map[string]string) []string {
+	var unknown []string
+	for k := range md {
+		if _, ok := ignoredRequestMetadata[strings.ToLower(k)]; ok {
+			continue
+		}
+		if _, ok := o.Metadata[k]; ok {
+			continue
+		}
+		known := false
+		for accepted := range o.Metadata {
+			if strings.EqualFold(k, accepted) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+
+	return unknown
+}
+
+// CheckRequestMetadata checks the request metadata of the operations described by the binding.
+// The unknown keys are logged as a warning, or returned in an error wrapping ErrUnknownMetadata in strict mode.
+func CheckRequestMetadata(binding OutputBinding, operation OperationKind, md map[string]string, strict bool, log logger.Logger) error {
+	getter, ok := binding.(OperationsMetadataGetter)
+	if !ok {
+		return nil
+	}
+	operationMetadata, ok := getter.GetOperationsMetadata()[operation]
+	if !ok {
+		return nil
+	}
+
+	unknown := operationMetadata.UnknownMetadata(md)
+	if len(unknown) == 0 {
+		return nil
+	}
+	if strict {
+		return fmt.Errorf("%w for operation %s: %s", ErrUnknownMetadata, operation, strings.Join(unknown, ", "))
+	}
+	log.Warnf("Ignoring unknown request metadata for operation %s: %s", operation, strings.Join(unknown, ", "))
+
+	return nil
+}
@@ -19,10 +19,15 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pkg/errors"
 
 	"github.com/JY29/components-contrib/bindings"
+	sqlcomponent "github.com/JY29/components-contrib/internal/component/sql"
+	"github.com/JY29/components-contrib/metadata"
+	"github.com/JY29/components-contrib/state"
 	"github.com/dapr/kit/logger"
 )
 
@@ -31,20 +36,51 @@ const (
 	execOperation  bindings.OperationKind = "exec"
 	queryOperation bindings.OperationKind = "query"
 	closeOperation bindings.OperationKind = "close"
+	// txOperation runs the statements of the request data in a transaction.
+	txOperation bindings.OperationKind = "tx"
 
 	connectionURLKey = "url"
-	commandSQLKey    = "sql"
+	commandSQLKey    = sqlcomponent.SQLKey
+	commandParamsKey = sqlcomponent.ParamsKey
+
+	// channelMetadataKey is the metadata key of the channel of the notifications.
+	channelMetadataKey = "channel"
+	// listenRetryDelay is the delay before listening again after the connection was lost.
+	listenRetryDelay = 5 * time.Second
 )
 
-// Postgres represents PostgreSQL output binding.
+// Postgres represents PostgreSQL input and output binding.
 type Postgres struct {
-	logger logger.Logger
-	db     *pgxpool.Pool
+	logger   logger.Logger
+	db       *pgxpool.Pool
+	metadata postgresMetadata
+	opts     Options
+}
+
+type postgresMetadata struct {
+	sqlcomponent.PollMetadata `mapstructure:",squash"`
+	// NotifyChannel is the channel listened for notifications, whose payloads are delivered instead of polling a query.
+	NotifyChannel string `mapstructure:"notifyChannel"`
 }
 
-// NewPostgres returns a new PostgreSQL output binding.
-func NewPostgres(logger logger.Logger) bindings.OutputBinding {
-	return &Postgres{logger: logger}
+// Options holds the optional dependencies of the binding.
+type Options struct {
+	// StateStore persists the watermark of the polled query.
+	StateStore state.Store
+	// StateStores resolves the state store named by the stateStoreName metadata, instead of StateStore.
+	StateStores state.StoreResolver
+}
+
+// NewPostgres returns a new PostgreSQL input and output binding.
+// The watermark of the polled query is persisted in the state store named by the stateStoreName metadata,
+// which is resolved with the Options given to NewPostgresWithOptions.
+func NewPostgres(logger logger.Logger) bindings.InputOutputBinding {
+	return NewPostgresWithOptions(logger, Options{})
+}
+
+// NewPostgresWithOptions returns a new PostgreSQL input and output binding using the given state store.
+func NewPostgresWithOptions(logger logger.Logger, opts Options) bindings.InputOutputBinding {
+	return &Postgres{logger: logger, opts: opts}
 }
 
 // Init initializes the PostgreSql binding.
@@ -54,6 +90,11 @@ func (p *Postgres) Init(metadata bindings.Metadata) error {
 		return errors.Errorf("required metadata not set: %s", connectionURLKey)
 	}
 
+	err := p.parseMetadata(metadata)
+	if err != nil {
+		return err
+	}
+
 	poolConfig, err := pgxpool.ParseConfig(url)
 	if err != nil {
 		return errors.Wrap(err, "error opening DB connection")
@@ -67,11 +108,92 @@ func (p *Postgres) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (p *Postgres) parseMetadata(meta bindings.Metadata) error {
+	m := postgresMetadata{}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return err
+	}
+	if m.PollQuery != "" && m.NotifyChannel != "" {
+		return errors.New("pollQuery and notifyChannel are mutually exclusive")
+	}
+	if err = m.Validate(meta.Name); err != nil {
+		return err
+	}
+	p.opts.StateStore, err = m.ResolveStateStore(p.opts.StateStore, p.opts.StateStores)
+	if err != nil {
+		return err
+	}
+	if m.PollQuery != "" && p.opts.StateStore == nil {
+		p.logger.Warn("The watermark is not persisted without a state store: the rows are delivered again from initialWatermark at each start")
+	}
+	p.metadata = m
+
+	return nil
+}
+
+// Read polls the query for new rows, or listens to the channel for notifications.
+func (p *Postgres) Read(ctx context.Context, handler bindings.Handler) error {
+	switch {
+	case p.metadata.PollQuery != "":
+		return sqlcomponent.NewPoller(p.metadata.PollMetadata, p.queryRows, p.opts.StateStore, p.logger).Start(ctx, handler)
+	case p.metadata.NotifyChannel != "":
+		go func() {
+			for {
+				err := p.listen(ctx, handler)
+				if ctx.Err() != nil {
+					return
+				}
+				p.logger.Errorf("error listening to channel %s, retrying in %v: %v", p.metadata.NotifyChannel, listenRetryDelay, err)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(listenRetryDelay):
+				}
+			}
+		}()
+
+		return nil
+	default:
+		return errors.New("either pollQuery or notifyChannel is required to read")
+	}
+}
+
+// listen delivers the payloads of the notifications of the channel until the connection fails.
+func (p *Postgres) listen(ctx context.Context, handler bindings.Handler) error {
+	conn, err := p.db.Acquire(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error acquiring connection")
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{p.metadata.NotifyChannel}.Sanitize()); err != nil {
+		return errors.Wrap(err, "error listening to channel")
+	}
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			if pgconn.Timeout(err) || errors.Is(err, context.Canceled) {
+				return ctx.Err()
+			}
+			return errors.Wrap(err, "error waiting for notification")
+		}
+		_, err = handler(ctx, &bindings.ReadResponse{
+			Data:     []byte(notification.Payload),
+			Metadata: map[string]string{channelMetadataKey: notification.Channel},
+		})
+		if err != nil {
+			p.logger.Errorf("error handling notification of channel %s: %v", notification.Channel, err)
+		}
+	}
+}
+
 // Operations returns list of operations supported by PostgreSql binding.
 func (p *Postgres) Operations() []bindings.OperationKind {
 	return []bindings.OperationKind{
 		execOperation,
 		queryOperation,
+		txOperation,
 		closeOperation,
 	}
 }
@@ -88,44 +210,65 @@ func (p *Postgres) Invoke(ctx context.Context, req *bindings.InvokeRequest) (res
 		return nil, nil
 	}
 
-	if req.Metadata == nil {
-		return nil, errors.Errorf("metadata required")
-	}
 	p.logger.Debugf("operation: %v", req.Operation)
 
-	sql, ok := req.Metadata[commandSQLKey]
-	if !ok || sql == "" {
-		return nil, errors.Errorf("required metadata not set: %s", commandSQLKey)
-	}
-
 	startTime := time.Now().UTC()
 	resp = &bindings.InvokeResponse{
 		Metadata: map[string]string{
 			"operation":  string(req.Operation),
-			"sql":        sql,
 			"start-time": startTime.Format(time.RFC3339Nano),
 		},
 	}
 
 	switch req.Operation { //nolint:exhaustive
-	case execOperation:
-		r, err := p.exec(ctx, sql)
+	case execOperation, queryOperation:
+		statement, err := sqlcomponent.StatementFromMetadata(req.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		args, err := statement.Args()
 		if err != nil {
-			return nil, errors.Wrapf(err, "error executing %s with %v", sql, err)
+			return nil, err
+		}
+		sql := statement.SQL
+		resp.Metadata["sql"] = sql
+
+		if req.Operation == execOperation {
+			r, err := p.exec(ctx, sql, args...)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error executing %s with %v", sql, err)
+			}
+			resp.Metadata["rows-affected"] = strconv.FormatInt(r, 10) // 0 if error
+		} else {
+			d, err := p.query(ctx, sql, args...)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error executing %s with %v", sql, err)
+			}
+			resp.Data = d
 		}
-		resp.Metadata["rows-affected"] = strconv.FormatInt(r, 10) // 0 if error
 
-	case queryOperation:
-		d, err := p.query(ctx, sql)
+	case txOperation:
+		statements, err := sqlcomponent.ParseStatements(req.Data)
+		if err != nil {
+			return nil, err
+		}
+		rowsAffected, err := p.tx(ctx, statements)
 		if err != nil {
-			return nil, errors.Wrapf(err, "error executing %s with %v", sql, err)
+			return nil, err
+		}
+		var total int64
+		for _, r := range rowsAffected {
+			total += r
+		}
+		resp.Metadata["rows-affected"] = strconv.FormatInt(total, 10)
+		if resp.Data, err = json.Marshal(rowsAffected); err != nil {
+			return nil, errors.Wrap(err, "error serializing results")
 		}
-		resp.Data = d
 
 	default:
 		return nil, errors.Errorf(
-			"invalid operation type: %s. Expected %s, %s, or %s",
-			req.Operation, execOperation, queryOperation, closeOperation,
+			"invalid operation type: %s. Expected %s, %s, %s, or %s",
+			req.Operation, execOperation, queryOperation, txOperation, closeOperation,
 		)
 	}
 
@@ -146,22 +289,30 @@ func (p *Postgres) Close() error {
 	return nil
 }
 
-func (p *Postgres) query(ctx context.Context, sql string) (result []byte, err error) {
+func (p *Postgres) query(ctx context.Context, sql string, args ...any) (result []byte, err error) {
 	p.logger.Debugf("query: %s", sql)
 
-	rows, err := p.db.Query(ctx, sql)
+	rows, err := p.db.Query(ctx, sql, args...)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error executing %s", sql)
 	}
+	defer rows.Close()
 
+	typeNames := columnTypeNames(rows)
 	rs := make([]any, 0)
 	for rows.Next() {
 		val, rowErr := rows.Values()
 		if rowErr != nil {
 			return nil, errors.Wrapf(rowErr, "error parsing result: %v", rows.Err())
 		}
+		for i := range val {
+			val[i] = sqlcomponent.DecodeValue(typeNames[i], val[i])
+		}
 		rs = append(rs, val) //nolint:asasalint
 	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "error executing %s", sql)
+	}
 
 	if result, err = json.Marshal(rs); err != nil {
 		err = errors.Wrap(err, "error serializing results")
@@ -170,10 +321,52 @@ func (p *Postgres) query(ctx context.Context, sql string) (result []byte, err er
 	return
 }
 
-func (p *Postgres) exec(ctx context.Context, sql string) (result int64, err error) {
+// queryRows returns the rows as maps of the column names to their values.
+func (p *Postgres) queryRows(ctx context.Context, sql string, args ...any) ([]map[string]any, error) {
+	rows, err := p.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error executing %s", sql)
+	}
+	defer rows.Close()
+
+	typeNames := columnTypeNames(rows)
+	fields := rows.FieldDescriptions()
+	var rs []map[string]any
+	for rows.Next() {
+		val, rowErr := rows.Values()
+		if rowErr != nil {
+			return nil, errors.Wrapf(rowErr, "error parsing result: %v", rows.Err())
+		}
+		row := make(map[string]any, len(val))
+		for i := range val {
+			row[fields[i].Name] = sqlcomponent.DecodeValue(typeNames[i], val[i])
+		}
+		rs = append(rs, row)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "error executing %s", sql)
+	}
+
+	return rs, nil
+}
+
+// columnTypeNames returns the names of the types of the columns of the rows.
+func columnTypeNames(rows pgx.Rows) []string {
+	fields := rows.FieldDescriptions()
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		if t, ok := rows.Conn().TypeMap().TypeForOID(f.DataTypeOID); ok {
+			names[i] = t.Name
+		}
+	}
+
+	return names
+}
+
+func (p *Postgres) exec(ctx context.Context, sql string, args ...any) (result int64, err error) {
 	p.logger.Debugf("exec: %s", sql)
 
-	res, err := p.db.Exec(ctx, sql)
+	res, err := p.db.Exec(ctx, sql, args...)
 	if err != nil {
 		return 0, errors.Wrapf(err, "error executing %s", sql)
 	}
@@ -182,3 +375,38 @@ func (p *Postgres) exec(ctx context.Context, sql string) (result int64, err erro
 
 	return
 }
+
+// tx executes the statements in a transaction, which is rolled back if any of them fails.
+// It returns the number of rows affected by each statement.
+func (p *Postgres) tx(ctx context.Context, statements []sqlcomponent.Statement) (result []int64, err error) {
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error starting transaction")
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	result = make([]int64, len(statements))
+	for i, statement := range statements {
+		p.logger.Debugf("tx exec: %s", statement.SQL)
+
+		args, argsErr := statement.Args()
+		if argsErr != nil {
+			return nil, errors.Wrapf(argsErr, "error executing statement %d of transaction", i)
+		}
+		res, execErr := tx.Exec(ctx, statement.SQL, args...)
+		if execErr != nil {
+			return nil, errors.Wrapf(execErr, "error executing statement %d of transaction", i)
+		}
+		result[i] = res.RowsAffected()
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, errors.Wrap(err, "error committing transaction")
+	}
+
+	return result, nil
+}
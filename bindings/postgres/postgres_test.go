@@ -21,9 +21,12 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/JY29/components-contrib/bindings"
 	"github.com/JY29/components-contrib/metadata"
+	"github.com/JY29/components-contrib/state"
+	inmemory "github.com/JY29/components-contrib/state/in-memory"
 	"github.com/dapr/kit/logger"
 )
 
@@ -44,7 +47,58 @@ func TestOperations(t *testing.T) {
 		b := NewPostgres(nil)
 		assert.NotNil(t, b)
 		l := b.Operations()
-		assert.Equal(t, 3, len(l))
+		assert.Equal(t, 4, len(l))
+	})
+}
+
+func TestParseMetadata(t *testing.T) {
+	t.Parallel()
+	props := func(kv ...string) bindings.Metadata {
+		m := bindings.Metadata{Base: metadata.Base{Name: "events", Properties: map[string]string{connectionURLKey: "postgres://localhost/db"}}}
+		for i := 0; i < len(kv); i += 2 {
+			m.Properties[kv[i]] = kv[i+1]
+		}
+		return m
+	}
+
+	t.Run("output only", func(t *testing.T) {
+		b := NewPostgres(logger.NewLogger("test")).(*Postgres)
+		assert.NoError(t, b.parseMetadata(props()))
+		assert.Error(t, b.Read(context.Background(), nil))
+	})
+
+	t.Run("poll query", func(t *testing.T) {
+		b := NewPostgres(logger.NewLogger("test")).(*Postgres)
+		err := b.parseMetadata(props("pollQuery", "SELECT * FROM events WHERE id > $1 ORDER BY id",
+			"watermarkColumn", "id", "initialWatermark", "0", "pollInterval", "1s"))
+		assert.NoError(t, err)
+		assert.Equal(t, time.Second, b.metadata.PollInterval)
+		assert.Equal(t, "events-watermark", b.metadata.WatermarkKey)
+	})
+
+	t.Run("named state store", func(t *testing.T) {
+		store := inmemory.NewInMemoryStateStore(logger.NewLogger("test"))
+		b := NewPostgresWithOptions(logger.NewLogger("test"), Options{StateStores: func(name string) (state.Store, bool) {
+			return store, name == "statestore"
+		}}).(*Postgres)
+		pollProps := []string{"pollQuery", "SELECT * FROM events WHERE id > $1 ORDER BY id",
+			"watermarkColumn", "id", "initialWatermark", "0"}
+		require.NoError(t, b.parseMetadata(props(append(pollProps, "stateStoreName", "statestore")...)))
+		assert.Equal(t, store, b.opts.StateStore)
+		assert.Error(t, b.parseMetadata(props(append(pollProps, "stateStoreName", "other")...)))
+	})
+
+	t.Run("poll query without watermark column", func(t *testing.T) {
+		b := NewPostgres(logger.NewLogger("test")).(*Postgres)
+		err := b.parseMetadata(props("pollQuery", "SELECT * FROM events", "initialWatermark", "0"))
+		assert.Error(t, err)
+	})
+
+	t.Run("poll query and notify channel", func(t *testing.T) {
+		b := NewPostgres(logger.NewLogger("test")).(*Postgres)
+		err := b.parseMetadata(props("pollQuery", "SELECT * FROM events WHERE id > $1", "watermarkColumn", "id",
+			"initialWatermark", "0", "notifyChannel", "events"))
+		assert.Error(t, err)
 	})
 }
 
@@ -108,6 +162,74 @@ func TestPostgresIntegration(t *testing.T) {
 		assertResponse(t, res, err)
 	})
 
+	t.Run("Invoke select with params", func(t *testing.T) {
+		req.Operation = queryOperation
+		req.Metadata[commandSQLKey] = "SELECT id, v1 FROM foo WHERE id = $1 AND v1 = $2"
+		req.Metadata[commandParamsKey] = `[1, "test-1"]`
+		defer delete(req.Metadata, commandParamsKey)
+		res, err := b.Invoke(ctx, req)
+		assertResponse(t, res, err)
+		assert.JSONEq(t, `[[1, "test-1"]]`, string(res.Data))
+	})
+
+	t.Run("Invoke tx", func(t *testing.T) {
+		txReq := &bindings.InvokeRequest{
+			Operation: txOperation,
+			Data: []byte(`[
+				{"sql": "UPDATE foo SET v1 = $1 WHERE id = $2", "params": ["tx-1", 1]},
+				{"sql": "UPDATE foo SET v1 = $1 WHERE id < $2", "params": ["tx-2", 0]}
+			]`),
+		}
+		res, err := b.Invoke(ctx, txReq)
+		assertResponse(t, res, err)
+		assert.Equal(t, "1", res.Metadata["rows-affected"])
+		assert.JSONEq(t, `[1, 0]`, string(res.Data))
+
+		// The failing statement rolls back the transaction
+		txReq.Data = []byte(`[{"sql": "UPDATE foo SET v1 = $1 WHERE id = $2", "params": ["tx-3", 1]}, {"sql": "NOT SQL"}]`)
+		_, err = b.Invoke(ctx, txReq)
+		assert.Error(t, err)
+
+		res, err = b.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: queryOperation,
+			Metadata:  map[string]string{commandSQLKey: "SELECT v1 FROM foo WHERE id = $1", commandParamsKey: `[1]`},
+		})
+		assertResponse(t, res, err)
+		assert.JSONEq(t, `[["tx-1"]]`, string(res.Data))
+	})
+
+	t.Run("Read notifications", func(t *testing.T) {
+		l := NewPostgres(logger.NewLogger("test")).(*Postgres)
+		lm := bindings.Metadata{Base: metadata.Base{Properties: map[string]string{connectionURLKey: url, "notifyChannel": "foo_events"}}}
+		require.NoError(t, l.Init(lm))
+		defer l.Close()
+
+		received := make(chan *bindings.ReadResponse, 1)
+		readCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		require.NoError(t, l.Read(readCtx, func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+			select {
+			case received <- res:
+			default:
+			}
+			return nil, nil
+		}))
+
+		// The listener may not be ready for the first notifications
+		assert.Eventually(t, func() bool {
+			_, err := b.db.Exec(ctx, "SELECT pg_notify('foo_events', '{\"id\":1}')")
+			require.NoError(t, err)
+			select {
+			case res := <-received:
+				assert.JSONEq(t, `{"id":1}`, string(res.Data))
+				assert.Equal(t, "foo_events", res.Metadata[channelMetadataKey])
+				return true
+			case <-time.After(100 * time.Millisecond):
+				return false
+			}
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
 	t.Run("Invoke delete", func(t *testing.T) {
 		req.Operation = execOperation
 		req.Metadata[commandSQLKey] = testDelete
@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bindings
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// RangeMetadataKey is the metadata key of the byte range of a get operation, formatted as "start-end" with an inclusive end,
+	// or "start-" to read until the end of the object.
+	RangeMetadataKey = "range"
+
+	// ContentLengthMetadataKey is the metadata key of the length of the data of a response.
+	ContentLengthMetadataKey = "contentLength"
+)
+
+// StreamInvokeRequest is an InvokeRequest whose data is streamed.
+type StreamInvokeRequest struct {
+	Data      io.Reader
+	Metadata  map[string]string
+	Operation OperationKind
+}
+
+// StreamInvokeResponse is an InvokeResponse whose data is streamed.
+// The caller must close Data.
+type StreamInvokeResponse struct {
+	Data        io.ReadCloser
+	Metadata    map[string]string
+	ContentType *string
+}
+
+// StreamingOutputBinding is implemented by the output bindings which can stream the data of the requests and of the responses,
+// instead of holding them in memory.
+type StreamingOutputBinding interface {
+	OutputBinding
+	// InvokeStream is like Invoke with streamed data.
+	InvokeStream(ctx context.Context, req *StreamInvokeRequest) (*StreamInvokeResponse, error)
+}
+
+// InvokeStream invokes a binding with streamed data.
+// The data is buffered if the binding doesn't implement StreamingOutputBinding.
+func InvokeStream(ctx context.Context, binding OutputBinding, req *StreamInvokeRequest) (*StreamInvokeResponse, error) {
+	if s, ok := binding.(StreamingOutputBinding); ok {
+		return s.InvokeStream(ctx, req)
+	}
+
+	return BufferedInvokeStream(ctx, binding, req)
+}
+
+// BufferedInvokeStream invokes a binding with the data of the request read in memory, and returns a response streaming the data in memory.
+// Streaming bindings use it for the operations they don't stream.
+func BufferedInvokeStream(ctx context.Context, binding OutputBinding, req *StreamInvokeRequest) (*StreamInvokeResponse, error) {
+	invokeReq := &InvokeRequest{
+		Metadata:  req.Metadata,
+		Operation: req.Operation,
+	}
+	if req.Data != nil {
+		data, err := io.ReadAll(req.Data)
+		if err != nil {
+			return nil, fmt.Errorf("error reading the request data: %w", err)
+		}
+		invokeReq.Data = data
+	}
+
+	res, err := binding.Invoke(ctx, invokeReq)
+	if err != nil || res == nil {
+		return nil, err
+	}
+
+	return &StreamInvokeResponse{
+		Data:        io.NopCloser(bytes.NewReader(res.Data)),
+		Metadata:    res.Metadata,
+		ContentType: res.ContentType,
+	}, nil
+}
+
+// ByteRange is a range of bytes of an object.
+type ByteRange struct {
+	Start int64
+	// End is inclusive, and -1 when the range extends to the end of the object.
+	End int64
+}
+
+// ParseByteRange returns the byte range set in the metadata of a request, if any.
+func ParseByteRange(md map[string]string) (*ByteRange, error) {
+	val, ok := md[RangeMetadataKey]
+	if !ok || val == "" {
+		return nil, nil
+	}
+
+	start, end, ok := strings.Cut(strings.TrimPrefix(val, "bytes="), "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid %s %q: expected start-end", RangeMetadataKey, val)
+	}
+	r := &ByteRange{End: -1}
+	var err error
+	r.Start, err = strconv.ParseInt(start, 10, 64)
+	if err != nil || r.Start < 0 {
+		return nil, fmt.Errorf("invalid %s %q: invalid start", RangeMetadataKey, val)
+	}
+	if end != "" {
+		r.End, err = strconv.ParseInt(end, 10, 64)
+		if err != nil || r.End < r.Start {
+			return nil, fmt.Errorf("invalid %s %q: invalid end", RangeMetadataKey, val)
+		}
+	}
+
+	return r, nil
+}
+
+// Length returns the number of bytes of the range, or -1 when it extends to the end of the object.
+func (r ByteRange) Length() int64 {
+	if r.End < 0 {
+		return -1
+	}
+
+	return r.End - r.Start + 1
+}
+
+// HTTPHeader returns the range as the value of an HTTP Range header.
+func (r ByteRange) HTTPHeader() string {
+	if r.End < 0 {
+		return fmt.Sprintf("bytes=%d-", r.Start)
+	}
+
+	return fmt.Sprintf("bytes=%d-%d", r.Start, r.End)
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Providers of the webhooks, which define how the requests are signed.
+const (
+	ProviderGitHub = "github"
+	ProviderStripe = "stripe"
+	ProviderTwilio = "twilio"
+	// ProviderNone accepts the requests without verifying them.
+	ProviderNone = "none"
+)
+
+// Headers of the signatures.
+const (
+	githubSignatureHeader = "X-Hub-Signature-256"
+	stripeSignatureHeader = "Stripe-Signature"
+	twilioSignatureHeader = "X-Twilio-Signature"
+)
+
+var errInvalidSignature = errors.New("invalid signature")
+
+// verifier checks the signature of the requests of a provider.
+type verifier interface {
+	// verify returns the signature of the request if it is valid, which identifies the delivery to detect the replays.
+	// The delivery IDs sent in the headers are not signed, so that a replay could change them.
+	// The timestamp of the signed requests must be in the window around now.
+	verify(r *http.Request, body []byte, secret []byte, now time.Time, window time.Duration) (string, error)
+}
+
+func newVerifier(provider string, publicURL string) (verifier, error) {
+	switch provider {
+	case ProviderGitHub:
+		return githubVerifier{}, nil
+	case ProviderStripe:
+		return stripeVerifier{}, nil
+	case ProviderTwilio:
+		return twilioVerifier{publicURL: publicURL}, nil
+	case ProviderNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("invalid provider %q, expected %s, %s, %s or %s", provider, ProviderGitHub, ProviderStripe, ProviderTwilio, ProviderNone)
+	}
+}
+
+// githubVerifier checks the hex HMAC-SHA256 of the body, in the "sha256=" signature header.
+// GitHub doesn't sign a timestamp nor the GUID of the deliveries: the deliveries are identified by their signature.
+type githubVerifier struct{}
+
+func (githubVerifier) verify(r *http.Request, body []byte, secret []byte, now time.Time, window time.Duration) (string, error) {
+	signature := strings.TrimPrefix(r.Header.Get(githubSignatureHeader), "sha256=")
+	if signature == "" {
+		return "", fmt.Errorf("missing %s header", githubSignatureHeader)
+	}
+	if !hmac.Equal([]byte(signature), []byte(hexHMAC(secret, body))) {
+		return "", errInvalidSignature
+	}
+
+	return signature, nil
+}
+
+// stripeVerifier checks the hex HMAC-SHA256 of the timestamp and the body, in the "t=<timestamp>,v1=<signature>" header.
+// The header holds several signatures while the secret is rolled.
+type stripeVerifier struct{}
+
+func (stripeVerifier) verify(r *http.Request, body []byte, secret []byte, now time.Time, window time.Duration) (string, error) {
+	header := r.Header.Get(stripeSignatureHeader)
+	if header == "" {
+		return "", fmt.Errorf("missing %s header", stripeSignatureHeader)
+	}
+
+	var timestamp string
+	var signatures []string
+	for _, item := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(item), "=")
+		switch k {
+		case "t":
+			timestamp = v
+		case "v1":
+			signatures = append(signatures, v)
+		}
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid timestamp in %s header", stripeSignatureHeader)
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > window || age < -window {
+		return "", fmt.Errorf("timestamp %s outside of the tolerance window", timestamp)
+	}
+
+	expected := []byte(hexHMAC(secret, []byte(timestamp+"."+string(body))))
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), expected) {
+			return signature, nil
+		}
+	}
+
+	return "", errInvalidSignature
+}
+
+// twilioVerifier checks the base64 HMAC-SHA1 of the URL followed by the sorted form parameters.
+// The JSON requests are signed without parameters, with the hex SHA256 of the body in the bodySHA256 query parameter.
+// Twilio doesn't sign a timestamp nor the idempotency token of the deliveries: the deliveries are identified by their signature.
+type twilioVerifier struct {
+	// publicURL is the URL called by Twilio, which differs from the URL of the request behind a proxy.
+	publicURL string
+}
+
+func (v twilioVerifier) verify(r *http.Request, body []byte, secret []byte, now time.Time, window time.Duration) (string, error) {
+	signature := r.Header.Get(twilioSignatureHeader)
+	if signature == "" {
+		return "", fmt.Errorf("missing %s header", twilioSignatureHeader)
+	}
+
+	payload := v.url(r)
+	if bodySHA256 := r.URL.Query().Get("bodySHA256"); bodySHA256 != "" {
+		sum := sha256.Sum256(body)
+		if !hmac.Equal([]byte(bodySHA256), []byte(hex.EncodeToString(sum[:]))) {
+			return "", errors.New("invalid bodySHA256")
+		}
+	} else if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		params, err := url.ParseQuery(string(body))
+		if err != nil {
+			return "", fmt.Errorf("invalid form: %w", err)
+		}
+		keys := make([]string, 0, len(params))
+		for k := range params {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var b strings.Builder
+		b.WriteString(payload)
+		for _, k := range keys {
+			values := params[k]
+			sort.Strings(values)
+			for _, value := range values {
+				b.WriteString(k)
+				b.WriteString(value)
+			}
+		}
+		payload = b.String()
+	}
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write([]byte(payload))
+	if !hmac.Equal([]byte(signature), []byte(base64.StdEncoding.EncodeToString(mac.Sum(nil)))) {
+		return "", errInvalidSignature
+	}
+
+	return signature, nil
+}
+
+// url returns the URL called by Twilio, query included.
+func (v twilioVerifier) url(r *http.Request) string {
+	u := v.publicURL
+	if u == "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		u = scheme + "://" + r.Host + r.URL.Path
+	}
+	if r.URL.RawQuery != "" {
+		u += "?" + r.URL.RawQuery
+	}
+
+	return u
+}
+
+// hexHMAC returns the hex HMAC-SHA256 of the data.
+func hexHMAC(secret []byte, data []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(data)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,309 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+
+	"github.com/JY29/components-contrib/bindings"
+	"github.com/JY29/components-contrib/metadata"
+	"github.com/JY29/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	defaultPort            = 8080
+	defaultPath            = "/"
+	defaultToleranceWindow = 5 * time.Minute
+	defaultMaxBodySize     = 1 << 20
+	shutdownTimeout        = 5 * time.Second
+	// maxSeenDeliveries bounds the memory used to detect the replays: beyond it, the oldest deliveries are forgotten.
+	maxSeenDeliveries = 100000
+)
+
+// Binding is an input binding receiving the webhooks of a provider.
+// The signatures of the requests are verified, and the deliveries received again in the tolerance window are rejected.
+// The deliveries are identified by their signature, which an attacker replaying a request can't change.
+// Only Stripe signs a timestamp, so that its requests are rejected after the window too. The deliveries of GitHub and
+// Twilio are remembered in memory by each instance of the binding: their requests replayed after the window, after a
+// restart, or to another replica are accepted.
+type Binding struct {
+	logger   logger.Logger
+	metadata webhookMetadata
+	opts     Options
+	clk      clock.Clock
+	verifier verifier
+	secret   []byte
+	listener net.Listener
+
+	lock sync.Mutex
+	// seen holds the elements of seenOrder by signature.
+	seen map[string]*list.Element
+	// seenOrder holds the deliveries received in the tolerance window, oldest first.
+	seenOrder *list.List
+}
+
+type seenDelivery struct {
+	id string
+	at time.Time
+}
+
+type webhookMetadata struct {
+	// Port is the port of the server.
+	Port int `mapstructure:"port"`
+	// Path is the path of the webhooks.
+	Path string `mapstructure:"path"`
+	// Provider defines how the requests are signed: github, stripe, twilio or none.
+	Provider string `mapstructure:"provider"`
+	// Secret is the secret of the signatures.
+	Secret string `mapstructure:"secret"`
+	// SecretName is the name of the secret of the signatures in the secret store, instead of Secret.
+	SecretName string `mapstructure:"secretName"`
+	// SecretKey is the key of the secret of the signatures in the secret named SecretName, SecretName by default.
+	SecretKey string `mapstructure:"secretKey"`
+	// ToleranceWindow is how long the deliveries are remembered to reject the replays,
+	// and the maximum difference between the signed timestamps and now.
+	ToleranceWindow time.Duration `mapstructure:"toleranceWindow"`
+	// PublicURL is the URL called by the provider, when it differs from the URL received by the server behind a proxy.
+	PublicURL string `mapstructure:"publicURL"`
+	// MaxBodySize is the maximum size of the requests in bytes.
+	MaxBodySize int64 `mapstructure:"maxBodySize"`
+}
+
+// Options holds the optional dependencies of the binding.
+type Options struct {
+	// SecretStore holds the secret of the signatures, named by the secretName metadata.
+	SecretStore secretstores.SecretStore
+}
+
+// NewWebhook returns a new webhook input binding.
+func NewWebhook(logger logger.Logger) bindings.InputBinding {
+	return NewWebhookWithOptions(logger, Options{})
+}
+
+// NewWebhookWithOptions returns a new webhook input binding using the given secret store.
+func NewWebhookWithOptions(logger logger.Logger, opts Options) bindings.InputBinding {
+	return &Binding{
+		logger:    logger,
+		opts:      opts,
+		clk:       clock.New(),
+		seen:      map[string]*list.Element{},
+		seenOrder: list.New(),
+	}
+}
+
+// Init parses the metadata.
+func (b *Binding) Init(meta bindings.Metadata) error {
+	m := webhookMetadata{
+		Port:            defaultPort,
+		Path:            defaultPath,
+		ToleranceWindow: defaultToleranceWindow,
+		MaxBodySize:     defaultMaxBodySize,
+	}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return err
+	}
+
+	if m.Port < 0 || m.Port > 65535 {
+		return fmt.Errorf("invalid port: %d", m.Port)
+	}
+	if !strings.HasPrefix(m.Path, "/") {
+		m.Path = "/" + m.Path
+	}
+	if m.ToleranceWindow <= 0 {
+		return fmt.Errorf("invalid toleranceWindow: %v", m.ToleranceWindow)
+	}
+	if m.MaxBodySize <= 0 {
+		return fmt.Errorf("invalid maxBodySize: %d", m.MaxBodySize)
+	}
+
+	if m.Provider == "" {
+		return errors.New("provider is required")
+	}
+	b.verifier, err = newVerifier(strings.ToLower(m.Provider), m.PublicURL)
+	if err != nil {
+		return err
+	}
+	if b.verifier != nil {
+		switch {
+		case m.Secret != "" && m.SecretName != "":
+			return errors.New("secret and secretName are mutually exclusive")
+		case m.SecretName != "" && b.opts.SecretStore == nil:
+			return errors.New("secretName requires a secret store")
+		case m.Secret == "" && m.SecretName == "":
+			return fmt.Errorf("secret or secretName is required to verify the requests of %s", m.Provider)
+		}
+		if m.SecretKey == "" {
+			m.SecretKey = m.SecretName
+		}
+		b.secret = []byte(m.Secret)
+	}
+	b.metadata = m
+
+	return nil
+}
+
+// Read starts the server, which runs until ctx is canceled.
+func (b *Binding) Read(ctx context.Context, handler bindings.Handler) error {
+	if b.verifier != nil && b.metadata.SecretName != "" {
+		res, err := b.opts.SecretStore.GetSecret(ctx, secretstores.GetSecretRequest{Name: b.metadata.SecretName})
+		if err != nil {
+			return fmt.Errorf("error getting secret %s: %w", b.metadata.SecretName, err)
+		}
+		secret, ok := res.Data[b.metadata.SecretKey]
+		if !ok || secret == "" {
+			return fmt.Errorf("key %s not found in secret %s", b.metadata.SecretKey, b.metadata.SecretName)
+		}
+		b.secret = []byte(secret)
+	}
+
+	var err error
+	b.listener, err = net.Listen("tcp", fmt.Sprintf(":%d", b.metadata.Port))
+	if err != nil {
+		return fmt.Errorf("error listening on port %d: %w", b.metadata.Port, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(b.metadata.Path, b.handler(handler))
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		b.logger.Debugf("Listening for webhooks at %s", b.listener.Addr())
+		if err := srv.Serve(b.listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			b.logger.Errorf("Error serving webhooks: %v", err)
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			b.logger.Errorf("Error shutting down server: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// handler verifies the requests, and returns the response of the binding handler.
+func (b *Binding) handler(handler bindings.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != b.metadata.Path {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, b.metadata.MaxBodySize))
+		if err != nil {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		var delivery string
+		if b.verifier != nil {
+			delivery, err = b.verify(r, body)
+			if err != nil {
+				b.logger.Warnf("Rejecting webhook: %v", err)
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+		}
+
+		// Headers with multiple values are delimited with ", ", like in the responses of the http binding
+		md := make(map[string]string, len(r.Header))
+		for k, values := range r.Header {
+			md[k] = strings.Join(values, ", ")
+		}
+		res, err := handler(r.Context(), &bindings.ReadResponse{
+			Data:     body,
+			Metadata: md,
+		})
+		if err != nil {
+			// The provider can deliver the request again
+			b.forget(delivery)
+			b.logger.Errorf("Error handling webhook: %v", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		if len(res) > 0 {
+			if _, err = w.Write(res); err != nil {
+				b.logger.Errorf("Error writing response: %v", err)
+			}
+		}
+	})
+}
+
+// verify checks the signature of the request, and that its delivery was not received in the tolerance window.
+// It returns the signature identifying the delivery, which is remembered for the tolerance window.
+func (b *Binding) verify(r *http.Request, body []byte) (string, error) {
+	now := b.clk.Now()
+	delivery, err := b.verifier.verify(r, body, b.secret, now, b.metadata.ToleranceWindow)
+	if err != nil {
+		return "", err
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for e := b.seenOrder.Front(); e != nil; e = b.seenOrder.Front() {
+		if d := e.Value.(seenDelivery); now.Sub(d.at) > b.metadata.ToleranceWindow || b.seenOrder.Len() >= maxSeenDeliveries {
+			b.seenOrder.Remove(e)
+			delete(b.seen, d.id)
+			continue
+		}
+		break
+	}
+	if _, ok := b.seen[delivery]; ok {
+		return "", errors.New("replayed request")
+	}
+	b.seen[delivery] = b.seenOrder.PushBack(seenDelivery{id: delivery, at: now})
+
+	return delivery, nil
+}
+
+// forget removes a delivery of the requests received.
+func (b *Binding) forget(delivery string) {
+	if delivery == "" {
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if e, ok := b.seen[delivery]; ok {
+		b.seenOrder.Remove(e)
+		delete(b.seen, delivery)
+	}
+}
@@ -0,0 +1,356 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JY29/components-contrib/bindings"
+	"github.com/JY29/components-contrib/metadata"
+	"github.com/JY29/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+// fakeSecretStore is a secret store holding a single secret.
+type fakeSecretStore struct {
+	data map[string]string
+}
+
+func (f *fakeSecretStore) Init(secretstores.Metadata) error        { return nil }
+func (f *fakeSecretStore) Features() []secretstores.Feature        { return nil }
+func (f *fakeSecretStore) GetComponentMetadata() map[string]string { return nil }
+
+func (f *fakeSecretStore) GetSecret(_ context.Context, req secretstores.GetSecretRequest) (secretstores.GetSecretResponse, error) {
+	return secretstores.GetSecretResponse{Data: f.data}, nil
+}
+
+func (f *fakeSecretStore) BulkGetSecret(context.Context, secretstores.BulkGetSecretRequest) (secretstores.BulkGetSecretResponse, error) {
+	return secretstores.BulkGetSecretResponse{}, nil
+}
+
+func initBinding(t *testing.T, opts Options, properties map[string]string) *Binding {
+	t.Helper()
+
+	b := NewWebhookWithOptions(logger.NewLogger("test"), opts).(*Binding)
+	require.NoError(t, b.Init(bindings.Metadata{Base: metadata.Base{Properties: properties}}))
+
+	return b
+}
+
+// recorder is a binding handler recording the requests, and failing when fail is set.
+type recorder struct {
+	requests []*bindings.ReadResponse
+	fail     bool
+}
+
+func (r *recorder) handle(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+	if r.fail {
+		return nil, errors.New("handler failed")
+	}
+	r.requests = append(r.requests, res)
+
+	return []byte(`{"ok":true}`), nil
+}
+
+func serve(b *Binding, h bindings.Handler, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	b.handler(h).ServeHTTP(w, r)
+
+	return w
+}
+
+func TestInit(t *testing.T) {
+	for name, properties := range map[string]map[string]string{
+		"no provider":           {},
+		"invalid provider":      {"provider": "gitlab", "secret": "s"},
+		"no secret":             {"provider": "github"},
+		"secretName and secret": {"provider": "github", "secret": "s", "secretName": "webhooks"},
+		"no secret store":       {"provider": "github", "secretName": "webhooks"},
+		"invalid window":        {"provider": "none", "toleranceWindow": "-1s"},
+	} {
+		b := NewWebhook(logger.NewLogger("test"))
+		err := b.Init(bindings.Metadata{Base: metadata.Base{Properties: properties}})
+		assert.Error(t, err, name)
+	}
+
+	b := initBinding(t, Options{}, map[string]string{"provider": "none", "path": "hooks"})
+	assert.Equal(t, "/hooks", b.metadata.Path)
+	assert.Equal(t, defaultPort, b.metadata.Port)
+	assert.Nil(t, b.verifier)
+}
+
+func githubRequest(delivery, body, secret string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	r.Header.Set(githubSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	r.Header.Set("X-GitHub-Event", "push")
+	r.Header.Set("X-GitHub-Delivery", delivery)
+
+	return r
+}
+
+func TestGitHub(t *testing.T) {
+	b := initBinding(t, Options{}, map[string]string{"provider": "GitHub", "secret": "s3cr3t"})
+	rec := &recorder{}
+
+	w := serve(b, rec.handle, githubRequest("d1", `{"ref":"main"}`, "s3cr3t"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"ok":true}`, w.Body.String())
+	require.Len(t, rec.requests, 1)
+	assert.Equal(t, `{"ref":"main"}`, string(rec.requests[0].Data))
+	assert.Equal(t, "push", rec.requests[0].Metadata["X-Github-Event"])
+
+	t.Run("replay", func(t *testing.T) {
+		w := serve(b, rec.handle, githubRequest("d1", `{"ref":"main"}`, "s3cr3t"))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Len(t, rec.requests, 1)
+	})
+
+	t.Run("replay after the window", func(t *testing.T) {
+		mock := clock.NewMock()
+		mock.Set(time.Now().Add(2 * defaultToleranceWindow))
+		b.clk = mock
+		defer func() { b.clk = clock.New() }()
+		w := serve(b, rec.handle, githubRequest("d1", `{"ref":"main"}`, "s3cr3t"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		// The deliveries received before the window were forgotten
+		assert.Equal(t, 1, b.seenOrder.Len())
+		assert.Len(t, b.seen, 1)
+	})
+
+	t.Run("deliveries are identified by their signature", func(t *testing.T) {
+		// The delivery ID is not signed
+		w := serve(b, rec.handle, githubRequest("d5", `{"ref":"main"}`, "s3cr3t"))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		w := serve(b, rec.handle, githubRequest("d2", `{"ref":"dev"}`, "other"))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		r := githubRequest("d2", `{"ref":"dev"}`, "s3cr3t")
+		r.Header.Del(githubSignatureHeader)
+		w = serve(b, rec.handle, r)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("handler error", func(t *testing.T) {
+		rec.fail = true
+		w := serve(b, rec.handle, githubRequest("d3", `{"ref":"feature"}`, "s3cr3t"))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+		// The redelivery is not a replay
+		rec.fail = false
+		w = serve(b, rec.handle, githubRequest("d3", `{"ref":"feature"}`, "s3cr3t"))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("method and path", func(t *testing.T) {
+		w := serve(b, rec.handle, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		w = serve(b, rec.handle, httptest.NewRequest(http.MethodPost, "/other", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("body too large", func(t *testing.T) {
+		b := initBinding(t, Options{}, map[string]string{"provider": "none", "maxBodySize": "4"})
+		w := serve(b, rec.handle, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("12345")))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+}
+
+func stripeRequest(body string, timestamp time.Time, secrets ...string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	header := "t=" + t
+	for _, secret := range secrets {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(t + "." + body))
+		header += ",v1=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	r.Header.Set(stripeSignatureHeader, header)
+
+	return r
+}
+
+func TestStripe(t *testing.T) {
+	b := initBinding(t, Options{}, map[string]string{"provider": "stripe", "secret": "whsec_test", "toleranceWindow": "1m"})
+	mock := clock.NewMock()
+	mock.Set(time.Unix(1700000000, 0))
+	b.clk = mock
+	rec := &recorder{}
+
+	w := serve(b, rec.handle, stripeRequest(`{"id":"evt_1"}`, mock.Now().Add(-30*time.Second), "whsec_test"))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// The header holds the signatures of the old and the new secrets while the secret is rolled
+	w = serve(b, rec.handle, stripeRequest(`{"id":"evt_2"}`, mock.Now(), "whsec_old", "whsec_test"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, rec.requests, 2)
+
+	t.Run("timestamp outside of the window", func(t *testing.T) {
+		w := serve(b, rec.handle, stripeRequest(`{"id":"evt_3"}`, mock.Now().Add(-2*time.Minute), "whsec_test"))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		w = serve(b, rec.handle, stripeRequest(`{"id":"evt_3"}`, mock.Now().Add(2*time.Minute), "whsec_test"))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		w := serve(b, rec.handle, stripeRequest(`{"id":"evt_4"}`, mock.Now(), "whsec_other"))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		w = serve(b, rec.handle, stripeRequest(`{"id":"evt_4"}`, mock.Now()))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		w := serve(b, rec.handle, stripeRequest(`{"id":"evt_1"}`, mock.Now().Add(-30*time.Second), "whsec_test"))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Len(t, rec.requests, 2)
+	})
+}
+
+// twilioSignature signs a request like Twilio, with the URL followed by the sorted parameters.
+func twilioSignature(secret, u string, params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	payload := u
+	for _, k := range keys {
+		payload += k + params.Get(k)
+	}
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write([]byte(payload))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestTwilio(t *testing.T) {
+	b := initBinding(t, Options{}, map[string]string{
+		"provider":  "twilio",
+		"secret":    "auth-token",
+		"publicURL": "https://example.com/twilio",
+	})
+	rec := &recorder{}
+
+	t.Run("form", func(t *testing.T) {
+		params := url.Values{"From": {"+15551234567"}, "Body": {"Hello"}, "AccountSid": {"AC123"}}
+		r := httptest.NewRequest(http.MethodPost, "/?lang=en", strings.NewReader(params.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set(twilioSignatureHeader, twilioSignature("auth-token", "https://example.com/twilio?lang=en", params))
+		w := serve(b, rec.handle, r)
+		assert.Equal(t, http.StatusOK, w.Code)
+		require.Len(t, rec.requests, 1)
+		assert.Equal(t, params.Encode(), string(rec.requests[0].Data))
+
+		// The parameters are signed
+		params.Set("Body", "Bye")
+		r = httptest.NewRequest(http.MethodPost, "/?lang=en", strings.NewReader(params.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set(twilioSignatureHeader, twilioSignature("auth-token", "https://example.com/twilio?lang=en", url.Values{"From": {"+15551234567"}, "Body": {"Hello"}, "AccountSid": {"AC123"}}))
+		w = serve(b, rec.handle, r)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		body := `{"event":"call.completed"}`
+		sum := sha256.Sum256([]byte(body))
+		query := "?bodySHA256=" + hex.EncodeToString(sum[:])
+		r := httptest.NewRequest(http.MethodPost, "/"+query, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set(twilioSignatureHeader, twilioSignature("auth-token", "https://example.com/twilio"+query, nil))
+		w := serve(b, rec.handle, r)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// The body must match its hash
+		r = httptest.NewRequest(http.MethodPost, "/"+query, strings.NewReader(`{"event":"call.failed"}`))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set(twilioSignatureHeader, twilioSignature("auth-token", "https://example.com/twilio"+query, nil))
+		w = serve(b, rec.handle, r)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("idempotency token is not signed", func(t *testing.T) {
+		params := url.Values{"Body": {"Again"}}
+		request := func(token string) *http.Request {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(params.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			r.Header.Set(twilioSignatureHeader, twilioSignature("auth-token", "https://example.com/twilio", params))
+			r.Header.Set("I-Twilio-Idempotency-Token", token)
+			return r
+		}
+		assert.Equal(t, http.StatusOK, serve(b, rec.handle, request("token-1")).Code)
+		assert.Equal(t, http.StatusUnauthorized, serve(b, rec.handle, request("token-1")).Code)
+		// The same signed request with another token is a replay
+		assert.Equal(t, http.StatusUnauthorized, serve(b, rec.handle, request("token-2")).Code)
+	})
+}
+
+func TestRead(t *testing.T) {
+	store := &fakeSecretStore{data: map[string]string{"github": "s3cr3t"}}
+	b := initBinding(t, Options{SecretStore: store}, map[string]string{
+		"provider":   "github",
+		"secretName": "webhooks",
+		"secretKey":  "github",
+		"port":       "0",
+		"path":       "/hooks/github",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, b.Read(ctx, func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+		return append([]byte("received "), res.Data...), nil
+	}))
+
+	u := fmt.Sprintf("http://%s/hooks/github", b.listener.Addr())
+	r := githubRequest("d4", "ping", "s3cr3t")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader([]byte("ping")))
+	require.NoError(t, err)
+	req.Header = r.Header
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "received ping", string(body))
+
+	t.Run("missing secret key", func(t *testing.T) {
+		b := initBinding(t, Options{SecretStore: store}, map[string]string{"provider": "github", "secretName": "webhooks", "port": "0"})
+		assert.Error(t, b.Read(ctx, nil))
+	})
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicebus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	sbadmin "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus/admin"
+
+	"github.com/JY29/components-contrib/pubsub"
+)
+
+const (
+	// FilterRuleName is the name of the subscription rule created for the subscription filters.
+	FilterRuleName = "dapr-filter"
+
+	defaultRuleName = "$Default"
+)
+
+// SQLFilterExpression translates a subscription filter to a SQL filter on the application properties of the messages.
+// Only filters on the metadata, which is published as application properties, with string values, can be translated.
+func SQLFilterExpression(filter *pubsub.Filter) (string, bool) {
+	switch {
+	case filter.And != nil || filter.Or != nil:
+		op, subs := " AND ", filter.And
+		if filter.Or != nil {
+			op, subs = " OR ", filter.Or
+		}
+		parts := make([]string, len(subs))
+		for i, sub := range subs {
+			expr, ok := SQLFilterExpression(sub)
+			if !ok {
+				return "", false
+			}
+			parts[i] = "(" + expr + ")"
+		}
+		return strings.Join(parts, op), true
+	case filter.Not != nil:
+		expr, ok := SQLFilterExpression(filter.Not)
+		if !ok {
+			return "", false
+		}
+		return "NOT (" + expr + ")", true
+	case filter.Condition != nil:
+		return sqlFilterCondition(filter.Condition)
+	default:
+		return "", false
+	}
+}
+
+// sqlFilterCondition translates a condition, which must be false rather than unknown when the property is missing,
+// so that it has the same result as the filters evaluated on the received messages when it is negated.
+func sqlFilterCondition(c *pubsub.FilterCondition) (string, bool) {
+	key := c.Field.Path[0]
+	if c.Field.Scope != pubsub.FilterScopeMetadata || strings.HasPrefix(key, "metadata.") || strings.Contains(key, "]") {
+		return "", false
+	}
+	property := "[" + key + "]"
+
+	values := make([]string, len(c.Values))
+	for i, v := range c.Values {
+		s, ok := v.(string)
+		if !ok {
+			return "", false
+		}
+		values[i] = "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	}
+
+	var expr string
+	switch c.Operator {
+	case pubsub.FilterOpExists:
+		return "EXISTS(" + property + ")", true
+	case pubsub.FilterOpEqual:
+		expr = property + " = " + values[0]
+	case pubsub.FilterOpNotEqual:
+		expr = property + " <> " + values[0]
+	case pubsub.FilterOpIn:
+		expr = property + " IN (" + strings.Join(values, ", ") + ")"
+	default:
+		// Comparisons of strings aren't supported
+		return "", false
+	}
+
+	return "EXISTS(" + property + ") AND " + expr, true
+}
+
+// EnsureSubscriptionFilter replaces the default rule of a subscription, which accepts all the messages, with a SQL filter rule.
+// If expression is empty, the default rule is restored.
+func (c *Client) EnsureSubscriptionFilter(parentCtx context.Context, name string, topic string, expression string) error {
+	if c.adminClient == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, time.Second*time.Duration(c.metadata.TimeoutInSec))
+	defer cancel()
+
+	// The messages matching any rule are delivered, so the filter rule and the default rule are exclusive
+	set, unset := FilterRuleName, defaultRuleName
+	var filter sbadmin.RuleFilter = &sbadmin.SQLFilter{Expression: expression}
+	if expression == "" {
+		// Only the rules set by a previous filter are reset, leaving the rules managed by the users untouched
+		rule, err := c.adminClient.GetRule(ctx, topic, name, FilterRuleName, nil)
+		if err != nil || rule == nil {
+			return err
+		}
+		set, unset = defaultRuleName, FilterRuleName
+		filter = &sbadmin.TrueFilter{}
+	}
+
+	rule, err := c.adminClient.GetRule(ctx, topic, name, set, nil)
+	if err != nil {
+		return fmt.Errorf("could not get rule %s of subscription %s: %w", set, name, err)
+	}
+	if rule == nil {
+		_, err = c.adminClient.CreateRule(ctx, topic, name, &sbadmin.CreateRuleOptions{Name: &set, Filter: filter})
+	} else if existing, ok := rule.Filter.(*sbadmin.SQLFilter); expression != "" && (!ok || existing.Expression != expression) {
+		_, err = c.adminClient.UpdateRule(ctx, topic, name, sbadmin.RuleProperties{Name: set, Filter: filter})
+	}
+	if err != nil {
+		return fmt.Errorf("could not set rule %s of subscription %s: %w", set, name, err)
+	}
+
+	rule, err = c.adminClient.GetRule(ctx, topic, name, unset, nil)
+	if err != nil {
+		return fmt.Errorf("could not get rule %s of subscription %s: %w", unset, name, err)
+	}
+	if rule != nil {
+		_, err = c.adminClient.DeleteRule(ctx, topic, name, unset, nil)
+		if err != nil {
+			return fmt.Errorf("could not delete rule %s of subscription %s: %w", unset, name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicebus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JY29/components-contrib/pubsub"
+)
+
+func TestSQLFilterExpression(t *testing.T) {
+	tests := map[string]string{
+		`metadata.region == 'eu'`: `EXISTS([region]) AND [region] = 'eu'`,
+		`metadata.region in ('eu', 'us') || !exists(metadata.x-tenant)`: `(EXISTS([region]) AND [region] IN ('eu', 'us')) OR (NOT (EXISTS([x-tenant])))`,
+		`!(metadata.name != "O'Brien") && exists(metadata.a)`:           `(NOT (EXISTS([name]) AND [name] <> 'O''Brien')) AND (EXISTS([a]))`,
+	}
+	for expr, expected := range tests {
+		f, err := pubsub.ParseFilter(expr)
+		require.NoError(t, err)
+		actual, ok := SQLFilterExpression(f)
+		require.True(t, ok, expr)
+		assert.Equal(t, expected, actual, expr)
+	}
+
+	for _, expr := range []string{
+		`type == 'order.created'`,
+		`data.amount > 10`,
+		`metadata.priority == 5`,
+		`metadata.region > 'eu'`,
+		`metadata.metadata.MessageId == 'a'`,
+		`metadata.region == 'eu' && data.amount > 10`,
+	} {
+		f, err := pubsub.ParseFilter(expr)
+		require.NoError(t, err)
+		_, ok := SQLFilterExpression(f)
+		assert.False(t, ok, expr)
+	}
+}
@@ -128,6 +128,15 @@ func addMetadataToMessage(asbMsg *azservicebus.Message, metadata map[string]stri
 			if ok {
 				asbMsg.TimeToLive = &ttl
 			}
+		case mdutils.DelayMetadataKey, mdutils.ScheduledTimeMetadataKey:
+			// Both keys resolve to the same scheduled enqueue time, with scheduledTime taking precedence
+			scheduled, ok, err := mdutils.TryGetScheduledTime(metadata)
+			if err != nil {
+				return err
+			}
+			if ok {
+				asbMsg.ScheduledEnqueueTime = &scheduled
+			}
 
 		// Keys with aliases
 		case MessageKeyMessageID, MessageKeyMessageIDAlias:
@@ -15,6 +15,7 @@ package servicebus
 
 import (
 	"encoding/base64"
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -105,6 +106,15 @@ func addMessageAttributesToMetadata(metadata map[string]string, asbMsg *azservic
 		metadata["metadata."+MessageKeyLockedUntilUtc] = asbMsg.LockedUntil.UTC().Format(http.TimeFormat)
 	}
 
+	// Application properties carry the metadata of the published messages.
+	for k, v := range asbMsg.ApplicationProperties {
+		if s, ok := v.(string); ok {
+			metadata[k] = s
+		} else {
+			metadata[k] = fmt.Sprint(v)
+		}
+	}
+
 	return metadata
 }
 
@@ -44,6 +44,10 @@ func TestAddMessageAttributesToMetadata(t *testing.T) {
 				ScheduledEnqueueTime: &testSampleTime,
 				PartitionKey:         &testPartitionKey,
 				LockedUntil:          &testSampleTime,
+				ApplicationProperties: map[string]interface{}{
+					"region":   "eu",
+					"priority": int64(5),
+				},
 			},
 			expectedMetadata: map[string]string{
 				"metadata." + MessageKeyMessageID:               testMessageID,
@@ -60,6 +64,8 @@ func TestAddMessageAttributesToMetadata(t *testing.T) {
 				"metadata." + MessageKeyScheduledEnqueueTimeUtc: testSampleTimeHTTPFormat,
 				"metadata." + MessageKeyPartitionKey:            testPartitionKey,
 				"metadata." + MessageKeyLockedUntilUtc:          testSampleTimeHTTPFormat,
+				"region":                                        "eu",
+				"priority":                                      "5",
 			},
 		},
 	}
@@ -22,6 +22,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	azservicebus "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+
+	mdutils "github.com/JY29/components-contrib/metadata"
 )
 
 var (
@@ -77,6 +79,23 @@ func TestAddMetadataToMessage(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "Maps standard scheduled time metadata to the scheduled enqueue time.",
+			metadata: map[string]string{
+				mdutils.ScheduledTimeMetadataKey: testSampleTime.Format(time.RFC3339),
+			},
+			expectedAzServiceBusMessage: azservicebus.Message{
+				ScheduledEnqueueTime: &testSampleTime,
+			},
+			expectError: false,
+		},
+		{
+			name: "Errors when scheduled time is invalid.",
+			metadata: map[string]string{
+				mdutils.ScheduledTimeMetadataKey: "not a time",
+			},
+			expectError: true,
+		},
 		{
 			name: "Errors when partition key and session id set but not equal.",
 			metadata: map[string]string{
@@ -0,0 +1,219 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// responseCache is a cache of the responses, whose freshness is defined by their Cache-Control or Expires headers.
+// The stale responses are not revalidated, they are fetched again.
+// As a binding is shared by its callers, the cache follows the rules of the shared caches of RFC 9111: the private
+// responses are not stored, nor the responses to the requests with credentials unless they are public.
+type responseCache struct {
+	entries *lru.Cache
+	now     func() time.Time
+}
+
+type cachedResponse struct {
+	status     string
+	statusCode int
+	header     http.Header
+	body       []byte
+	storedAt   time.Time
+	expires    time.Time
+	// age is the age of the response when it was stored.
+	age time.Duration
+	// vary holds the values of the request headers named by the Vary header of the response.
+	vary map[string]string
+}
+
+// cacheable returns whether the response of the request can be cached.
+func (c *responseCache) cacheable(req *http.Request) bool {
+	switch {
+	case req.Method == http.MethodGet, req.Method == http.MethodHead:
+	case req.Method == http.MethodPost && isIdempotent(req.Context()):
+	default:
+		return false
+	}
+	_, noStore := parseCacheControl(req.Header)["no-store"]
+
+	return !noStore
+}
+
+// cacheKey returns the key of the response of the request, which includes the hash of the body of the requests with a body.
+// The key is empty when the body cannot be read again.
+func cacheKey(req *http.Request) (string, error) {
+	key := req.Method + " " + req.URL.String()
+	if req.Body == nil || req.Body == http.NoBody {
+		return key, nil
+	}
+	if req.GetBody == nil {
+		return "", nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, body); err != nil {
+		return "", err
+	}
+
+	return key + " " + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// get returns the fresh response of the request, or nil.
+func (c *responseCache) get(key string, req *http.Request) *http.Response {
+	directives := parseCacheControl(req.Header)
+	if _, ok := directives["no-cache"]; ok || directives["max-age"] == "0" || req.Header.Get("Pragma") == "no-cache" {
+		return nil
+	}
+
+	v, ok := c.entries.Get(key)
+	if !ok {
+		return nil
+	}
+	entry := v.(*cachedResponse)
+	now := c.now()
+	if !now.Before(entry.expires) {
+		c.entries.Remove(key)
+		return nil
+	}
+	for name, value := range entry.vary {
+		if req.Header.Get(name) != value {
+			return nil
+		}
+	}
+
+	header := entry.header.Clone()
+	header.Set("Age", strconv.Itoa(int((entry.age + now.Sub(entry.storedAt)).Seconds())))
+
+	return &http.Response{
+		Status:        entry.status,
+		StatusCode:    entry.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(entry.body)),
+		ContentLength: int64(len(entry.body)),
+		Request:       req,
+	}
+}
+
+// store caches the response if its headers allow it, and returns it with its body buffered.
+func (c *responseCache) store(key string, req *http.Request, res *http.Response) (*http.Response, error) {
+	if res.StatusCode != http.StatusOK {
+		return res, nil
+	}
+
+	directives := parseCacheControl(res.Header)
+	_, noStore := directives["no-store"]
+	_, noCache := directives["no-cache"]
+	_, private := directives["private"]
+	if noStore || noCache || private {
+		return res, nil
+	}
+	if _, public := directives["public"]; !public && hasCredentials(req) {
+		return res, nil
+	}
+
+	now := c.now()
+	var lifetime time.Duration
+	if maxAge, ok := directives["max-age"]; ok {
+		seconds, err := strconv.Atoi(maxAge)
+		if err != nil {
+			return res, nil
+		}
+		lifetime = time.Duration(seconds) * time.Second
+	} else if expires, err := http.ParseTime(res.Header.Get("Expires")); err == nil {
+		date, err := http.ParseTime(res.Header.Get("Date"))
+		if err != nil {
+			date = now
+		}
+		lifetime = expires.Sub(date)
+	}
+	var age time.Duration
+	if seconds, err := strconv.Atoi(res.Header.Get("Age")); err == nil && seconds > 0 {
+		age = time.Duration(seconds) * time.Second
+	}
+	if lifetime-age <= 0 {
+		return res, nil
+	}
+
+	vary := map[string]string{}
+	for _, value := range res.Header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "*" {
+				return res, nil
+			}
+			if name != "" {
+				vary[name] = req.Header.Get(name)
+			}
+		}
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	c.entries.Add(key, &cachedResponse{
+		status:     res.Status,
+		statusCode: res.StatusCode,
+		header:     res.Header.Clone(),
+		body:       body,
+		storedAt:   now,
+		expires:    now.Add(lifetime - age),
+		age:        age,
+		vary:       vary,
+	})
+
+	return res, nil
+}
+
+// hasCredentials returns whether the request carries the credentials of its caller.
+func hasCredentials(req *http.Request) bool {
+	return req.Header.Get("Authorization") != "" || req.Header.Get("Cookie") != ""
+}
+
+// parseCacheControl returns the directives of the Cache-Control headers, by lowercase name.
+func parseCacheControl(header http.Header) map[string]string {
+	directives := map[string]string{}
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, val, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name != "" {
+				directives[strings.ToLower(name)] = strings.Trim(val, `"`)
+			}
+		}
+	}
+
+	return directives
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JY29/components-contrib/metadata"
+)
+
+const (
+	// TimeoutMetadataKey is the key of the request metadata overriding the timeout of a request.
+	TimeoutMetadataKey = "timeout"
+
+	defaultRetryInitialInterval  = 100 * time.Millisecond
+	defaultRetryMaxInterval      = 10 * time.Second
+	defaultCircuitBreakerTimeout = 30 * time.Second
+	defaultCacheMaxEntries       = 1000
+)
+
+//nolint:gochecknoglobals
+var (
+	defaultRetryStatusCodes = []string{"429", "502", "503", "504"}
+	// defaultRetryMethods are the idempotent methods, per RFC 9110.
+	defaultRetryMethods = []string{
+		http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete,
+	}
+)
+
+// PolicyMetadata is the metadata of the policies of the requests of the HTTP bindings.
+type PolicyMetadata struct {
+	// MaxRetries is the maximum number of retries of a request, none by default.
+	MaxRetries int `mapstructure:"maxRetries"`
+	// RetryInitialInterval is the delay before the first retry, which doubles with each retry.
+	RetryInitialInterval time.Duration `mapstructure:"retryInitialInterval"`
+	// RetryMaxInterval is the maximum delay between the retries.
+	RetryMaxInterval time.Duration `mapstructure:"retryMaxInterval"`
+	// RetryStatusCodes are the status codes of the responses retried, besides the transport errors.
+	RetryStatusCodes []string `mapstructure:"retryStatusCodes"`
+	// RetryMethods are the methods of the requests retried. The requests marked with WithIdempotent are retried too.
+	RetryMethods []string `mapstructure:"retryMethods"`
+	// CircuitBreakerFailures is the number of consecutive failures of a host opening its circuit, disabled by default.
+	// The failures are the transport errors and the 5xx responses.
+	CircuitBreakerFailures uint32 `mapstructure:"circuitBreakerFailures"`
+	// CircuitBreakerTimeout is how long the circuit stays open before a request is let through to probe the host.
+	CircuitBreakerTimeout time.Duration `mapstructure:"circuitBreakerTimeout"`
+	// CacheResponses caches the responses, as long as their Cache-Control header allows it.
+	CacheResponses bool `mapstructure:"cacheResponses"`
+	// CacheMaxEntries is the maximum number of cached responses.
+	CacheMaxEntries int `mapstructure:"cacheMaxEntries"`
+
+	retryStatusCodes map[int]struct{}
+	retryMethods     map[string]struct{}
+}
+
+// ParsePolicyMetadata returns the policies of the component metadata, with the defaults.
+func ParsePolicyMetadata(properties map[string]string) (PolicyMetadata, error) {
+	m := PolicyMetadata{
+		RetryInitialInterval:  defaultRetryInitialInterval,
+		RetryMaxInterval:      defaultRetryMaxInterval,
+		CircuitBreakerTimeout: defaultCircuitBreakerTimeout,
+		CacheMaxEntries:       defaultCacheMaxEntries,
+	}
+	err := metadata.DecodeMetadata(properties, &m)
+	if err != nil {
+		return m, err
+	}
+
+	// The slices are not defaulted before decoding, as mapstructure would reuse them
+	if len(m.RetryStatusCodes) == 0 {
+		m.RetryStatusCodes = defaultRetryStatusCodes
+	}
+	if len(m.RetryMethods) == 0 {
+		m.RetryMethods = defaultRetryMethods
+	}
+	if m.MaxRetries < 0 {
+		return m, fmt.Errorf("invalid maxRetries: %d", m.MaxRetries)
+	}
+	if m.RetryInitialInterval <= 0 || m.RetryMaxInterval < m.RetryInitialInterval {
+		return m, fmt.Errorf("invalid retry intervals: %v to %v", m.RetryInitialInterval, m.RetryMaxInterval)
+	}
+	if m.CircuitBreakerTimeout <= 0 {
+		return m, fmt.Errorf("invalid circuitBreakerTimeout: %v", m.CircuitBreakerTimeout)
+	}
+	if m.CacheMaxEntries <= 0 {
+		return m, fmt.Errorf("invalid cacheMaxEntries: %d", m.CacheMaxEntries)
+	}
+
+	m.retryStatusCodes = make(map[int]struct{}, len(m.RetryStatusCodes))
+	for _, s := range m.RetryStatusCodes {
+		code, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || code < 100 || code > 599 {
+			return m, fmt.Errorf("invalid status code in retryStatusCodes: %s", s)
+		}
+		m.retryStatusCodes[code] = struct{}{}
+	}
+	m.retryMethods = make(map[string]struct{}, len(m.RetryMethods))
+	for _, method := range m.RetryMethods {
+		m.retryMethods[strings.ToUpper(strings.TrimSpace(method))] = struct{}{}
+	}
+
+	return m, nil
+}
+
+type idempotentKey struct{}
+
+// WithIdempotent marks the requests of the context as idempotent: they are retried and cached whatever their method,
+// like the GraphQL queries sent with POST.
+func WithIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+func isIdempotent(ctx context.Context) bool {
+	idempotent, _ := ctx.Value(idempotentKey{}).(bool)
+
+	return idempotent
+}
+
+// WithRequestTimeout returns a context canceled after the timeout of the request metadata,
+// or after the default timeout if the metadata has none. There is no timeout when both are zero.
+func WithRequestTimeout(ctx context.Context, md map[string]string, defaultTimeout time.Duration) (context.Context, context.CancelFunc, error) {
+	timeout := defaultTimeout
+	if val, ok := md[TimeoutMetadataKey]; ok && val != "" {
+		var err error
+		timeout, err = time.ParseDuration(val)
+		if err != nil || timeout <= 0 {
+			return ctx, func() {}, errors.New("invalid timeout: " + val)
+		}
+	}
+	if timeout <= 0 {
+		return ctx, func() {}, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+
+	return ctx, cancel, nil
+}
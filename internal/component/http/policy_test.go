@@ -0,0 +1,115 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePolicyMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m, err := ParsePolicyMetadata(map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, 0, m.MaxRetries)
+		assert.Equal(t, defaultRetryInitialInterval, m.RetryInitialInterval)
+		assert.Equal(t, defaultRetryMaxInterval, m.RetryMaxInterval)
+		assert.Equal(t, uint32(0), m.CircuitBreakerFailures)
+		assert.False(t, m.CacheResponses)
+		assert.Equal(t, defaultCacheMaxEntries, m.CacheMaxEntries)
+		assert.Len(t, m.retryStatusCodes, 4)
+		assert.Contains(t, m.retryStatusCodes, 503)
+		assert.Contains(t, m.retryMethods, "GET")
+		assert.NotContains(t, m.retryMethods, "POST")
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		m, err := ParsePolicyMetadata(map[string]string{
+			"maxRetries":             "3",
+			"retryInitialInterval":   "1s",
+			"retryMaxInterval":       "1m",
+			"retryStatusCodes":       "500, 503",
+			"retryMethods":           "get,post",
+			"circuitBreakerFailures": "5",
+			"circuitBreakerTimeout":  "10s",
+			"cacheResponses":         "true",
+			"cacheMaxEntries":        "10",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, m.MaxRetries)
+		assert.Equal(t, time.Second, m.RetryInitialInterval)
+		assert.Equal(t, time.Minute, m.RetryMaxInterval)
+		assert.Equal(t, map[int]struct{}{500: {}, 503: {}}, m.retryStatusCodes)
+		assert.Equal(t, map[string]struct{}{"GET": {}, "POST": {}}, m.retryMethods)
+		assert.Equal(t, uint32(5), m.CircuitBreakerFailures)
+		assert.Equal(t, 10*time.Second, m.CircuitBreakerTimeout)
+		assert.True(t, m.CacheResponses)
+		assert.Equal(t, 10, m.CacheMaxEntries)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for name, properties := range map[string]map[string]string{
+			"negative retries":  {"maxRetries": "-1"},
+			"intervals":         {"retryInitialInterval": "10s", "retryMaxInterval": "1s"},
+			"status code":       {"retryStatusCodes": "503,abc"},
+			"status code range": {"retryStatusCodes": "600"},
+			"breaker timeout":   {"circuitBreakerTimeout": "0s"},
+			"cache entries":     {"cacheMaxEntries": "0"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := ParsePolicyMetadata(properties)
+				assert.Error(t, err)
+			})
+		}
+	})
+}
+
+func TestWithRequestTimeout(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		ctx, cancel, err := WithRequestTimeout(context.Background(), nil, time.Minute)
+		require.NoError(t, err)
+		defer cancel()
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+		ctx, cancel, err := WithRequestTimeout(context.Background(), map[string]string{TimeoutMetadataKey: "5s"}, time.Minute)
+		require.NoError(t, err)
+		defer cancel()
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(5*time.Second), deadline, time.Second)
+	})
+
+	t.Run("none", func(t *testing.T) {
+		ctx, cancel, err := WithRequestTimeout(context.Background(), map[string]string{}, 0)
+		require.NoError(t, err)
+		defer cancel()
+		_, ok := ctx.Deadline()
+		assert.False(t, ok)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, _, err := WithRequestTimeout(context.Background(), map[string]string{TimeoutMetadataKey: "soon"}, 0)
+		assert.Error(t, err)
+		_, _, err = WithRequestTimeout(context.Background(), map[string]string{TimeoutMetadataKey: "-1s"}, 0)
+		assert.Error(t, err)
+	})
+}
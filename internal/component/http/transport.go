@@ -0,0 +1,208 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/sony/gobreaker"
+)
+
+// ErrCircuitOpen is returned for the requests to a host whose circuit is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// Transport is a http.RoundTripper applying the policies to the requests sent with another RoundTripper.
+// The cache is checked first, then the requests are sent through the circuit breaker of their host, and retried.
+type Transport struct {
+	base   http.RoundTripper
+	policy PolicyMetadata
+	cache  *responseCache
+
+	lock     sync.Mutex
+	breakers map[string]*gobreaker.TwoStepCircuitBreaker
+}
+
+// NewTransport returns a RoundTripper applying the policies to the requests sent with base.
+func NewTransport(base http.RoundTripper, policy PolicyMetadata) (*Transport, error) {
+	t := &Transport{
+		base:     base,
+		policy:   policy,
+		breakers: map[string]*gobreaker.TwoStepCircuitBreaker{},
+	}
+	if policy.CacheResponses {
+		entries, err := lru.New(policy.CacheMaxEntries)
+		if err != nil {
+			return nil, err
+		}
+		t.cache = &responseCache{entries: entries, now: time.Now}
+	}
+
+	return t, nil
+}
+
+// RoundTrip sends the request, or returns its cached response.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var key string
+	if t.cache != nil && t.cache.cacheable(req) {
+		var err error
+		key, err = cacheKey(req)
+		if err != nil {
+			return nil, err
+		}
+		if res := t.cache.get(key, req); res != nil {
+			return res, nil
+		}
+	}
+
+	res, err := t.retry(req)
+	if err != nil || key == "" {
+		return res, err
+	}
+
+	return t.cache.store(key, req, res)
+}
+
+// retry sends the request until the response is not retried, or the retries are exhausted.
+func (t *Transport) retry(req *http.Request) (*http.Response, error) {
+	attempts := 1
+	if t.retryable(req) {
+		attempts += t.policy.MaxRetries
+	}
+
+	bo := backoff.NewExponentialBackOff()
+	bo.InitialInterval = t.policy.RetryInitialInterval
+	bo.MaxInterval = t.policy.RetryMaxInterval
+	bo.MaxElapsedTime = 0
+	bo.Reset()
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 {
+			attemptReq = req.Clone(req.Context())
+			if req.Body != nil && req.Body != http.NoBody {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		res, err := t.send(attemptReq)
+		if attempt == attempts || errors.Is(err, ErrCircuitOpen) {
+			return res, err
+		}
+		if err == nil {
+			if _, ok := t.policy.retryStatusCodes[res.StatusCode]; !ok {
+				return res, nil
+			}
+		}
+
+		delay := bo.NextBackOff()
+		if res != nil {
+			if retryAfter := retryAfter(res, t.policy.RetryMaxInterval); retryAfter > delay {
+				delay = retryAfter
+			}
+			// The connection is reused only once the body is read
+			_, _ = io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryable returns whether the request is idempotent and can be sent again.
+func (t *Transport) retryable(req *http.Request) bool {
+	if t.policy.MaxRetries == 0 {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if _, ok := t.policy.retryMethods[req.Method]; ok {
+		return true
+	}
+
+	return isIdempotent(req.Context())
+}
+
+// retryAfter returns the delay of the Retry-After header of the response, in seconds, capped by the maximum interval.
+func retryAfter(res *http.Response, max time.Duration) time.Duration {
+	seconds, err := strconv.Atoi(res.Header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	if delay := time.Duration(seconds) * time.Second; delay < max {
+		return delay
+	}
+
+	return max
+}
+
+// send sends the request through the circuit breaker of its host.
+func (t *Transport) send(req *http.Request) (*http.Response, error) {
+	breaker := t.breaker(req.URL.Host)
+	if breaker == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	done, err := breaker.Allow()
+	if err != nil {
+		return nil, fmt.Errorf("%w for host %s", ErrCircuitOpen, req.URL.Host)
+	}
+	res, err := t.base.RoundTrip(req)
+	done(err == nil && res.StatusCode < http.StatusInternalServerError)
+
+	return res, err
+}
+
+func (t *Transport) breaker(host string) *gobreaker.TwoStepCircuitBreaker {
+	if t.policy.CircuitBreakerFailures == 0 {
+		return nil
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	breaker, ok := t.breakers[host]
+	if !ok {
+		failures := t.policy.CircuitBreakerFailures
+		breaker = gobreaker.NewTwoStepCircuitBreaker(gobreaker.Settings{
+			Name:        host,
+			MaxRequests: 1,
+			Timeout:     t.policy.CircuitBreakerTimeout,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				return counts.ConsecutiveFailures >= failures
+			},
+		})
+		t.breakers[host] = breaker
+	}
+
+	return breaker
+}
@@ -0,0 +1,357 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testServer counts the requests and answers them with the handler.
+func testServer(t *testing.T, handler func(w http.ResponseWriter, r *http.Request, n int32)) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var count int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r, atomic.AddInt32(&count, 1))
+	}))
+	t.Cleanup(s.Close)
+
+	return s, &count
+}
+
+func testClient(t *testing.T, properties map[string]string) *http.Client {
+	t.Helper()
+
+	policy, err := ParsePolicyMetadata(properties)
+	require.NoError(t, err)
+	transport, err := NewTransport(http.DefaultTransport, policy)
+	require.NoError(t, err)
+
+	return &http.Client{Transport: transport}
+}
+
+func do(ctx context.Context, t *testing.T, client *http.Client, method, url, body string) (*http.Response, string, error) {
+	t.Helper()
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	require.NoError(t, err)
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	return res, string(b), nil
+}
+
+func TestRetry(t *testing.T) {
+	properties := map[string]string{
+		"maxRetries":           "2",
+		"retryInitialInterval": "1ms",
+		"retryMaxInterval":     "5ms",
+	}
+	unavailableOnce := func(w http.ResponseWriter, r *http.Request, n int32) {
+		if n == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		w.Write([]byte("ok" + string(b)))
+	}
+
+	t.Run("idempotent method is retried", func(t *testing.T) {
+		s, count := testServer(t, unavailableOnce)
+		res, body, err := do(context.Background(), t, testClient(t, properties), http.MethodGet, s.URL, "")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "ok", body)
+		assert.Equal(t, int32(2), atomic.LoadInt32(count))
+	})
+
+	t.Run("body is sent again", func(t *testing.T) {
+		s, count := testServer(t, unavailableOnce)
+		res, body, err := do(context.Background(), t, testClient(t, properties), http.MethodPut, s.URL, "-put")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "ok-put", body)
+		assert.Equal(t, int32(2), atomic.LoadInt32(count))
+	})
+
+	t.Run("post is not retried", func(t *testing.T) {
+		s, count := testServer(t, unavailableOnce)
+		res, _, err := do(context.Background(), t, testClient(t, properties), http.MethodPost, s.URL, "body")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+		assert.Equal(t, int32(1), atomic.LoadInt32(count))
+	})
+
+	t.Run("post marked idempotent is retried", func(t *testing.T) {
+		s, count := testServer(t, unavailableOnce)
+		res, body, err := do(WithIdempotent(context.Background()), t, testClient(t, properties), http.MethodPost, s.URL, "-post")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "ok-post", body)
+		assert.Equal(t, int32(2), atomic.LoadInt32(count))
+	})
+
+	t.Run("other status codes are not retried", func(t *testing.T) {
+		s, count := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+		res, _, err := do(context.Background(), t, testClient(t, properties), http.MethodGet, s.URL, "")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+		assert.Equal(t, int32(1), atomic.LoadInt32(count))
+	})
+
+	t.Run("retries are exhausted", func(t *testing.T) {
+		s, count := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+		res, _, err := do(context.Background(), t, testClient(t, properties), http.MethodGet, s.URL, "")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+		assert.Equal(t, int32(3), atomic.LoadInt32(count))
+	})
+
+	t.Run("no retries by default", func(t *testing.T) {
+		s, count := testServer(t, unavailableOnce)
+		res, _, err := do(context.Background(), t, testClient(t, map[string]string{}), http.MethodGet, s.URL, "")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+		assert.Equal(t, int32(1), atomic.LoadInt32(count))
+	})
+
+	t.Run("context is canceled while waiting", func(t *testing.T) {
+		s, _ := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+		client := testClient(t, map[string]string{"maxRetries": "2", "retryMaxInterval": "1m"})
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, _, err := do(ctx, t, client, http.MethodGet, s.URL, "")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	client := testClient(t, map[string]string{
+		"circuitBreakerFailures": "2",
+		"circuitBreakerTimeout":  "100ms",
+	})
+	var healthy int32
+	failing, count := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	other, _ := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {})
+
+	for i := 0; i < 2; i++ {
+		res, _, err := do(context.Background(), t, client, http.MethodGet, failing.URL, "")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	}
+
+	// The circuit is open for the failing host only
+	_, _, err := do(context.Background(), t, client, http.MethodGet, failing.URL, "")
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(2), atomic.LoadInt32(count))
+	res, _, err := do(context.Background(), t, client, http.MethodGet, other.URL, "")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	// The circuit closes once a request succeeds after the timeout
+	atomic.StoreInt32(&healthy, 1)
+	time.Sleep(150 * time.Millisecond)
+	res, _, err = do(context.Background(), t, client, http.MethodGet, failing.URL, "")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	res, _, err = do(context.Background(), t, client, http.MethodGet, failing.URL, "")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestCache(t *testing.T) {
+	properties := map[string]string{"cacheResponses": "true"}
+	cacheControl := func(value string) func(w http.ResponseWriter, r *http.Request, n int32) {
+		return func(w http.ResponseWriter, r *http.Request, n int32) {
+			w.Header().Set("Cache-Control", value)
+			w.Write([]byte(r.Header.Get("Accept-Language")))
+		}
+	}
+
+	t.Run("fresh response is cached", func(t *testing.T) {
+		s, count := testServer(t, cacheControl("max-age=60"))
+		client := testClient(t, properties)
+		for i := 0; i < 2; i++ {
+			res, _, err := do(context.Background(), t, client, http.MethodGet, s.URL, "")
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(count))
+	})
+
+	t.Run("no-store is not cached", func(t *testing.T) {
+		s, count := testServer(t, cacheControl("no-store"))
+		client := testClient(t, properties)
+		for i := 0; i < 2; i++ {
+			_, _, err := do(context.Background(), t, client, http.MethodGet, s.URL, "")
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(2), atomic.LoadInt32(count))
+	})
+
+	t.Run("request no-cache skips the cache", func(t *testing.T) {
+		s, count := testServer(t, cacheControl("max-age=60"))
+		client := testClient(t, properties)
+		_, _, err := do(context.Background(), t, client, http.MethodGet, s.URL, "")
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodGet, s.URL, nil)
+		require.NoError(t, err)
+		req.Header.Set("Cache-Control", "no-cache")
+		res, err := client.Do(req)
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, int32(2), atomic.LoadInt32(count))
+	})
+
+	t.Run("post is cached when idempotent", func(t *testing.T) {
+		s, count := testServer(t, cacheControl("max-age=60"))
+		client := testClient(t, properties)
+		ctx := WithIdempotent(context.Background())
+		for _, body := range []string{"a", "a", "b"} {
+			_, _, err := do(ctx, t, client, http.MethodPost, s.URL, body)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(2), atomic.LoadInt32(count))
+		_, _, err := do(context.Background(), t, client, http.MethodPost, s.URL, "a")
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), atomic.LoadInt32(count))
+	})
+
+	t.Run("response expires", func(t *testing.T) {
+		s, count := testServer(t, cacheControl("max-age=60"))
+		client := testClient(t, properties)
+		now := time.Now()
+		client.Transport.(*Transport).cache.now = func() time.Time { return now }
+		_, _, err := do(context.Background(), t, client, http.MethodGet, s.URL, "")
+		require.NoError(t, err)
+
+		now = now.Add(30 * time.Second)
+		res, _, err := do(context.Background(), t, client, http.MethodGet, s.URL, "")
+		require.NoError(t, err)
+		assert.Equal(t, "30", res.Header.Get("Age"))
+		assert.Equal(t, int32(1), atomic.LoadInt32(count))
+
+		now = now.Add(30 * time.Second)
+		_, _, err = do(context.Background(), t, client, http.MethodGet, s.URL, "")
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(count))
+	})
+
+	t.Run("vary", func(t *testing.T) {
+		s, count := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Vary", "Accept-Language")
+			w.Write([]byte(r.Header.Get("Accept-Language")))
+		})
+		client := testClient(t, properties)
+		for _, language := range []string{"en", "en", "fr"} {
+			req, err := http.NewRequest(http.MethodGet, s.URL, nil)
+			require.NoError(t, err)
+			req.Header.Set("Accept-Language", language)
+			res, err := client.Do(req)
+			require.NoError(t, err)
+			b, err := io.ReadAll(res.Body)
+			res.Body.Close()
+			require.NoError(t, err)
+			assert.Equal(t, language, string(b))
+		}
+		assert.Equal(t, int32(2), atomic.LoadInt32(count))
+	})
+
+	t.Run("credentials", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			cacheControl string
+			header       string
+			cached       bool
+		}{
+			"authorization":        {cacheControl: "max-age=60", header: "Authorization", cached: false},
+			"cookie":               {cacheControl: "max-age=60", header: "Cookie", cached: false},
+			"public authorization": {cacheControl: "public, max-age=60", header: "Authorization", cached: true},
+			"private":              {cacheControl: "private, max-age=60", cached: false},
+		} {
+			tc := tc
+			t.Run(name, func(t *testing.T) {
+				s, count := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+					w.Header().Set("Cache-Control", tc.cacheControl)
+					w.Write([]byte(r.Header.Get("Authorization") + r.Header.Get("Cookie")))
+				})
+				client := testClient(t, properties)
+				var bodies []string
+				for _, credentials := range []string{"alice", "bob"} {
+					req, err := http.NewRequest(http.MethodGet, s.URL, nil)
+					require.NoError(t, err)
+					if tc.header != "" {
+						req.Header.Set(tc.header, credentials)
+					}
+					res, err := client.Do(req)
+					require.NoError(t, err)
+					b, err := io.ReadAll(res.Body)
+					res.Body.Close()
+					require.NoError(t, err)
+					bodies = append(bodies, string(b))
+				}
+				if tc.cached {
+					assert.Equal(t, int32(1), atomic.LoadInt32(count))
+				} else {
+					assert.Equal(t, int32(2), atomic.LoadInt32(count))
+					if tc.header != "" {
+						assert.Equal(t, []string{"alice", "bob"}, bodies)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		s, count := testServer(t, cacheControl("max-age=60"))
+		client := testClient(t, map[string]string{})
+		for i := 0; i < 2; i++ {
+			_, _, err := do(context.Background(), t, client, http.MethodGet, s.URL, "")
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(2), atomic.LoadInt32(count))
+	})
+}
@@ -0,0 +1,299 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+
+	"github.com/JY29/components-contrib/pubsub"
+)
+
+// Subscriptions are consumer groups. A consumer group can consume several topics:
+// it is listed as a subscription of each topic it has committed offsets for, and deleting it affects all of them.
+
+const (
+	// ReplicationFactor is the request metadata key with the replication factor of the topics created.
+	ReplicationFactor        = "replicationFactor"
+	defaultReplicationFactor = 1
+	defaultPartitions        = 1
+
+	retentionConfig = "retention.ms"
+)
+
+// CreateTopic creates a topic. Its replication factor is set with the ReplicationFactor request metadata.
+func (k *Kafka) CreateTopic(_ context.Context, req pubsub.CreateTopicRequest) error {
+	detail := &sarama.TopicDetail{
+		NumPartitions:     defaultPartitions,
+		ReplicationFactor: defaultReplicationFactor,
+	}
+	if req.Partitions > 0 {
+		detail.NumPartitions = int32(req.Partitions)
+	}
+	if v := req.Metadata[ReplicationFactor]; v != "" {
+		replicationFactor, err := strconv.ParseInt(v, 10, 16)
+		if err != nil {
+			return fmt.Errorf("kafka error: invalid %s: %w", ReplicationFactor, err)
+		}
+		detail.ReplicationFactor = int16(replicationFactor)
+	}
+	if req.Retention > 0 {
+		retention := strconv.FormatInt(req.Retention.Milliseconds(), 10)
+		detail.ConfigEntries = map[string]*string{retentionConfig: &retention}
+	}
+
+	admin, err := k.clusterAdmin(k.brokers, k.config)
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	err = admin.CreateTopic(req.Topic, detail, false)
+	if err != nil && !errors.Is(err, sarama.ErrTopicAlreadyExists) {
+		return fmt.Errorf("kafka error: failed to create topic %s: %w", req.Topic, err)
+	}
+
+	return nil
+}
+
+func (k *Kafka) DeleteTopic(_ context.Context, topic string) error {
+	admin, err := k.clusterAdmin(k.brokers, k.config)
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	err = admin.DeleteTopic(topic)
+	if errors.Is(err, sarama.ErrUnknownTopicOrPartition) {
+		return fmt.Errorf("kafka error: %w: %s", pubsub.ErrTopicNotFound, topic)
+	}
+
+	return err
+}
+
+// ListTopics returns the topics of the cluster, except the internal ones.
+func (k *Kafka) ListTopics(_ context.Context) ([]string, error) {
+	admin, err := k.clusterAdmin(k.brokers, k.config)
+	if err != nil {
+		return nil, err
+	}
+	defer admin.Close()
+
+	details, err := admin.ListTopics()
+	if err != nil {
+		return nil, fmt.Errorf("kafka error: failed to list topics: %w", err)
+	}
+	topics := make([]string, 0, len(details))
+	for topic := range details {
+		if !strings.HasPrefix(topic, "__") {
+			topics = append(topics, topic)
+		}
+	}
+	sort.Strings(topics)
+
+	return topics, nil
+}
+
+func (k *Kafka) DescribeTopic(_ context.Context, topic string) (*pubsub.TopicDescription, error) {
+	admin, err := k.clusterAdmin(k.brokers, k.config)
+	if err != nil {
+		return nil, err
+	}
+	defer admin.Close()
+
+	metadata, err := describeTopic(admin, topic)
+	if err != nil {
+		return nil, err
+	}
+	desc := &pubsub.TopicDescription{
+		Name:       topic,
+		Partitions: len(metadata.Partitions),
+	}
+	if len(metadata.Partitions) > 0 {
+		desc.Metadata = map[string]string{
+			ReplicationFactor: strconv.Itoa(len(metadata.Partitions[0].Replicas)),
+		}
+	}
+
+	entries, err := admin.DescribeConfig(sarama.ConfigResource{
+		Type:        sarama.TopicResource,
+		Name:        topic,
+		ConfigNames: []string{retentionConfig},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("kafka error: failed to describe the configuration of topic %s: %w", topic, err)
+	}
+	for _, entry := range entries {
+		if entry.Name != retentionConfig {
+			continue
+		}
+		// A negative retention is unlimited
+		if ms, err := strconv.ParseInt(entry.Value, 10, 64); err == nil && ms > 0 {
+			desc.Retention = time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	return desc, nil
+}
+
+// CreateSubscription creates a consumer group by committing its offsets on all the partitions of the topic,
+// at the initial offset of the component.
+func (k *Kafka) CreateSubscription(_ context.Context, req pubsub.CreateSubscriptionRequest) error {
+	client, err := sarama.NewClient(k.brokers, k.config)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	partitions, err := client.Partitions(req.Topic)
+	if errors.Is(err, sarama.ErrUnknownTopicOrPartition) {
+		return fmt.Errorf("kafka error: %w: %s", pubsub.ErrTopicNotFound, req.Topic)
+	} else if err != nil {
+		return err
+	}
+
+	coordinator, err := client.Coordinator(req.Subscription)
+	if err != nil {
+		return err
+	}
+	committed, err := committedOffsets(coordinator, req.Subscription, req.Topic, partitions)
+	if err != nil {
+		return err
+	}
+
+	offsets := make(map[int32]int64, len(partitions))
+	for _, partition := range partitions {
+		offset, err := client.GetOffset(req.Topic, partition, k.initialOffset)
+		if err != nil {
+			return err
+		}
+		// Offsets already committed are kept, unless they are before the initial offset
+		if committed[partition] < offset {
+			offsets[partition] = offset
+		}
+	}
+
+	return commitOffsets(coordinator, k.config, req.Subscription, req.Topic, offsets)
+}
+
+func (k *Kafka) DeleteSubscription(_ context.Context, topic string, subscription string) error {
+	admin, err := k.clusterAdmin(k.brokers, k.config)
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	if _, err = describeGroupOffsets(admin, topic, subscription); err != nil {
+		return err
+	}
+
+	return admin.DeleteConsumerGroup(subscription)
+}
+
+// ListSubscriptions returns the consumer groups with committed offsets on the topic.
+func (k *Kafka) ListSubscriptions(_ context.Context, topic string) ([]string, error) {
+	admin, err := k.clusterAdmin(k.brokers, k.config)
+	if err != nil {
+		return nil, err
+	}
+	defer admin.Close()
+
+	if _, err = describeTopic(admin, topic); err != nil {
+		return nil, err
+	}
+	groups, err := admin.ListConsumerGroups()
+	if err != nil {
+		return nil, fmt.Errorf("kafka error: failed to list consumer groups: %w", err)
+	}
+	subscriptions := []string{}
+	for group := range groups {
+		offsets, err := admin.ListConsumerGroupOffsets(group, nil)
+		if err != nil {
+			return nil, fmt.Errorf("kafka error: failed to list the offsets of consumer group %s: %w", group, err)
+		}
+		if len(offsets.Blocks[topic]) > 0 {
+			subscriptions = append(subscriptions, group)
+		}
+	}
+	sort.Strings(subscriptions)
+
+	return subscriptions, nil
+}
+
+func (k *Kafka) DescribeSubscription(_ context.Context, topic string, subscription string) (*pubsub.SubscriptionDescription, error) {
+	admin, err := k.clusterAdmin(k.brokers, k.config)
+	if err != nil {
+		return nil, err
+	}
+	defer admin.Close()
+
+	offsets, err := describeGroupOffsets(admin, topic, subscription)
+	if err != nil {
+		return nil, err
+	}
+	desc := &pubsub.SubscriptionDescription{
+		Name:     subscription,
+		Topic:    topic,
+		Metadata: map[string]string{},
+	}
+	for partition, block := range offsets {
+		desc.Metadata["offset."+strconv.Itoa(int(partition))] = strconv.FormatInt(block.Offset, 10)
+	}
+
+	groups, err := admin.DescribeConsumerGroups([]string{subscription})
+	if err != nil {
+		return nil, fmt.Errorf("kafka error: failed to describe consumer group %s: %w", subscription, err)
+	}
+	if len(groups) == 1 {
+		desc.Metadata["state"] = groups[0].State
+		desc.Metadata["members"] = strconv.Itoa(len(groups[0].Members))
+	}
+
+	return desc, nil
+}
+
+func describeTopic(admin sarama.ClusterAdmin, topic string) (*sarama.TopicMetadata, error) {
+	metadata, err := admin.DescribeTopics([]string{topic})
+	if err != nil {
+		return nil, fmt.Errorf("kafka error: failed to describe topic %s: %w", topic, err)
+	}
+	if len(metadata) != 1 || errors.Is(metadata[0].Err, sarama.ErrUnknownTopicOrPartition) {
+		return nil, fmt.Errorf("kafka error: %w: %s", pubsub.ErrTopicNotFound, topic)
+	}
+	if metadata[0].Err != sarama.ErrNoError {
+		return nil, fmt.Errorf("kafka error: failed to describe topic %s: %w", topic, metadata[0].Err)
+	}
+
+	return metadata[0], nil
+}
+
+// describeGroupOffsets returns the offsets committed by a consumer group on the partitions of a topic.
+func describeGroupOffsets(admin sarama.ClusterAdmin, topic string, group string) (map[int32]*sarama.OffsetFetchResponseBlock, error) {
+	offsets, err := admin.ListConsumerGroupOffsets(group, nil)
+	if err != nil {
+		return nil, fmt.Errorf("kafka error: failed to list the offsets of consumer group %s: %w", group, err)
+	}
+	if len(offsets.Blocks[topic]) == 0 {
+		return nil, fmt.Errorf("kafka error: %w: %s", pubsub.ErrSubscriptionNotFound, group)
+	}
+
+	return offsets.Blocks[topic], nil
+}
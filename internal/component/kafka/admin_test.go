@@ -0,0 +1,168 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JY29/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+)
+
+// fakeClusterAdmin keeps the topics and the committed offsets of the consumer groups in memory.
+type fakeClusterAdmin struct {
+	sarama.ClusterAdmin
+
+	topics map[string]*sarama.TopicDetail
+	groups map[string]map[string]map[int32]int64
+}
+
+func (f *fakeClusterAdmin) CreateTopic(topic string, detail *sarama.TopicDetail, _ bool) error {
+	if _, ok := f.topics[topic]; ok {
+		return sarama.ErrTopicAlreadyExists
+	}
+	f.topics[topic] = detail
+	return nil
+}
+
+func (f *fakeClusterAdmin) DeleteTopic(topic string) error {
+	if _, ok := f.topics[topic]; !ok {
+		return sarama.ErrUnknownTopicOrPartition
+	}
+	delete(f.topics, topic)
+	return nil
+}
+
+func (f *fakeClusterAdmin) ListTopics() (map[string]sarama.TopicDetail, error) {
+	res := map[string]sarama.TopicDetail{"__consumer_offsets": {}}
+	for topic, detail := range f.topics {
+		res[topic] = *detail
+	}
+	return res, nil
+}
+
+func (f *fakeClusterAdmin) DescribeTopics(topics []string) ([]*sarama.TopicMetadata, error) {
+	res := make([]*sarama.TopicMetadata, len(topics))
+	for i, topic := range topics {
+		res[i] = &sarama.TopicMetadata{Name: topic}
+		detail, ok := f.topics[topic]
+		if !ok {
+			res[i].Err = sarama.ErrUnknownTopicOrPartition
+			continue
+		}
+		for p := int32(0); p < detail.NumPartitions; p++ {
+			res[i].Partitions = append(res[i].Partitions, &sarama.PartitionMetadata{ID: p, Replicas: make([]int32, detail.ReplicationFactor)})
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeClusterAdmin) DescribeConfig(resource sarama.ConfigResource) ([]sarama.ConfigEntry, error) {
+	value := "-1"
+	if v := f.topics[resource.Name].ConfigEntries[retentionConfig]; v != nil {
+		value = *v
+	}
+	return []sarama.ConfigEntry{{Name: retentionConfig, Value: value}}, nil
+}
+
+func (f *fakeClusterAdmin) ListConsumerGroups() (map[string]string, error) {
+	res := map[string]string{}
+	for group := range f.groups {
+		res[group] = "consumer"
+	}
+	return res, nil
+}
+
+func (f *fakeClusterAdmin) ListConsumerGroupOffsets(group string, _ map[string][]int32) (*sarama.OffsetFetchResponse, error) {
+	res := &sarama.OffsetFetchResponse{}
+	for topic, offsets := range f.groups[group] {
+		for partition, offset := range offsets {
+			res.AddBlock(topic, partition, &sarama.OffsetFetchResponseBlock{Offset: offset})
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeClusterAdmin) DescribeConsumerGroups(groups []string) ([]*sarama.GroupDescription, error) {
+	return []*sarama.GroupDescription{{GroupId: groups[0], State: "Empty"}}, nil
+}
+
+func (f *fakeClusterAdmin) DeleteConsumerGroup(group string) error {
+	delete(f.groups, group)
+	return nil
+}
+
+func (f *fakeClusterAdmin) Close() error {
+	return nil
+}
+
+func TestTopicAdmin(t *testing.T) {
+	admin := &fakeClusterAdmin{
+		topics: map[string]*sarama.TopicDetail{},
+		groups: map[string]map[string]map[int32]int64{
+			"app1": {"orders": {0: 10, 1: 12}, "payments": {0: 3}},
+		},
+	}
+	k := NewKafka(logger.NewLogger("test"))
+	k.clusterAdmin = func([]string, *sarama.Config) (sarama.ClusterAdmin, error) {
+		return admin, nil
+	}
+	ctx := context.Background()
+
+	require.NoError(t, k.CreateTopic(ctx, pubsub.CreateTopicRequest{
+		Topic:      "orders",
+		Partitions: 2,
+		Retention:  time.Hour,
+		Metadata:   map[string]string{ReplicationFactor: "3"},
+	}))
+	require.NoError(t, k.CreateTopic(ctx, pubsub.CreateTopicRequest{Topic: "orders"}))
+	require.NoError(t, k.CreateTopic(ctx, pubsub.CreateTopicRequest{Topic: "payments"}))
+	assert.Equal(t, int16(3), admin.topics["orders"].ReplicationFactor)
+
+	topics, err := k.ListTopics(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders", "payments"}, topics)
+
+	desc, err := k.DescribeTopic(ctx, "orders")
+	require.NoError(t, err)
+	assert.Equal(t, 2, desc.Partitions)
+	assert.Equal(t, time.Hour, desc.Retention)
+	assert.Equal(t, "3", desc.Metadata[ReplicationFactor])
+	desc, err = k.DescribeTopic(ctx, "payments")
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), desc.Retention)
+	_, err = k.DescribeTopic(ctx, "missing")
+	assert.ErrorIs(t, err, pubsub.ErrTopicNotFound)
+
+	subscriptions, err := k.ListSubscriptions(ctx, "orders")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app1"}, subscriptions)
+	sub, err := k.DescribeSubscription(ctx, "orders", "app1")
+	require.NoError(t, err)
+	assert.Equal(t, "12", sub.Metadata["offset.1"])
+	assert.Equal(t, "Empty", sub.Metadata["state"])
+	_, err = k.DescribeSubscription(ctx, "orders", "app2")
+	assert.ErrorIs(t, err, pubsub.ErrSubscriptionNotFound)
+
+	require.NoError(t, k.DeleteSubscription(ctx, "orders", "app1"))
+	assert.ErrorIs(t, k.DeleteSubscription(ctx, "orders", "app1"), pubsub.ErrSubscriptionNotFound)
+	require.NoError(t, k.DeleteTopic(ctx, "orders"))
+	assert.ErrorIs(t, k.DeleteTopic(ctx, "orders"), pubsub.ErrTopicNotFound)
+}
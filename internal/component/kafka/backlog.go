@@ -0,0 +1,128 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Shopify/sarama"
+
+	"github.com/JY29/components-contrib/pubsub"
+)
+
+// Maximum time to wait for the oldest message of a partition, to compute its age.
+const backlogFetchTimeout = 5 * time.Second
+
+// Backlog reports the lag of a consumer group on a topic, which is the difference between the high-water marks
+// and the committed offsets of the partitions. Kafka does not track deliveries, so all the lag is pending.
+// The age of the oldest message is read from the timestamp of the first message not committed of each partition.
+func (k *Kafka) Backlog(ctx context.Context, topic string, group string) (*pubsub.Backlog, error) {
+	if group == "" {
+		group = k.consumerGroup
+	}
+
+	client, err := sarama.NewClient(k.brokers, k.config)
+	if err != nil {
+		return nil, err
+	}
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	// Closing the admin also closes the client
+	defer admin.Close()
+
+	partitions, err := client.Partitions(topic)
+	if errors.Is(err, sarama.ErrUnknownTopicOrPartition) {
+		return nil, fmt.Errorf("kafka error: %w: %s", pubsub.ErrTopicNotFound, topic)
+	} else if err != nil {
+		return nil, err
+	}
+	offsets, err := admin.ListConsumerGroupOffsets(group, map[string][]int32{topic: partitions})
+	if err != nil {
+		return nil, fmt.Errorf("kafka error: failed to list the offsets of consumer group %s: %w", group, err)
+	}
+
+	backlog := &pubsub.Backlog{
+		Topic:        topic,
+		Subscription: group,
+	}
+	for _, partition := range partitions {
+		newest, err := client.GetOffset(topic, partition, sarama.OffsetNewest)
+		if err != nil {
+			return nil, err
+		}
+		committed := int64(-1)
+		if block := offsets.GetBlock(topic, partition); block != nil {
+			committed = block.Offset
+		}
+		if committed < 0 {
+			// Without committed offsets, the group starts consuming the partition from the initial offset
+			if committed, err = client.GetOffset(topic, partition, k.initialOffset); err != nil {
+				return nil, err
+			}
+		}
+		// Messages deleted by the retention policy are no longer pending, and can't be read anymore
+		oldest, err := client.GetOffset(topic, partition, sarama.OffsetOldest)
+		if err != nil {
+			return nil, err
+		}
+		if committed < oldest {
+			committed = oldest
+		}
+		if newest <= committed {
+			continue
+		}
+		backlog.Pending += newest - committed
+
+		timestamp, err := readMessageTimestamp(ctx, client, topic, partition, committed)
+		if err != nil {
+			return nil, err
+		}
+		if !timestamp.IsZero() && time.Since(timestamp) > backlog.OldestMessageAge {
+			backlog.OldestMessageAge = time.Since(timestamp)
+		}
+	}
+
+	return backlog, nil
+}
+
+// readMessageTimestamp returns the timestamp of the message at the given offset.
+// It is zero if the message is not received in time, or the broker does not support timestamps.
+func readMessageTimestamp(ctx context.Context, client sarama.Client, topic string, partition int32, offset int64) (time.Time, error) {
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer consumer.Close()
+
+	pc, err := consumer.ConsumePartition(topic, partition, offset)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("kafka error: failed to read partition %d of topic %s: %w", partition, topic, err)
+	}
+	defer pc.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, backlogFetchTimeout)
+	defer cancel()
+	select {
+	case msg := <-pc.Messages():
+		return msg.Timestamp, nil
+	case <-ctx.Done():
+		return time.Time{}, nil
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JY29/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+)
+
+func TestBacklog(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V1_0_0_0
+	timestamp := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+	fetch := &sarama.FetchResponse{Version: 4}
+	fetch.AddRecordWithTimestamp("orders", 0, nil, sarama.StringEncoder("1"), 5, timestamp)
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetController(broker.BrokerID()).
+			SetLeader("orders", 0, broker.BrokerID()).
+			SetLeader("orders", 1, broker.BrokerID()),
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+			SetCoordinator(sarama.CoordinatorGroup, "group1", broker),
+		"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(t).
+			SetOffset("group1", "orders", 0, 2, "", sarama.ErrNoError).
+			SetOffset("group1", "orders", 1, -1, "", sarama.ErrNoError),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset("orders", 0, sarama.OffsetOldest, 5).
+			SetOffset("orders", 0, sarama.OffsetNewest, 8).
+			SetOffset("orders", 1, sarama.OffsetOldest, 0).
+			SetOffset("orders", 1, sarama.OffsetNewest, 4),
+		"FetchRequest": sarama.NewMockWrapper(fetch),
+	})
+
+	k := NewKafka(logger.NewLogger("test"))
+	k.brokers = []string{broker.Addr()}
+	k.config = config
+	k.consumerGroup = "group1"
+	k.initialOffset = sarama.OffsetNewest
+
+	backlog, err := k.Backlog(context.Background(), "orders", "")
+	require.NoError(t, err)
+	assert.Equal(t, "group1", backlog.Subscription)
+	// The committed offset of partition 0 was deleted by the retention policy, and the messages are read from the oldest offset.
+	// Partition 1 has no committed offsets, and is consumed from the newest offset.
+	assert.Equal(t, int64(3), backlog.Pending)
+	assert.InDelta(t, time.Minute, backlog.OldestMessageAge, float64(10*time.Second))
+
+	_, err = k.Backlog(context.Background(), "missing", "")
+	assert.ErrorIs(t, err, pubsub.ErrTopicNotFound)
+}
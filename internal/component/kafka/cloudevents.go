@@ -0,0 +1,73 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/JY29/components-contrib/contenttype"
+	"github.com/JY29/components-contrib/pubsub"
+)
+
+// toBinaryCloudEvent converts a structured-mode CloudEvent to binary mode, adding its attributes to the headers.
+// Payloads which are not CloudEvents are returned unchanged.
+func (k *Kafka) toBinaryCloudEvent(data []byte, metadata map[string]string) ([]byte, map[string]string) {
+	if !k.cloudEventsBinaryMode {
+		return data, metadata
+	}
+
+	var ce map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&ce); err != nil || ce[pubsub.SpecVersionField] == nil {
+		return data, metadata
+	}
+
+	headers, payload, err := pubsub.ToBinaryCloudEvent(ce, pubsub.CloudEventsKafkaBinding)
+	if err != nil {
+		k.logger.Warnf("Error converting CloudEvent to binary mode, publishing it in structured mode: %v", err)
+		return data, metadata
+	}
+
+	for name, value := range metadata {
+		if _, ok := headers[name]; !ok {
+			headers[name] = value
+		}
+	}
+
+	return payload, headers
+}
+
+// fromBinaryCloudEvent converts a binary-mode CloudEvent to structured mode.
+// Messages which do not carry a CloudEvent in their headers are returned unchanged, with a nil content type.
+func (k *Kafka) fromBinaryCloudEvent(data []byte, headers map[string]string) ([]byte, *string) {
+	if !k.cloudEventsBinaryMode || !pubsub.IsBinaryCloudEvent(headers, pubsub.CloudEventsKafkaBinding) {
+		return data, nil
+	}
+
+	ce, err := pubsub.FromBinaryCloudEvent(headers, data, pubsub.CloudEventsKafkaBinding)
+	if err != nil {
+		k.logger.Warnf("Error reading binary-mode CloudEvent, delivering the raw message: %v", err)
+		return data, nil
+	}
+	structured, err := json.Marshal(ce)
+	if err != nil {
+		k.logger.Warnf("Error encoding CloudEvent, delivering the raw message: %v", err)
+		return data, nil
+	}
+
+	contentType := contenttype.CloudEventContentType
+	return structured, &contentType
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinaryCloudEvents(t *testing.T) {
+	structured := []byte(`{"specversion":"1.0","id":"1","source":"test","type":"test.event","datacontenttype":"application/json","data":{"a":1}}`)
+
+	t.Run("structured mode is unchanged", func(t *testing.T) {
+		k := getKafka()
+		data, headers := k.toBinaryCloudEvent(structured, map[string]string{"foo": "bar"})
+		require.Equal(t, structured, data)
+		require.Equal(t, map[string]string{"foo": "bar"}, headers)
+
+		data, contentType := k.fromBinaryCloudEvent([]byte("raw"), map[string]string{"ce_specversion": "1.0"})
+		require.Equal(t, "raw", string(data))
+		require.Nil(t, contentType)
+	})
+
+	t.Run("binary mode round trip", func(t *testing.T) {
+		k := getKafka()
+		k.cloudEventsBinaryMode = true
+
+		data, headers := k.toBinaryCloudEvent(structured, map[string]string{"foo": "bar"})
+		require.JSONEq(t, `{"a":1}`, string(data))
+		require.Equal(t, "bar", headers["foo"])
+		require.Equal(t, "1", headers["ce_id"])
+		require.Equal(t, "application/json", headers["content-type"])
+
+		res, contentType := k.fromBinaryCloudEvent(data, headers)
+		require.NotNil(t, contentType)
+		require.Equal(t, "application/cloudevents+json", *contentType)
+		var ce map[string]interface{}
+		require.NoError(t, json.Unmarshal(res, &ce))
+		require.Equal(t, "1", ce["id"])
+		require.Equal(t, "test.event", ce["type"])
+		require.Equal(t, map[string]interface{}{"a": float64(1)}, ce["data"])
+	})
+
+	t.Run("binary mode leaves other payloads unchanged", func(t *testing.T) {
+		k := getKafka()
+		k.cloudEventsBinaryMode = true
+
+		data, headers := k.toBinaryCloudEvent([]byte("raw"), nil)
+		require.Equal(t, "raw", string(data))
+		require.Nil(t, headers)
+
+		data, contentType := k.fromBinaryCloudEvent([]byte("raw"), map[string]string{"foo": "bar"})
+		require.Equal(t, "raw", string(data))
+		require.Nil(t, contentType)
+	})
+}
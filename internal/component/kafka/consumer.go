@@ -24,6 +24,7 @@ import (
 	"github.com/Shopify/sarama"
 	"github.com/cenkalti/backoff/v4"
 
+	"github.com/JY29/components-contrib/pubsub"
 	"github.com/dapr/kit/retry"
 )
 
@@ -128,11 +129,15 @@ func (consumer *consumer) flushBulkMessages(claim sarama.ConsumerGroupClaim,
 	return nil
 }
 
+// doBulkCallback passes the messages to the handler, and marks them until the first one which failed.
+// The messages which can't be deserialized fail alone: they are not passed to the handler.
 func (consumer *consumer) doBulkCallback(session sarama.ConsumerGroupSession,
 	messages []*sarama.ConsumerMessage, handler BulkEventHandler, topic string,
 ) error {
 	consumer.k.logger.Debugf("Processing Kafka bulk message: %s", topic)
-	messageValues := make([]KafkaBulkMessageEntry, (len(messages)))
+	messageValues := make([]KafkaBulkMessageEntry, 0, len(messages))
+	// The errors of the messages which can't be deserialized, by index
+	deserializeErrs := map[int]error{}
 
 	for i, message := range messages {
 		if message != nil {
@@ -142,37 +147,77 @@ func (consumer *consumer) doBulkCallback(session sarama.ConsumerGroupSession,
 					metadata[string(t.Key)] = string(t.Value)
 				}
 			}
+			value, err := consumer.k.deserializeValue(session.Context(), consumer.k.subscribeTopics[topic], message.Value)
+			if err != nil {
+				deserializeErrs[i] = fmt.Errorf("error deserializing Kafka message: %s/%d/%d: %w", message.Topic, message.Partition, message.Offset, err)
+				continue
+			}
 			childMessage := KafkaBulkMessageEntry{
 				EntryId:  strconv.Itoa(i),
-				Event:    message.Value,
+				Event:    value,
 				Metadata: metadata,
 			}
-			messageValues[i] = childMessage
+			if data, contentType := consumer.k.fromBinaryCloudEvent(value, metadata); contentType != nil {
+				childMessage.Event = data
+				childMessage.ContentType = *contentType
+			}
+			messageValues = append(messageValues, childMessage)
 		}
 	}
-	event := KafkaBulkMessage{
-		Topic:   topic,
-		Entries: messageValues,
+
+	var (
+		responses []pubsub.BulkSubscribeResponseEntry
+		err       error
+	)
+	if len(messageValues) > 0 {
+		event := KafkaBulkMessage{
+			Topic:   topic,
+			Entries: messageValues,
+		}
+		responses, err = handler(session.Context(), &event)
 	}
-	responses, err := handler(session.Context(), &event)
 
-	if err != nil {
-		for i, resp := range responses {
+	if err == nil && len(deserializeErrs) == 0 {
+		for _, message := range messages {
+			session.MarkMessage(message, "")
+		}
+		return nil
+	}
+
+	// The messages are marked until the first one which failed, so that it is consumed again
+	j := 0
+	for i, message := range messages {
+		if deserializeErrs[i] != nil {
+			break
+		}
+		if message == nil {
+			continue
+		}
+		if err != nil {
+			if j >= len(responses) {
+				break
+			}
 			// An extra check to confirm that runtime returned responses are in order
-			if resp.EntryId != messageValues[i].EntryId {
+			if responses[j].EntryId != messageValues[j].EntryId {
 				return errors.New("entry id mismatch while processing bulk messages")
 			}
-			if resp.Error != nil {
+			if responses[j].Error != nil {
 				break
 			}
-			session.MarkMessage(messages[i], "")
 		}
-	} else {
-		for _, message := range messages {
-			session.MarkMessage(message, "")
+		j++
+		session.MarkMessage(message, "")
+	}
+	if err != nil {
+		return err
+	}
+	for i := range messages {
+		if deserializeErr, ok := deserializeErrs[i]; ok {
+			return deserializeErr
 		}
 	}
-	return err
+
+	return nil
 }
 
 func (consumer *consumer) doCallback(session sarama.ConsumerGroupSession, message *sarama.ConsumerMessage) error {
@@ -184,9 +229,13 @@ func (consumer *consumer) doCallback(session sarama.ConsumerGroupSession, messag
 	if !handlerConfig.IsBulkSubscribe && handlerConfig.Handler == nil {
 		return errors.New("invalid handler config for subscribe call")
 	}
+	data, err := consumer.k.deserializeValue(session.Context(), handlerConfig, message.Value)
+	if err != nil {
+		return fmt.Errorf("error deserializing Kafka message: %w", err)
+	}
 	event := NewEvent{
 		Topic: message.Topic,
-		Data:  message.Value,
+		Data:  data,
 	}
 	// This is true only when headers are set (Kafka > 0.11)
 	if len(message.Headers) > 0 {
@@ -194,6 +243,7 @@ func (consumer *consumer) doCallback(session sarama.ConsumerGroupSession, messag
 		for _, header := range message.Headers {
 			event.Metadata[string(header.Key)] = string(header.Value)
 		}
+		event.Data, event.ContentType = consumer.k.fromBinaryCloudEvent(event.Data, event.Metadata)
 	}
 	err = handlerConfig.Handler(session.Context(), &event)
 	if err == nil {
@@ -20,6 +20,7 @@ import (
 
 	"github.com/Shopify/sarama"
 
+	"github.com/JY29/components-contrib/internal/component/schemaregistry"
 	"github.com/JY29/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/retry"
@@ -49,6 +50,14 @@ type Kafka struct {
 	DefaultConsumeRetryEnabled bool
 	consumeRetryEnabled        bool
 	consumeRetryInterval       time.Duration
+
+	// When enabled, CloudEvents are published and consumed in binary mode, with the attributes in the message headers
+	cloudEventsBinaryMode bool
+
+	// Set when a schema registry is configured
+	serializer *schemaregistry.Serializer
+
+	clusterAdmin func(addrs []string, conf *sarama.Config) (sarama.ClusterAdmin, error)
 }
 
 func NewKafka(logger logger.Logger) *Kafka {
@@ -56,6 +65,7 @@ func NewKafka(logger logger.Logger) *Kafka {
 		logger:          logger,
 		subscribeTopics: make(TopicHandlerConfig),
 		subscribeLock:   sync.Mutex{},
+		clusterAdmin:    sarama.NewClusterAdmin,
 	}
 }
 
@@ -127,6 +137,16 @@ func (k *Kafka) Init(metadata map[string]string) error {
 	}
 	k.consumeRetryEnabled = meta.ConsumeRetryEnabled
 	k.consumeRetryInterval = meta.ConsumeRetryInterval
+	k.cloudEventsBinaryMode = meta.CloudEventsMode == pubsub.CloudEventsModeBinary
+
+	if meta.SchemaRegistryURL != "" {
+		client := schemaregistry.NewHTTPClient(schemaregistry.HTTPClientOptions{
+			URL:       meta.SchemaRegistryURL,
+			APIKey:    meta.SchemaRegistryAPIKey,
+			APISecret: meta.SchemaRegistryAPISecret,
+		})
+		k.serializer = schemaregistry.NewSerializer(schemaregistry.NewCachingClient(client, meta.SchemaCachingTTL))
+	}
 
 	k.logger.Debug("Kafka message bus initialization complete")
 
@@ -156,6 +176,8 @@ type SubscriptionHandlerConfig struct {
 	SubscribeConfig pubsub.BulkSubscribeConfig
 	BulkHandler     BulkEventHandler
 	Handler         EventHandler
+	// ValueSchemaType is the type of the schema of the message values, if they are serialized with a schema registry
+	ValueSchemaType string
 }
 
 // NewEvent is an event arriving from a message bus instance.
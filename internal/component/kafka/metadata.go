@@ -21,6 +21,8 @@ import (
 	"time"
 
 	"github.com/Shopify/sarama"
+
+	"github.com/JY29/components-contrib/pubsub"
 )
 
 const (
@@ -31,11 +33,19 @@ const (
 	clientKey            = "clientKey"
 	consumeRetryEnabled  = "consumeRetryEnabled"
 	consumeRetryInterval = "consumeRetryInterval"
+	cloudEventsMode      = "cloudEventsMode"
 	authType             = "authType"
 	passwordAuthType     = "password"
 	oidcAuthType         = "oidc"
 	mtlsAuthType         = "mtls"
 	noAuthType           = "none"
+
+	schemaRegistryURL       = "schemaRegistryURL"
+	schemaRegistryAPIKey    = "schemaRegistryAPIKey"
+	schemaRegistryAPISecret = "schemaRegistryAPISecret"
+	schemaCachingTTL        = "schemaCachingTTL"
+	// ValueSchemaType is the metadata key of the type of the schema used to serialize message values.
+	ValueSchemaType = "valueSchemaType"
 )
 
 type kafkaMetadata struct {
@@ -60,6 +70,12 @@ type kafkaMetadata struct {
 	ConsumeRetryEnabled  bool
 	ConsumeRetryInterval time.Duration
 	Version              sarama.KafkaVersion
+	CloudEventsMode      string
+
+	SchemaRegistryURL       string
+	SchemaRegistryAPIKey    string
+	SchemaRegistryAPISecret string
+	SchemaCachingTTL        time.Duration
 }
 
 // upgradeMetadata updates metadata properties based on deprecated usage.
@@ -102,6 +118,7 @@ func (k *Kafka) upgradeMetadata(metadata map[string]string) (map[string]string,
 func (k *Kafka) getKafkaMetadata(metadata map[string]string) (*kafkaMetadata, error) {
 	meta := kafkaMetadata{
 		ConsumeRetryInterval: 100 * time.Millisecond,
+		SchemaCachingTTL:     5 * time.Minute,
 	}
 	// use the runtimeConfig.ID as the consumer group so that each dapr runtime creates its own consumergroup
 	if val, ok := metadata["consumerID"]; ok && val != "" {
@@ -270,6 +287,26 @@ func (k *Kafka) getKafkaMetadata(metadata map[string]string) (*kafkaMetadata, er
 		meta.ConsumeRetryInterval = durationVal
 	}
 
+	switch val := metadata[cloudEventsMode]; strings.ToLower(val) {
+	case "", pubsub.CloudEventsModeStructured:
+		meta.CloudEventsMode = pubsub.CloudEventsModeStructured
+	case pubsub.CloudEventsModeBinary:
+		meta.CloudEventsMode = pubsub.CloudEventsModeBinary
+	default:
+		return nil, fmt.Errorf("kafka error: invalid value for '%s' attribute: %s", cloudEventsMode, val)
+	}
+
+	meta.SchemaRegistryURL = metadata[schemaRegistryURL]
+	meta.SchemaRegistryAPIKey = metadata[schemaRegistryAPIKey]
+	meta.SchemaRegistryAPISecret = metadata[schemaRegistryAPISecret]
+	if val, ok := metadata[schemaCachingTTL]; ok && val != "" {
+		durationVal, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("kafka error: invalid value for '%s' attribute: %w", schemaCachingTTL, err)
+		}
+		meta.SchemaCachingTTL = durationVal
+	}
+
 	if val, ok := metadata["version"]; ok && val != "" {
 		version, err := sarama.ParseKafkaVersion(val)
 		if err != nil {
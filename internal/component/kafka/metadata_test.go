@@ -255,6 +255,23 @@ func TestInitialOffset(t *testing.T) {
 	require.Equal(t, sarama.OffsetNewest, meta.InitialOffset)
 }
 
+func TestCloudEventsMode(t *testing.T) {
+	k := getKafka()
+	m := getBaseMetadata()
+	meta, err := k.getKafkaMetadata(m)
+	require.NoError(t, err)
+	require.Equal(t, "structured", meta.CloudEventsMode)
+
+	m[cloudEventsMode] = "Binary"
+	meta, err = k.getKafkaMetadata(m)
+	require.NoError(t, err)
+	require.Equal(t, "binary", meta.CloudEventsMode)
+
+	m[cloudEventsMode] = "foo"
+	_, err = k.getKafkaMetadata(m)
+	require.Error(t, err)
+}
+
 func TestTls(t *testing.T) {
 	k := getKafka()
 
@@ -41,13 +41,19 @@ func getSyncProducer(config sarama.Config, brokers []string, maxMessageBytes int
 }
 
 // Publish message to Kafka cluster.
-func (k *Kafka) Publish(_ context.Context, topic string, data []byte, metadata map[string]string) error {
+func (k *Kafka) Publish(ctx context.Context, topic string, data []byte, metadata map[string]string) error {
 	if k.producer == nil {
 		return errors.New("component is closed")
 	}
 	// k.logger.Debugf("Publishing topic %v with data: %v", topic, string(data))
 	k.logger.Debugf("Publishing on topic %v", topic)
 
+	data, metadata = k.toBinaryCloudEvent(data, metadata)
+	data, err := k.serializeValue(ctx, topic, data, metadata)
+	if err != nil {
+		return err
+	}
+
 	msg := &sarama.ProducerMessage{
 		Topic: topic,
 		Value: sarama.ByteEncoder(data),
@@ -56,7 +62,7 @@ func (k *Kafka) Publish(_ context.Context, topic string, data []byte, metadata m
 	for name, value := range metadata {
 		if name == key {
 			msg.Key = sarama.StringEncoder(value)
-		} else {
+		} else if name != ValueSchemaType {
 			if msg.Headers == nil {
 				msg.Headers = make([]sarama.RecordHeader, 0, len(metadata))
 			}
@@ -78,7 +84,7 @@ func (k *Kafka) Publish(_ context.Context, topic string, data []byte, metadata m
 	return nil
 }
 
-func (k *Kafka) BulkPublish(_ context.Context, topic string, entries []pubsub.BulkMessageEntry, metadata map[string]string) (pubsub.BulkPublishResponse, error) {
+func (k *Kafka) BulkPublish(ctx context.Context, topic string, entries []pubsub.BulkMessageEntry, metadata map[string]string) (pubsub.BulkPublishResponse, error) {
 	if k.producer == nil {
 		err := errors.New("component is closed")
 		return pubsub.NewBulkPublishResponse(entries, err), err
@@ -87,9 +93,14 @@ func (k *Kafka) BulkPublish(_ context.Context, topic string, entries []pubsub.Bu
 
 	msgs := []*sarama.ProducerMessage{}
 	for _, entry := range entries {
+		event, headers := k.toBinaryCloudEvent(entry.Event, metadata)
+		event, err := k.serializeValue(ctx, topic, event, headers)
+		if err != nil {
+			return pubsub.NewBulkPublishResponse(entries, err), err
+		}
 		msg := &sarama.ProducerMessage{
 			Topic: topic,
-			Value: sarama.ByteEncoder(entry.Event),
+			Value: sarama.ByteEncoder(event),
 		}
 		// From Sarama documentation
 		// This field is used to hold arbitrary data you wish to include so it
@@ -101,12 +112,12 @@ func (k *Kafka) BulkPublish(_ context.Context, topic string, entries []pubsub.Bu
 		// the metadata in that field is compared to the entry metadata to generate the right response on partial failures
 		msg.Metadata = entry.EntryId
 
-		for name, value := range metadata {
+		for name, value := range headers {
 			if name == key {
 				msg.Key = sarama.StringEncoder(value)
-			} else {
+			} else if name != ValueSchemaType {
 				if msg.Headers == nil {
-					msg.Headers = make([]sarama.RecordHeader, 0, len(metadata))
+					msg.Headers = make([]sarama.RecordHeader, 0, len(headers))
 				}
 				msg.Headers = append(msg.Headers, sarama.RecordHeader{
 					Key:   []byte(name),
@@ -0,0 +1,52 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"errors"
+
+	"github.com/JY29/components-contrib/internal/component/schemaregistry"
+)
+
+// serializeValue validates a JSON value against the latest schema of the topic and serializes it in the
+// schema registry wire format. Values are returned unchanged if valueSchemaType is not set in the metadata.
+func (k *Kafka) serializeValue(ctx context.Context, topic string, data []byte, metadata map[string]string) ([]byte, error) {
+	val, ok := metadata[ValueSchemaType]
+	if !ok || val == "" {
+		return data, nil
+	}
+	if k.serializer == nil {
+		return nil, errors.New("kafka error: 'schemaRegistryURL' is required to publish with '" + ValueSchemaType + "'")
+	}
+
+	schemaType, err := schemaregistry.ParseSchemaType(val)
+	if err != nil {
+		return nil, err
+	}
+
+	return k.serializer.Serialize(ctx, schemaregistry.ValueSubject(topic), schemaType, data)
+}
+
+// deserializeValue decodes a value serialized in the schema registry wire format to JSON.
+func (k *Kafka) deserializeValue(ctx context.Context, handlerConfig SubscriptionHandlerConfig, data []byte) ([]byte, error) {
+	if handlerConfig.ValueSchemaType == "" {
+		return data, nil
+	}
+	if k.serializer == nil {
+		return nil, errors.New("kafka error: 'schemaRegistryURL' is required to subscribe with '" + ValueSchemaType + "'")
+	}
+
+	return k.serializer.Deserialize(ctx, data)
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JY29/components-contrib/internal/component/schemaregistry"
+	"github.com/JY29/components-contrib/pubsub"
+)
+
+// markingSession is a consumer group session recording the marked messages.
+type markingSession struct {
+	sarama.ConsumerGroupSession
+
+	marked []*sarama.ConsumerMessage
+}
+
+func (s *markingSession) Context() context.Context {
+	return context.Background()
+}
+
+func (s *markingSession) MarkMessage(msg *sarama.ConsumerMessage, _ string) {
+	s.marked = append(s.marked, msg)
+}
+
+func TestSchemaRegistry(t *testing.T) {
+	registry := schemaregistry.NewLocalRegistry()
+	registry.Register("orders-value", schemaregistry.SchemaTypeAvro, `{"type":"record","name":"Order","fields":[{"name":"id","type":"string"}]}`)
+	server := httptest.NewServer(registry)
+	defer server.Close()
+
+	ctx := context.Background()
+	k := getKafka()
+	client := schemaregistry.NewHTTPClient(schemaregistry.HTTPClientOptions{URL: server.URL})
+	k.serializer = schemaregistry.NewSerializer(schemaregistry.NewCachingClient(client, time.Minute))
+
+	t.Run("values are serialized with the schema of the topic", func(t *testing.T) {
+		data, err := k.serializeValue(ctx, "orders", []byte(`{"id":"a"}`), map[string]string{ValueSchemaType: "Avro"})
+		require.NoError(t, err)
+		require.Equal(t, byte(0), data[0])
+
+		res, err := k.deserializeValue(ctx, SubscriptionHandlerConfig{ValueSchemaType: "Avro"}, data)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"id":"a"}`, string(res))
+	})
+
+	t.Run("invalid values fail to publish", func(t *testing.T) {
+		_, err := k.serializeValue(ctx, "orders", []byte(`{"name":"a"}`), map[string]string{ValueSchemaType: "Avro"})
+		require.Error(t, err)
+	})
+
+	t.Run("values are unchanged without a schema type", func(t *testing.T) {
+		data, err := k.serializeValue(ctx, "orders", []byte(`{"name":"a"}`), nil)
+		require.NoError(t, err)
+		require.Equal(t, `{"name":"a"}`, string(data))
+
+		data, err = k.deserializeValue(ctx, SubscriptionHandlerConfig{}, []byte(`{"name":"a"}`))
+		require.NoError(t, err)
+		require.Equal(t, `{"name":"a"}`, string(data))
+	})
+
+	t.Run("bulk messages which can't be deserialized fail alone", func(t *testing.T) {
+		k.subscribeTopics = TopicHandlerConfig{"orders": {IsBulkSubscribe: true, ValueSchemaType: "Avro"}}
+		defer func() { k.subscribeTopics = nil }()
+
+		messages := make([]*sarama.ConsumerMessage, 3)
+		for i, value := range []string{`{"id":"a"}`, "", `{"id":"c"}`} {
+			data := []byte(`{"id":"b"}`)
+			if value != "" {
+				var err error
+				data, err = k.serializeValue(ctx, "orders", []byte(value), map[string]string{ValueSchemaType: "Avro"})
+				require.NoError(t, err)
+			}
+			messages[i] = &sarama.ConsumerMessage{Topic: "orders", Offset: int64(i), Value: data}
+		}
+
+		var received []KafkaBulkMessageEntry
+		session := &markingSession{}
+		c := &consumer{k: k}
+		err := c.doBulkCallback(session, messages, func(ctx context.Context, msg *KafkaBulkMessage) ([]pubsub.BulkSubscribeResponseEntry, error) {
+			received = append(received, msg.Entries...)
+			return nil, nil
+		}, "orders")
+		require.ErrorIs(t, err, schemaregistry.ErrNotSerialized)
+
+		require.Len(t, received, 2)
+		assert.Equal(t, "0", received[0].EntryId)
+		assert.JSONEq(t, `{"id":"a"}`, string(received[0].Event))
+		assert.Equal(t, "2", received[1].EntryId)
+		assert.JSONEq(t, `{"id":"c"}`, string(received[1].Event))
+		// The messages after the one which failed are not marked, so that it is consumed again
+		assert.Equal(t, messages[:1], session.marked)
+	})
+
+	t.Run("a registry is required", func(t *testing.T) {
+		_, err := getKafka().serializeValue(ctx, "orders", []byte(`{"id":"a"}`), map[string]string{ValueSchemaType: "Avro"})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/Shopify/sarama"
+
+	"github.com/JY29/components-contrib/pubsub"
+)
+
+// Seek commits the offsets of a consumer group on all the partitions of a topic at the given position.
+// An offset position is applied to every partition.
+// Kafka rejects the commits of a group with active members, so the consumers of the group must be stopped first.
+func (k *Kafka) Seek(_ context.Context, topic string, group string, position pubsub.Position) error {
+	if group == "" {
+		group = k.consumerGroup
+	}
+
+	return k.commitPosition(topic, group, position, false)
+}
+
+// ApplyStartPosition commits the offsets of the consumer group of the component at the given position,
+// on the partitions of the topic without committed offsets.
+// It is used to start new subscriptions at a position other than the initial offset.
+func (k *Kafka) ApplyStartPosition(topic string, position pubsub.Position) error {
+	return k.commitPosition(topic, k.consumerGroup, position, true)
+}
+
+func (k *Kafka) commitPosition(topic string, group string, position pubsub.Position, onlyUncommitted bool) error {
+	client, err := sarama.NewClient(k.brokers, k.config)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	partitions, err := client.Partitions(topic)
+	if errors.Is(err, sarama.ErrUnknownTopicOrPartition) {
+		return fmt.Errorf("kafka error: %w: %s", pubsub.ErrTopicNotFound, topic)
+	} else if err != nil {
+		return err
+	}
+
+	coordinator, err := client.Coordinator(group)
+	if err != nil {
+		return err
+	}
+	var committed map[int32]int64
+	if onlyUncommitted {
+		committed, err = committedOffsets(coordinator, group, topic, partitions)
+		if err != nil {
+			return err
+		}
+	}
+
+	offsets := make(map[int32]int64, len(partitions))
+	for _, partition := range partitions {
+		if onlyUncommitted && committed[partition] >= 0 {
+			continue
+		}
+		offsets[partition], err = positionOffset(client, topic, partition, position)
+		if err != nil {
+			return err
+		}
+	}
+
+	return commitOffsets(coordinator, k.config, group, topic, offsets)
+}
+
+// committedOffsets returns the offsets committed by a consumer group on the partitions of a topic, -1 when none was.
+func committedOffsets(coordinator *sarama.Broker, group string, topic string, partitions []int32) (map[int32]int64, error) {
+	req := &sarama.OffsetFetchRequest{Version: 1, ConsumerGroup: group}
+	for _, partition := range partitions {
+		req.AddPartition(topic, partition)
+	}
+	res, err := coordinator.FetchOffset(req)
+	if err != nil {
+		return nil, fmt.Errorf("kafka error: failed to fetch the offsets of consumer group %s: %w", group, err)
+	}
+
+	offsets := make(map[int32]int64, len(partitions))
+	for _, partition := range partitions {
+		offsets[partition] = -1
+		block := res.GetBlock(topic, partition)
+		if block == nil {
+			continue
+		}
+		if block.Err != sarama.ErrNoError {
+			return nil, fmt.Errorf("kafka error: failed to fetch the offset of partition %d of topic %s for consumer group %s: %w", partition, topic, group, block.Err)
+		}
+		offsets[partition] = block.Offset
+	}
+
+	return offsets, nil
+}
+
+// commitOffsets commits the offsets of a consumer group on the partitions of a topic.
+// The commit request is sent to the coordinator of the group, as the offset manager of sarama only logs its errors,
+// like the rejection of the commits of a group with active members.
+func commitOffsets(coordinator *sarama.Broker, config *sarama.Config, group string, topic string, offsets map[int32]int64) error {
+	if len(offsets) == 0 {
+		return nil
+	}
+
+	req := &sarama.OffsetCommitRequest{
+		Version:                 1,
+		ConsumerGroup:           group,
+		ConsumerGroupGeneration: sarama.GroupGenerationUndefined,
+	}
+	timestamp := sarama.ReceiveTime
+	if config.Consumer.Offsets.Retention > 0 {
+		req.Version = 2
+		req.RetentionTime = config.Consumer.Offsets.Retention.Milliseconds()
+		timestamp = 0
+	}
+	for partition, offset := range offsets {
+		req.AddBlock(topic, partition, offset, 0, timestamp, "")
+	}
+
+	res, err := coordinator.CommitOffset(req)
+	if err != nil {
+		return fmt.Errorf("kafka error: failed to commit the offsets of consumer group %s: %w", group, err)
+	}
+	for partition := range offsets {
+		kerr, ok := res.Errors[topic][partition]
+		if !ok {
+			return fmt.Errorf("kafka error: failed to commit the offset of partition %d of topic %s for consumer group %s: %w", partition, topic, group, sarama.ErrIncompleteResponse)
+		}
+		if kerr != sarama.ErrNoError {
+			return fmt.Errorf("kafka error: failed to commit the offset of partition %d of topic %s for consumer group %s: %w", partition, topic, group, kerr)
+		}
+	}
+
+	return nil
+}
+
+// positionOffset returns the offset of the first message at position in a partition.
+func positionOffset(client sarama.Client, topic string, partition int32, position pubsub.Position) (int64, error) {
+	switch position.Type {
+	case pubsub.PositionEarliest:
+		return client.GetOffset(topic, partition, sarama.OffsetOldest)
+	case pubsub.PositionLatest:
+		return client.GetOffset(topic, partition, sarama.OffsetNewest)
+	case pubsub.PositionTimestamp:
+		offset, err := client.GetOffset(topic, partition, position.Timestamp.UnixMilli())
+		if err != nil {
+			return 0, err
+		}
+		// No message was published after the timestamp
+		if offset < 0 {
+			return client.GetOffset(topic, partition, sarama.OffsetNewest)
+		}
+		return offset, nil
+	case pubsub.PositionOffset:
+		offset, err := strconv.ParseInt(position.Offset, 10, 64)
+		if err != nil || offset < 0 {
+			return 0, fmt.Errorf("kafka error: invalid offset %q", position.Offset)
+		}
+		return offset, nil
+	default:
+		return 0, fmt.Errorf("kafka error: unsupported position type %q", position.Type)
+	}
+}
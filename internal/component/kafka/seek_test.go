@@ -0,0 +1,153 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JY29/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+)
+
+func TestSeek(t *testing.T) {
+	timestamp := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+
+	newMockBroker := func(t *testing.T, commitErr sarama.KError) *sarama.MockBroker {
+		broker := sarama.NewMockBroker(t, 1)
+		broker.SetHandlerByMap(map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(t).
+				SetBroker(broker.Addr(), broker.BrokerID()).
+				SetController(broker.BrokerID()).
+				SetLeader("orders", 0, broker.BrokerID()).
+				SetLeader("orders", 1, broker.BrokerID()),
+			"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+				SetCoordinator(sarama.CoordinatorGroup, "group1", broker),
+			"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(t).
+				SetOffset("group1", "orders", 0, 7, "", sarama.ErrNoError).
+				SetOffset("group1", "orders", 1, -1, "", sarama.ErrNoError),
+			"OffsetRequest": sarama.NewMockOffsetResponse(t).
+				SetOffset("orders", 0, sarama.OffsetOldest, 2).
+				SetOffset("orders", 0, sarama.OffsetNewest, 8).
+				SetOffset("orders", 0, timestamp.UnixMilli(), 5).
+				SetOffset("orders", 1, sarama.OffsetOldest, 0).
+				SetOffset("orders", 1, sarama.OffsetNewest, 4).
+				SetOffset("orders", 1, timestamp.UnixMilli(), -1),
+			"OffsetCommitRequest": sarama.NewMockOffsetCommitResponse(t).
+				SetError("group1", "orders", 0, commitErr).
+				SetError("group1", "orders", 1, commitErr),
+		})
+		return broker
+	}
+
+	newKafka := func(broker *sarama.MockBroker) *Kafka {
+		config := sarama.NewConfig()
+		config.Version = sarama.V1_0_0_0
+		k := NewKafka(logger.NewLogger("test"))
+		k.brokers = []string{broker.Addr()}
+		k.config = config
+		k.consumerGroup = "group1"
+		return k
+	}
+
+	committed := func(t *testing.T, broker *sarama.MockBroker) map[int32]int64 {
+		offsets := map[int32]int64{}
+		for _, rr := range broker.History() {
+			req, ok := rr.Request.(*sarama.OffsetCommitRequest)
+			if !ok {
+				continue
+			}
+			for _, partition := range []int32{0, 1} {
+				if offset, _, err := req.Offset("orders", partition); err == nil {
+					offsets[partition] = offset
+				}
+			}
+		}
+		return offsets
+	}
+
+	tests := map[string]struct {
+		position pubsub.Position
+		expected map[int32]int64
+	}{
+		"earliest": {
+			position: pubsub.Position{Type: pubsub.PositionEarliest},
+			expected: map[int32]int64{0: 2, 1: 0},
+		},
+		"latest": {
+			position: pubsub.Position{Type: pubsub.PositionLatest},
+			expected: map[int32]int64{0: 8, 1: 4},
+		},
+		"timestamp": {
+			position: pubsub.Position{Type: pubsub.PositionTimestamp, Timestamp: timestamp},
+			expected: map[int32]int64{0: 5, 1: 4},
+		},
+		"offset": {
+			position: pubsub.Position{Type: pubsub.PositionOffset, Offset: "3"},
+			expected: map[int32]int64{0: 3, 1: 3},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			broker := newMockBroker(t, sarama.ErrNoError)
+			defer broker.Close()
+
+			require.NoError(t, newKafka(broker).Seek(context.Background(), "orders", "", tt.position))
+			assert.Equal(t, tt.expected, committed(t, broker))
+		})
+	}
+
+	t.Run("start position only applies to partitions without committed offsets", func(t *testing.T) {
+		broker := newMockBroker(t, sarama.ErrNoError)
+		defer broker.Close()
+
+		require.NoError(t, newKafka(broker).ApplyStartPosition("orders", pubsub.Position{Type: pubsub.PositionEarliest}))
+		assert.Equal(t, map[int32]int64{1: 0}, committed(t, broker))
+	})
+
+	t.Run("subscriptions are created at the initial offset", func(t *testing.T) {
+		broker := newMockBroker(t, sarama.ErrNoError)
+		defer broker.Close()
+
+		k := newKafka(broker)
+		k.initialOffset = sarama.OffsetNewest
+		require.NoError(t, k.CreateSubscription(context.Background(), pubsub.CreateSubscriptionRequest{Topic: "orders", Subscription: "group1"}))
+		assert.Equal(t, map[int32]int64{0: 8, 1: 4}, committed(t, broker))
+	})
+
+	t.Run("rejected commits are returned", func(t *testing.T) {
+		broker := newMockBroker(t, sarama.ErrUnknownMemberId)
+		defer broker.Close()
+
+		err := newKafka(broker).Seek(context.Background(), "orders", "", pubsub.Position{Type: pubsub.PositionEarliest})
+		assert.ErrorIs(t, err, sarama.ErrUnknownMemberId)
+		k := newKafka(broker)
+		k.initialOffset = sarama.OffsetNewest
+		err = k.CreateSubscription(context.Background(), pubsub.CreateSubscriptionRequest{Topic: "orders", Subscription: "group1"})
+		assert.ErrorIs(t, err, sarama.ErrUnknownMemberId)
+	})
+
+	t.Run("invalid offset", func(t *testing.T) {
+		broker := newMockBroker(t, sarama.ErrNoError)
+		defer broker.Close()
+
+		err := newKafka(broker).Seek(context.Background(), "orders", "", pubsub.Position{Type: pubsub.PositionOffset, Offset: "abc"})
+		assert.Error(t, err)
+	})
+}
@@ -46,6 +46,21 @@ type RedisXPendingExt struct {
 	RetryCount int64
 }
 
+type RedisXPending struct {
+	Count  int64
+	Lower  string
+	Higher string
+}
+
+type RedisXInfoGroup struct {
+	Name            string
+	Consumers       int64
+	Pending         int64
+	LastDeliveredID string
+	// Lag is only reported by Redis 7 and later, with the v9 client
+	Lag int64
+}
+
 type RedisPipeliner interface {
 	Exec(ctx context.Context) error
 	Do(ctx context.Context, args ...interface{})
@@ -73,6 +88,14 @@ type RedisClient interface {
 	XClaimResult(ctx context.Context, stream string, group string, consumer string, minIdleTime time.Duration, messageIDs []string) ([]RedisXMessage, error)
 	TxPipeline() RedisPipeliner
 	TTLResult(ctx context.Context, key string) (time.Duration, error)
+	TypeResult(ctx context.Context, key string) (string, error)
+	ScanTypeResult(ctx context.Context, cursor uint64, match string, count int64, keyType string) ([]string, uint64, error)
+	XLenResult(ctx context.Context, stream string) (int64, error)
+	XGroupDestroy(ctx context.Context, stream string, group string) error
+	XInfoGroupsResult(ctx context.Context, stream string) ([]RedisXInfoGroup, error)
+	XPendingResult(ctx context.Context, stream string, group string) (*RedisXPending, error)
+	XRangeNResult(ctx context.Context, stream string, start string, stop string, count int64) ([]RedisXMessage, error)
+	XGroupSetID(ctx context.Context, stream string, group string, start string) error
 }
 
 func ParseClientFromProperties(properties map[string]string, defaultSettings *Settings) (client RedisClient, settings *Settings, err error) {
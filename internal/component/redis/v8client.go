@@ -288,6 +288,135 @@ func (c v8Client) TTLResult(ctx context.Context, key string) (time.Duration, err
 	return c.client.TTL(writeCtx, key).Result()
 }
 
+func (c v8Client) TypeResult(ctx context.Context, key string) (string, error) {
+	var readCtx context.Context
+	if c.readTimeout > 0 {
+		timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(c.readTimeout))
+		defer cancel()
+		readCtx = timeoutCtx
+	} else {
+		readCtx = ctx
+	}
+	return c.client.Type(readCtx, key).Result()
+}
+
+func (c v8Client) ScanTypeResult(ctx context.Context, cursor uint64, match string, count int64, keyType string) ([]string, uint64, error) {
+	var readCtx context.Context
+	if c.readTimeout > 0 {
+		timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(c.readTimeout))
+		defer cancel()
+		readCtx = timeoutCtx
+	} else {
+		readCtx = ctx
+	}
+	return c.client.ScanType(readCtx, cursor, match, count, keyType).Result()
+}
+
+func (c v8Client) XLenResult(ctx context.Context, stream string) (int64, error) {
+	var readCtx context.Context
+	if c.readTimeout > 0 {
+		timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(c.readTimeout))
+		defer cancel()
+		readCtx = timeoutCtx
+	} else {
+		readCtx = ctx
+	}
+	return c.client.XLen(readCtx, stream).Result()
+}
+
+func (c v8Client) XGroupDestroy(ctx context.Context, stream string, group string) error {
+	var writeCtx context.Context
+	if c.writeTimeout > 0 {
+		timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(c.writeTimeout))
+		defer cancel()
+		writeCtx = timeoutCtx
+	} else {
+		writeCtx = ctx
+	}
+	return c.client.XGroupDestroy(writeCtx, stream, group).Err()
+}
+
+func (c v8Client) XInfoGroupsResult(ctx context.Context, stream string) ([]RedisXInfoGroup, error) {
+	var readCtx context.Context
+	if c.readTimeout > 0 {
+		timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(c.readTimeout))
+		defer cancel()
+		readCtx = timeoutCtx
+	} else {
+		readCtx = ctx
+	}
+	res, err := c.client.XInfoGroups(readCtx, stream).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	// convert []v8.XInfoGroup to []RedisXInfoGroup
+	groups := make([]RedisXInfoGroup, len(res))
+	for i, group := range res {
+		groups[i] = RedisXInfoGroup{
+			Name:            group.Name,
+			Consumers:       group.Consumers,
+			Pending:         group.Pending,
+			LastDeliveredID: group.LastDeliveredID,
+		}
+	}
+	return groups, nil
+}
+
+func (c v8Client) XPendingResult(ctx context.Context, stream string, group string) (*RedisXPending, error) {
+	var readCtx context.Context
+	if c.readTimeout > 0 {
+		timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(c.readTimeout))
+		defer cancel()
+		readCtx = timeoutCtx
+	} else {
+		readCtx = ctx
+	}
+	res, err := c.client.XPending(readCtx, stream, group).Result()
+	if err != nil {
+		return nil, err
+	}
+	return &RedisXPending{
+		Count:  res.Count,
+		Lower:  res.Lower,
+		Higher: res.Higher,
+	}, nil
+}
+
+func (c v8Client) XRangeNResult(ctx context.Context, stream string, start string, stop string, count int64) ([]RedisXMessage, error) {
+	var readCtx context.Context
+	if c.readTimeout > 0 {
+		timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(c.readTimeout))
+		defer cancel()
+		readCtx = timeoutCtx
+	} else {
+		readCtx = ctx
+	}
+	res, err := c.client.XRangeN(readCtx, stream, start, stop, count).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	// convert []v8.XMessage to []RedisXMessage
+	redisXMessages := make([]RedisXMessage, len(res))
+	for i, xMessage := range res {
+		redisXMessages[i] = RedisXMessage(xMessage)
+	}
+	return redisXMessages, nil
+}
+
+func (c v8Client) XGroupSetID(ctx context.Context, stream string, group string, start string) error {
+	var writeCtx context.Context
+	if c.writeTimeout > 0 {
+		timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(c.writeTimeout))
+		defer cancel()
+		writeCtx = timeoutCtx
+	} else {
+		writeCtx = ctx
+	}
+	return c.client.XGroupSetID(writeCtx, stream, group, start).Err()
+}
+
 func newV8FailoverClient(s *Settings) RedisClient {
 	if s == nil {
 		return nil
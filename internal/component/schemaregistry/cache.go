@@ -0,0 +1,87 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemaregistry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type cachedSchema struct {
+	schema  *Schema
+	expires time.Time
+}
+
+type cachingClient struct {
+	client Client
+	ttl    time.Duration
+
+	lock   sync.RWMutex
+	latest map[string]cachedSchema
+	byID   map[int]*Schema
+}
+
+// NewCachingClient returns a Client which caches the schemas returned by client.
+// Schemas looked up by id never change, so they are cached forever; the latest schema
+// of a subject is cached for ttl.
+func NewCachingClient(client Client, ttl time.Duration) Client {
+	return &cachingClient{
+		client: client,
+		ttl:    ttl,
+		latest: map[string]cachedSchema{},
+		byID:   map[int]*Schema{},
+	}
+}
+
+func (c *cachingClient) GetLatestSchema(ctx context.Context, subject string) (*Schema, error) {
+	c.lock.RLock()
+	cached, ok := c.latest[subject]
+	c.lock.RUnlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.schema, nil
+	}
+
+	schema, err := c.client.GetLatestSchema(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	c.latest[subject] = cachedSchema{schema: schema, expires: time.Now().Add(c.ttl)}
+	c.byID[schema.ID] = schema
+	c.lock.Unlock()
+
+	return schema, nil
+}
+
+func (c *cachingClient) GetSchemaByID(ctx context.Context, id int) (*Schema, error) {
+	c.lock.RLock()
+	schema, ok := c.byID[id]
+	c.lock.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	schema, err := c.client.GetSchemaByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	c.byID[id] = schema
+	c.lock.Unlock()
+
+	return schema, nil
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package schemaregistry validates and serializes message payloads against the schemas
+// stored in a Confluent-compatible schema registry.
+package schemaregistry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// SchemaType is the format of a schema.
+type SchemaType string
+
+const (
+	SchemaTypeAvro     SchemaType = "AVRO"
+	SchemaTypeJSON     SchemaType = "JSON"
+	SchemaTypeProtobuf SchemaType = "PROTOBUF"
+)
+
+// ParseSchemaType parses a schema type, case-insensitively.
+func ParseSchemaType(val string) (SchemaType, error) {
+	switch t := SchemaType(strings.ToUpper(val)); t {
+	case SchemaTypeAvro, SchemaTypeJSON, SchemaTypeProtobuf:
+		return t, nil
+	default:
+		return "", fmt.Errorf("invalid schema type: %s", val)
+	}
+}
+
+// Schema is a schema stored in the registry.
+type Schema struct {
+	ID         int        `json:"id"`
+	Subject    string     `json:"subject,omitempty"`
+	Version    int        `json:"version,omitempty"`
+	SchemaType SchemaType `json:"schemaType,omitempty"`
+	Schema     string     `json:"schema"`
+}
+
+// Client retrieves schemas from a schema registry.
+type Client interface {
+	// GetLatestSchema returns the latest version of the schema registered for the subject.
+	GetLatestSchema(ctx context.Context, subject string) (*Schema, error)
+	// GetSchemaByID returns the schema with the given id.
+	GetSchemaByID(ctx context.Context, id int) (*Schema, error)
+}
+
+// HTTPClientOptions configures a client for the REST API of a Confluent-compatible schema registry.
+type HTTPClientOptions struct {
+	URL        string
+	APIKey     string
+	APISecret  string
+	HTTPClient *http.Client
+}
+
+type httpClient struct {
+	opts HTTPClientOptions
+}
+
+// NewHTTPClient returns a client for the REST API of a Confluent-compatible schema registry.
+func NewHTTPClient(opts HTTPClientOptions) Client {
+	opts.URL = strings.TrimRight(opts.URL, "/")
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+
+	return &httpClient{opts: opts}
+}
+
+func (c *httpClient) GetLatestSchema(ctx context.Context, subject string) (*Schema, error) {
+	schema, err := c.get(ctx, "/subjects/"+url.PathEscape(subject)+"/versions/latest")
+	if err != nil {
+		return nil, fmt.Errorf("error getting the latest schema of subject %s: %w", subject, err)
+	}
+
+	return schema, nil
+}
+
+func (c *httpClient) GetSchemaByID(ctx context.Context, id int) (*Schema, error) {
+	schema, err := c.get(ctx, "/schemas/ids/"+strconv.Itoa(id))
+	if err != nil {
+		return nil, fmt.Errorf("error getting schema %d: %w", id, err)
+	}
+	schema.ID = id
+
+	return schema, nil
+}
+
+func (c *httpClient) get(ctx context.Context, path string) (*Schema, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.opts.URL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if c.opts.APIKey != "" {
+		req.SetBasicAuth(c.opts.APIKey, c.opts.APISecret)
+	}
+
+	res, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("schema registry returned status code %d: %s", res.StatusCode, string(body))
+	}
+
+	var schema Schema
+	if err = json.Unmarshal(body, &schema); err != nil {
+		return nil, fmt.Errorf("invalid response from schema registry: %w", err)
+	}
+	// The registry omits the type of Avro schemas
+	if schema.SchemaType == "" {
+		schema.SchemaType = SchemaTypeAvro
+	}
+
+	return &schema, nil
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemaregistry
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/linkedin/goavro/v2"
+	"github.com/xeipuuv/gojsonschema"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Codec converts JSON documents to and from the serialized form defined by a schema.
+type Codec interface {
+	// Encode validates a JSON document against the schema and returns its serialized form.
+	Encode(data []byte) ([]byte, error)
+	// Decode deserializes a payload and returns it as a JSON document.
+	Decode(data []byte) ([]byte, error)
+}
+
+// NewCodec returns the codec for a schema.
+func NewCodec(schema *Schema) (Codec, error) {
+	switch schema.SchemaType {
+	case SchemaTypeAvro, "":
+		return newAvroCodec(schema.Schema)
+	case SchemaTypeJSON:
+		return newJSONCodec(schema.Schema)
+	case SchemaTypeProtobuf:
+		return newProtobufCodec(schema.Schema)
+	default:
+		return nil, fmt.Errorf("unsupported schema type: %s", schema.SchemaType)
+	}
+}
+
+type avroCodec struct {
+	codec *goavro.Codec
+}
+
+func newAvroCodec(schema string) (Codec, error) {
+	codec, err := goavro.NewCodecForStandardJSONFull(schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Avro schema: %w", err)
+	}
+
+	return &avroCodec{codec: codec}, nil
+}
+
+func (c *avroCodec) Encode(data []byte) ([]byte, error) {
+	native, _, err := c.codec.NativeFromTextual(data)
+	if err != nil {
+		return nil, fmt.Errorf("data does not match the Avro schema: %w", err)
+	}
+
+	return c.codec.BinaryFromNative(nil, native)
+}
+
+func (c *avroCodec) Decode(data []byte) ([]byte, error) {
+	native, _, err := c.codec.NativeFromBinary(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding Avro data: %w", err)
+	}
+
+	return c.codec.TextualFromNative(nil, native)
+}
+
+type jsonCodec struct {
+	schema *gojsonschema.Schema
+}
+
+func newJSONCodec(schema string) (Codec, error) {
+	s, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(schema))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+
+	return &jsonCodec{schema: s}, nil
+}
+
+func (c *jsonCodec) Encode(data []byte) ([]byte, error) {
+	res, err := c.schema.Validate(gojsonschema.NewBytesLoader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON data: %w", err)
+	}
+	if !res.Valid() {
+		errs := make([]string, len(res.Errors()))
+		for i, e := range res.Errors() {
+			errs[i] = e.String()
+		}
+		return nil, fmt.Errorf("data does not match the JSON schema: %s", strings.Join(errs, "; "))
+	}
+
+	return data, nil
+}
+
+func (c *jsonCodec) Decode(data []byte) ([]byte, error) {
+	return data, nil
+}
+
+// protobufCodec serializes messages of the first message type defined in a Protobuf schema.
+// The payload is prefixed with the indexes of the message type in the schema, as required by the Confluent wire format.
+type protobufCodec struct {
+	file protoreflect.FileDescriptor
+}
+
+func newProtobufCodec(schema string) (Codec, error) {
+	const filename = "schema.proto"
+	parser := protoparse.Parser{
+		Accessor: protoparse.FileContentsFromMap(map[string]string{filename: schema}),
+	}
+	fds, err := parser.ParseFiles(filename)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Protobuf schema: %w", err)
+	}
+	file, err := protodesc.NewFile(fds[0].AsFileDescriptorProto(), protoregistry.GlobalFiles)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Protobuf schema: %w", err)
+	}
+	if file.Messages().Len() == 0 {
+		return nil, errors.New("the Protobuf schema does not define any message")
+	}
+
+	return &protobufCodec{file: file}, nil
+}
+
+func (c *protobufCodec) Encode(data []byte) ([]byte, error) {
+	msg := dynamicpb.NewMessage(c.file.Messages().Get(0))
+	if err := protojson.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("data does not match the Protobuf schema: %w", err)
+	}
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	// A single 0 stands for the indexes [0], i.e. the first message type
+	return append([]byte{0}, payload...), nil
+}
+
+func (c *protobufCodec) Decode(data []byte) ([]byte, error) {
+	r := bytes.NewReader(data)
+	count, err := binary.ReadVarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Protobuf message indexes: %w", err)
+	}
+	indexes := []int64{0}
+	if count > 0 {
+		indexes = make([]int64, count)
+		for i := range indexes {
+			if indexes[i], err = binary.ReadVarint(r); err != nil {
+				return nil, fmt.Errorf("invalid Protobuf message indexes: %w", err)
+			}
+		}
+	}
+
+	md, err := c.messageDescriptor(indexes)
+	if err != nil {
+		return nil, err
+	}
+	msg := dynamicpb.NewMessage(md)
+	if err = proto.Unmarshal(data[len(data)-r.Len():], msg); err != nil {
+		return nil, fmt.Errorf("error decoding Protobuf data: %w", err)
+	}
+
+	return protojson.Marshal(msg)
+}
+
+// messageDescriptor resolves the path of indexes of a message type, nested types included.
+func (c *protobufCodec) messageDescriptor(indexes []int64) (protoreflect.MessageDescriptor, error) {
+	messages := c.file.Messages()
+	var md protoreflect.MessageDescriptor
+	for _, i := range indexes {
+		if i < 0 || int(i) >= messages.Len() {
+			return nil, fmt.Errorf("message index %d not found in the Protobuf schema", i)
+		}
+		md = messages.Get(int(i))
+		messages = md.Messages()
+	}
+
+	return md, nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemaregistry
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// LocalRegistry is an in-memory stand-in for a schema registry, serving the subset of the REST API used by
+// the HTTP client. It is meant to be used in tests, with net/http/httptest.
+type LocalRegistry struct {
+	lock     sync.RWMutex
+	schemas  []Schema
+	subjects map[string][]int
+}
+
+// NewLocalRegistry returns an empty LocalRegistry.
+func NewLocalRegistry() *LocalRegistry {
+	return &LocalRegistry{
+		subjects: map[string][]int{},
+	}
+}
+
+// Register adds a new version of the schema of a subject and returns its id.
+func (l *LocalRegistry) Register(subject string, schemaType SchemaType, schema string) int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	id := len(l.schemas) + 1
+	l.subjects[subject] = append(l.subjects[subject], id)
+	l.schemas = append(l.schemas, Schema{
+		ID:         id,
+		Subject:    subject,
+		Version:    len(l.subjects[subject]),
+		SchemaType: schemaType,
+		Schema:     schema,
+	})
+
+	return id
+}
+
+func (l *LocalRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 4 && parts[0] == "subjects" && parts[2] == "versions" && parts[3] == "latest":
+		ids := l.subjects[parts[1]]
+		if len(ids) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		l.write(w, l.schemas[ids[len(ids)-1]-1])
+	case len(parts) == 3 && parts[0] == "schemas" && parts[1] == "ids":
+		id, err := strconv.Atoi(parts[2])
+		if err != nil || id < 1 || id > len(l.schemas) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		schema := l.schemas[id-1]
+		l.write(w, Schema{SchemaType: schema.SchemaType, Schema: schema.Schema})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (l *LocalRegistry) write(w http.ResponseWriter, schema Schema) {
+	// Like the actual registry, omit the type of Avro schemas
+	if schema.SchemaType == SchemaTypeAvro {
+		schema.SchemaType = ""
+	}
+	w.Header().Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	json.NewEncoder(w).Encode(schema)
+}
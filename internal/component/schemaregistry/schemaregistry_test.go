@@ -0,0 +1,148 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemaregistry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	avroSchema  = `{"type":"record","name":"Order","fields":[{"name":"id","type":"string"},{"name":"amount","type":"int"},{"name":"note","type":["null","string"],"default":null}]}`
+	jsonSchema  = `{"type":"object","properties":{"id":{"type":"string"},"amount":{"type":"integer"}},"required":["id","amount"]}`
+	protoSchema = `syntax = "proto3";
+message Order {
+  string id = 1;
+  int32 amount = 2;
+  message Line {
+    string sku = 1;
+  }
+}`
+)
+
+func newTestSerializer(t *testing.T) (*Serializer, *LocalRegistry, *int32) {
+	registry := NewLocalRegistry()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		registry.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewCachingClient(NewHTTPClient(HTTPClientOptions{URL: server.URL}), time.Minute)
+	return NewSerializer(client), registry, &requests
+}
+
+func TestSerializer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("avro", func(t *testing.T) {
+		s, registry, _ := newTestSerializer(t)
+		id := registry.Register("orders-value", SchemaTypeAvro, avroSchema)
+
+		data, err := s.Serialize(ctx, "orders-value", SchemaTypeAvro, []byte(`{"id":"a","amount":3,"note":"hi"}`))
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0, 0, 0, 0, byte(id)}, data[:5])
+
+		res, err := s.Deserialize(ctx, data)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"id":"a","amount":3,"note":"hi"}`, string(res))
+
+		_, err = s.Serialize(ctx, "orders-value", SchemaTypeAvro, []byte(`{"id":"a"}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("json schema", func(t *testing.T) {
+		s, registry, _ := newTestSerializer(t)
+		registry.Register("orders-value", SchemaTypeJSON, jsonSchema)
+
+		data, err := s.Serialize(ctx, "orders-value", SchemaTypeJSON, []byte(`{"id":"a","amount":3}`))
+		require.NoError(t, err)
+		res, err := s.Deserialize(ctx, data)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"id":"a","amount":3}`, string(res))
+
+		_, err = s.Serialize(ctx, "orders-value", SchemaTypeJSON, []byte(`{"id":"a","amount":"3"}`))
+		assert.ErrorContains(t, err, "amount")
+	})
+
+	t.Run("protobuf", func(t *testing.T) {
+		s, registry, _ := newTestSerializer(t)
+		registry.Register("orders-value", SchemaTypeProtobuf, protoSchema)
+
+		data, err := s.Serialize(ctx, "orders-value", SchemaTypeProtobuf, []byte(`{"id":"a","amount":3}`))
+		require.NoError(t, err)
+		res, err := s.Deserialize(ctx, data)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"id":"a","amount":3}`, string(res))
+
+		// Payloads of nested message types are prefixed with the full list of indexes: [0, 0]
+		nested := append([]byte{0, 0, 0, 0, 1, 4, 0, 0}, 0x0a, 0x01, 'x')
+		res, err = s.Deserialize(ctx, nested)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"sku":"x"}`, string(res))
+
+		_, err = s.Serialize(ctx, "orders-value", SchemaTypeProtobuf, []byte(`{"unknown":1}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("schema type mismatch", func(t *testing.T) {
+		s, registry, _ := newTestSerializer(t)
+		registry.Register("orders-value", SchemaTypeJSON, jsonSchema)
+
+		_, err := s.Serialize(ctx, "orders-value", SchemaTypeAvro, []byte(`{"id":"a","amount":3}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown subject", func(t *testing.T) {
+		s, _, _ := newTestSerializer(t)
+		_, err := s.Serialize(ctx, "orders-value", SchemaTypeJSON, []byte(`{}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("payload not in wire format", func(t *testing.T) {
+		s, _, _ := newTestSerializer(t)
+		_, err := s.Deserialize(ctx, []byte(`{"id":"a"}`))
+		assert.ErrorIs(t, err, ErrNotSerialized)
+	})
+
+	t.Run("schemas are cached", func(t *testing.T) {
+		s, registry, requests := newTestSerializer(t)
+		registry.Register("orders-value", SchemaTypeJSON, jsonSchema)
+
+		for i := 0; i < 3; i++ {
+			data, err := s.Serialize(ctx, "orders-value", SchemaTypeJSON, []byte(`{"id":"a","amount":3}`))
+			require.NoError(t, err)
+			_, err = s.Deserialize(ctx, data)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+	})
+}
+
+func TestParseSchemaType(t *testing.T) {
+	st, err := ParseSchemaType("avro")
+	require.NoError(t, err)
+	assert.Equal(t, SchemaTypeAvro, st)
+
+	_, err = ParseSchemaType("xml")
+	assert.Error(t, err)
+}
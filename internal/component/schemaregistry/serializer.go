@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemaregistry
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	// magicByte is the first byte of payloads in the Confluent wire format.
+	magicByte = 0
+	// headerLength is the length of the magic byte and the schema id.
+	headerLength = 5
+)
+
+// ErrNotSerialized is returned when decoding payloads which are not in the Confluent wire format.
+var ErrNotSerialized = errors.New("payload is not in the schema registry wire format")
+
+// ValueSubject returns the subject of the values published to a topic, following the TopicNameStrategy.
+func ValueSubject(topic string) string {
+	return topic + "-value"
+}
+
+// Serializer converts JSON documents to and from the Confluent wire format: a magic byte,
+// the schema id as a 4-byte big-endian integer, and the payload serialized with the schema.
+type Serializer struct {
+	client Client
+
+	lock   sync.RWMutex
+	codecs map[int]Codec
+}
+
+// NewSerializer returns a Serializer retrieving the schemas from client.
+// Compiled schemas are kept in memory, so client is usually wrapped with NewCachingClient.
+func NewSerializer(client Client) *Serializer {
+	return &Serializer{
+		client: client,
+		codecs: map[int]Codec{},
+	}
+}
+
+// Serialize validates a JSON document against the latest schema of the subject, which must be of the given type,
+// and returns it in the wire format.
+func (s *Serializer) Serialize(ctx context.Context, subject string, schemaType SchemaType, data []byte) ([]byte, error) {
+	schema, err := s.client.GetLatestSchema(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	if schema.SchemaType != schemaType {
+		return nil, fmt.Errorf("schema of subject %s has type %s, expected %s", subject, schema.SchemaType, schemaType)
+	}
+
+	codec, err := s.codec(schema)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := codec.Encode(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data for subject %s: %w", subject, err)
+	}
+
+	res := make([]byte, headerLength, headerLength+len(payload))
+	res[0] = magicByte
+	binary.BigEndian.PutUint32(res[1:headerLength], uint32(schema.ID))
+
+	return append(res, payload...), nil
+}
+
+// Deserialize decodes a payload in the wire format and returns it as a JSON document.
+func (s *Serializer) Deserialize(ctx context.Context, data []byte) ([]byte, error) {
+	if len(data) < headerLength || data[0] != magicByte {
+		return nil, ErrNotSerialized
+	}
+
+	id := int(binary.BigEndian.Uint32(data[1:headerLength]))
+	codec, err := s.codecByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return codec.Decode(data[headerLength:])
+}
+
+func (s *Serializer) codecByID(ctx context.Context, id int) (Codec, error) {
+	s.lock.RLock()
+	codec, ok := s.codecs[id]
+	s.lock.RUnlock()
+	if ok {
+		return codec, nil
+	}
+
+	schema, err := s.client.GetSchemaByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.codec(schema)
+}
+
+func (s *Serializer) codec(schema *Schema) (Codec, error) {
+	s.lock.RLock()
+	codec, ok := s.codecs[schema.ID]
+	s.lock.RUnlock()
+	if ok {
+		return codec, nil
+	}
+
+	codec, err := NewCodec(schema)
+	if err != nil {
+		return nil, fmt.Errorf("error compiling schema %d: %w", schema.ID, err)
+	}
+
+	s.lock.Lock()
+	s.codecs[schema.ID] = codec
+	s.lock.Unlock()
+
+	return codec, nil
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/JY29/components-contrib/bindings"
+	"github.com/JY29/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	defaultPollInterval = 10 * time.Second
+
+	// WatermarkMetadataKey is the metadata key of the value of the watermark column of a row.
+	WatermarkMetadataKey = "watermark"
+)
+
+// PollMetadata is the metadata of the input bindings polling a query for new rows.
+type PollMetadata struct {
+	// PollQuery selects the rows after the watermark, which is its only parameter, ordered by the watermark column.
+	PollQuery string `mapstructure:"pollQuery"`
+	// WatermarkColumn is the column whose value in the last row read is the watermark of the next poll.
+	WatermarkColumn string `mapstructure:"watermarkColumn"`
+	// InitialWatermark is the watermark of the first poll when none is persisted, as a JSON value or as a string.
+	InitialWatermark string `mapstructure:"initialWatermark"`
+	// PollInterval is the delay between the polls.
+	PollInterval time.Duration `mapstructure:"pollInterval"`
+	// WatermarkKey is the key of the watermark in the state store.
+	WatermarkKey string `mapstructure:"watermarkKey"`
+	// StateStoreName is the name of the state store persisting the watermark.
+	StateStoreName string `mapstructure:"stateStoreName"`
+}
+
+// Validate checks the metadata when the poll query is set, and sets the defaults.
+func (m *PollMetadata) Validate(name string) error {
+	if m.PollQuery == "" {
+		return nil
+	}
+	if m.WatermarkColumn == "" {
+		return errors.New("watermarkColumn is required with pollQuery")
+	}
+	if m.InitialWatermark == "" {
+		return errors.New("initialWatermark is required with pollQuery")
+	}
+	if m.PollInterval <= 0 {
+		m.PollInterval = defaultPollInterval
+	}
+	if m.WatermarkKey == "" {
+		m.WatermarkKey = name + "-watermark"
+	}
+
+	return nil
+}
+
+// ResolveStateStore returns the state store named by StateStoreName, or the given store when no name is set.
+func (m *PollMetadata) ResolveStateStore(store state.Store, resolver state.StoreResolver) (state.Store, error) {
+	if m.StateStoreName == "" {
+		return store, nil
+	}
+	if resolver == nil {
+		return nil, errors.New("stateStoreName requires a state store resolver")
+	}
+	store, ok := resolver(m.StateStoreName)
+	if !ok {
+		return nil, fmt.Errorf("state store %s not found", m.StateStoreName)
+	}
+
+	return store, nil
+}
+
+// RowsFunc runs a query and returns its rows, as maps of the column names to their values decoded with DecodeValue.
+type RowsFunc func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error)
+
+// Poller polls a query for the rows after a watermark, and triggers a handler for each of them.
+// The watermark advances once the handler succeeded for a row, and is persisted when a state store is set,
+// so that the rows are delivered at least once.
+type Poller struct {
+	metadata PollMetadata
+	rows     RowsFunc
+	store    state.Store
+	logger   logger.Logger
+
+	watermark json.RawMessage
+}
+
+// NewPoller returns a poller. The state store is optional.
+func NewPoller(metadata PollMetadata, rows RowsFunc, store state.Store, logger logger.Logger) *Poller {
+	return &Poller{
+		metadata: metadata,
+		rows:     rows,
+		store:    store,
+		logger:   logger,
+	}
+}
+
+// Start polls the query until ctx is canceled.
+func (p *Poller) Start(ctx context.Context, handler bindings.Handler) error {
+	err := p.loadWatermark(ctx)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(p.metadata.PollInterval)
+		defer ticker.Stop()
+		for {
+			p.Poll(ctx, handler)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (p *Poller) loadWatermark(ctx context.Context) error {
+	if p.store != nil {
+		res, err := p.store.Get(ctx, &state.GetRequest{Key: p.metadata.WatermarkKey})
+		if err != nil {
+			return fmt.Errorf("error reading the watermark: %w", err)
+		}
+		if res != nil && len(res.Data) > 0 {
+			p.watermark = res.Data
+			return nil
+		}
+	}
+
+	if json.Valid([]byte(p.metadata.InitialWatermark)) {
+		p.watermark = json.RawMessage(p.metadata.InitialWatermark)
+		return nil
+	}
+	var err error
+	p.watermark, err = json.Marshal(p.metadata.InitialWatermark)
+
+	return err
+}
+
+// Poll runs the query once, and triggers the handler for the rows until it fails.
+func (p *Poller) Poll(ctx context.Context, handler bindings.Handler) {
+	args, err := ParseParams(append(append([]byte("["), p.watermark...), ']'))
+	if err != nil {
+		p.logger.Errorf("invalid watermark %s: %v", p.watermark, err)
+		return
+	}
+	rows, err := p.rows(ctx, p.metadata.PollQuery, args...)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.logger.Errorf("error polling rows: %v", err)
+		}
+		return
+	}
+
+	for _, row := range rows {
+		value, ok := row[p.metadata.WatermarkColumn]
+		if !ok {
+			p.logger.Errorf("the rows have no watermark column %s", p.metadata.WatermarkColumn)
+			return
+		}
+		watermark, err := json.Marshal(value)
+		if err != nil {
+			p.logger.Errorf("invalid watermark %v: %v", value, err)
+			return
+		}
+		data, err := json.Marshal(row)
+		if err != nil {
+			p.logger.Errorf("error serializing row: %v", err)
+			return
+		}
+
+		_, err = handler(ctx, &bindings.ReadResponse{
+			Data:     data,
+			Metadata: map[string]string{WatermarkMetadataKey: string(watermark)},
+		})
+		if err != nil {
+			// The row is delivered again by the next poll
+			p.logger.Errorf("error handling row with watermark %s: %v", watermark, err)
+			return
+		}
+
+		p.watermark = watermark
+		if p.store != nil {
+			err = p.store.Set(ctx, &state.SetRequest{Key: p.metadata.WatermarkKey, Value: []byte(watermark)})
+			if err != nil {
+				p.logger.Errorf("error saving watermark %s: %v", watermark, err)
+			}
+		}
+	}
+}
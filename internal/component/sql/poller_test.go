@@ -0,0 +1,162 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JY29/components-contrib/bindings"
+	"github.com/JY29/components-contrib/state"
+	inmemory "github.com/JY29/components-contrib/state/in-memory"
+	"github.com/dapr/kit/logger"
+)
+
+func TestPollMetadataValidate(t *testing.T) {
+	m := PollMetadata{}
+	assert.NoError(t, m.Validate("binding"), "polling is disabled")
+
+	m.PollQuery = "SELECT * FROM t WHERE id > $1 ORDER BY id"
+	assert.Error(t, m.Validate("binding"))
+	m.WatermarkColumn = "id"
+	assert.Error(t, m.Validate("binding"))
+	m.InitialWatermark = "0"
+	require.NoError(t, m.Validate("binding"))
+	assert.Equal(t, defaultPollInterval, m.PollInterval)
+	assert.Equal(t, "binding-watermark", m.WatermarkKey)
+}
+
+func TestPollMetadataResolveStateStore(t *testing.T) {
+	store := inmemory.NewInMemoryStateStore(logger.NewLogger("test"))
+	resolver := func(name string) (state.Store, bool) {
+		return store, name == "statestore"
+	}
+
+	m := PollMetadata{}
+	res, err := m.ResolveStateStore(nil, resolver)
+	require.NoError(t, err)
+	assert.Nil(t, res, "no store is named")
+
+	m.StateStoreName = "statestore"
+	res, err = m.ResolveStateStore(nil, resolver)
+	require.NoError(t, err)
+	assert.Equal(t, store, res)
+
+	_, err = m.ResolveStateStore(nil, nil)
+	assert.Error(t, err, "no resolver")
+
+	m.StateStoreName = "other"
+	_, err = m.ResolveStateStore(nil, resolver)
+	assert.Error(t, err, "unknown store")
+}
+
+// fakeTable returns the rows whose id is greater than the argument.
+type fakeTable struct {
+	rows []map[string]interface{}
+	args []interface{}
+}
+
+func (f *fakeTable) query(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	f.args = append(f.args, args...)
+	var res []map[string]interface{}
+	for _, row := range f.rows {
+		if row["id"].(int64) > args[0].(int64) {
+			res = append(res, row)
+		}
+	}
+
+	return res, nil
+}
+
+func TestPoller(t *testing.T) {
+	store := inmemory.NewInMemoryStateStore(logger.NewLogger("test"))
+	require.NoError(t, store.Init(state.Metadata{}))
+	ctx := context.Background()
+	m := PollMetadata{
+		PollQuery:        "SELECT * FROM t WHERE id > $1 ORDER BY id",
+		WatermarkColumn:  "id",
+		InitialWatermark: "1",
+	}
+	require.NoError(t, m.Validate("test"))
+
+	table := &fakeTable{rows: []map[string]interface{}{
+		{"id": int64(1), "v": "a"},
+		{"id": int64(2), "v": "b"},
+		{"id": int64(3), "v": "c"},
+	}}
+
+	var (
+		lock     sync.Mutex
+		received []string
+	)
+	failOn := ""
+	handler := func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		if res.Metadata[WatermarkMetadataKey] == failOn {
+			return nil, errors.New("handler failed")
+		}
+		received = append(received, string(res.Data))
+		return nil, nil
+	}
+
+	p := NewPoller(m, table.query, store, logger.NewLogger("test"))
+	require.NoError(t, p.loadWatermark(ctx))
+
+	// The row 3 fails, so the watermark stays at 2
+	failOn = "3"
+	p.Poll(ctx, handler)
+	assert.Equal(t, []string{`{"id":2,"v":"b"}`}, received)
+
+	failOn = ""
+	p.Poll(ctx, handler)
+	assert.Equal(t, []string{`{"id":2,"v":"b"}`, `{"id":3,"v":"c"}`}, received)
+	assert.Equal(t, []interface{}{int64(1), int64(2)}, table.args)
+
+	// The watermark is persisted for the next pollers
+	res, err := store.Get(ctx, &state.GetRequest{Key: "test-watermark"})
+	require.NoError(t, err)
+	assert.Equal(t, "3", string(res.Data))
+
+	table.rows = append(table.rows, map[string]interface{}{"id": int64(4), "v": "d"})
+	p = NewPoller(m, table.query, store, logger.NewLogger("test"))
+	pollCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	require.NoError(t, p.Start(pollCtx, handler))
+	assert.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(received) == 3
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, `{"id":4,"v":"d"}`, received[2])
+}
+
+func TestPollerInitialWatermark(t *testing.T) {
+	p := NewPoller(PollMetadata{InitialWatermark: "2023-01-02T03:04:05Z"}, nil, nil, logger.NewLogger("test"))
+	require.NoError(t, p.loadWatermark(context.Background()))
+	assert.Equal(t, `"2023-01-02T03:04:05Z"`, string(p.watermark))
+
+	p = NewPoller(PollMetadata{InitialWatermark: "42"}, nil, nil, logger.NewLogger("test"))
+	require.NoError(t, p.loadWatermark(context.Background()))
+	assert.Equal(t, `42`, string(p.watermark))
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sql contains the request parsing and result decoding shared by the SQL bindings.
+package sql
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// SQLKey is the metadata key of the statement of a request.
+	SQLKey = "sql"
+	// ParamsKey is the metadata key of the parameters of the statement, as a JSON array.
+	ParamsKey = "params"
+)
+
+// Statement is a statement with its positional parameters.
+type Statement struct {
+	SQL    string          `json:"sql"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// Args decodes the parameters of the statement.
+func (s Statement) Args() ([]interface{}, error) {
+	return ParseParams(s.Params)
+}
+
+// StatementFromMetadata returns the statement of a request.
+func StatementFromMetadata(md map[string]string) (Statement, error) {
+	s := Statement{SQL: md[SQLKey]}
+	if s.SQL == "" {
+		return s, fmt.Errorf("required metadata not set: %s", SQLKey)
+	}
+	if params := md[ParamsKey]; params != "" {
+		s.Params = json.RawMessage(params)
+	}
+
+	return s, nil
+}
+
+// ParseStatements decodes the statements of a transaction, sent as a JSON array of objects with the sql and params properties.
+func ParseStatements(data []byte) ([]Statement, error) {
+	var statements []Statement
+	if err := json.Unmarshal(data, &statements); err != nil {
+		return nil, fmt.Errorf("the transaction must be a JSON array of statements: %w", err)
+	}
+	if len(statements) == 0 {
+		return nil, errors.New("the transaction has no statement")
+	}
+	for i, s := range statements {
+		if s.SQL == "" {
+			return nil, fmt.Errorf("statement %d has no sql", i)
+		}
+	}
+
+	return statements, nil
+}
+
+// ParseParams decodes a JSON array of parameters, to bind to the placeholders of a statement.
+// Integers are decoded as int64, other numbers as float64, and objects and arrays are bound as their JSON encoding.
+func ParseParams(data []byte) ([]interface{}, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s must be a JSON array: %w", ParamsKey, err)
+	}
+
+	params := make([]interface{}, len(raw))
+	for i, r := range raw {
+		decoder := json.NewDecoder(bytes.NewReader(r))
+		decoder.UseNumber()
+		var v interface{}
+		if err := decoder.Decode(&v); err != nil {
+			return nil, fmt.Errorf("invalid parameter %d: %w", i, err)
+		}
+
+		switch val := v.(type) {
+		case json.Number:
+			if n, err := val.Int64(); err == nil {
+				params[i] = n
+			} else if f, err := val.Float64(); err == nil {
+				params[i] = f
+			} else {
+				return nil, fmt.Errorf("invalid parameter %d: %w", i, err)
+			}
+		case map[string]interface{}, []interface{}:
+			params[i] = string(r)
+		default:
+			params[i] = val
+		}
+	}
+
+	return params, nil
+}
+
+// DecodeValue converts a value read from a column with the given database type name to the value encoded in the JSON results.
+// Textual values read as bytes are returned as strings, JSON documents as raw JSON, decimals and integers as numbers,
+// UUIDs in their canonical form and times in RFC 3339. The other binary values are encoded in base64.
+func DecodeValue(typeName string, v interface{}) interface{} {
+	typeName = strings.ToUpper(typeName)
+
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return decodeBytes(typeName, val)
+	case *[]byte:
+		if val == nil {
+			return nil
+		}
+		return decodeBytes(typeName, *val)
+	case [16]byte:
+		if typeName == "UUID" {
+			return uuid.UUID(val).String()
+		}
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	}
+
+	return v
+}
+
+// decodeBytes copies the values it returns, as drivers may reuse the buffers.
+func decodeBytes(typeName string, b []byte) interface{} {
+	if b == nil {
+		return nil
+	}
+
+	switch typeName {
+	case "JSON", "JSONB":
+		if json.Valid(b) {
+			return json.RawMessage(append([]byte(nil), b...))
+		}
+	case "DECIMAL", "NUMERIC":
+		if _, err := strconv.ParseFloat(string(b), 64); err == nil {
+			return json.Number(b)
+		}
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT", "YEAR",
+		"UNSIGNED TINYINT", "UNSIGNED SMALLINT", "UNSIGNED MEDIUMINT", "UNSIGNED INT", "UNSIGNED BIGINT":
+		if _, err := strconv.ParseInt(string(b), 10, 64); err == nil {
+			return json.Number(b)
+		}
+		if _, err := strconv.ParseUint(string(b), 10, 64); err == nil {
+			return json.Number(b)
+		}
+	case "FLOAT", "DOUBLE", "REAL", "FLOAT4", "FLOAT8":
+		if _, err := strconv.ParseFloat(string(b), 64); err == nil {
+			return json.Number(b)
+		}
+	case "BINARY", "VARBINARY", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BYTEA", "BIT", "GEOMETRY":
+		return append([]byte(nil), b...)
+	}
+
+	// The other types, like the character and date types, are read as text
+	return string(b)
+}
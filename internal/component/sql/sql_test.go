@@ -0,0 +1,90 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseParams(t *testing.T) {
+	params, err := ParseParams([]byte(`[1, 9007199254740993, 1.5, "a", true, null, {"k": "v"}, [1, 2]]`))
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{int64(1), int64(9007199254740993), 1.5, "a", true, nil, `{"k": "v"}`, `[1, 2]`}, params)
+
+	params, err = ParseParams(nil)
+	require.NoError(t, err)
+	assert.Nil(t, params)
+
+	_, err = ParseParams([]byte(`{"id": 1}`))
+	assert.ErrorContains(t, err, ParamsKey)
+}
+
+func TestStatementFromMetadata(t *testing.T) {
+	s, err := StatementFromMetadata(map[string]string{SQLKey: "SELECT * FROM foo WHERE id = ?", ParamsKey: `[1]`})
+	require.NoError(t, err)
+	args, err := s.Args()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{int64(1)}, args)
+
+	_, err = StatementFromMetadata(nil)
+	assert.ErrorContains(t, err, SQLKey)
+}
+
+func TestParseStatements(t *testing.T) {
+	statements, err := ParseStatements([]byte(`[{"sql": "DELETE FROM foo WHERE id = ?", "params": [1]}, {"sql": "DELETE FROM bar"}]`))
+	require.NoError(t, err)
+	require.Len(t, statements, 2)
+	assert.Equal(t, "DELETE FROM bar", statements[1].SQL)
+
+	for _, data := range []string{`[]`, `[{"params": [1]}]`, `"DELETE FROM foo"`} {
+		_, err = ParseStatements([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
+
+func TestDecodeValue(t *testing.T) {
+	ts := time.Date(2023, 1, 2, 3, 4, 5, 6, time.UTC)
+	tests := []struct {
+		typeName string
+		in       interface{}
+		out      interface{}
+	}{
+		{"VARCHAR", []byte("text"), "text"},
+		{"DATETIME", []byte("2023-01-02 03:04:05"), "2023-01-02 03:04:05"},
+		{"json", []byte(`{"a":1}`), json.RawMessage(`{"a":1}`)},
+		{"DECIMAL", []byte("12.50"), json.Number("12.50")},
+		{"BIGINT", []byte("42"), json.Number("42")},
+		{"UNSIGNED BIGINT", []byte("18446744073709551615"), json.Number("18446744073709551615")},
+		{"BLOB", []byte{0, 1}, []byte{0, 1}},
+		{"uuid", [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}, "123e4567-e89b-12d3-a456-426614174000"},
+		{"TIMESTAMP", ts, "2023-01-02T03:04:05.000000006Z"},
+		{"INT", int64(1), int64(1)},
+		{"VARCHAR", nil, nil},
+		{"VARCHAR", []byte(nil), nil},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.out, DecodeValue(tt.typeName, tt.in), tt.typeName)
+	}
+
+	// The values are copied from the buffers of the drivers
+	buf := []byte(`{"a":1}`)
+	v := DecodeValue("JSON", buf)
+	buf[1] = 'x'
+	assert.Equal(t, json.RawMessage(`{"a":1}`), v)
+}
@@ -1,4 +1,4 @@
-package pubsub
+package tlsutils
 
 import (
 	"crypto/x509"
@@ -25,3 +25,6 @@ type Store interface {
 	// Unlock tries to release a lock.
 	Unlock(ctx context.Context, req *UnlockRequest) (*UnlockResponse, error)
 }
+
+// StoreResolver returns the lock store with the given name.
+type StoreResolver func(name string) (Store, bool)
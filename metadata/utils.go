@@ -52,6 +52,19 @@ const (
 
 	// MaxBulkPubBytesKey defines the maximum bytes to publish in a bulk publish request metadata.
 	MaxBulkPubBytesKey string = "maxBulkPubBytes"
+
+	// DelayMetadataKey defines the metadata key for delaying the delivery of a published message (in seconds).
+	DelayMetadataKey = "delayInSeconds"
+
+	// ScheduledTimeMetadataKey defines the metadata key for the time (RFC3339) at which a published message becomes visible.
+	ScheduledTimeMetadataKey = "scheduledTime"
+
+	// StartPositionMetadataKey defines the subscription metadata key for the position from which a new subscription starts consuming.
+	// The value is "earliest", "latest", an RFC3339 timestamp or a broker-specific offset.
+	StartPositionMetadataKey = "startPosition"
+
+	// FilterMetadataKey defines the subscription metadata key for the expression filtering the messages delivered to the subscriber.
+	FilterMetadataKey = "filter"
 )
 
 // TryGetTTL tries to get the ttl as a time.Duration value for pubsub, binding and any other building block.
@@ -99,6 +112,34 @@ func TryGetPriority(props map[string]string) (uint8, bool, error) {
 	return 0, false, nil
 }
 
+// TryGetScheduledTime tries to get the time at which a published message should become visible to subscribers.
+// ScheduledTimeMetadataKey takes precedence over DelayMetadataKey when both are set.
+func TryGetScheduledTime(props map[string]string) (time.Time, bool, error) {
+	if val, ok := props[ScheduledTimeMetadataKey]; ok && val != "" {
+		t, err := time.Parse(time.RFC3339, val)
+		if err != nil {
+			return time.Time{}, false, errors.Wrapf(err, "%s value must be a valid RFC3339 time: actual is '%s'", ScheduledTimeMetadataKey, val)
+		}
+
+		return t, true, nil
+	}
+
+	if val, ok := props[DelayMetadataKey]; ok && val != "" {
+		valInt64, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return time.Time{}, false, errors.Wrapf(err, "%s value must be a valid integer: actual is '%s'", DelayMetadataKey, val)
+		}
+
+		if valInt64 < 0 {
+			return time.Time{}, false, fmt.Errorf("%s value must not be negative: actual is %d", DelayMetadataKey, valInt64)
+		}
+
+		return time.Now().Add(time.Duration(valInt64) * time.Second), true, nil
+	}
+
+	return time.Time{}, false, nil
+}
+
 // IsRawPayload determines if payload should be used as-is.
 func IsRawPayload(props map[string]string) (bool, error) {
 	if val, ok := props[RawPayloadKey]; ok && val != "" {
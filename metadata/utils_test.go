@@ -66,6 +66,67 @@ func TestIsRawPayload(t *testing.T) {
 	})
 }
 
+func TestTryGetScheduledTime(t *testing.T) {
+	t.Run("Metadata not found", func(t *testing.T) {
+		_, ok, err := TryGetScheduledTime(map[string]string{
+			"notfound": "1",
+		})
+
+		assert.False(t, ok)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Scheduled time", func(t *testing.T) {
+		val, ok, err := TryGetScheduledTime(map[string]string{
+			"scheduledTime": "2030-01-02T03:04:05Z",
+		})
+
+		assert.True(t, ok)
+		assert.Nil(t, err)
+		assert.Equal(t, time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC), val.UTC())
+	})
+
+	t.Run("Scheduled time with bad value", func(t *testing.T) {
+		_, ok, err := TryGetScheduledTime(map[string]string{
+			"scheduledTime": "tomorrow",
+		})
+
+		assert.False(t, ok)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Delay in seconds", func(t *testing.T) {
+		before := time.Now()
+		val, ok, err := TryGetScheduledTime(map[string]string{
+			"delayInSeconds": "30",
+		})
+
+		assert.True(t, ok)
+		assert.Nil(t, err)
+		assert.False(t, val.Before(before.Add(30*time.Second)))
+	})
+
+	t.Run("Negative delay", func(t *testing.T) {
+		_, ok, err := TryGetScheduledTime(map[string]string{
+			"delayInSeconds": "-1",
+		})
+
+		assert.False(t, ok)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Scheduled time takes precedence", func(t *testing.T) {
+		val, ok, err := TryGetScheduledTime(map[string]string{
+			"delayInSeconds": "30",
+			"scheduledTime":  "2030-01-02T03:04:05Z",
+		})
+
+		assert.True(t, ok)
+		assert.Nil(t, err)
+		assert.Equal(t, 2030, val.Year())
+	})
+}
+
 func TestTryGetContentType(t *testing.T) {
 	t.Run("Metadata without content type", func(t *testing.T) {
 		val, ok := TryGetContentType(map[string]string{})
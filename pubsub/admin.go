@@ -0,0 +1,43 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrTopicNotFound is returned by TopicAdmin when a topic does not exist.
+	ErrTopicNotFound = errors.New("topic not found")
+	// ErrSubscriptionNotFound is returned by TopicAdmin when a subscription does not exist.
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+	// ErrTopicAdminNotSupported is returned by TopicAdmin for the operations, or the options, that the broker does not support.
+	ErrTopicAdminNotSupported = errors.New("operation not supported by the broker")
+)
+
+// TopicAdmin is implemented by the PubSub components that can manage the topics and subscriptions of the broker.
+// A subscription is named after the consumer group, or the consumer ID, that the component subscribes with.
+// Creating a topic or a subscription that already exists is not an error.
+type TopicAdmin interface {
+	CreateTopic(ctx context.Context, req CreateTopicRequest) error
+	DeleteTopic(ctx context.Context, topic string) error
+	ListTopics(ctx context.Context) ([]string, error)
+	DescribeTopic(ctx context.Context, topic string) (*TopicDescription, error)
+
+	CreateSubscription(ctx context.Context, req CreateSubscriptionRequest) error
+	DeleteSubscription(ctx context.Context, topic string, subscription string) error
+	ListSubscriptions(ctx context.Context, topic string) ([]string, error)
+	DescribeSubscription(ctx context.Context, topic string, subscription string) (*SubscriptionDescription, error)
+}
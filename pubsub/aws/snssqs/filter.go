@@ -0,0 +1,196 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snssqs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+
+	"github.com/JY29/components-contrib/pubsub"
+)
+
+const (
+	// the filter policies are applied to the published CloudEvents, as the metadata isn't sent as message attributes.
+	snsFilterPolicyScopeMessageBody = "MessageBody"
+	// snsEmptyFilterPolicy removes the filter policy of a subscription.
+	snsEmptyFilterPolicy = "{}"
+)
+
+// subscriptionFilterPolicy returns the filter policy of a subscription, which is empty when there is no filter
+// or when it can't be translated, so that the policy of a previous subscription doesn't drop the expected messages.
+func subscriptionFilterPolicy(filter *pubsub.Filter) (string, bool) {
+	if filter == nil {
+		return snsEmptyFilterPolicy, true
+	}
+	policy, ok := snsFilterPolicy(filter)
+	if !ok {
+		return snsEmptyFilterPolicy, false
+	}
+
+	return policy, true
+}
+
+// snsFilterPolicy translates a subscription filter to a SNS filter policy on the message body.
+// Only conjunctions of conditions on the CloudEvent attributes and the data fields, with string and number values, can be translated.
+func snsFilterPolicy(filter *pubsub.Filter) (string, bool) {
+	policy := map[string]interface{}{}
+	if !addToSnsFilterPolicy(policy, filter) {
+		return "", false
+	}
+
+	res, err := json.Marshal(policy)
+	if err != nil {
+		return "", false
+	}
+
+	return string(res), true
+}
+
+func addToSnsFilterPolicy(policy map[string]interface{}, filter *pubsub.Filter) bool {
+	switch {
+	case filter.And != nil:
+		for _, f := range filter.And {
+			if !addToSnsFilterPolicy(policy, f) {
+				return false
+			}
+		}
+		return true
+	case filter.Not != nil:
+		c := filter.Not.Condition
+		if c == nil || c.Operator != pubsub.FilterOpExists {
+			return false
+		}
+		return addSnsFilterRule(policy, c.Field, map[string]interface{}{"exists": false})
+	case filter.Condition != nil:
+		rule, ok := snsFilterRule(filter.Condition)
+		if !ok {
+			return false
+		}
+		return addSnsFilterRule(policy, filter.Condition.Field, rule)
+	default:
+		return false
+	}
+}
+
+// snsFilterRule returns the rule matching a condition.
+// The rules listing values match any of them, and the other rules are objects.
+func snsFilterRule(c *pubsub.FilterCondition) (interface{}, bool) {
+	for _, v := range c.Values {
+		switch v.(type) {
+		case string, float64:
+		default:
+			return nil, false
+		}
+	}
+
+	switch c.Operator {
+	case pubsub.FilterOpEqual, pubsub.FilterOpIn:
+		return c.Values, true
+	case pubsub.FilterOpNotEqual:
+		return map[string]interface{}{"anything-but": c.Values[0]}, true
+	case pubsub.FilterOpExists:
+		return map[string]interface{}{"exists": true}, true
+	case pubsub.FilterOpLess, pubsub.FilterOpLessOrEqual, pubsub.FilterOpGreater, pubsub.FilterOpGreaterOrEqual:
+		if _, ok := c.Values[0].(float64); !ok {
+			return nil, false
+		}
+		return map[string]interface{}{"numeric": []interface{}{string(c.Operator), c.Values[0]}}, true
+	default:
+		return nil, false
+	}
+}
+
+// addSnsFilterRule sets the rule of a field in the policy.
+// The values of a field are alternatives in SNS, so a field can only have a single rule, except numeric ranges which are merged.
+func addSnsFilterRule(policy map[string]interface{}, field pubsub.FilterField, rule interface{}) bool {
+	var path []string
+	switch field.Scope {
+	case pubsub.FilterScopeAttribute:
+		if field.Path[0] == pubsub.DataField || field.Path[0] == pubsub.DataBase64Field {
+			return false
+		}
+		path = field.Path
+	case pubsub.FilterScopeData:
+		path = append([]string{pubsub.DataField}, field.Path...)
+	default:
+		return false
+	}
+
+	node := policy
+	for _, key := range path[:len(path)-1] {
+		child, ok := node[key]
+		if !ok {
+			child = map[string]interface{}{}
+			node[key] = child
+		}
+		if node, ok = child.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+
+	key := path[len(path)-1]
+	existing, ok := node[key]
+	if !ok {
+		node[key] = []interface{}{rule}
+		if values, ok := rule.([]interface{}); ok {
+			node[key] = values
+		}
+		return true
+	}
+
+	// Merge numeric ranges, such as "numeric": [">", 0, "<=", 100]
+	rules, ok := existing.([]interface{})
+	if !ok || len(rules) != 1 {
+		return false
+	}
+	prev, ok := rules[0].(map[string]interface{})
+	if !ok || prev["numeric"] == nil {
+		return false
+	}
+	next, ok := rule.(map[string]interface{})
+	if !ok || next["numeric"] == nil {
+		return false
+	}
+	prev["numeric"] = append(prev["numeric"].([]interface{}), next["numeric"].([]interface{})...)
+
+	return true
+}
+
+// setSubscriptionFilterPolicy applies a filter policy on the message body to a subscription, or removes it when empty.
+func (s *snsSqs) setSubscriptionFilterPolicy(parentCtx context.Context, subscriptionArn string, policy string) error {
+	var attributes []struct{ name, value string }
+	if policy != snsEmptyFilterPolicy {
+		// The scope is set first, as it applies to the policy
+		attributes = append(attributes, struct{ name, value string }{"FilterPolicyScope", snsFilterPolicyScopeMessageBody})
+	}
+	attributes = append(attributes, struct{ name, value string }{"FilterPolicy", policy})
+	for _, attr := range attributes {
+		ctx, cancel := context.WithTimeout(parentCtx, s.opsTimeout)
+		_, err := s.snsClient.SetSubscriptionAttributesWithContext(ctx, &sns.SetSubscriptionAttributesInput{
+			SubscriptionArn: aws.String(subscriptionArn),
+			AttributeName:   aws.String(attr.name),
+			AttributeValue:  aws.String(attr.value),
+		})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("error setting the %s of subscription %s: %w", attr.name, subscriptionArn, err)
+		}
+	}
+
+	return nil
+}
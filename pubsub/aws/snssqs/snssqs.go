@@ -17,6 +17,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"sync"
@@ -33,6 +34,7 @@ import (
 	gonanoid "github.com/matoous/go-nanoid/v2"
 
 	awsAuth "github.com/JY29/components-contrib/internal/authentication/aws"
+	"github.com/JY29/components-contrib/metadata"
 	"github.com/JY29/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 )
@@ -75,8 +77,14 @@ type sqsQueueInfo struct {
 }
 
 type snsMessage struct {
-	Message  string
-	TopicArn string
+	Message           string
+	TopicArn          string
+	MessageAttributes map[string]snsMessageAttribute
+}
+
+type snsMessageAttribute struct {
+	Type  string
+	Value string
 }
 
 func (sn *snsMessage) parseTopicArn() string {
@@ -84,9 +92,26 @@ func (sn *snsMessage) parseTopicArn() string {
 	return arn[strings.LastIndex(arn, ":")+1:]
 }
 
+// parseScheduledTime returns the time at which the message should become visible, if it was published with a delay.
+func (sn *snsMessage) parseScheduledTime() (time.Time, bool) {
+	attr, ok := sn.MessageAttributes[awsSnsScheduledTimeKey]
+	if !ok {
+		return time.Time{}, false
+	}
+	ms, err := strconv.ParseInt(attr.Value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.UnixMilli(ms), true
+}
+
 const (
-	awsSqsQueueNameKey                    = "dapr-queue-name"
-	awsSnsTopicNameKey                    = "dapr-topic-name"
+	awsSqsQueueNameKey     = "dapr-queue-name"
+	awsSnsTopicNameKey     = "dapr-topic-name"
+	awsSnsScheduledTimeKey = "dapr-scheduled-time"
+	// SQS does not allow delaying a message for longer than 15 minutes.
+	maxSqsDelaySeconds                    = 900
 	awsSqsFifoSuffix                      = ".fifo"
 	maxAWSNameLength                      = 80
 	assetsManagementDefaultTimeoutSeconds = 5.0
@@ -485,6 +510,55 @@ func (s *snsSqs) resetMessageVisibilityTimeout(parentCtx context.Context, queueU
 	return nil
 }
 
+// sqsDelaySeconds returns the DelaySeconds of a message due after delay, rounded up and capped at the SQS maximum.
+func sqsDelaySeconds(delay time.Duration) int64 {
+	seconds := int64(math.Ceil(delay.Seconds()))
+	if seconds > maxSqsDelaySeconds {
+		seconds = maxSqsDelaySeconds
+	}
+
+	return seconds
+}
+
+// deferMessage sends again a message which is not due yet to the queue with the remaining delay as DelaySeconds,
+// and deletes the received one. As the message sent again is a new SQS message, the deferral doesn't count
+// against the receive count of the message, and so against the retry limit or the redrive policy of the queue.
+func (s *snsSqs) deferMessage(parentCtx context.Context, queueURL string, message *sqs.Message, delay time.Duration) error {
+	ctx, cancelFn := context.WithCancel(parentCtx)
+	_, err := s.sqsClient.SendMessageWithContext(ctx, &sqs.SendMessageInput{
+		QueueUrl:     aws.String(queueURL),
+		MessageBody:  message.Body,
+		DelaySeconds: aws.Int64(sqsDelaySeconds(delay)),
+	})
+	cancelFn()
+	if err != nil {
+		return fmt.Errorf("error deferring delayed message: %w", err)
+	}
+
+	return s.acknowledgeMessage(parentCtx, queueURL, message.ReceiptHandle)
+}
+
+// deferIfNotDue defers the message if it was published with a delay and isn't due yet, and returns whether it did.
+// The messages which can't be parsed are not deferred, so that the handler reports them.
+func (s *snsSqs) deferIfNotDue(ctx context.Context, message *sqs.Message, queueInfo *sqsQueueInfo) (bool, error) {
+	var snsMessagePayload snsMessage
+	if err := json.Unmarshal([]byte(*(message.Body)), &snsMessagePayload); err != nil {
+		return false, nil
+	}
+	scheduled, ok := snsMessagePayload.parseScheduledTime()
+	if !ok {
+		return false, nil
+	}
+	delay := time.Until(scheduled)
+	if delay <= 0 {
+		return false, nil
+	}
+
+	s.logger.Debugf("Deferring SNS message id: %s until %s", *message.MessageId, scheduled)
+
+	return true, s.deferMessage(ctx, queueInfo.url, message, delay)
+}
+
 func (s *snsSqs) parseReceiveCount(message *sqs.Message) (int64, error) {
 	// if this message has been received > x times, delete from queue, it's borked.
 	recvCount, ok := message.Attributes[sqs.MessageSystemAttributeNameApproximateReceiveCount]
@@ -619,6 +693,17 @@ func (s *snsSqs) consumeSubscription(ctx context.Context, queueInfo, deadLetters
 
 		var wg sync.WaitGroup
 		for _, message := range messageResponse.Messages {
+			// SNS has no per-message DelaySeconds, so the delayed messages are delayed on the SQS side until they are due.
+			// This is done before the validation, so that the deferrals are not counted as retries.
+			deferred, err := s.deferIfNotDue(ctx, message, queueInfo)
+			if err != nil {
+				s.logger.Errorf("error while deferring received message. error is: %v", err)
+				continue
+			}
+			if deferred {
+				continue
+			}
+
 			if err := s.validateMessage(ctx, message, queueInfo, deadLettersQueueInfo); err != nil {
 				s.logger.Errorf("message is not valid for further processing by the handler. error is: %v", err)
 				continue
@@ -748,6 +833,11 @@ func (s *snsSqs) restrictQueuePublishPolicyToOnlySNS(parentCtx context.Context,
 }
 
 func (s *snsSqs) Subscribe(subscribeCtx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	filter, err := pubsub.SubscriptionFilter(req)
+	if err != nil {
+		return err
+	}
+
 	// subscribers declare a topic ARN and declare a SQS queue to use
 	// these should be idempotent - queues should not be created if they exist.
 	topicArn, sanitizedName, err := s.getOrCreateTopic(subscribeCtx, req.Topic)
@@ -801,7 +891,7 @@ func (s *snsSqs) Subscribe(subscribeCtx context.Context, req pubsub.SubscribeReq
 	}
 
 	// subscription creation is idempotent. Subscriptions are unique by topic/queue.
-	_, err = s.getOrCreateSnsSqsSubscription(subscribeCtx, queueInfo.arn, topicArn)
+	subscriptionArn, err := s.getOrCreateSnsSqsSubscription(subscribeCtx, queueInfo.arn, topicArn)
 	if err != nil {
 		wrappedErr := fmt.Errorf("error subscribing topic: %s, to queue: %s, with error: %w", topicArn, queueInfo.arn, err)
 		s.logger.Error(wrappedErr)
@@ -809,6 +899,22 @@ func (s *snsSqs) Subscribe(subscribeCtx context.Context, req pubsub.SubscribeReq
 		return wrappedErr
 	}
 
+	// the filter is pushed down to SNS when it can be translated to a filter policy, and always evaluated on the received messages.
+	// the policy of a previous subscription of the queue is removed otherwise.
+	if !s.metadata.disableEntityManagement {
+		policy, ok := subscriptionFilterPolicy(filter)
+		if !ok {
+			s.logger.Debugf("filter of the subscription to topic %s can't be translated to a SNS filter policy: evaluating it on the received messages", req.Topic)
+		}
+		err = s.setSubscriptionFilterPolicy(subscribeCtx, subscriptionArn, policy)
+		if err != nil {
+			s.logger.Error(err)
+
+			return err
+		}
+	}
+	handler = pubsub.FilterHandler(filter, handler)
+
 	// Store the handler for this topic
 	s.topicsLock.Lock()
 	defer s.topicsLock.Unlock()
@@ -849,6 +955,20 @@ func (s *snsSqs) Subscribe(subscribeCtx context.Context, req pubsub.SubscribeReq
 }
 
 func (s *snsSqs) Publish(ctx context.Context, req *pubsub.PublishRequest) error {
+	scheduled, delayed, err := metadata.TryGetScheduledTime(req.Metadata)
+	if err != nil {
+		return fmt.Errorf("error publishing to topic: %s: %w", req.Topic, err)
+	}
+	if delayed {
+		// FIFO queues only support a delay per queue, and SQS doesn't delay the messages longer than 15 minutes
+		if s.metadata.fifo {
+			return fmt.Errorf("error publishing to topic: %s: delayed messages are not supported by FIFO topics", req.Topic)
+		}
+		if time.Until(scheduled) > maxSqsDelaySeconds*time.Second {
+			return fmt.Errorf("error publishing to topic: %s: messages can't be delayed longer than %d seconds", req.Topic, maxSqsDelaySeconds)
+		}
+	}
+
 	topicArn, _, err := s.getOrCreateTopic(ctx, req.Topic)
 	if err != nil {
 		s.logger.Errorf("error getting topic ARN for %s: %v", req.Topic, err)
@@ -863,6 +983,15 @@ func (s *snsSqs) Publish(ctx context.Context, req *pubsub.PublishRequest) error
 		snsPublishInput.MessageGroupId = s.getMessageGroupID(req)
 	}
 
+	if delayed {
+		snsPublishInput.MessageAttributes = map[string]*sns.MessageAttributeValue{
+			awsSnsScheduledTimeKey: {
+				DataType:    aws.String("Number"),
+				StringValue: aws.String(strconv.FormatInt(scheduled.UnixMilli(), 10)),
+			},
+		}
+	}
+
 	// sns client has internal exponential backoffs.
 	_, err = s.snsClient.PublishWithContext(ctx, snsPublishInput)
 	if err != nil {
@@ -882,5 +1011,10 @@ func (s *snsSqs) Close() error {
 }
 
 func (s *snsSqs) Features() []pubsub.Feature {
-	return nil
+	// FIFO queues don't support per-message delays
+	if s.metadata == nil || s.metadata.fifo {
+		return nil
+	}
+
+	return []pubsub.Feature{pubsub.FeatureDelayedPublish}
 }
@@ -14,8 +14,10 @@ limitations under the License.
 package snssqs
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -37,6 +39,65 @@ func Test_parseTopicArn(t *testing.T) {
 	r.Equal("qqnoob", tSnsMessage.parseTopicArn())
 }
 
+func Test_parseScheduledTime(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	var tSnsMessage snsMessage
+	err := json.Unmarshal([]byte(`{
+		"Message": "hello",
+		"TopicArn": "arn:aws:sns:us-east-1:000000000000:qqnoob",
+		"MessageAttributes": {
+			"dapr-scheduled-time": {"Type": "Number", "Value": "1893456000000"}
+		}
+	}`), &tSnsMessage)
+	r.NoError(err)
+
+	scheduled, ok := tSnsMessage.parseScheduledTime()
+	r.True(ok)
+	r.Equal(int64(1893456000000), scheduled.UnixMilli())
+
+	_, ok = (&snsMessage{Message: "hello"}).parseScheduledTime()
+	r.False(ok)
+}
+
+func Test_sqsDelaySeconds(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	r.Equal(int64(1), sqsDelaySeconds(10*time.Millisecond))
+	r.Equal(int64(60), sqsDelaySeconds(time.Minute))
+	r.Equal(int64(maxSqsDelaySeconds), sqsDelaySeconds(time.Hour))
+}
+
+func Test_delayedPublish(t *testing.T) {
+	t.Parallel()
+
+	t.Run("feature is advertised for standard topics only", func(t *testing.T) {
+		r := require.New(t)
+		r.True(pubsub.FeatureDelayedPublish.IsPresent((&snsSqs{metadata: &snsSqsMetadata{}}).Features()))
+		r.False(pubsub.FeatureDelayedPublish.IsPresent((&snsSqs{metadata: &snsSqsMetadata{fifo: true}}).Features()))
+	})
+
+	t.Run("delays longer than the SQS maximum are rejected", func(t *testing.T) {
+		s := &snsSqs{metadata: &snsSqsMetadata{}}
+		err := s.Publish(context.Background(), &pubsub.PublishRequest{
+			Topic:    "topic",
+			Metadata: map[string]string{metadata.DelayMetadataKey: "901"},
+		})
+		require.ErrorContains(t, err, "900 seconds")
+	})
+
+	t.Run("delays are rejected by FIFO topics", func(t *testing.T) {
+		s := &snsSqs{metadata: &snsSqsMetadata{fifo: true}}
+		err := s.Publish(context.Background(), &pubsub.PublishRequest{
+			Topic:    "topic",
+			Metadata: map[string]string{metadata.DelayMetadataKey: "10"},
+		})
+		require.ErrorContains(t, err, "FIFO")
+	})
+}
+
 // Verify that all metadata ends up in the correct spot.
 func Test_getSnsSqsMetatdata_AllConfiguration(t *testing.T) {
 	t.Parallel()
@@ -562,3 +623,43 @@ func Test_buildARN_NonStandardPartition(t *testing.T) {
 	arn := ps.buildARN("sns", "myTopic")
 	r.Equal("arn:aws-cn:sns:cn-northwest-1:123456789012:myTopic", arn)
 }
+
+func Test_snsFilterPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		`type == 'order.created' && data.customer.tier in ('gold', 'silver')`: `{"data":{"customer":{"tier":["gold","silver"]}},"type":["order.created"]}`,
+		`data.amount > 0 && data.amount <= 100`:                               `{"data":{"amount":[{"numeric":[">",0,"<=",100]}]}}`,
+		`source != 'test' && exists(subject) && !exists(data.replay)`:         `{"data":{"replay":[{"exists":false}]},"source":[{"anything-but":"test"}],"subject":[{"exists":true}]}`,
+	}
+	for expr, expected := range tests {
+		f, err := pubsub.ParseFilter(expr)
+		require.NoError(t, err)
+		policy, ok := snsFilterPolicy(f)
+		require.True(t, ok, expr)
+		require.JSONEq(t, expected, policy, expr)
+	}
+
+	for _, expr := range []string{
+		`type == 'a' || type == 'b'`,
+		`metadata.region == 'eu'`,
+		`data.express == true`,
+		`type > 'a'`,
+		`type == 'a' && type != 'b'`,
+		`!(type == 'a')`,
+	} {
+		f, err := pubsub.ParseFilter(expr)
+		require.NoError(t, err)
+		_, ok := snsFilterPolicy(f)
+		require.False(t, ok, expr)
+
+		// The policy of a previous subscription is removed
+		policy, ok := subscriptionFilterPolicy(f)
+		require.False(t, ok, expr)
+		require.Equal(t, snsEmptyFilterPolicy, policy, expr)
+	}
+
+	policy, ok := subscriptionFilterPolicy(nil)
+	require.True(t, ok)
+	require.Equal(t, snsEmptyFilterPolicy, policy)
+}
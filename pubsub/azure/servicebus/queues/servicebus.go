@@ -45,7 +45,7 @@ type azureServiceBus struct {
 func NewAzureServiceBusQueues(logger logger.Logger) pubsub.PubSub {
 	return &azureServiceBus{
 		logger:   logger,
-		features: []pubsub.Feature{pubsub.FeatureMessageTTL},
+		features: []pubsub.Feature{pubsub.FeatureMessageTTL, pubsub.FeatureDelayedPublish},
 	}
 }
 
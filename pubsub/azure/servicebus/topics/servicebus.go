@@ -45,7 +45,7 @@ type azureServiceBus struct {
 func NewAzureServiceBusTopics(logger logger.Logger) pubsub.PubSub {
 	return &azureServiceBus{
 		logger:   logger,
-		features: []pubsub.Feature{pubsub.FeatureMessageTTL},
+		features: []pubsub.Feature{pubsub.FeatureMessageTTL, pubsub.FeatureDelayedPublish},
 	}
 }
 
@@ -172,6 +172,12 @@ func (a *azureServiceBus) BulkPublish(ctx context.Context, req *pubsub.BulkPubli
 }
 
 func (a *azureServiceBus) Subscribe(subscribeCtx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	filter, err := pubsub.SubscriptionFilter(req)
+	if err != nil {
+		return err
+	}
+	handler = pubsub.FilterHandler(filter, handler)
+
 	sub := impl.NewSubscription(
 		subscribeCtx,
 		a.metadata.MaxActiveMessages,
@@ -192,10 +198,16 @@ func (a *azureServiceBus) Subscribe(subscribeCtx context.Context, req pubsub.Sub
 		)
 	}
 
-	return a.doSubscribe(subscribeCtx, req, sub, receiveAndBlockFn)
+	return a.doSubscribe(subscribeCtx, req, filter, sub, receiveAndBlockFn)
 }
 
 func (a *azureServiceBus) BulkSubscribe(subscribeCtx context.Context, req pubsub.SubscribeRequest, handler pubsub.BulkHandler) error {
+	filter, err := pubsub.SubscriptionFilter(req)
+	if err != nil {
+		return err
+	}
+	handler = pubsub.FilterBulkHandler(filter, handler)
+
 	maxBulkSubCount := utils.GetElemOrDefaultFromMap(req.Metadata, contribMetadata.MaxBulkSubCountKey, defaultMaxBulkSubCount)
 	sub := impl.NewSubscription(
 		subscribeCtx,
@@ -217,13 +229,13 @@ func (a *azureServiceBus) BulkSubscribe(subscribeCtx context.Context, req pubsub
 		)
 	}
 
-	return a.doSubscribe(subscribeCtx, req, sub, receiveAndBlockFn)
+	return a.doSubscribe(subscribeCtx, req, filter, sub, receiveAndBlockFn)
 }
 
 // doSubscribe is a helper function that handles the common logic for both Subscribe and BulkSubscribe.
 // The receiveAndBlockFn is a function should invoke a blocking call to receive messages from the topic.
 func (a *azureServiceBus) doSubscribe(subscribeCtx context.Context,
-	req pubsub.SubscribeRequest, sub *impl.Subscription, receiveAndBlockFn func(func()) error,
+	req pubsub.SubscribeRequest, filter *pubsub.Filter, sub *impl.Subscription, receiveAndBlockFn func(func()) error,
 ) error {
 	// Does nothing if DisableEntityManagement is true
 	err := a.client.EnsureSubscription(subscribeCtx, a.metadata.ConsumerID, req.Topic)
@@ -231,6 +243,20 @@ func (a *azureServiceBus) doSubscribe(subscribeCtx context.Context,
 		return err
 	}
 
+	// The filter is pushed down as a SQL rule when it can be translated, and always evaluated on the received messages
+	var expression string
+	if filter != nil {
+		var ok bool
+		expression, ok = impl.SQLFilterExpression(filter)
+		if !ok {
+			a.logger.Debugf("Filter of the subscription to topic %s can't be translated to a SQL filter: evaluating it on the received messages", req.Topic)
+		}
+	}
+	err = a.client.EnsureSubscriptionFilter(subscribeCtx, a.metadata.ConsumerID, req.Topic, expression)
+	if err != nil {
+		return err
+	}
+
 	// Reconnection backoff policy
 	bo := backoff.NewExponentialBackOff()
 	bo.MaxElapsedTime = 0
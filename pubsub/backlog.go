@@ -0,0 +1,50 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// BacklogInspector is implemented by the PubSub components that can report how far behind a subscription is.
+type BacklogInspector interface {
+	// Backlog returns the backlog of a subscription to a topic.
+	// When subscription is empty, the consumer group, or consumer ID, of the component is used.
+	Backlog(ctx context.Context, topic string, subscription string) (*Backlog, error)
+}
+
+// Backlog is the backlog of a subscription to a topic.
+type Backlog struct {
+	Topic        string `json:"topic"`
+	Subscription string `json:"subscription"`
+	// Pending is the number of messages not delivered to the subscription yet, or -1 when it is unknown.
+	Pending int64 `json:"pending"`
+	// Unacknowledged is the number of messages delivered to the subscription but not acknowledged yet.
+	// It is zero for the brokers which do not track deliveries, where these messages are counted in Pending.
+	Unacknowledged int64 `json:"unacknowledged"`
+	// OldestMessageAge is the age of the oldest message pending or unacknowledged.
+	// It is zero when there are no such messages, or when the broker does not report it.
+	OldestMessageAge time.Duration `json:"oldestMessageAge"`
+}
+
+// InspectBacklog returns the backlog of a subscription to a topic, if the pubsub implements BacklogInspector.
+func InspectBacklog(ctx context.Context, pubsub PubSub, topic string, subscription string) (*Backlog, error) {
+	if inspector, ok := pubsub.(BacklogInspector); ok {
+		return inspector.Backlog(ctx, topic, subscription)
+	}
+
+	return nil, fmt.Errorf("backlog inspection is not implemented by this pubsub")
+}
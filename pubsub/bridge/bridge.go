@@ -0,0 +1,269 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/JY29/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+)
+
+const defaultBatchTimeout = 100 * time.Millisecond
+
+// Route forwards the messages of a topic of the source to a topic of the target.
+type Route struct {
+	SourceTopic string
+	// TargetTopic defaults to SourceTopic.
+	TargetTopic string
+}
+
+// Options configures a Bridge.
+type Options struct {
+	Routes []Route
+	// SubscribeMetadata is the metadata of the subscriptions to the source, for example the consumer group.
+	SubscribeMetadata map[string]string
+	// TargetPubsubName is the name of the target set in the publish requests.
+	TargetPubsubName string
+	// BatchSize is the maximum number of messages published together with BulkPublish, which the target must implement.
+	// Batching is disabled when it is 0 or 1.
+	BatchSize int
+	// BatchTimeout is how long a batch waits for more messages before being published.
+	BatchTimeout time.Duration
+}
+
+// Bridge subscribes to topics of a source PubSub and republishes their messages to a target PubSub.
+// The payloads, which carry the CloudEvent attributes, the content type and the metadata of the messages are forwarded as they are.
+// Messages are acknowledged to the source only once the target has accepted them, so delivery is at-least-once.
+type Bridge struct {
+	source pubsub.PubSub
+	target pubsub.PubSub
+	bulk   pubsub.BulkPublisher
+	opts   Options
+	logger logger.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New returns a Bridge from source to target.
+func New(source pubsub.PubSub, target pubsub.PubSub, opts Options, logger logger.Logger) (*Bridge, error) {
+	if len(opts.Routes) == 0 {
+		return nil, errors.New("bridge: at least one route is required")
+	}
+	for i, route := range opts.Routes {
+		if route.SourceTopic == "" {
+			return nil, fmt.Errorf("bridge: the source topic of route %d is empty", i)
+		}
+		if route.TargetTopic == "" {
+			opts.Routes[i].TargetTopic = route.SourceTopic
+		}
+	}
+
+	b := &Bridge{
+		source: source,
+		target: target,
+		opts:   opts,
+		logger: logger,
+	}
+	if opts.BatchSize > 1 {
+		bulk, ok := target.(pubsub.BulkPublisher)
+		if !ok {
+			return nil, errors.New("bridge: batching requires a target implementing BulkPublish")
+		}
+		b.bulk = bulk
+		if b.opts.BatchTimeout <= 0 {
+			b.opts.BatchTimeout = defaultBatchTimeout
+		}
+	}
+
+	return b, nil
+}
+
+// Start subscribes to the source topics.
+// The bridge runs until ctx is canceled or Close is called.
+func (b *Bridge) Start(ctx context.Context) error {
+	ctx, b.cancel = context.WithCancel(ctx)
+
+	for _, route := range b.opts.Routes {
+		handler := b.forwardHandler(route)
+		if b.bulk != nil {
+			handler = b.startBatcher(ctx, route)
+		}
+
+		err := b.source.Subscribe(ctx, pubsub.SubscribeRequest{
+			Topic:    route.SourceTopic,
+			Metadata: b.opts.SubscribeMetadata,
+		}, handler)
+		if err != nil {
+			b.Close()
+			return fmt.Errorf("bridge: error subscribing to topic %s: %w", route.SourceTopic, err)
+		}
+		b.logger.Infof("bridge: forwarding topic %s to topic %s", route.SourceTopic, route.TargetTopic)
+	}
+
+	return nil
+}
+
+// Close stops forwarding messages.
+// The messages waiting in a batch are not acknowledged, so the source redelivers them.
+func (b *Bridge) Close() error {
+	if b.cancel != nil {
+		b.cancel()
+		b.wg.Wait()
+	}
+
+	return nil
+}
+
+// forwardHandler publishes each message to the target.
+func (b *Bridge) forwardHandler(route Route) pubsub.Handler {
+	return func(ctx context.Context, msg *pubsub.NewMessage) error {
+		err := b.target.Publish(ctx, &pubsub.PublishRequest{
+			Data:        msg.Data,
+			PubsubName:  b.opts.TargetPubsubName,
+			Topic:       route.TargetTopic,
+			Metadata:    copyMetadata(msg.Metadata),
+			ContentType: msg.ContentType,
+		})
+		if err != nil {
+			return fmt.Errorf("bridge: error publishing to topic %s: %w", route.TargetTopic, err)
+		}
+
+		return nil
+	}
+}
+
+// batchItem is a message waiting in a batch, with the channel receiving the result of its publication.
+type batchItem struct {
+	entry pubsub.BulkMessageEntry
+	done  chan error
+}
+
+// startBatcher starts publishing the messages of a route in batches, and returns the handler adding messages to them.
+// The handler returns once the batch of the message is published, so the source must deliver messages concurrently to fill the batches.
+func (b *Bridge) startBatcher(ctx context.Context, route Route) pubsub.Handler {
+	items := make(chan *batchItem)
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+
+		var batch []*batchItem
+		var timeout <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				for _, item := range batch {
+					item.done <- ctx.Err()
+				}
+				return
+			case item := <-items:
+				batch = append(batch, item)
+				if len(batch) == 1 {
+					timeout = time.After(b.opts.BatchTimeout)
+				}
+				if len(batch) < b.opts.BatchSize {
+					continue
+				}
+			case <-timeout:
+			}
+
+			b.publishBatch(ctx, route, batch)
+			batch, timeout = nil, nil
+		}
+	}()
+
+	return func(ctx context.Context, msg *pubsub.NewMessage) error {
+		item := &batchItem{
+			entry: pubsub.BulkMessageEntry{
+				EntryId:  uuid.New().String(),
+				Event:    msg.Data,
+				Metadata: copyMetadata(msg.Metadata),
+			},
+			// Buffered, as the batcher doesn't wait for the handlers which returned
+			done: make(chan error, 1),
+		}
+		if msg.ContentType != nil {
+			item.entry.ContentType = *msg.ContentType
+		}
+
+		select {
+		case items <- item:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		select {
+		case err := <-item.done:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// publishBatch publishes a batch, and reports the result of each message to its handler.
+func (b *Bridge) publishBatch(ctx context.Context, route Route, batch []*batchItem) {
+	req := &pubsub.BulkPublishRequest{
+		Entries:    make([]pubsub.BulkMessageEntry, len(batch)),
+		PubsubName: b.opts.TargetPubsubName,
+		Topic:      route.TargetTopic,
+	}
+	for i, item := range batch {
+		req.Entries[i] = item.entry
+	}
+
+	res, err := b.bulk.BulkPublish(ctx, req)
+	failed := make(map[string]error, len(res.FailedEntries))
+	for _, entry := range res.FailedEntries {
+		failed[entry.EntryId] = entry.Error
+		if entry.Error == nil {
+			failed[entry.EntryId] = err
+		}
+	}
+	if err != nil {
+		b.logger.Warnf("bridge: error publishing %d messages to topic %s: %v", len(batch), route.TargetTopic, err)
+	}
+
+	for _, item := range batch {
+		itemErr, ok := failed[item.entry.EntryId]
+		if !ok && len(res.FailedEntries) == 0 {
+			// The error applies to all the entries when none is reported as failed
+			itemErr = err
+		}
+		if itemErr != nil {
+			itemErr = fmt.Errorf("bridge: error publishing to topic %s: %w", route.TargetTopic, itemErr)
+		}
+		item.done <- itemErr
+	}
+}
+
+func copyMetadata(md map[string]string) map[string]string {
+	if md == nil {
+		return nil
+	}
+
+	res := make(map[string]string, len(md))
+	for k, v := range md {
+		res[k] = v
+	}
+
+	return res
+}
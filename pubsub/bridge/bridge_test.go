@@ -0,0 +1,199 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JY29/components-contrib/pubsub"
+	inmemory "github.com/JY29/components-contrib/pubsub/in-memory"
+	"github.com/dapr/kit/logger"
+)
+
+func newInMemory(t *testing.T) pubsub.PubSub {
+	ps := inmemory.New(logger.NewLogger("test"))
+	require.NoError(t, ps.Init(pubsub.Metadata{}))
+	return ps
+}
+
+// recordingTarget records the requests published to the wrapped PubSub, and fails the first ones.
+type recordingTarget struct {
+	pubsub.PubSub
+
+	lock      sync.Mutex
+	failures  int
+	published []*pubsub.PublishRequest
+	batches   []*pubsub.BulkPublishRequest
+}
+
+func (r *recordingTarget) Publish(ctx context.Context, req *pubsub.PublishRequest) error {
+	r.lock.Lock()
+	if r.failures > 0 {
+		r.failures--
+		r.lock.Unlock()
+		return errors.New("target unavailable")
+	}
+	r.published = append(r.published, req)
+	r.lock.Unlock()
+
+	return r.PubSub.Publish(ctx, req)
+}
+
+func (r *recordingTarget) BulkPublish(ctx context.Context, req *pubsub.BulkPublishRequest) (pubsub.BulkPublishResponse, error) {
+	r.lock.Lock()
+	r.batches = append(r.batches, req)
+	r.lock.Unlock()
+
+	res := pubsub.BulkPublishResponse{}
+	for _, entry := range req.Entries {
+		if string(entry.Event) == "fail" {
+			res.FailedEntries = append(res.FailedEntries, pubsub.BulkPublishResponseFailedEntry{EntryId: entry.EntryId, Error: errors.New("rejected")})
+			continue
+		}
+		if err := r.PubSub.Publish(ctx, &pubsub.PublishRequest{Topic: req.Topic, Data: entry.Event, Metadata: entry.Metadata}); err != nil {
+			return res, err
+		}
+	}
+	if len(res.FailedEntries) > 0 {
+		return res, errors.New("some entries failed")
+	}
+
+	return res, nil
+}
+
+func receive(t *testing.T, ps pubsub.PubSub, topic string) <-chan []byte {
+	ch := make(chan []byte, 10)
+	require.NoError(t, ps.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: topic}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		ch <- msg.Data
+		return nil
+	}))
+	return ch
+}
+
+func TestBridge(t *testing.T) {
+	log := logger.NewLogger("test")
+	ctx := context.Background()
+
+	t.Run("messages are forwarded to the renamed topic", func(t *testing.T) {
+		source, target := newInMemory(t), newInMemory(t)
+		received := receive(t, target, "orders-v2")
+
+		b, err := New(source, target, Options{Routes: []Route{{SourceTopic: "orders", TargetTopic: "orders-v2"}, {SourceTopic: "payments"}}}, log)
+		require.NoError(t, err)
+		require.NoError(t, b.Start(ctx))
+		defer b.Close()
+		payments := receive(t, target, "payments")
+
+		ce, err := json.Marshal(pubsub.NewCloudEventsEnvelope("1", "shop", "order.created", "", "orders", "rabbitmq", "application/json", []byte(`{"id":1}`), "00-trace-01", ""))
+		require.NoError(t, err)
+		require.NoError(t, source.Publish(ctx, &pubsub.PublishRequest{Topic: "orders", Data: ce}))
+		require.NoError(t, source.Publish(ctx, &pubsub.PublishRequest{Topic: "payments", Data: []byte("paid")}))
+
+		assert.Equal(t, ce, <-received)
+		assert.Equal(t, []byte("paid"), <-payments)
+	})
+
+	t.Run("the source is acknowledged once the target accepted the message", func(t *testing.T) {
+		source, inner := newInMemory(t), newInMemory(t)
+		target := &recordingTarget{PubSub: inner, failures: 2}
+		received := receive(t, inner, "orders")
+
+		b, err := New(source, target, Options{Routes: []Route{{SourceTopic: "orders"}}, TargetPubsubName: "kafka"}, log)
+		require.NoError(t, err)
+		require.NoError(t, b.Start(ctx))
+		defer b.Close()
+
+		// The in-memory pubsub redelivers the messages whose handler failed
+		require.NoError(t, source.Publish(ctx, &pubsub.PublishRequest{Topic: "orders", Data: []byte("order")}))
+		assert.Equal(t, []byte("order"), <-received)
+
+		target.lock.Lock()
+		defer target.lock.Unlock()
+		assert.Equal(t, 0, target.failures)
+		require.Len(t, target.published, 1)
+		assert.Equal(t, "kafka", target.published[0].PubsubName)
+	})
+
+	t.Run("content type and metadata are preserved", func(t *testing.T) {
+		target := &recordingTarget{PubSub: newInMemory(t)}
+		b, err := New(newInMemory(t), target, Options{Routes: []Route{{SourceTopic: "orders"}}}, log)
+		require.NoError(t, err)
+
+		contentType := "application/cloudevents+json"
+		handler := b.forwardHandler(b.opts.Routes[0])
+		require.NoError(t, handler(ctx, &pubsub.NewMessage{Topic: "orders", Data: []byte("{}"), ContentType: &contentType, Metadata: map[string]string{"region": "eu"}}))
+
+		require.Len(t, target.published, 1)
+		assert.Equal(t, &contentType, target.published[0].ContentType)
+		assert.Equal(t, map[string]string{"region": "eu"}, target.published[0].Metadata)
+	})
+
+	t.Run("batches", func(t *testing.T) {
+		inner := newInMemory(t)
+		target := &recordingTarget{PubSub: inner}
+		received := receive(t, inner, "orders")
+
+		b, err := New(newInMemory(t), target, Options{Routes: []Route{{SourceTopic: "orders"}}, BatchSize: 3, BatchTimeout: time.Minute}, log)
+		require.NoError(t, err)
+		bctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		handler := b.startBatcher(bctx, b.opts.Routes[0])
+
+		// Deliver the messages concurrently, like a source with parallel handlers
+		errs := make([]error, 3)
+		var wg sync.WaitGroup
+		for i, data := range []string{"a", "fail", "c"} {
+			wg.Add(1)
+			go func(i int, data string) {
+				defer wg.Done()
+				errs[i] = handler(ctx, &pubsub.NewMessage{Topic: "orders", Data: []byte(data)})
+			}(i, data)
+		}
+		wg.Wait()
+
+		require.Len(t, target.batches, 1)
+		assert.Len(t, target.batches[0].Entries, 3)
+		assert.NoError(t, errs[0])
+		assert.ErrorContains(t, errs[1], "rejected")
+		assert.NoError(t, errs[2])
+		assert.ElementsMatch(t, []string{"a", "c"}, []string{string(<-received), string(<-received)})
+	})
+
+	t.Run("batches are published after the timeout", func(t *testing.T) {
+		target := &recordingTarget{PubSub: newInMemory(t)}
+		b, err := New(newInMemory(t), target, Options{Routes: []Route{{SourceTopic: "orders"}}, BatchSize: 10, BatchTimeout: 10 * time.Millisecond}, log)
+		require.NoError(t, err)
+		bctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		require.NoError(t, b.startBatcher(bctx, b.opts.Routes[0])(ctx, &pubsub.NewMessage{Topic: "orders", Data: []byte("a")}))
+		require.Len(t, target.batches, 1)
+		assert.Len(t, target.batches[0].Entries, 1)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, err := New(newInMemory(t), newInMemory(t), Options{}, log)
+		assert.Error(t, err)
+		_, err = New(newInMemory(t), newInMemory(t), Options{Routes: []Route{{SourceTopic: "orders"}}, BatchSize: 10}, log)
+		assert.ErrorContains(t, err, "BulkPublish")
+	})
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/JY29/components-contrib/bindings"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	defaultClaimCheckThreshold        = 256 * 1024
+	defaultClaimCheckKeyMetadataField = "key"
+	defaultClaimCheckKeyPrefix        = "dapr-claimcheck/"
+
+	// claimCheckDecodeBase64Key asks the bindings which support it to decode the uploaded payloads.
+	claimCheckDecodeBase64Key = "decodeBase64"
+)
+
+// ClaimCheckOptions configures how oversized payloads are offloaded to an output binding.
+type ClaimCheckOptions struct {
+	// Threshold is the size in bytes above which the payloads are offloaded.
+	Threshold int
+	// KeyMetadataField is the request metadata field the binding reads the name of the object from,
+	// for example "key" for AWS S3, "blobName" for Azure Blob Storage and "fileName" for local storage.
+	KeyMetadataField string
+	// KeyPrefix is prepended to the generated names of the objects.
+	KeyPrefix string
+	// DeleteAfterProcessing deletes the payloads once the handler processed them successfully.
+	// It must only be enabled when a single subscription consumes the topic.
+	DeleteAfterProcessing bool
+}
+
+// claimCheckMessage is published in place of an offloaded payload.
+type claimCheckMessage struct {
+	ClaimCheck *claimCheckReference `json:"claimCheck"`
+}
+
+type claimCheckReference struct {
+	Key         string  `json:"key"`
+	Size        int     `json:"size"`
+	ContentType *string `json:"contentType,omitempty"`
+}
+
+// claimCheck wraps a PubSub to publish a reference to the payloads above the threshold,
+// which are uploaded with an output binding, and to fetch them back on the subscriber side.
+type claimCheck struct {
+	PubSub
+
+	binding bindings.OutputBinding
+	opts    ClaimCheckOptions
+	logger  logger.Logger
+}
+
+// NewClaimCheck returns a PubSub which uploads the payloads larger than the threshold to an output binding
+// supporting the create and get operations, and publishes a small reference message instead.
+// Subscribers fetch the payload before invoking the handler.
+func NewClaimCheck(pubsub PubSub, binding bindings.OutputBinding, opts ClaimCheckOptions, logger logger.Logger) (PubSub, error) {
+	if opts.Threshold <= 0 {
+		opts.Threshold = defaultClaimCheckThreshold
+	}
+	if opts.KeyMetadataField == "" {
+		opts.KeyMetadataField = defaultClaimCheckKeyMetadataField
+	}
+	if opts.KeyPrefix == "" {
+		opts.KeyPrefix = defaultClaimCheckKeyPrefix
+	}
+
+	required := []bindings.OperationKind{bindings.CreateOperation, bindings.GetOperation}
+	if opts.DeleteAfterProcessing {
+		required = append(required, bindings.DeleteOperation)
+	}
+	for _, op := range required {
+		if !hasOperation(binding, op) {
+			return nil, fmt.Errorf("the output binding does not support the %s operation", op)
+		}
+	}
+
+	return &claimCheck{
+		PubSub:  pubsub,
+		binding: binding,
+		opts:    opts,
+		logger:  logger,
+	}, nil
+}
+
+func (c *claimCheck) Publish(ctx context.Context, req *PublishRequest) error {
+	if len(req.Data) <= c.opts.Threshold {
+		return c.PubSub.Publish(ctx, req)
+	}
+
+	key := c.keyPrefix(req.Topic) + uuid.New().String()
+	// The payload is base64-encoded, as some bindings always try to decode the data they store
+	_, err := c.binding.Invoke(ctx, &bindings.InvokeRequest{
+		Operation: bindings.CreateOperation,
+		Data:      []byte(base64.StdEncoding.EncodeToString(req.Data)),
+		Metadata: map[string]string{
+			c.opts.KeyMetadataField:   key,
+			claimCheckDecodeBase64Key: "true",
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error uploading the payload to %s: %w", key, err)
+	}
+
+	data, err := json.Marshal(claimCheckMessage{
+		ClaimCheck: &claimCheckReference{
+			Key:         key,
+			Size:        len(req.Data),
+			ContentType: req.ContentType,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	c.logger.Debugf("payload of %d bytes published to topic %s was offloaded to %s", len(req.Data), req.Topic, key)
+
+	reference := *req
+	reference.Data = data
+
+	return c.PubSub.Publish(ctx, &reference)
+}
+
+func (c *claimCheck) Subscribe(ctx context.Context, req SubscribeRequest, handler Handler) error {
+	return c.PubSub.Subscribe(ctx, req, func(ctx context.Context, msg *NewMessage) error {
+		ref, ok := parseClaimCheckReference(msg.Data)
+		if !ok {
+			return handler(ctx, msg)
+		}
+		// Any producer can publish a reference: only the objects uploaded by Publish for the topic are fetched
+		if !c.isTopicKey(req.Topic, ref.Key) {
+			return fmt.Errorf("claim check reference %s is not a payload of topic %s", ref.Key, req.Topic)
+		}
+
+		data, err := c.fetch(ctx, ref)
+		if err != nil {
+			return err
+		}
+		msg.Data = data
+		if ref.ContentType != nil {
+			msg.ContentType = ref.ContentType
+		}
+
+		if err = handler(ctx, msg); err != nil {
+			return err
+		}
+
+		if c.opts.DeleteAfterProcessing {
+			_, err = c.binding.Invoke(ctx, &bindings.InvokeRequest{
+				Operation: bindings.DeleteOperation,
+				Metadata:  map[string]string{c.opts.KeyMetadataField: ref.Key},
+			})
+			if err != nil {
+				// The message was processed, so it must not be redelivered
+				c.logger.Warnf("error deleting the payload %s: %v", ref.Key, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// keyPrefix returns the prefix of the names of the objects of a topic.
+func (c *claimCheck) keyPrefix(topic string) string {
+	return c.opts.KeyPrefix + topic + "/"
+}
+
+// isTopicKey checks that a key was generated by Publish for the topic.
+func (c *claimCheck) isTopicKey(topic string, key string) bool {
+	prefix := c.keyPrefix(topic)
+	if !strings.HasPrefix(key, prefix) {
+		return false
+	}
+	_, err := uuid.Parse(strings.TrimPrefix(key, prefix))
+
+	return err == nil
+}
+
+// fetch downloads an offloaded payload.
+func (c *claimCheck) fetch(ctx context.Context, ref *claimCheckReference) ([]byte, error) {
+	resp, err := c.binding.Invoke(ctx, &bindings.InvokeRequest{
+		Operation: bindings.GetOperation,
+		Metadata:  map[string]string{c.opts.KeyMetadataField: ref.Key},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the payload %s: %w", ref.Key, err)
+	}
+	if resp == nil {
+		return nil, fmt.Errorf("error fetching the payload %s: empty response", ref.Key)
+	}
+
+	// Bindings which did not decode the payload return it base64-encoded, which never has the original size
+	if len(resp.Data) == ref.Size {
+		return resp.Data, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(string(resp.Data))
+	if err != nil || len(data) != ref.Size {
+		return nil, fmt.Errorf("error fetching the payload %s: expected %d bytes, got %d", ref.Key, ref.Size, len(resp.Data))
+	}
+
+	return data, nil
+}
+
+// parseClaimCheckReference returns the reference carried by a message, if it is a claim check.
+func parseClaimCheckReference(data []byte) (*claimCheckReference, bool) {
+	// References are small JSON objects: skip parsing the other payloads
+	if len(data) == 0 || len(data) > 4096 || data[0] != '{' {
+		return nil, false
+	}
+
+	var msg claimCheckMessage
+	if err := json.Unmarshal(data, &msg); err != nil || msg.ClaimCheck == nil || msg.ClaimCheck.Key == "" {
+		return nil, false
+	}
+
+	return msg.ClaimCheck, true
+}
+
+func hasOperation(binding bindings.OutputBinding, op bindings.OperationKind) bool {
+	for _, o := range binding.Operations() {
+		if o == op {
+			return true
+		}
+	}
+
+	return false
+}
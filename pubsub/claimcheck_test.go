@@ -0,0 +1,185 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JY29/components-contrib/bindings"
+	"github.com/JY29/components-contrib/bindings/localstorage"
+	"github.com/JY29/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// loopbackPubSub delivers the published messages to the handlers subscribed to the topic.
+type loopbackPubSub struct {
+	recordingPubSub
+
+	handlers map[string]Handler
+	errs     []error
+}
+
+func (l *loopbackPubSub) Subscribe(_ context.Context, req SubscribeRequest, handler Handler) error {
+	if l.handlers == nil {
+		l.handlers = map[string]Handler{}
+	}
+	l.handlers[req.Topic] = handler
+	return nil
+}
+
+func (l *loopbackPubSub) Publish(ctx context.Context, req *PublishRequest) error {
+	if err := l.recordingPubSub.Publish(ctx, req); err != nil {
+		return err
+	}
+	if handler, ok := l.handlers[req.Topic]; ok {
+		l.errs = append(l.errs, handler(ctx, &NewMessage{Topic: req.Topic, Data: req.Data, ContentType: req.ContentType}))
+	}
+	return nil
+}
+
+func newTestLocalStorage(t *testing.T) (bindings.OutputBinding, string) {
+	root := t.TempDir()
+	binding := localstorage.NewLocalStorage(logger.NewLogger("test"))
+	require.NoError(t, binding.Init(bindings.Metadata{Base: metadata.Base{
+		Properties: map[string]string{"rootPath": root},
+	}}))
+	return binding, root
+}
+
+func TestClaimCheck(t *testing.T) {
+	log := logger.NewLogger("test")
+	ctx := context.Background()
+	large := bytes.Repeat([]byte("0123456789"), 20)
+
+	t.Run("large payloads are offloaded and fetched back", func(t *testing.T) {
+		binding, root := newTestLocalStorage(t)
+		inner := &loopbackPubSub{}
+		ps, err := NewClaimCheck(inner, binding, ClaimCheckOptions{Threshold: 100, KeyMetadataField: "fileName"}, log)
+		require.NoError(t, err)
+
+		var received [][]byte
+		require.NoError(t, ps.Subscribe(ctx, SubscribeRequest{Topic: "docs"}, func(ctx context.Context, msg *NewMessage) error {
+			received = append(received, msg.Data)
+			return nil
+		}))
+
+		contentType := "application/octet-stream"
+		require.NoError(t, ps.Publish(ctx, &PublishRequest{Topic: "docs", Data: []byte("small")}))
+		require.NoError(t, ps.Publish(ctx, &PublishRequest{Topic: "docs", Data: large, ContentType: &contentType}))
+
+		require.Equal(t, 2, inner.count())
+		assert.Equal(t, []byte("small"), inner.published[0].Data)
+		assert.Contains(t, string(inner.published[1].Data), `"claimCheck"`)
+		assert.Less(t, len(inner.published[1].Data), 200)
+		assert.Equal(t, [][]byte{[]byte("small"), large}, received)
+		assert.Equal(t, []error{nil, nil}, inner.errs)
+
+		// The payload is kept
+		files, err := filepath.Glob(filepath.Join(root, defaultClaimCheckKeyPrefix, "docs", "*"))
+		require.NoError(t, err)
+		assert.Len(t, files, 1)
+	})
+
+	t.Run("payloads are deleted after processing", func(t *testing.T) {
+		binding, root := newTestLocalStorage(t)
+		inner := &loopbackPubSub{}
+		ps, err := NewClaimCheck(inner, binding, ClaimCheckOptions{Threshold: 100, KeyMetadataField: "fileName", DeleteAfterProcessing: true}, log)
+		require.NoError(t, err)
+
+		fail := true
+		require.NoError(t, ps.Subscribe(ctx, SubscribeRequest{Topic: "docs"}, func(ctx context.Context, msg *NewMessage) error {
+			if fail {
+				return errors.New("handler failed")
+			}
+			return nil
+		}))
+
+		require.NoError(t, ps.Publish(ctx, &PublishRequest{Topic: "docs", Data: large}))
+		assert.Error(t, inner.errs[0])
+		files, err := filepath.Glob(filepath.Join(root, defaultClaimCheckKeyPrefix, "docs", "*"))
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+
+		// Redeliver the reference
+		fail = false
+		require.NoError(t, inner.handlers["docs"](ctx, &NewMessage{Topic: "docs", Data: inner.published[0].Data}))
+		_, err = os.Stat(files[0])
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("references outside of the topic are rejected", func(t *testing.T) {
+		binding, root := newTestLocalStorage(t)
+		require.NoError(t, os.WriteFile(filepath.Join(root, "secret"), []byte("0123456789"), 0o600))
+		inner := &loopbackPubSub{}
+		ps, err := NewClaimCheck(inner, binding, ClaimCheckOptions{Threshold: 100, KeyMetadataField: "fileName", DeleteAfterProcessing: true}, log)
+		require.NoError(t, err)
+
+		called := false
+		require.NoError(t, ps.Subscribe(ctx, SubscribeRequest{Topic: "docs"}, func(ctx context.Context, msg *NewMessage) error {
+			called = true
+			return nil
+		}))
+
+		for _, key := range []string{
+			"secret",
+			defaultClaimCheckKeyPrefix + "other/" + "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+			defaultClaimCheckKeyPrefix + "docs/../../secret",
+		} {
+			data := []byte(`{"claimCheck":{"key":"` + key + `","size":10}}`)
+			assert.Error(t, inner.handlers["docs"](ctx, &NewMessage{Topic: "docs", Data: data}), key)
+		}
+		assert.False(t, called)
+		_, err = os.Stat(filepath.Join(root, "secret"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("payloads stored base64-encoded are decoded", func(t *testing.T) {
+		ref := &claimCheckReference{Key: "k", Size: 10}
+		c := &claimCheck{binding: &fixedBinding{data: []byte("MDEyMzQ1Njc4OQ==")}, opts: ClaimCheckOptions{KeyMetadataField: "key"}}
+		data, err := c.fetch(ctx, ref)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("0123456789"), data)
+
+		ref.Size = 11
+		_, err = c.fetch(ctx, ref)
+		assert.Error(t, err)
+	})
+
+	t.Run("bindings without the required operations are rejected", func(t *testing.T) {
+		_, err := NewClaimCheck(&loopbackPubSub{}, &fixedBinding{}, ClaimCheckOptions{}, log)
+		assert.Error(t, err)
+	})
+}
+
+// fixedBinding is an output binding which returns the same data for all the requests.
+type fixedBinding struct {
+	data []byte
+}
+
+func (f *fixedBinding) Init(bindings.Metadata) error { return nil }
+func (f *fixedBinding) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{bindings.GetOperation}
+}
+
+func (f *fixedBinding) Invoke(context.Context, *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	return &bindings.InvokeResponse{Data: f.data}, nil
+}
@@ -0,0 +1,296 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/JY29/components-contrib/metadata"
+	"github.com/JY29/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	defaultDelayBufferKey          = "dapr-delay-buffer"
+	defaultDelayBufferPollInterval = time.Second
+	defaultDelayBufferLease        = 30 * time.Second
+	delayBufferMaxWriteAttempts    = 5
+	// delayBufferReleaseTimeout bounds the update releasing the messages once flushed, which runs after Close too.
+	delayBufferReleaseTimeout = 5 * time.Second
+)
+
+// DelayBufferOptions configures the persistent buffer used to emulate delayed publishing.
+type DelayBufferOptions struct {
+	// Key under which the buffer is persisted in the state store.
+	// Components sharing a state store must use different keys.
+	Key string
+	// PollInterval is how often the buffer is checked for messages that are due.
+	PollInterval time.Duration
+	// Lease is how long the messages being published are reserved by a process. The messages of a process
+	// which stopped before removing them are published again once their lease expired.
+	Lease time.Duration
+}
+
+// delayBuffer wraps a PubSub that does not support FeatureDelayedPublish natively.
+// Messages with a scheduled time in the future are persisted in a state store and
+// published to the wrapped component once they are due, at least once.
+type delayBuffer struct {
+	PubSub
+
+	store  state.Store
+	opts   DelayBufferOptions
+	logger logger.Logger
+	native bool
+
+	// lock serializes the read-modify-write cycles on the buffer within this process.
+	// The ETag protects from concurrent writers in other processes.
+	lock   sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type delayedMessage struct {
+	ID            string         `json:"id"`
+	ScheduledTime time.Time      `json:"scheduledTime"`
+	Request       PublishRequest `json:"request"`
+	// LeasedUntil is set while a process publishes the message.
+	LeasedUntil time.Time `json:"leasedUntil,omitempty"`
+}
+
+type delayBufferDocument struct {
+	Messages []delayedMessage `json:"messages"`
+}
+
+// NewDelayBuffer returns a PubSub which delays the messages published with
+// metadata.DelayMetadataKey or metadata.ScheduledTimeMetadataKey using a buffer
+// persisted in the given state store. If the wrapped component supports
+// FeatureDelayedPublish natively, the buffer is bypassed.
+func NewDelayBuffer(pubsub PubSub, store state.Store, opts DelayBufferOptions, logger logger.Logger) PubSub {
+	if opts.Key == "" {
+		opts.Key = defaultDelayBufferKey
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultDelayBufferPollInterval
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = defaultDelayBufferLease
+	}
+
+	return &delayBuffer{
+		PubSub: pubsub,
+		store:  store,
+		opts:   opts,
+		logger: logger,
+	}
+}
+
+func (d *delayBuffer) Init(metadata Metadata) error {
+	if err := d.PubSub.Init(metadata); err != nil {
+		return err
+	}
+
+	d.native = FeatureDelayedPublish.IsPresent(d.PubSub.Features())
+	if d.native {
+		return nil
+	}
+
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	d.wg.Add(1)
+	go d.flushLoop()
+
+	return nil
+}
+
+func (d *delayBuffer) Publish(ctx context.Context, req *PublishRequest) error {
+	if d.native {
+		return d.PubSub.Publish(ctx, req)
+	}
+
+	scheduled, ok, err := metadata.TryGetScheduledTime(req.Metadata)
+	if err != nil {
+		return err
+	}
+	if !ok || !scheduled.After(time.Now()) {
+		return d.PubSub.Publish(ctx, withoutDelayMetadata(req))
+	}
+
+	msg := delayedMessage{
+		ID:            uuid.New().String(),
+		ScheduledTime: scheduled,
+		Request:       *withoutDelayMetadata(req),
+	}
+
+	return d.update(ctx, func(doc *delayBufferDocument) {
+		doc.Messages = append(doc.Messages, msg)
+	})
+}
+
+func (d *delayBuffer) Close() error {
+	if d.cancel != nil {
+		d.cancel()
+		d.wg.Wait()
+	}
+
+	return d.PubSub.Close()
+}
+
+func (d *delayBuffer) flushLoop() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.flush(d.ctx); err != nil && d.ctx.Err() == nil {
+				d.logger.Errorf("error flushing the delay buffer: %v", err)
+			}
+		}
+	}
+}
+
+// flush publishes the messages that are due. The messages are leased in the buffer before being published,
+// so that concurrent processes don't publish them too, and removed once published. The ones that failed to
+// be published are released for the next poll, and the ones of a process which stopped are published again
+// once their lease expired.
+func (d *delayBuffer) flush(ctx context.Context) error {
+	now := time.Now()
+	lease := now.Add(d.opts.Lease)
+	var due []delayedMessage
+	// update may run the callback more than once, the last run is the one that was persisted
+	err := d.update(ctx, func(doc *delayBufferDocument) {
+		due = nil
+		for i := range doc.Messages {
+			msg := &doc.Messages[i]
+			if msg.ScheduledTime.After(now) || msg.LeasedUntil.After(now) {
+				continue
+			}
+			msg.LeasedUntil = lease
+			due = append(due, *msg)
+		}
+	})
+	if err != nil || len(due) == 0 {
+		return err
+	}
+
+	published := make(map[string]bool, len(due))
+	for _, msg := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		req := msg.Request
+		if err = d.PubSub.Publish(ctx, &req); err != nil {
+			d.logger.Warnf("error publishing delayed message %s to topic %s, it will be retried: %v", msg.ID, req.Topic, err)
+			continue
+		}
+		published[msg.ID] = true
+	}
+
+	// ctx is canceled by Close, while the published messages must still be removed
+	releaseCtx, cancel := context.WithTimeout(context.Background(), delayBufferReleaseTimeout)
+	defer cancel()
+
+	return d.update(releaseCtx, func(doc *delayBufferDocument) {
+		remaining := make([]delayedMessage, 0, len(doc.Messages))
+		for _, msg := range doc.Messages {
+			if !msg.LeasedUntil.Equal(lease) {
+				// Not leased by this flush
+				remaining = append(remaining, msg)
+				continue
+			}
+			if !published[msg.ID] {
+				msg.LeasedUntil = time.Time{}
+				remaining = append(remaining, msg)
+			}
+		}
+		doc.Messages = remaining
+	})
+}
+
+// update applies fn to the persisted buffer, retrying on ETag mismatches caused by concurrent writers.
+func (d *delayBuffer) update(ctx context.Context, fn func(doc *delayBufferDocument)) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	useETag := state.FeatureETag.IsPresent(d.store.Features())
+	for attempt := 1; ; attempt++ {
+		res, err := d.store.Get(ctx, &state.GetRequest{Key: d.opts.Key})
+		if err != nil {
+			return fmt.Errorf("error reading the delay buffer: %w", err)
+		}
+
+		var doc delayBufferDocument
+		if res != nil && len(res.Data) > 0 {
+			if err = json.Unmarshal(res.Data, &doc); err != nil {
+				return fmt.Errorf("error decoding the delay buffer: %w", err)
+			}
+		}
+
+		fn(&doc)
+
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return fmt.Errorf("error encoding the delay buffer: %w", err)
+		}
+
+		req := &state.SetRequest{
+			Key:   d.opts.Key,
+			Value: data,
+		}
+		if useETag {
+			req.Options.Concurrency = state.FirstWrite
+			if res != nil {
+				req.ETag = res.ETag
+			}
+		}
+
+		err = d.store.Set(ctx, req)
+		if err == nil {
+			return nil
+		}
+
+		var etagErr *state.ETagError
+		if !errors.As(err, &etagErr) || attempt >= delayBufferMaxWriteAttempts {
+			return fmt.Errorf("error writing the delay buffer: %w", err)
+		}
+	}
+}
+
+// withoutDelayMetadata returns a copy of the request without the delay metadata keys, which are consumed by the buffer.
+func withoutDelayMetadata(req *PublishRequest) *PublishRequest {
+	if req.Metadata == nil {
+		return req
+	}
+
+	res := *req
+	res.Metadata = make(map[string]string, len(req.Metadata))
+	for k, v := range req.Metadata {
+		if k != metadata.DelayMetadataKey && k != metadata.ScheduledTimeMetadataKey {
+			res.Metadata[k] = v
+		}
+	}
+
+	return &res
+}
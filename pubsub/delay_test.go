@@ -0,0 +1,225 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JY29/components-contrib/metadata"
+	"github.com/JY29/components-contrib/state"
+	inmemory "github.com/JY29/components-contrib/state/in-memory"
+	"github.com/dapr/kit/logger"
+)
+
+// recordingPubSub is a PubSub that records the published messages.
+type recordingPubSub struct {
+	features  []Feature
+	failAfter int
+
+	lock      sync.Mutex
+	published []*PublishRequest
+}
+
+func (r *recordingPubSub) Init(Metadata) error { return nil }
+func (r *recordingPubSub) Features() []Feature { return r.features }
+func (r *recordingPubSub) Close() error        { return nil }
+func (r *recordingPubSub) Subscribe(context.Context, SubscribeRequest, Handler) error {
+	return nil
+}
+
+func (r *recordingPubSub) Publish(_ context.Context, req *PublishRequest) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.failAfter > 0 && len(r.published) >= r.failAfter {
+		return errors.New("publish failed")
+	}
+	r.published = append(r.published, req)
+	return nil
+}
+
+// cancelingPubSub cancels the context of the publishing, which then fails.
+type cancelingPubSub struct {
+	recordingPubSub
+
+	cancel context.CancelFunc
+}
+
+func (c *cancelingPubSub) Publish(ctx context.Context, req *PublishRequest) error {
+	c.cancel()
+	return ctx.Err()
+}
+
+func (r *recordingPubSub) count() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return len(r.published)
+}
+
+func newTestStateStore(t *testing.T) state.Store {
+	store := inmemory.NewInMemoryStateStore(logger.NewLogger("test"))
+	require.NoError(t, store.Init(state.Metadata{}))
+	t.Cleanup(func() { store.(interface{ Close() error }).Close() })
+	return store
+}
+
+func TestDelayBuffer(t *testing.T) {
+	log := logger.NewLogger("test")
+
+	t.Run("messages without delay are published immediately", func(t *testing.T) {
+		inner := &recordingPubSub{}
+		ps := NewDelayBuffer(inner, newTestStateStore(t), DelayBufferOptions{PollInterval: time.Hour}, log)
+		require.NoError(t, ps.Init(Metadata{}))
+		defer ps.Close()
+
+		err := ps.Publish(context.Background(), &PublishRequest{Topic: "a", Data: []byte("now")})
+		require.NoError(t, err)
+		assert.Equal(t, 1, inner.count())
+	})
+
+	t.Run("delayed messages are buffered until due", func(t *testing.T) {
+		inner := &recordingPubSub{}
+		ps := NewDelayBuffer(inner, newTestStateStore(t), DelayBufferOptions{PollInterval: time.Hour}, log)
+		require.NoError(t, ps.Init(Metadata{}))
+		defer ps.Close()
+		d := ps.(*delayBuffer)
+
+		err := ps.Publish(context.Background(), &PublishRequest{
+			Topic:    "a",
+			Data:     []byte("later"),
+			Metadata: map[string]string{metadata.DelayMetadataKey: "3600", "foo": "bar"},
+		})
+		require.NoError(t, err)
+		err = ps.Publish(context.Background(), &PublishRequest{
+			Topic:    "a",
+			Data:     []byte("soon"),
+			Metadata: map[string]string{metadata.ScheduledTimeMetadataKey: time.Now().Add(100 * time.Millisecond).Format(time.RFC3339Nano)},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 0, inner.count())
+
+		time.Sleep(200 * time.Millisecond)
+		require.NoError(t, d.flush(context.Background()))
+		require.Equal(t, 1, inner.count())
+		assert.Equal(t, "soon", string(inner.published[0].Data))
+		assert.NotContains(t, inner.published[0].Metadata, metadata.ScheduledTimeMetadataKey)
+
+		// The message due in one hour is still in the buffer
+		require.NoError(t, d.update(context.Background(), func(doc *delayBufferDocument) {
+			require.Len(t, doc.Messages, 1)
+			assert.Equal(t, "later", string(doc.Messages[0].Request.Data))
+			assert.Equal(t, map[string]string{"foo": "bar"}, doc.Messages[0].Request.Metadata)
+		}))
+	})
+
+	t.Run("messages that fail to publish are kept", func(t *testing.T) {
+		inner := &recordingPubSub{failAfter: 1}
+		ps := NewDelayBuffer(inner, newTestStateStore(t), DelayBufferOptions{PollInterval: time.Hour}, log)
+		require.NoError(t, ps.Init(Metadata{}))
+		defer ps.Close()
+		d := ps.(*delayBuffer)
+
+		soon := time.Now().Add(50 * time.Millisecond).Format(time.RFC3339Nano)
+		for i := 0; i < 2; i++ {
+			err := ps.Publish(context.Background(), &PublishRequest{
+				Topic:    "a",
+				Metadata: map[string]string{metadata.ScheduledTimeMetadataKey: soon},
+			})
+			require.NoError(t, err)
+		}
+
+		time.Sleep(100 * time.Millisecond)
+		require.NoError(t, d.flush(context.Background()))
+		assert.Equal(t, 1, inner.count())
+		require.NoError(t, d.update(context.Background(), func(doc *delayBufferDocument) {
+			require.Len(t, doc.Messages, 1)
+			assert.True(t, doc.Messages[0].LeasedUntil.IsZero(), "the message is released")
+		}))
+	})
+
+	t.Run("messages are kept until published", func(t *testing.T) {
+		inner := &recordingPubSub{}
+		store := newTestStateStore(t)
+		ps := NewDelayBuffer(inner, store, DelayBufferOptions{PollInterval: time.Hour, Lease: 50 * time.Millisecond}, log)
+		require.NoError(t, ps.Init(Metadata{}))
+		defer ps.Close()
+		d := ps.(*delayBuffer)
+
+		err := ps.Publish(context.Background(), &PublishRequest{
+			Topic:    "a",
+			Metadata: map[string]string{metadata.ScheduledTimeMetadataKey: time.Now().Add(5 * time.Millisecond).Format(time.RFC3339Nano)},
+		})
+		require.NoError(t, err)
+		time.Sleep(10 * time.Millisecond)
+
+		// The process leasing the message stopped before publishing it
+		require.NoError(t, d.update(context.Background(), func(doc *delayBufferDocument) {
+			doc.Messages[0].LeasedUntil = time.Now().Add(50 * time.Millisecond)
+		}))
+		require.NoError(t, d.flush(context.Background()))
+		assert.Equal(t, 0, inner.count(), "the message is leased")
+
+		time.Sleep(100 * time.Millisecond)
+		require.NoError(t, d.flush(context.Background()))
+		assert.Equal(t, 1, inner.count())
+		require.NoError(t, d.update(context.Background(), func(doc *delayBufferDocument) {
+			assert.Empty(t, doc.Messages)
+		}))
+	})
+
+	t.Run("messages are released when the flush is canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		inner := &cancelingPubSub{cancel: cancel}
+		ps := NewDelayBuffer(inner, newTestStateStore(t), DelayBufferOptions{PollInterval: time.Hour}, log)
+		require.NoError(t, ps.Init(Metadata{}))
+		defer ps.Close()
+		d := ps.(*delayBuffer)
+
+		err := ps.Publish(context.Background(), &PublishRequest{
+			Topic:    "a",
+			Metadata: map[string]string{metadata.ScheduledTimeMetadataKey: time.Now().Add(5 * time.Millisecond).Format(time.RFC3339Nano)},
+		})
+		require.NoError(t, err)
+		time.Sleep(10 * time.Millisecond)
+
+		// Close cancels the flush while publishing
+		require.NoError(t, d.flush(ctx))
+		assert.Equal(t, 0, inner.count())
+		require.NoError(t, d.update(context.Background(), func(doc *delayBufferDocument) {
+			require.Len(t, doc.Messages, 1)
+			assert.True(t, doc.Messages[0].LeasedUntil.IsZero())
+		}))
+	})
+
+	t.Run("native support bypasses the buffer", func(t *testing.T) {
+		inner := &recordingPubSub{features: []Feature{FeatureDelayedPublish}}
+		ps := NewDelayBuffer(inner, newTestStateStore(t), DelayBufferOptions{}, log)
+		require.NoError(t, ps.Init(Metadata{}))
+		defer ps.Close()
+
+		err := ps.Publish(context.Background(), &PublishRequest{
+			Topic:    "a",
+			Metadata: map[string]string{metadata.DelayMetadataKey: "3600"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, inner.count())
+		assert.Equal(t, "3600", inner.published[0].Metadata[metadata.DelayMetadataKey])
+	})
+}
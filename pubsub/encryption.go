@@ -0,0 +1,544 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/JY29/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// EncryptionKeyIDMetadataKey is the metadata key holding the ID of the key which encrypted a message.
+	EncryptionKeyIDMetadataKey = "encryptionKeyID"
+	// EncryptedContentType is the datacontenttype of the CloudEvents whose data is encrypted.
+	EncryptedContentType = "application/x-dapr-encrypted+json"
+
+	// ActiveKeyIDSecretKey is the entry of the keys secret naming the key used to encrypt new messages.
+	ActiveKeyIDSecretKey = "activeKeyID"
+
+	// CloudEvents extension attributes set on the events whose data is encrypted.
+	encryptionKeyIDField      = "encryptionkeyid"
+	encryptedContentTypeField = "encryptedcontenttype"
+
+	encryptionAlgorithm     = "A256GCM"
+	encryptionKeySize       = 32
+	defaultEncryptionKeyTTL = 5 * time.Minute
+	// Minimum time between two reads of the secret for keys which are not found in it.
+	missingEncryptionKeyRefreshInterval = 10 * time.Second
+)
+
+// EncryptionOptions configures the encryption of the payloads.
+type EncryptionOptions struct {
+	// SecretName is the secret holding the keys. Each entry of the secret is a base64-encoded 256-bit key,
+	// named after its key ID. The ActiveKeyIDSecretKey entry can name the key used to encrypt new messages.
+	SecretName string
+	// SecretMetadata is passed to the secret store when getting the secret.
+	SecretMetadata map[string]string
+	// ActiveKeyID is the ID of the key used to encrypt new messages.
+	// If empty, the ActiveKeyIDSecretKey entry of the secret is used.
+	ActiveKeyID string
+	// KeyTTL is how long the keys are cached before the secret is read again.
+	KeyTTL time.Duration
+	// RequireEncryption rejects the messages which are not encrypted, instead of delivering them as they are.
+	RequireEncryption bool
+}
+
+// encryptedPayload is an envelope-encrypted payload: the data is encrypted with a random data key,
+// which is itself encrypted with the key named by KeyID.
+type encryptedPayload struct {
+	Algorithm    string `json:"alg"`
+	KeyID        string `json:"kid"`
+	EncryptedKey []byte `json:"ek"`
+	Nonce        []byte `json:"iv"`
+	Ciphertext   []byte `json:"ct"`
+}
+
+// encryption wraps a PubSub to encrypt the published payloads and decrypt them before the handlers run.
+type encryption struct {
+	PubSub
+
+	keyring           *encryptionKeyring
+	requireEncryption bool
+	logger            logger.Logger
+}
+
+// bulkSubscribingEncryption is the encryption of a PubSub implementing BulkSubscriber.
+type bulkSubscribingEncryption struct {
+	*encryption
+}
+
+// NewEncryption returns a PubSub which envelope-encrypts the published payloads with AES-256-GCM,
+// using keys fetched from a secret store, and decrypts them on delivery.
+// The payloads of structured CloudEvents are encrypted in place, so the envelope stays readable.
+// Keys are rotated by adding a key to the secret and changing the active key: messages encrypted
+// with the previous keys can be decrypted as long as they are kept in the secret.
+// Messages which are not encrypted are delivered as they are, unless RequireEncryption is set.
+// The returned PubSub implements BulkSubscriber if the wrapped PubSub does.
+func NewEncryption(pubsub PubSub, store secretstores.SecretStore, opts EncryptionOptions, logger logger.Logger) (PubSub, error) {
+	if opts.SecretName == "" {
+		return nil, errors.New("the name of the secret holding the encryption keys is required")
+	}
+	if opts.KeyTTL <= 0 {
+		opts.KeyTTL = defaultEncryptionKeyTTL
+	}
+
+	e := &encryption{
+		PubSub: pubsub,
+		keyring: &encryptionKeyring{
+			store: store,
+			opts:  opts,
+		},
+		requireEncryption: opts.RequireEncryption,
+		logger:            logger,
+	}
+	if _, ok := pubsub.(BulkSubscriber); ok {
+		return &bulkSubscribingEncryption{e}, nil
+	}
+
+	return e, nil
+}
+
+func (e *encryption) Publish(ctx context.Context, req *PublishRequest) error {
+	data, keyID, err := e.encrypt(ctx, req.Data)
+	if err != nil {
+		return err
+	}
+
+	encrypted := *req
+	encrypted.Data = data
+	encrypted.Metadata = withEncryptionKeyID(req.Metadata, keyID)
+
+	return e.PubSub.Publish(ctx, &encrypted)
+}
+
+// BulkPublish encrypts the entries and publishes them with the wrapped PubSub,
+// or one by one if it does not implement BulkPublisher.
+func (e *encryption) BulkPublish(ctx context.Context, req *BulkPublishRequest) (BulkPublishResponse, error) {
+	encrypted := *req
+	encrypted.Entries = make([]BulkMessageEntry, len(req.Entries))
+	for i, entry := range req.Entries {
+		data, keyID, err := e.encrypt(ctx, entry.Event)
+		if err != nil {
+			return NewBulkPublishResponse(req.Entries, err), err
+		}
+		entry.Event = data
+		entry.Metadata = withEncryptionKeyID(entry.Metadata, keyID)
+		encrypted.Entries[i] = entry
+	}
+
+	if publisher, ok := e.PubSub.(BulkPublisher); ok {
+		return publisher.BulkPublish(ctx, &encrypted)
+	}
+
+	res := BulkPublishResponse{}
+	for _, entry := range encrypted.Entries {
+		pr := &PublishRequest{
+			Data:       entry.Event,
+			PubsubName: req.PubsubName,
+			Topic:      req.Topic,
+			Metadata:   mergeMetadata(req.Metadata, entry.Metadata),
+		}
+		if entry.ContentType != "" {
+			contentType := entry.ContentType
+			pr.ContentType = &contentType
+		}
+		err := e.PubSub.Publish(ctx, pr)
+		if err != nil {
+			res.FailedEntries = append(res.FailedEntries, BulkPublishResponseFailedEntry{EntryId: entry.EntryId, Error: err})
+		}
+	}
+	if len(res.FailedEntries) > 0 {
+		return res, fmt.Errorf("failed to publish %d of %d messages", len(res.FailedEntries), len(encrypted.Entries))
+	}
+
+	return res, nil
+}
+
+func (e *encryption) Subscribe(ctx context.Context, req SubscribeRequest, handler Handler) error {
+	return e.PubSub.Subscribe(ctx, req, func(ctx context.Context, msg *NewMessage) error {
+		data, err := e.decrypt(ctx, msg.Data)
+		if err != nil {
+			return err
+		}
+		msg.Data = data
+
+		return handler(ctx, msg)
+	})
+}
+
+// BulkSubscribe decrypts the entries before invoking the handler.
+// The entries which can't be decrypted are reported as failed, and not passed to the handler.
+func (e *bulkSubscribingEncryption) BulkSubscribe(ctx context.Context, req SubscribeRequest, handler BulkHandler) error {
+	return e.PubSub.(BulkSubscriber).BulkSubscribe(ctx, req, func(ctx context.Context, msg *BulkMessage) ([]BulkSubscribeResponseEntry, error) {
+		decrypted := *msg
+		decrypted.Entries = make([]BulkMessageEntry, 0, len(msg.Entries))
+		var failed []BulkSubscribeResponseEntry
+		for _, entry := range msg.Entries {
+			data, err := e.decrypt(ctx, entry.Event)
+			if err != nil {
+				failed = append(failed, BulkSubscribeResponseEntry{EntryId: entry.EntryId, Error: err})
+				continue
+			}
+			entry.Event = data
+			decrypted.Entries = append(decrypted.Entries, entry)
+		}
+		if len(failed) == 0 {
+			return handler(ctx, &decrypted)
+		}
+
+		var (
+			statuses []BulkSubscribeResponseEntry
+			err      error
+		)
+		if len(decrypted.Entries) > 0 {
+			statuses, err = handler(ctx, &decrypted)
+			if statuses == nil {
+				// The same error applies to all the entries passed to the handler
+				for _, entry := range decrypted.Entries {
+					statuses = append(statuses, BulkSubscribeResponseEntry{EntryId: entry.EntryId, Error: err})
+				}
+			}
+		}
+
+		return append(statuses, failed...), errors.New("failed to decrypt some of the messages")
+	})
+}
+
+// encrypt encrypts a payload with the active key, in place if it is a structured CloudEvent.
+func (e *encryption) encrypt(ctx context.Context, data []byte) ([]byte, string, error) {
+	keyID, key, err := e.keyring.activeKey(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ce, isCloudEvent := parseCloudEvent(data)
+	if !isCloudEvent {
+		payload, err := sealPayload(keyID, key, data)
+		if err != nil {
+			return nil, "", err
+		}
+		b, err := json.Marshal(payload)
+		return b, keyID, err
+	}
+
+	plaintext, err := cloudEventDataBytes(ce)
+	if err != nil {
+		return nil, "", err
+	}
+	payload, err := sealPayload(keyID, key, plaintext)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if contentType, ok := ce[DataContentTypeField]; ok {
+		ce[encryptedContentTypeField] = contentType
+	}
+	delete(ce, DataBase64Field)
+	ce[DataField] = payload
+	ce[DataContentTypeField] = EncryptedContentType
+	ce[encryptionKeyIDField] = keyID
+	b, err := json.Marshal(ce)
+
+	return b, keyID, err
+}
+
+// decrypt decrypts a payload, or returns it as it is if it is not encrypted and encryption is not required.
+func (e *encryption) decrypt(ctx context.Context, data []byte) ([]byte, error) {
+	ce, isCloudEvent := parseCloudEvent(data)
+	if !isCloudEvent {
+		var payload encryptedPayload
+		if err := json.Unmarshal(data, &payload); err != nil || payload.Algorithm != encryptionAlgorithm {
+			return e.unencrypted(data)
+		}
+		return e.openPayload(ctx, &payload)
+	}
+
+	if ce[DataContentTypeField] != EncryptedContentType {
+		return e.unencrypted(data)
+	}
+	b, err := json.Marshal(ce[DataField])
+	if err != nil {
+		return nil, err
+	}
+	var payload encryptedPayload
+	if err = json.Unmarshal(b, &payload); err != nil {
+		return nil, fmt.Errorf("invalid encrypted CloudEvent data: %w", err)
+	}
+	plaintext, err := e.openPayload(ctx, &payload)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType, hasContentType := ce[encryptedContentTypeField].(string)
+	delete(ce, DataField)
+	delete(ce, encryptedContentTypeField)
+	delete(ce, encryptionKeyIDField)
+	if hasContentType {
+		ce[DataContentTypeField] = contentType
+	} else {
+		delete(ce, DataContentTypeField)
+	}
+	field, value := cloudEventData(contentType, plaintext)
+	ce[field] = value
+
+	return json.Marshal(ce)
+}
+
+func (e *encryption) unencrypted(data []byte) ([]byte, error) {
+	if e.requireEncryption {
+		return nil, errors.New("the message is not encrypted")
+	}
+
+	return data, nil
+}
+
+func (e *encryption) openPayload(ctx context.Context, payload *encryptedPayload) ([]byte, error) {
+	key, err := e.keyring.key(ctx, payload.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := openAESGCM(key, payload.EncryptedKey, []byte(payload.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting the data key with key %s: %w", payload.KeyID, err)
+	}
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, payload.Nonce, payload.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting the payload: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+// sealPayload encrypts data with a random data key, which is encrypted with key.
+func sealPayload(keyID string, key []byte, data []byte) (*encryptedPayload, error) {
+	dataKey := make([]byte, encryptionKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	encryptedKey, err := sealAESGCM(key, dataKey, []byte(keyID))
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return &encryptedPayload{
+		Algorithm:    encryptionAlgorithm,
+		KeyID:        keyID,
+		EncryptedKey: encryptedKey,
+		Nonce:        nonce,
+		Ciphertext:   gcm.Seal(nil, nonce, data, nil),
+	}, nil
+}
+
+// sealAESGCM encrypts plaintext with AES-GCM, and returns the nonce followed by the ciphertext.
+func sealAESGCM(key []byte, plaintext []byte, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func openAESGCM(key []byte, sealed []byte, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], additionalData)
+}
+
+// parseCloudEvent returns the attributes of a structured CloudEvent.
+func parseCloudEvent(data []byte) (map[string]interface{}, bool) {
+	if len(data) == 0 || data[0] != '{' {
+		return nil, false
+	}
+	var ce map[string]interface{}
+	if err := unmarshalPrecise(data, &ce); err != nil {
+		return nil, false
+	}
+	if _, ok := ce[SpecVersionField]; !ok {
+		return nil, false
+	}
+
+	return ce, true
+}
+
+// cloudEventDataBytes returns the data of a CloudEvent, as stored by cloudEventData.
+func cloudEventDataBytes(ce map[string]interface{}) ([]byte, error) {
+	if v, ok := ce[DataBase64Field].(string); ok {
+		return base64.StdEncoding.DecodeString(v)
+	}
+	switch v := ce[DataField].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(v), nil
+	default:
+		return json.Marshal(v)
+	}
+}
+
+func withEncryptionKeyID(md map[string]string, keyID string) map[string]string {
+	res := make(map[string]string, len(md)+1)
+	for k, v := range md {
+		res[k] = v
+	}
+	res[EncryptionKeyIDMetadataKey] = keyID
+
+	return res
+}
+
+func mergeMetadata(base map[string]string, override map[string]string) map[string]string {
+	res := make(map[string]string, len(base)+len(override))
+	for k, v := range base {
+		res[k] = v
+	}
+	for k, v := range override {
+		res[k] = v
+	}
+
+	return res
+}
+
+// encryptionKeyring caches the keys read from the secret store.
+type encryptionKeyring struct {
+	store secretstores.SecretStore
+	opts  EncryptionOptions
+
+	lock      sync.Mutex
+	keys      map[string][]byte
+	activeID  string
+	fetchedAt time.Time
+}
+
+// activeKey returns the key used to encrypt new messages.
+func (k *encryptionKeyring) activeKey(ctx context.Context) (string, []byte, error) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	if time.Since(k.fetchedAt) > k.opts.KeyTTL {
+		if err := k.refresh(ctx); err != nil {
+			return "", nil, err
+		}
+	}
+	key, ok := k.keys[k.activeID]
+	if !ok {
+		return "", nil, fmt.Errorf("active encryption key %q not found in secret %s", k.activeID, k.opts.SecretName)
+	}
+
+	return k.activeID, key, nil
+}
+
+// key returns the key with the given ID. The secret is read again if the key is unknown, as it may have been rotated.
+// Unknown keys are cached as missing for missingEncryptionKeyRefreshInterval after the secret is read, so that
+// messages with invalid key IDs don't cause a read of the secret each: they are rejected, and redelivered later
+// if the key was added to the secret in between.
+func (k *encryptionKeyring) key(ctx context.Context, keyID string) ([]byte, error) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	key, ok := k.keys[keyID]
+	age := time.Since(k.fetchedAt)
+	if age > k.opts.KeyTTL || (!ok && age > missingEncryptionKeyRefreshInterval) {
+		if err := k.refresh(ctx); err != nil {
+			return nil, err
+		}
+		key, ok = k.keys[keyID]
+	}
+	if !ok {
+		return nil, fmt.Errorf("encryption key %q not found in secret %s", keyID, k.opts.SecretName)
+	}
+
+	return key, nil
+}
+
+func (k *encryptionKeyring) refresh(ctx context.Context) error {
+	res, err := k.store.GetSecret(ctx, secretstores.GetSecretRequest{
+		Name:     k.opts.SecretName,
+		Metadata: k.opts.SecretMetadata,
+	})
+	if err != nil {
+		return fmt.Errorf("error getting the encryption keys from secret %s: %w", k.opts.SecretName, err)
+	}
+
+	keys := make(map[string][]byte, len(res.Data))
+	for id, val := range res.Data {
+		if id == ActiveKeyIDSecretKey {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(val)
+		if err != nil || len(key) != encryptionKeySize {
+			return fmt.Errorf("encryption key %q in secret %s must be a base64-encoded %d-byte key", id, k.opts.SecretName, encryptionKeySize)
+		}
+		keys[id] = key
+	}
+
+	k.keys = keys
+	k.activeID = k.opts.ActiveKeyID
+	if k.activeID == "" {
+		k.activeID = res.Data[ActiveKeyIDSecretKey]
+	}
+	k.fetchedAt = time.Now()
+
+	return nil
+}
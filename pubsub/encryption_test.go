@@ -0,0 +1,279 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JY29/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+// fakeSecretStore is a secret store holding a single secret.
+type fakeSecretStore struct {
+	data  map[string]string
+	calls int
+}
+
+func (f *fakeSecretStore) Init(secretstores.Metadata) error        { return nil }
+func (f *fakeSecretStore) Features() []secretstores.Feature        { return nil }
+func (f *fakeSecretStore) GetComponentMetadata() map[string]string { return nil }
+
+func (f *fakeSecretStore) GetSecret(_ context.Context, req secretstores.GetSecretRequest) (secretstores.GetSecretResponse, error) {
+	f.calls++
+	data := make(map[string]string, len(f.data))
+	for k, v := range f.data {
+		data[k] = v
+	}
+	return secretstores.GetSecretResponse{Data: data}, nil
+}
+
+func (f *fakeSecretStore) BulkGetSecret(context.Context, secretstores.BulkGetSecretRequest) (secretstores.BulkGetSecretResponse, error) {
+	return secretstores.BulkGetSecretResponse{}, nil
+}
+
+// bulkLoopbackPubSub is a loopbackPubSub implementing BulkSubscriber.
+type bulkLoopbackPubSub struct {
+	loopbackPubSub
+
+	bulkHandlers map[string]BulkHandler
+}
+
+func (l *bulkLoopbackPubSub) BulkSubscribe(_ context.Context, req SubscribeRequest, handler BulkHandler) error {
+	if l.bulkHandlers == nil {
+		l.bulkHandlers = map[string]BulkHandler{}
+	}
+	l.bulkHandlers[req.Topic] = handler
+	return nil
+}
+
+func testEncryptionKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, encryptionKeySize))
+}
+
+func TestEncryption(t *testing.T) {
+	log := logger.NewLogger("test")
+	ctx := context.Background()
+
+	newEncryption := func(t *testing.T, store *fakeSecretStore) (PubSub, *loopbackPubSub, *[]*NewMessage) {
+		inner := &loopbackPubSub{}
+		ps, err := NewEncryption(inner, store, EncryptionOptions{SecretName: "keys"}, log)
+		require.NoError(t, err)
+		received := &[]*NewMessage{}
+		require.NoError(t, ps.Subscribe(ctx, SubscribeRequest{Topic: "orders"}, func(ctx context.Context, msg *NewMessage) error {
+			*received = append(*received, msg)
+			return nil
+		}))
+		return ps, inner, received
+	}
+
+	t.Run("raw payloads", func(t *testing.T) {
+		store := &fakeSecretStore{data: map[string]string{"k1": testEncryptionKey(1), ActiveKeyIDSecretKey: "k1"}}
+		ps, inner, received := newEncryption(t, store)
+
+		require.NoError(t, ps.Publish(ctx, &PublishRequest{Topic: "orders", Data: []byte("secret data"), Metadata: map[string]string{"a": "b"}}))
+
+		require.Equal(t, 1, inner.count())
+		assert.NotContains(t, string(inner.published[0].Data), "secret data")
+		assert.Equal(t, map[string]string{"a": "b", EncryptionKeyIDMetadataKey: "k1"}, inner.published[0].Metadata)
+		require.Len(t, *received, 1)
+		assert.Equal(t, "secret data", string((*received)[0].Data))
+	})
+
+	t.Run("CloudEvents", func(t *testing.T) {
+		store := &fakeSecretStore{data: map[string]string{"k1": testEncryptionKey(1), ActiveKeyIDSecretKey: "k1"}}
+		ps, inner, received := newEncryption(t, store)
+
+		events := []map[string]interface{}{
+			NewCloudEventsEnvelope("1", "", "", "", "orders", "ps", "application/json", []byte(`{"amount":12.5,"id":12345678901234567}`), "", ""),
+			NewCloudEventsEnvelope("2", "", "", "", "orders", "ps", "text/plain", []byte("hello"), "", ""),
+			NewCloudEventsEnvelope("3", "", "", "", "orders", "ps", "application/octet-stream", []byte{0, 1, 2}, "", ""),
+		}
+		for _, ce := range events {
+			data, err := json.Marshal(ce)
+			require.NoError(t, err)
+			require.NoError(t, ps.Publish(ctx, &PublishRequest{Topic: "orders", Data: data}))
+		}
+
+		require.Len(t, *received, len(events))
+		for i, ce := range events {
+			var published map[string]interface{}
+			require.NoError(t, json.Unmarshal(inner.published[i].Data, &published))
+			assert.Equal(t, ce[IDField], published[IDField])
+			assert.Equal(t, EncryptedContentType, published[DataContentTypeField])
+			assert.Equal(t, "k1", published[encryptionKeyIDField])
+			assert.NotContains(t, published, DataBase64Field)
+
+			var delivered map[string]interface{}
+			require.NoError(t, unmarshalPrecise((*received)[i].Data, &delivered))
+			expected, err := json.Marshal(ce)
+			require.NoError(t, err)
+			actual, err := json.Marshal(delivered)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(expected), string(actual))
+		}
+	})
+
+	t.Run("key rotation", func(t *testing.T) {
+		store := &fakeSecretStore{data: map[string]string{"k1": testEncryptionKey(1), ActiveKeyIDSecretKey: "k1"}}
+		ps, inner, received := newEncryption(t, store)
+
+		require.NoError(t, ps.Publish(ctx, &PublishRequest{Topic: "orders", Data: []byte("before")}))
+		old := inner.published[0].Data
+
+		// Rotate the key, and expire the cache
+		store.data["k2"] = testEncryptionKey(2)
+		store.data[ActiveKeyIDSecretKey] = "k2"
+		ps.(*encryption).keyring.fetchedAt = time.Time{}
+
+		require.NoError(t, ps.Publish(ctx, &PublishRequest{Topic: "orders", Data: []byte("after")}))
+		assert.Equal(t, "k2", inner.published[1].Metadata[EncryptionKeyIDMetadataKey])
+
+		// Messages encrypted with the previous key can still be decrypted
+		require.NoError(t, inner.handlers["orders"](ctx, &NewMessage{Topic: "orders", Data: old}))
+		require.Len(t, *received, 3)
+		assert.Equal(t, "before", string((*received)[2].Data))
+
+		// Once the previous key is removed, they can't
+		delete(store.data, "k1")
+		ps.(*encryption).keyring.fetchedAt = time.Time{}
+		assert.Error(t, inner.handlers["orders"](ctx, &NewMessage{Topic: "orders", Data: old}))
+	})
+
+	t.Run("unencrypted messages are delivered as they are", func(t *testing.T) {
+		store := &fakeSecretStore{data: map[string]string{"k1": testEncryptionKey(1), ActiveKeyIDSecretKey: "k1"}}
+		_, inner, received := newEncryption(t, store)
+
+		require.NoError(t, inner.handlers["orders"](ctx, &NewMessage{Topic: "orders", Data: []byte(`{"plain":true}`)}))
+		require.Len(t, *received, 1)
+		assert.Equal(t, `{"plain":true}`, string((*received)[0].Data))
+	})
+
+	t.Run("bulk publish falls back to publish", func(t *testing.T) {
+		store := &fakeSecretStore{data: map[string]string{"k1": testEncryptionKey(1), ActiveKeyIDSecretKey: "k1"}}
+		ps, inner, received := newEncryption(t, store)
+
+		res, err := ps.(BulkPublisher).BulkPublish(ctx, &BulkPublishRequest{
+			Topic: "orders",
+			Entries: []BulkMessageEntry{
+				{EntryId: "1", Event: []byte("one")},
+				{EntryId: "2", Event: []byte("two")},
+			},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, res.FailedEntries)
+		assert.Equal(t, 2, inner.count())
+		require.Len(t, *received, 2)
+		assert.Equal(t, "two", string((*received)[1].Data))
+		// The secret is only read once
+		assert.Equal(t, 1, store.calls)
+	})
+
+	t.Run("unencrypted messages are rejected if encryption is required", func(t *testing.T) {
+		store := &fakeSecretStore{data: map[string]string{"k1": testEncryptionKey(1), ActiveKeyIDSecretKey: "k1"}}
+		inner := &loopbackPubSub{}
+		ps, err := NewEncryption(inner, store, EncryptionOptions{SecretName: "keys", RequireEncryption: true}, log)
+		require.NoError(t, err)
+		var received []*NewMessage
+		require.NoError(t, ps.Subscribe(ctx, SubscribeRequest{Topic: "orders"}, func(ctx context.Context, msg *NewMessage) error {
+			received = append(received, msg)
+			return nil
+		}))
+
+		assert.Error(t, inner.handlers["orders"](ctx, &NewMessage{Topic: "orders", Data: []byte(`{"plain":true}`)}))
+		event, err := json.Marshal(NewCloudEventsEnvelope("1", "", "", "", "orders", "ps", "text/plain", []byte("hello"), "", ""))
+		require.NoError(t, err)
+		assert.Error(t, inner.handlers["orders"](ctx, &NewMessage{Topic: "orders", Data: event}))
+		assert.Empty(t, received)
+
+		require.NoError(t, ps.Publish(ctx, &PublishRequest{Topic: "orders", Data: []byte("secret data")}))
+		require.Len(t, received, 1)
+		assert.Equal(t, "secret data", string(received[0].Data))
+	})
+
+	t.Run("unknown keys don't read the secret for each message", func(t *testing.T) {
+		store := &fakeSecretStore{data: map[string]string{"k1": testEncryptionKey(1), ActiveKeyIDSecretKey: "k1"}}
+		ps, inner, received := newEncryption(t, store)
+		require.NoError(t, ps.Publish(ctx, &PublishRequest{Topic: "orders", Data: []byte("data")}))
+		require.Equal(t, 1, store.calls)
+
+		for _, keyID := range []string{"k2", "k2", "k3"} {
+			payload, err := sealPayload(keyID, bytes.Repeat([]byte{2}, encryptionKeySize), []byte("data"))
+			require.NoError(t, err)
+			data, err := json.Marshal(payload)
+			require.NoError(t, err)
+			assert.Error(t, inner.handlers["orders"](ctx, &NewMessage{Topic: "orders", Data: data}))
+		}
+		assert.Equal(t, 1, store.calls)
+		assert.Len(t, *received, 1)
+
+		// Once the interval has elapsed, the secret is read again for the unknown keys
+		store.data["k2"] = testEncryptionKey(2)
+		ps.(*encryption).keyring.fetchedAt = time.Now().Add(-missingEncryptionKeyRefreshInterval - time.Second)
+		payload, err := sealPayload("k2", bytes.Repeat([]byte{2}, encryptionKeySize), []byte("rotated"))
+		require.NoError(t, err)
+		data, err := json.Marshal(payload)
+		require.NoError(t, err)
+		require.NoError(t, inner.handlers["orders"](ctx, &NewMessage{Topic: "orders", Data: data}))
+		assert.Equal(t, 2, store.calls)
+		require.Len(t, *received, 2)
+		assert.Equal(t, "rotated", string((*received)[1].Data))
+	})
+
+	t.Run("bulk subscribe is only implemented if the pubsub does", func(t *testing.T) {
+		store := &fakeSecretStore{data: map[string]string{"k1": testEncryptionKey(1), ActiveKeyIDSecretKey: "k1"}}
+		ps, err := NewEncryption(&loopbackPubSub{}, store, EncryptionOptions{SecretName: "keys"}, log)
+		require.NoError(t, err)
+		_, ok := ps.(BulkSubscriber)
+		assert.False(t, ok)
+
+		inner := &bulkLoopbackPubSub{}
+		ps, err = NewEncryption(inner, store, EncryptionOptions{SecretName: "keys"}, log)
+		require.NoError(t, err)
+		subscriber, ok := ps.(BulkSubscriber)
+		require.True(t, ok)
+		var received []BulkMessageEntry
+		require.NoError(t, subscriber.BulkSubscribe(ctx, SubscribeRequest{Topic: "orders"}, func(ctx context.Context, msg *BulkMessage) ([]BulkSubscribeResponseEntry, error) {
+			received = append(received, msg.Entries...)
+			return nil, nil
+		}))
+
+		require.NoError(t, ps.Publish(ctx, &PublishRequest{Topic: "orders", Data: []byte("secret data")}))
+		res, err := inner.bulkHandlers["orders"](ctx, &BulkMessage{Topic: "orders", Entries: []BulkMessageEntry{
+			{EntryId: "1", Event: inner.published[0].Data},
+			{EntryId: "2", Event: []byte(`{"alg":"A256GCM","kid":"k1"}`)},
+		}})
+		assert.Error(t, err)
+		require.Len(t, received, 1)
+		assert.Equal(t, "secret data", string(received[0].Event))
+		assert.Equal(t, []BulkSubscribeResponseEntry{{EntryId: "1"}}, res[:1])
+		require.Len(t, res, 2)
+		assert.Equal(t, "2", res[1].EntryId)
+		assert.Error(t, res[1].Error)
+	})
+
+	t.Run("invalid keys are rejected", func(t *testing.T) {
+		store := &fakeSecretStore{data: map[string]string{"k1": "short", ActiveKeyIDSecretKey: "k1"}}
+		ps, _, _ := newEncryption(t, store)
+		assert.Error(t, ps.Publish(ctx, &PublishRequest{Topic: "orders", Data: []byte("data")}))
+	})
+}
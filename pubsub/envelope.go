@@ -17,7 +17,11 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -54,6 +58,35 @@ const (
 	IDField              = "id"
 	SubjectField         = "subject"
 	TimeField            = "time"
+
+	// CloudEventsModeStructured sends CloudEvents as a JSON document in the message payload.
+	CloudEventsModeStructured = "structured"
+	// CloudEventsModeBinary sends the CloudEvents attributes as protocol headers and the event data as the payload.
+	CloudEventsModeBinary = "binary"
+)
+
+// CloudEventsBinaryBinding describes how the attributes of binary-mode CloudEvents are mapped to protocol headers.
+type CloudEventsBinaryBinding struct {
+	// HeaderPrefix is prepended to the name of the attributes.
+	HeaderPrefix string
+	// ContentTypeHeader carries the datacontenttype attribute.
+	ContentTypeHeader string
+	// PercentEncode enables the percent-encoding of header values required by the HTTP binding.
+	PercentEncode bool
+}
+
+var (
+	// CloudEventsKafkaBinding is the Kafka protocol binding of CloudEvents.
+	CloudEventsKafkaBinding = CloudEventsBinaryBinding{
+		HeaderPrefix:      "ce_",
+		ContentTypeHeader: "content-type",
+	}
+	// CloudEventsHTTPBinding is the HTTP protocol binding of CloudEvents.
+	CloudEventsHTTPBinding = CloudEventsBinaryBinding{
+		HeaderPrefix:      "ce-",
+		ContentTypeHeader: "Content-Type",
+		PercentEncode:     true,
+	}
 )
 
 // unmarshalPrecise is a wrapper around encoding/json's Decoder
@@ -86,21 +119,7 @@ func NewCloudEventsEnvelope(id, source, eventType, subject string, topic string,
 		dataContentType = DefaultCloudEventDataContentType
 	}
 
-	var ceData interface{}
-	ceDataField := DataField
-	var err error
-	if contribContenttype.IsJSONContentType(dataContentType) {
-		err = unmarshalPrecise(data, &ceData)
-	} else if contribContenttype.IsBinaryContentType(dataContentType) {
-		ceData = base64.StdEncoding.EncodeToString(data)
-		ceDataField = DataBase64Field
-	} else {
-		ceData = string(data)
-	}
-
-	if err != nil {
-		ceData = string(data)
-	}
+	ceDataField, ceData := cloudEventData(dataContentType, data)
 
 	ce := map[string]interface{}{
 		IDField:              id,
@@ -125,6 +144,20 @@ func NewCloudEventsEnvelope(id, source, eventType, subject string, topic string,
 	return ce
 }
 
+// cloudEventData returns the attribute and the value under which data is stored in a CloudEvent.
+func cloudEventData(dataContentType string, data []byte) (string, interface{}) {
+	if contribContenttype.IsJSONContentType(dataContentType) {
+		var ceData interface{}
+		if err := unmarshalPrecise(data, &ceData); err == nil {
+			return DataField, ceData
+		}
+	} else if contribContenttype.IsBinaryContentType(dataContentType) {
+		return DataBase64Field, base64.StdEncoding.EncodeToString(data)
+	}
+
+	return DataField, string(data)
+}
+
 // FromCloudEvent returns a map representation of an existing cloudevents JSON.
 func FromCloudEvent(cloudEvent []byte, topic, pubsub, traceParent string, traceState string) (map[string]interface{}, error) {
 	var m map[string]interface{}
@@ -211,3 +244,136 @@ func ApplyMetadata(cloudEvent map[string]interface{}, componentFeatures []Featur
 		cloudEvent[ExpirationField] = expiration.Format(time.RFC3339)
 	}
 }
+
+// IsBinaryCloudEvent returns true if the headers carry a binary-mode CloudEvent.
+func IsBinaryCloudEvent(headers map[string]string, binding CloudEventsBinaryBinding) bool {
+	specVersionHeader := strings.ToLower(binding.HeaderPrefix + SpecVersionField)
+	for k := range headers {
+		if strings.ToLower(k) == specVersionHeader {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ToBinaryCloudEvent converts a CloudEvent to its binary-mode representation: the attributes are
+// returned as headers and the event data as the payload.
+func ToBinaryCloudEvent(cloudEvent map[string]interface{}, binding CloudEventsBinaryBinding) (map[string]string, []byte, error) {
+	headers := make(map[string]string, len(cloudEvent))
+	dataContentType := ""
+	for k, v := range cloudEvent {
+		if v == nil || k == DataField || k == DataBase64Field {
+			continue
+		}
+
+		var value string
+		switch x := v.(type) {
+		case string:
+			value = x
+		case json.Number:
+			value = x.String()
+		case bool:
+			value = strconv.FormatBool(x)
+		default:
+			value = fmt.Sprintf("%v", x)
+		}
+		if value == "" {
+			continue
+		}
+
+		if k == DataContentTypeField {
+			dataContentType = value
+			headers[binding.ContentTypeHeader] = value
+			continue
+		}
+		if binding.PercentEncode {
+			value = percentEncodeHeaderValue(value)
+		}
+		headers[binding.HeaderPrefix+k] = value
+	}
+
+	if _, ok := headers[binding.HeaderPrefix+SpecVersionField]; !ok {
+		return nil, nil, errors.New("the CloudEvent has no specversion attribute")
+	}
+
+	if v, ok := cloudEvent[DataBase64Field]; ok && v != nil {
+		data, err := base64.StdEncoding.DecodeString(fmt.Sprintf("%v", v))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid %s attribute: %w", DataBase64Field, err)
+		}
+		return headers, data, nil
+	}
+
+	v, ok := cloudEvent[DataField]
+	if !ok || v == nil {
+		return headers, nil, nil
+	}
+	if str, isString := v.(string); isString && !contribContenttype.IsJSONContentType(dataContentType) {
+		return headers, []byte(str), nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error encoding the CloudEvent data: %w", err)
+	}
+
+	return headers, data, nil
+}
+
+// FromBinaryCloudEvent returns a map representation of a binary-mode CloudEvent, so that it can be
+// delivered in structured mode. Headers that are not CloudEvents attributes are ignored.
+func FromBinaryCloudEvent(headers map[string]string, data []byte, binding CloudEventsBinaryBinding) (map[string]interface{}, error) {
+	prefix := strings.ToLower(binding.HeaderPrefix)
+	contentTypeHeader := strings.ToLower(binding.ContentTypeHeader)
+
+	ce := make(map[string]interface{}, len(headers)+1)
+	dataContentType := ""
+	for k, v := range headers {
+		lk := strings.ToLower(k)
+		if lk == contentTypeHeader {
+			dataContentType = v
+			continue
+		}
+		if !strings.HasPrefix(lk, prefix) || len(lk) == len(prefix) {
+			continue
+		}
+		if binding.PercentEncode {
+			if unescaped, err := url.PathUnescape(v); err == nil {
+				v = unescaped
+			}
+		}
+		ce[lk[len(prefix):]] = v
+	}
+
+	if ce[SpecVersionField] == nil {
+		return nil, errors.New("the headers do not carry a binary-mode CloudEvent")
+	}
+
+	if dataContentType != "" {
+		ce[DataContentTypeField] = dataContentType
+	} else {
+		// Without a content type, the data can only be treated as opaque bytes
+		dataContentType = "application/octet-stream"
+	}
+	if len(data) > 0 {
+		field, value := cloudEventData(dataContentType, data)
+		ce[field] = value
+	}
+
+	return ce, nil
+}
+
+// percentEncodeHeaderValue encodes the characters that are not allowed in the values of
+// CloudEvents HTTP headers: space, double quote, percent sign and non-printable ASCII.
+func percentEncodeHeaderValue(v string) string {
+	var sb strings.Builder
+	for _, b := range []byte(v) {
+		if b <= ' ' || b >= 0x7f || b == '"' || b == '%' {
+			fmt.Fprintf(&sb, "%%%02X", b)
+		} else {
+			sb.WriteByte(b)
+		}
+	}
+
+	return sb.String()
+}
@@ -335,3 +335,73 @@ func TestNewFromRawPayload(t *testing.T) {
 		assert.Equal(t, "aGVsbG8gd29ybGQ=", n[DataBase64Field])
 	})
 }
+
+func TestBinaryCloudEvents(t *testing.T) {
+	t.Run("json data round trip", func(t *testing.T) {
+		envelope := NewCloudEventsEnvelope("a", "source", "eventType", "subject", "mytopic",
+			"mypubsub", "application/json", []byte(`{"count":1}`), "", "")
+
+		headers, data, err := ToBinaryCloudEvent(envelope, CloudEventsKafkaBinding)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"count":1}`, string(data))
+		assert.Equal(t, "a", headers["ce_id"])
+		assert.Equal(t, "1.0", headers["ce_specversion"])
+		assert.Equal(t, "subject", headers["ce_subject"])
+		assert.Equal(t, "application/json", headers["content-type"])
+		assert.NotContains(t, headers, "ce_traceparent")
+		assert.True(t, IsBinaryCloudEvent(headers, CloudEventsKafkaBinding))
+
+		ce, err := FromBinaryCloudEvent(headers, data, CloudEventsKafkaBinding)
+		assert.NoError(t, err)
+		assert.Equal(t, "a", ce[IDField])
+		assert.Equal(t, "source", ce[SourceField])
+		assert.Equal(t, "mytopic", ce[TopicField])
+		assert.Equal(t, "application/json", ce[DataContentTypeField])
+		assert.Equal(t, map[string]interface{}{"count": json.Number("1")}, ce[DataField])
+	})
+
+	t.Run("binary data round trip", func(t *testing.T) {
+		envelope := NewCloudEventsEnvelope("a", "", "", "", "", "",
+			"application/octet-stream", []byte{0x1, 0x2}, "", "")
+
+		headers, data, err := ToBinaryCloudEvent(envelope, CloudEventsKafkaBinding)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0x1, 0x2}, data)
+
+		ce, err := FromBinaryCloudEvent(headers, data, CloudEventsKafkaBinding)
+		assert.NoError(t, err)
+		assert.Equal(t, base64.StdEncoding.EncodeToString([]byte{0x1, 0x2}), ce[DataBase64Field])
+	})
+
+	t.Run("http headers are case insensitive and percent-encoded", func(t *testing.T) {
+		envelope := NewCloudEventsEnvelope("a", "", "", "hello \"world\" 100%", "", "",
+			"text/plain", []byte("hi"), "", "")
+
+		headers, data, err := ToBinaryCloudEvent(envelope, CloudEventsHTTPBinding)
+		assert.NoError(t, err)
+		assert.Equal(t, "hi", string(data))
+		assert.Equal(t, "hello%20%22world%22%20100%25", headers["ce-subject"])
+		assert.Equal(t, "text/plain", headers["Content-Type"])
+
+		ce, err := FromBinaryCloudEvent(map[string]string{
+			"Ce-Specversion": "1.0",
+			"Ce-Id":          "a",
+			"Ce-Subject":     headers["ce-subject"],
+			"Content-Type":   "text/plain",
+			"Accept":         "*/*",
+		}, data, CloudEventsHTTPBinding)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello \"world\" 100%", ce[SubjectField])
+		assert.Equal(t, "hi", ce[DataField])
+		assert.NotContains(t, ce, "accept")
+	})
+
+	t.Run("not a cloud event", func(t *testing.T) {
+		headers := map[string]string{"foo": "bar"}
+		assert.False(t, IsBinaryCloudEvent(headers, CloudEventsKafkaBinding))
+		_, err := FromBinaryCloudEvent(headers, []byte("hi"), CloudEventsKafkaBinding)
+		assert.Error(t, err)
+		_, _, err = ToBinaryCloudEvent(map[string]interface{}{DataField: "hi"}, CloudEventsKafkaBinding)
+		assert.Error(t, err)
+	})
+}
@@ -18,6 +18,10 @@ const (
 	FeatureMessageTTL Feature = "MESSAGE_TTL"
 	// FeatureSubscribeWildcards is the feature to allow subscribing to topics/queues using a wildcard.
 	FeatureSubscribeWildcards Feature = "SUBSCRIBE_WILDCARDS"
+	// FeatureDelayedPublish is the feature to natively deliver messages after a delay or at a scheduled time.
+	FeatureDelayedPublish Feature = "DELAYED_PUBLISH"
+	// FeatureMessagePriority is the feature to deliver the messages with a higher priority, set with the "priority" metadata, first.
+	FeatureMessagePriority Feature = "MESSAGE_PRIORITY"
 )
 
 // Feature names a feature that can be implemented by PubSub components.
@@ -0,0 +1,689 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/JY29/components-contrib/metadata"
+)
+
+// FilterScope is the part of a message a filter field refers to.
+type FilterScope string
+
+const (
+	// FilterScopeAttribute is a CloudEvent attribute, such as type, source or subject.
+	FilterScopeAttribute FilterScope = "attribute"
+	// FilterScopeData is a field of the JSON data of the message, prefixed with "data.".
+	FilterScopeData FilterScope = "data"
+	// FilterScopeMetadata is a metadata entry of the message, which brokers carry as headers or properties, prefixed with "metadata.".
+	FilterScopeMetadata FilterScope = "metadata"
+)
+
+// FilterOperator is the operator of a filter condition.
+type FilterOperator string
+
+const (
+	FilterOpEqual          FilterOperator = "=="
+	FilterOpNotEqual       FilterOperator = "!="
+	FilterOpLess           FilterOperator = "<"
+	FilterOpLessOrEqual    FilterOperator = "<="
+	FilterOpGreater        FilterOperator = ">"
+	FilterOpGreaterOrEqual FilterOperator = ">="
+	FilterOpIn             FilterOperator = "in"
+	FilterOpExists         FilterOperator = "exists"
+)
+
+// FilterField is a field of a message referenced by a filter.
+type FilterField struct {
+	Scope FilterScope
+	// Path is the name of the attribute, the path to the data field, or the metadata key in a single element.
+	Path []string
+}
+
+// FilterCondition compares a field to literal values: strings, float64 numbers, booleans or nil.
+// Comparisons of a field which is missing are false.
+type FilterCondition struct {
+	Field    FilterField
+	Operator FilterOperator
+	// Values has one element, except for FilterOpIn, and none for FilterOpExists.
+	Values []interface{}
+}
+
+// Filter is a parsed filter expression. Exactly one of its fields is set.
+type Filter struct {
+	And       []*Filter
+	Or        []*Filter
+	Not       *Filter
+	Condition *FilterCondition
+}
+
+// ParseFilter parses a filter expression over the CloudEvent attributes, the JSON data fields and the metadata of the messages, for example:
+//
+//	type == 'order.created' && (data.amount >= 100 || data.customer.tier in ('gold', 'platinum')) && !exists(metadata.replay)
+func ParseFilter(expr string) (*Filter, error) {
+	p := &filterParser{input: expr}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != filterTokenEOF {
+		return nil, p.errorf("unexpected %q", p.tok.text)
+	}
+
+	return f, nil
+}
+
+// SubscriptionFilter returns the filter set in the subscription metadata with metadata.FilterMetadataKey, if any.
+func SubscriptionFilter(req SubscribeRequest) (*Filter, error) {
+	expr := strings.TrimSpace(req.Metadata[metadata.FilterMetadataKey])
+	if expr == "" {
+		return nil, nil
+	}
+
+	return ParseFilter(expr)
+}
+
+// Conditions returns the conditions of a filter which is a conjunction of conditions.
+func (f *Filter) Conditions() ([]FilterCondition, bool) {
+	switch {
+	case f.Condition != nil:
+		return []FilterCondition{*f.Condition}, true
+	case f.And != nil:
+		var conditions []FilterCondition
+		for _, sub := range f.And {
+			c, ok := sub.Conditions()
+			if !ok {
+				return nil, false
+			}
+			conditions = append(conditions, c...)
+		}
+		return conditions, true
+	default:
+		return nil, false
+	}
+}
+
+// Match evaluates the filter against a message.
+func (f *Filter) Match(msg *NewMessage) bool {
+	return f.match(newFilterMessage(msg.Data, msg.Metadata))
+}
+
+func (f *Filter) match(msg *filterMessage) bool {
+	switch {
+	case f.Condition != nil:
+		return f.Condition.match(msg)
+	case f.Not != nil:
+		return !f.Not.match(msg)
+	case f.And != nil:
+		for _, sub := range f.And {
+			if !sub.match(msg) {
+				return false
+			}
+		}
+		return true
+	default:
+		for _, sub := range f.Or {
+			if sub.match(msg) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (c *FilterCondition) match(msg *filterMessage) bool {
+	val, ok := msg.lookup(c.Field)
+	if c.Operator == FilterOpExists {
+		return ok
+	}
+	if !ok {
+		return false
+	}
+
+	switch c.Operator {
+	case FilterOpIn:
+		for _, v := range c.Values {
+			if cmp, ok := compareFilterValues(val, v); ok && cmp == 0 {
+				return true
+			}
+		}
+		return false
+	case FilterOpNotEqual:
+		cmp, ok := compareFilterValues(val, c.Values[0])
+		return !ok || cmp != 0
+	}
+
+	cmp, ok := compareFilterValues(val, c.Values[0])
+	if !ok {
+		return false
+	}
+	switch c.Operator {
+	case FilterOpEqual:
+		return cmp == 0
+	case FilterOpLess:
+		return cmp < 0
+	case FilterOpLessOrEqual:
+		return cmp <= 0
+	case FilterOpGreater:
+		return cmp > 0
+	case FilterOpGreaterOrEqual:
+		return cmp >= 0
+	default:
+		return false
+	}
+}
+
+// compareFilterValues compares a value of a message to a literal, if they have comparable types.
+// Strings are compared to numbers as numbers, as metadata values are always strings.
+func compareFilterValues(val interface{}, literal interface{}) (int, bool) {
+	switch l := literal.(type) {
+	case nil:
+		return 0, val == nil
+	case bool:
+		switch v := val.(type) {
+		case bool:
+			if v == l {
+				return 0, true
+			}
+			return 1, false
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil || b != l {
+				return 1, false
+			}
+			return 0, true
+		}
+	case float64:
+		var f float64
+		switch v := val.(type) {
+		case float64:
+			f = v
+		case json.Number:
+			n, err := v.Float64()
+			if err != nil {
+				return 0, false
+			}
+			f = n
+		case string:
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return 0, false
+			}
+			f = n
+		default:
+			return 0, false
+		}
+		switch {
+		case f < l:
+			return -1, true
+		case f > l:
+			return 1, true
+		default:
+			return 0, true
+		}
+	case string:
+		if v, ok := val.(string); ok {
+			return strings.Compare(v, l), true
+		}
+	}
+
+	return 0, false
+}
+
+// FilterHandler returns a Handler which acknowledges the messages not matching the filter without invoking handler.
+// If filter is nil, handler is returned.
+func FilterHandler(filter *Filter, handler Handler) Handler {
+	if filter == nil {
+		return handler
+	}
+
+	return func(ctx context.Context, msg *NewMessage) error {
+		if !filter.Match(msg) {
+			return nil
+		}
+		return handler(ctx, msg)
+	}
+}
+
+// FilterBulkHandler returns a BulkHandler which acknowledges the entries not matching the filter without passing them to handler.
+// If filter is nil, handler is returned.
+func FilterBulkHandler(filter *Filter, handler BulkHandler) BulkHandler {
+	if filter == nil {
+		return handler
+	}
+
+	return func(ctx context.Context, msg *BulkMessage) ([]BulkSubscribeResponseEntry, error) {
+		matched := *msg
+		matched.Entries = make([]BulkMessageEntry, 0, len(msg.Entries))
+		var skipped []BulkSubscribeResponseEntry
+		for _, entry := range msg.Entries {
+			if filter.match(newFilterMessage(entry.Event, mergeMetadata(msg.Metadata, entry.Metadata))) {
+				matched.Entries = append(matched.Entries, entry)
+			} else {
+				skipped = append(skipped, BulkSubscribeResponseEntry{EntryId: entry.EntryId})
+			}
+		}
+		if len(skipped) == 0 {
+			return handler(ctx, msg)
+		}
+		if len(matched.Entries) == 0 {
+			return skipped, nil
+		}
+
+		statuses, err := handler(ctx, &matched)
+		if statuses == nil && err != nil {
+			// The same error applies to all the entries passed to the handler
+			for _, entry := range matched.Entries {
+				statuses = append(statuses, BulkSubscribeResponseEntry{EntryId: entry.EntryId, Error: err})
+			}
+		}
+
+		return append(statuses, skipped...), err
+	}
+}
+
+// filteringPubSub wraps a PubSub to evaluate the subscription filters client-side.
+type filteringPubSub struct {
+	PubSub
+}
+
+// NewFilteringPubSub returns a PubSub which evaluates the filter set in the subscription metadata with metadata.FilterMetadataKey,
+// and acknowledges the messages not matching it without invoking the handler.
+// The subscription metadata is passed to the wrapped component, which can push the filter down to the broker:
+// the filter is still evaluated on the messages it delivers, as native filters may apply with a delay.
+func NewFilteringPubSub(pubsub PubSub) PubSub {
+	return &filteringPubSub{PubSub: pubsub}
+}
+
+func (f *filteringPubSub) Subscribe(ctx context.Context, req SubscribeRequest, handler Handler) error {
+	filter, err := SubscriptionFilter(req)
+	if err != nil {
+		return err
+	}
+
+	return f.PubSub.Subscribe(ctx, req, FilterHandler(filter, handler))
+}
+
+func (f *filteringPubSub) BulkSubscribe(ctx context.Context, req SubscribeRequest, handler BulkHandler) error {
+	subscriber, ok := f.PubSub.(BulkSubscriber)
+	if !ok {
+		return errors.New("bulk subscribe is not implemented by the wrapped pubsub")
+	}
+	filter, err := SubscriptionFilter(req)
+	if err != nil {
+		return err
+	}
+
+	return subscriber.BulkSubscribe(ctx, req, FilterBulkHandler(filter, handler))
+}
+
+// filterMessage gives access to the fields of a message, parsing its payload once.
+type filterMessage struct {
+	attributes map[string]interface{}
+	data       interface{}
+	hasData    bool
+	metadata   map[string]string
+}
+
+func newFilterMessage(payload []byte, md map[string]string) *filterMessage {
+	msg := &filterMessage{metadata: md}
+	if ce, ok := parseCloudEvent(payload); ok {
+		msg.attributes = ce
+		msg.data, msg.hasData = ce[DataField]
+		if s, ok := msg.data.(string); ok {
+			// The data of JSON events which is not valid JSON is stored as a string
+			var v interface{}
+			if unmarshalPrecise([]byte(s), &v) == nil {
+				msg.data = v
+			}
+		}
+	} else if len(payload) > 0 {
+		var v interface{}
+		if unmarshalPrecise(payload, &v) == nil {
+			msg.data, msg.hasData = v, true
+		}
+	}
+
+	return msg
+}
+
+func (m *filterMessage) lookup(field FilterField) (interface{}, bool) {
+	switch field.Scope {
+	case FilterScopeMetadata:
+		v, ok := m.metadata[field.Path[0]]
+		return v, ok
+	case FilterScopeAttribute:
+		if m.attributes == nil || field.Path[0] == DataField || field.Path[0] == DataBase64Field {
+			return nil, false
+		}
+		v, ok := m.attributes[field.Path[0]]
+		return v, ok
+	default:
+		if !m.hasData {
+			return nil, false
+		}
+		cur := m.data
+		for _, key := range field.Path {
+			obj, ok := cur.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if cur, ok = obj[key]; !ok {
+				return nil, false
+			}
+		}
+		return cur, true
+	}
+}
+
+// The parser of filter expressions.
+
+type filterTokenKind int
+
+const (
+	filterTokenEOF filterTokenKind = iota
+	filterTokenIdent
+	filterTokenString
+	filterTokenNumber
+	filterTokenOperator
+	filterTokenPunct
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+	pos  int
+}
+
+type filterParser struct {
+	input string
+	pos   int
+	tok   filterToken
+}
+
+func (p *filterParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid filter at position %d: %s", p.tok.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *filterParser) next() error {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+	start := p.pos
+	if p.pos >= len(p.input) {
+		p.tok = filterToken{kind: filterTokenEOF, pos: start}
+		return nil
+	}
+
+	c := p.input[p.pos]
+	switch {
+	case c == '\'' || c == '"':
+		var sb strings.Builder
+		p.pos++
+		for {
+			if p.pos >= len(p.input) {
+				p.tok = filterToken{pos: start}
+				return p.errorf("unterminated string")
+			}
+			ch := p.input[p.pos]
+			if ch == c {
+				p.pos++
+				break
+			}
+			if ch == '\\' && p.pos+1 < len(p.input) {
+				p.pos++
+				ch = p.input[p.pos]
+			}
+			sb.WriteByte(ch)
+			p.pos++
+		}
+		p.tok = filterToken{kind: filterTokenString, text: sb.String(), pos: start}
+	case c == '-' || (c >= '0' && c <= '9'):
+		p.pos++
+		for p.pos < len(p.input) && strings.IndexByte("0123456789.eE+-", p.input[p.pos]) >= 0 {
+			if (p.input[p.pos] == '+' || p.input[p.pos] == '-') && !strings.ContainsAny(p.input[p.pos-1:p.pos], "eE") {
+				break
+			}
+			p.pos++
+		}
+		p.tok = filterToken{kind: filterTokenNumber, text: p.input[start:p.pos], pos: start}
+	case c == '_' || unicode.IsLetter(rune(c)):
+		for p.pos < len(p.input) && isFilterIdentChar(p.input[p.pos]) {
+			p.pos++
+		}
+		p.tok = filterToken{kind: filterTokenIdent, text: p.input[start:p.pos], pos: start}
+	default:
+		for _, op := range []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!"} {
+			if strings.HasPrefix(p.input[p.pos:], op) {
+				p.pos += len(op)
+				p.tok = filterToken{kind: filterTokenOperator, text: op, pos: start}
+				return nil
+			}
+		}
+		if strings.IndexByte("(),", c) >= 0 {
+			p.pos++
+			p.tok = filterToken{kind: filterTokenPunct, text: string(c), pos: start}
+			return nil
+		}
+		p.tok = filterToken{pos: start}
+		return p.errorf("unexpected character %q", c)
+	}
+
+	return nil
+}
+
+func isFilterIdentChar(c byte) bool {
+	return c == '_' || c == '-' || c == '.' || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
+
+func (p *filterParser) is(kind filterTokenKind, text string) bool {
+	return p.tok.kind == kind && p.tok.text == text
+}
+
+func (p *filterParser) expect(kind filterTokenKind, text string) error {
+	if !p.is(kind, text) {
+		return p.errorf("expected %q", text)
+	}
+	return p.next()
+}
+
+func (p *filterParser) parseOr() (*Filter, error) {
+	f, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	if !p.is(filterTokenOperator, "||") {
+		return f, nil
+	}
+
+	or := &Filter{Or: []*Filter{f}}
+	for p.is(filterTokenOperator, "||") {
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+		if f, err = p.parseAnd(); err != nil {
+			return nil, err
+		}
+		or.Or = append(or.Or, f)
+	}
+
+	return or, nil
+}
+
+func (p *filterParser) parseAnd() (*Filter, error) {
+	f, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if !p.is(filterTokenOperator, "&&") {
+		return f, nil
+	}
+
+	and := &Filter{And: []*Filter{f}}
+	for p.is(filterTokenOperator, "&&") {
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+		if f, err = p.parseUnary(); err != nil {
+			return nil, err
+		}
+		and.And = append(and.And, f)
+	}
+
+	return and, nil
+}
+
+func (p *filterParser) parseUnary() (*Filter, error) {
+	switch {
+	case p.is(filterTokenOperator, "!"):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		f, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Filter{Not: f}, nil
+	case p.is(filterTokenPunct, "("):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return f, p.expect(filterTokenPunct, ")")
+	case p.is(filterTokenIdent, "exists"):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(filterTokenPunct, "("); err != nil {
+			return nil, err
+		}
+		field, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(filterTokenPunct, ")"); err != nil {
+			return nil, err
+		}
+		return &Filter{Condition: &FilterCondition{Field: field, Operator: FilterOpExists}}, nil
+	default:
+		return p.parseComparison()
+	}
+}
+
+func (p *filterParser) parseComparison() (*Filter, error) {
+	field, err := p.parseField()
+	if err != nil {
+		return nil, err
+	}
+
+	cond := &FilterCondition{Field: field}
+	switch {
+	case p.is(filterTokenIdent, "in"):
+		cond.Operator = FilterOpIn
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+		if err = p.expect(filterTokenPunct, "("); err != nil {
+			return nil, err
+		}
+		for {
+			v, err := p.parseLiteral()
+			if err != nil {
+				return nil, err
+			}
+			cond.Values = append(cond.Values, v)
+			if !p.is(filterTokenPunct, ",") {
+				break
+			}
+			if err = p.next(); err != nil {
+				return nil, err
+			}
+		}
+		if err = p.expect(filterTokenPunct, ")"); err != nil {
+			return nil, err
+		}
+	case p.tok.kind == filterTokenOperator && p.tok.text != "&&" && p.tok.text != "||" && p.tok.text != "!":
+		cond.Operator = FilterOperator(p.tok.text)
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+		v, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		cond.Values = []interface{}{v}
+	default:
+		return nil, p.errorf("expected a comparison operator")
+	}
+
+	return &Filter{Condition: cond}, nil
+}
+
+func (p *filterParser) parseField() (FilterField, error) {
+	if p.tok.kind != filterTokenIdent {
+		return FilterField{}, p.errorf("expected a field name")
+	}
+	name := p.tok.text
+	if err := p.next(); err != nil {
+		return FilterField{}, err
+	}
+
+	scope, rest, found := strings.Cut(name, ".")
+	switch {
+	case found && scope == string(FilterScopeData) && rest != "":
+		return FilterField{Scope: FilterScopeData, Path: strings.Split(rest, ".")}, nil
+	case found && scope == string(FilterScopeMetadata) && rest != "":
+		return FilterField{Scope: FilterScopeMetadata, Path: []string{rest}}, nil
+	case !found:
+		return FilterField{Scope: FilterScopeAttribute, Path: []string{name}}, nil
+	default:
+		return FilterField{}, fmt.Errorf("invalid filter field %q: only data fields and metadata keys can contain dots", name)
+	}
+}
+
+func (p *filterParser) parseLiteral() (interface{}, error) {
+	tok := p.tok
+	var v interface{}
+	switch {
+	case tok.kind == filterTokenString:
+		v = tok.text
+	case tok.kind == filterTokenNumber:
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", tok.text)
+		}
+		v = f
+	case tok.kind == filterTokenIdent && (tok.text == "true" || tok.text == "false"):
+		v = tok.text == "true"
+	case tok.kind == filterTokenIdent && tok.text == "null":
+		v = nil
+	default:
+		return nil, p.errorf("expected a literal value")
+	}
+
+	return v, p.next()
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JY29/components-contrib/metadata"
+)
+
+func TestParseFilter(t *testing.T) {
+	t.Run("conditions", func(t *testing.T) {
+		f, err := ParseFilter(`type == 'order.created' && data.customer.tier in ("gold", 'platinum') && metadata.x-tenant.id != 12 && exists(subject)`)
+		require.NoError(t, err)
+
+		conditions, ok := f.Conditions()
+		require.True(t, ok)
+		assert.Equal(t, []FilterCondition{
+			{Field: FilterField{Scope: FilterScopeAttribute, Path: []string{"type"}}, Operator: FilterOpEqual, Values: []interface{}{"order.created"}},
+			{Field: FilterField{Scope: FilterScopeData, Path: []string{"customer", "tier"}}, Operator: FilterOpIn, Values: []interface{}{"gold", "platinum"}},
+			{Field: FilterField{Scope: FilterScopeMetadata, Path: []string{"x-tenant.id"}}, Operator: FilterOpNotEqual, Values: []interface{}{float64(12)}},
+			{Field: FilterField{Scope: FilterScopeAttribute, Path: []string{"subject"}}, Operator: FilterOpExists},
+		}, conditions)
+	})
+
+	t.Run("precedence", func(t *testing.T) {
+		f, err := ParseFilter(`a == 1 || b == 2 && !(c == 3 || d == null)`)
+		require.NoError(t, err)
+		require.Len(t, f.Or, 2)
+		require.Len(t, f.Or[1].And, 2)
+		require.NotNil(t, f.Or[1].And[1].Not)
+		assert.Len(t, f.Or[1].And[1].Not.Or, 2)
+
+		_, ok := f.Conditions()
+		assert.False(t, ok)
+	})
+
+	t.Run("invalid expressions", func(t *testing.T) {
+		for _, expr := range []string{
+			``,
+			`type`,
+			`type ==`,
+			`type == 'a`,
+			`type == a`,
+			`(type == 'a'`,
+			`type == 'a' extra`,
+			`type in ()`,
+			`ce.type == 'a'`,
+			`type ~ 'a'`,
+			`exists(type`,
+		} {
+			_, err := ParseFilter(expr)
+			assert.Error(t, err, expr)
+		}
+	})
+}
+
+func TestFilterMatch(t *testing.T) {
+	ce, err := json.Marshal(NewCloudEventsEnvelope("1", "shop", "order.created", "", "orders", "ps", "application/json",
+		[]byte(`{"amount":150,"customer":{"tier":"gold"},"express":true}`), "", ""))
+	require.NoError(t, err)
+	msg := &NewMessage{Topic: "orders", Data: ce, Metadata: map[string]string{"region": "eu", "priority": "5"}}
+
+	tests := map[string]bool{
+		`type == 'order.created'`:                                  true,
+		`type != 'order.created'`:                                  false,
+		`source == "shop" && data.amount >= 150`:                   true,
+		`data.amount > 150`:                                        false,
+		`data.amount < 200 && data.amount <= 150`:                  true,
+		`data.customer.tier in ('silver', 'gold')`:                 true,
+		`data.customer.tier == 'platinum' || data.express == true`: true,
+		`data.customer.tier.name == 'gold'`:                        false,
+		`data.missing != 'x'`:                                      false,
+		`!(data.missing == 'x')`:                                   true,
+		`exists(data.customer.tier) && !exists(subject)`:           true,
+		`metadata.region == 'eu' && metadata.priority > 3`:         true,
+		`metadata.priority == 'high'`:                              false,
+		`metadata.missing == 'eu'`:                                 false,
+		`data == 'x'`:                                              false,
+		`data.amount == '150'`:                                     false,
+	}
+	for expr, expected := range tests {
+		f, err := ParseFilter(expr)
+		require.NoError(t, err, expr)
+		assert.Equal(t, expected, f.Match(msg), expr)
+	}
+
+	t.Run("raw JSON payloads", func(t *testing.T) {
+		f, err := ParseFilter(`data.amount == 10 && !exists(type)`)
+		require.NoError(t, err)
+		assert.True(t, f.Match(&NewMessage{Data: []byte(`{"amount":10,"type":"x"}`)}))
+		assert.False(t, f.Match(&NewMessage{Data: []byte(`not json`)}))
+	})
+}
+
+func TestFilteringPubSub(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("messages not matching are acknowledged without invoking the handler", func(t *testing.T) {
+		inner := &loopbackPubSub{}
+		ps := NewFilteringPubSub(inner)
+
+		var received []string
+		require.NoError(t, ps.Subscribe(ctx, SubscribeRequest{
+			Topic:    "orders",
+			Metadata: map[string]string{metadata.FilterMetadataKey: "data.amount >= 100"},
+		}, func(ctx context.Context, msg *NewMessage) error {
+			received = append(received, string(msg.Data))
+			return errors.New("handler failed")
+		}))
+
+		require.NoError(t, ps.Publish(ctx, &PublishRequest{Topic: "orders", Data: []byte(`{"amount":10}`)}))
+		require.NoError(t, ps.Publish(ctx, &PublishRequest{Topic: "orders", Data: []byte(`{"amount":100}`)}))
+
+		assert.Equal(t, []string{`{"amount":100}`}, received)
+		require.Len(t, inner.errs, 2)
+		assert.NoError(t, inner.errs[0])
+		assert.Error(t, inner.errs[1])
+	})
+
+	t.Run("invalid filters are rejected", func(t *testing.T) {
+		ps := NewFilteringPubSub(&loopbackPubSub{})
+		err := ps.Subscribe(ctx, SubscribeRequest{
+			Topic:    "orders",
+			Metadata: map[string]string{metadata.FilterMetadataKey: "data.amount >="},
+		}, func(ctx context.Context, msg *NewMessage) error { return nil })
+		assert.Error(t, err)
+	})
+
+	t.Run("bulk handler", func(t *testing.T) {
+		f, err := ParseFilter(`metadata.region == 'eu'`)
+		require.NoError(t, err)
+
+		var passed []string
+		handler := FilterBulkHandler(f, func(ctx context.Context, msg *BulkMessage) ([]BulkSubscribeResponseEntry, error) {
+			res := make([]BulkSubscribeResponseEntry, 0, len(msg.Entries))
+			for _, e := range msg.Entries {
+				passed = append(passed, e.EntryId)
+				res = append(res, BulkSubscribeResponseEntry{EntryId: e.EntryId})
+			}
+			return res, nil
+		})
+
+		res, err := handler(ctx, &BulkMessage{
+			Topic:    "orders",
+			Metadata: map[string]string{"region": "us"},
+			Entries: []BulkMessageEntry{
+				{EntryId: "1", Event: []byte(`{}`)},
+				{EntryId: "2", Event: []byte(`{}`), Metadata: map[string]string{"region": "eu"}},
+				{EntryId: "3", Event: []byte(`{}`)},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"2"}, passed)
+		assert.ElementsMatch(t, []BulkSubscribeResponseEntry{{EntryId: "1"}, {EntryId: "2"}, {EntryId: "3"}}, res)
+	})
+}
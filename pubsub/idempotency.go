@@ -0,0 +1,227 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/JY29/components-contrib/metadata"
+	"github.com/JY29/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	defaultIdempotencyKeyPrefix = "dapr-idempotency||"
+	defaultIdempotencyTTL       = 24 * time.Hour
+	defaultIdempotencyLease     = time.Minute
+
+	idempotencyStatusProcessing = "processing"
+	idempotencyStatusDone       = "done"
+)
+
+// ErrMessageInProgress is returned by idempotent handlers when the same message
+// is being processed by another consumer. The broker should redeliver it later.
+var ErrMessageInProgress = errors.New("message with the same idempotency key is being processed")
+
+// IdempotencyOptions configures how idempotent handlers detect duplicate messages.
+type IdempotencyOptions struct {
+	// KeyMetadataField is the message metadata field holding the idempotency key.
+	// If empty, the id of the CloudEvent carried by the message is used.
+	KeyMetadataField string
+	// KeyPrefix is prepended to the idempotency key to build the state key.
+	KeyPrefix string
+	// TTL is how long processed keys are remembered.
+	TTL time.Duration
+	// Lease is how long a message being processed blocks duplicates, in case the consumer crashes.
+	// It is not used with transactional handlers.
+	Lease time.Duration
+}
+
+// TransactionalHandler is a Handler which returns the state operations resulting from processing a message,
+// so that they can be committed in the same transaction that records the message as processed.
+type TransactionalHandler func(ctx context.Context, msg *NewMessage) ([]state.TransactionalStateOperation, error)
+
+type idempotencyChecker struct {
+	store  state.Store
+	opts   IdempotencyOptions
+	logger logger.Logger
+}
+
+func newIdempotencyChecker(store state.Store, opts IdempotencyOptions, logger logger.Logger) *idempotencyChecker {
+	if opts.KeyPrefix == "" {
+		opts.KeyPrefix = defaultIdempotencyKeyPrefix
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = defaultIdempotencyTTL
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = defaultIdempotencyLease
+	}
+
+	return &idempotencyChecker{
+		store:  store,
+		opts:   opts,
+		logger: logger,
+	}
+}
+
+// NewIdempotentHandler wraps a Handler so that messages whose idempotency key was already processed are
+// acknowledged without invoking it. Keys are claimed in the state store with first-write concurrency before
+// the handler runs, and released if the handler fails so that the message can be retried.
+func NewIdempotentHandler(handler Handler, store state.Store, opts IdempotencyOptions, logger logger.Logger) Handler {
+	c := newIdempotencyChecker(store, opts, logger)
+
+	return func(ctx context.Context, msg *NewMessage) error {
+		key, ok := c.key(msg)
+		if !ok {
+			c.logger.Debugf("no idempotency key found for message on topic %s, processing it without deduplication", msg.Topic)
+			return handler(ctx, msg)
+		}
+
+		err := c.store.Set(ctx, c.markerRequest(key, idempotencyStatusProcessing, c.opts.Lease))
+		if err != nil {
+			var etagErr *state.ETagError
+			if !errors.As(err, &etagErr) {
+				return fmt.Errorf("error claiming idempotency key %s: %w", key, err)
+			}
+
+			return c.duplicate(ctx, key)
+		}
+
+		if err = handler(ctx, msg); err != nil {
+			if delErr := c.store.Delete(ctx, &state.DeleteRequest{Key: key}); delErr != nil {
+				c.logger.Warnf("error releasing idempotency key %s: %v", key, delErr)
+			}
+			return err
+		}
+
+		// The key is owned by this consumer, so last-write is fine
+		req := c.markerRequest(key, idempotencyStatusDone, c.opts.TTL)
+		req.Options.Concurrency = state.LastWrite
+		if err = c.store.Set(ctx, req); err != nil {
+			return fmt.Errorf("error marking idempotency key %s as processed: %w", key, err)
+		}
+
+		return nil
+	}
+}
+
+// NewTransactionalIdempotentHandler wraps a TransactionalHandler so that the state operations it returns are
+// committed atomically with the record of the message being processed. Messages whose idempotency key was
+// already processed are acknowledged without invoking the handler. If a duplicate is processed concurrently,
+// only one of the transactions succeeds and the state changes of the other are discarded.
+func NewTransactionalIdempotentHandler(handler TransactionalHandler, store state.Store, opts IdempotencyOptions, logger logger.Logger) (Handler, error) {
+	transactionalStore, ok := store.(state.TransactionalStore)
+	if !ok || !state.FeatureTransactional.IsPresent(store.Features()) {
+		return nil, errors.New("the state store does not support transactions")
+	}
+
+	c := newIdempotencyChecker(store, opts, logger)
+
+	return func(ctx context.Context, msg *NewMessage) error {
+		key, ok := c.key(msg)
+		if !ok {
+			c.logger.Debugf("no idempotency key found for message on topic %s, processing it without deduplication", msg.Topic)
+			ops, err := handler(ctx, msg)
+			if err != nil || len(ops) == 0 {
+				return err
+			}
+			return transactionalStore.Multi(ctx, &state.TransactionalStateRequest{Operations: ops})
+		}
+
+		res, err := c.store.Get(ctx, &state.GetRequest{Key: key})
+		if err != nil {
+			return fmt.Errorf("error reading idempotency key %s: %w", key, err)
+		}
+		if res != nil && res.Data != nil {
+			c.logger.Debugf("skipping message with idempotency key %s which was already processed", key)
+			return nil
+		}
+
+		ops, err := handler(ctx, msg)
+		if err != nil {
+			return err
+		}
+
+		ops = append(ops, state.TransactionalStateOperation{
+			Operation: state.Upsert,
+			Request:   *c.markerRequest(key, idempotencyStatusDone, c.opts.TTL),
+		})
+		err = transactionalStore.Multi(ctx, &state.TransactionalStateRequest{Operations: ops})
+		if err != nil {
+			// The conflict may be on the operations of the handler: the changes are only discarded if a duplicate committed
+			var etagErr *state.ETagError
+			if errors.As(err, &etagErr) {
+				res, getErr := c.store.Get(ctx, &state.GetRequest{Key: key})
+				if getErr == nil && res != nil && string(res.Data) == idempotencyStatusDone {
+					c.logger.Debugf("message with idempotency key %s was processed concurrently, discarding its changes", key)
+					return nil
+				}
+			}
+			return fmt.Errorf("error committing message with idempotency key %s: %w", key, err)
+		}
+
+		return nil
+	}, nil
+}
+
+// key returns the state key used to track the message.
+func (c *idempotencyChecker) key(msg *NewMessage) (string, bool) {
+	var id string
+	if c.opts.KeyMetadataField != "" {
+		id = msg.Metadata[c.opts.KeyMetadataField]
+	} else if ce, err := FromCloudEvent(msg.Data, msg.Topic, "", "", ""); err == nil {
+		if v, ok := ce[IDField]; ok && v != nil {
+			id = fmt.Sprintf("%v", v)
+		}
+	}
+
+	if id == "" {
+		return "", false
+	}
+
+	return c.opts.KeyPrefix + msg.Topic + "||" + id, true
+}
+
+// markerRequest builds the first-write request recording the status of a message.
+func (c *idempotencyChecker) markerRequest(key, status string, ttl time.Duration) *state.SetRequest {
+	return &state.SetRequest{
+		Key:   key,
+		Value: []byte(status),
+		Metadata: map[string]string{
+			metadata.TTLMetadataKey: strconv.FormatInt(int64(ttl.Seconds()), 10),
+		},
+		Options: state.SetStateOption{
+			Concurrency: state.FirstWrite,
+		},
+	}
+}
+
+// duplicate handles a message whose key has already been claimed.
+func (c *idempotencyChecker) duplicate(ctx context.Context, key string) error {
+	res, err := c.store.Get(ctx, &state.GetRequest{Key: key})
+	if err != nil {
+		return fmt.Errorf("error reading idempotency key %s: %w", key, err)
+	}
+	if res != nil && string(res.Data) == idempotencyStatusProcessing {
+		return ErrMessageInProgress
+	}
+
+	c.logger.Debugf("skipping message with idempotency key %s which was already processed", key)
+	return nil
+}
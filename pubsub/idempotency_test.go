@@ -0,0 +1,185 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JY29/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+func TestIdempotentHandler(t *testing.T) {
+	log := logger.NewLogger("test")
+	ctx := context.Background()
+	event := []byte(`{"id": "event-1", "specversion": "1.0", "data": "hello"}`)
+
+	t.Run("duplicates are skipped", func(t *testing.T) {
+		calls := 0
+		handler := NewIdempotentHandler(func(ctx context.Context, msg *NewMessage) error {
+			calls++
+			return nil
+		}, newTestStateStore(t), IdempotencyOptions{}, log)
+
+		require.NoError(t, handler(ctx, &NewMessage{Topic: "a", Data: event}))
+		require.NoError(t, handler(ctx, &NewMessage{Topic: "a", Data: event}))
+		assert.Equal(t, 1, calls)
+
+		// The same id on another topic is a different message
+		require.NoError(t, handler(ctx, &NewMessage{Topic: "b", Data: event}))
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("failed messages can be retried", func(t *testing.T) {
+		calls := 0
+		handler := NewIdempotentHandler(func(ctx context.Context, msg *NewMessage) error {
+			calls++
+			if calls == 1 {
+				return errors.New("failed")
+			}
+			return nil
+		}, newTestStateStore(t), IdempotencyOptions{}, log)
+
+		require.Error(t, handler(ctx, &NewMessage{Topic: "a", Data: event}))
+		require.NoError(t, handler(ctx, &NewMessage{Topic: "a", Data: event}))
+		require.NoError(t, handler(ctx, &NewMessage{Topic: "a", Data: event}))
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("messages being processed are not processed twice", func(t *testing.T) {
+		var handler Handler
+		nestedErr := errors.New("not called")
+		handler = NewIdempotentHandler(func(ctx context.Context, msg *NewMessage) error {
+			// Simulate the broker redelivering the message while it is being processed
+			nestedErr = handler(ctx, msg)
+			return nil
+		}, newTestStateStore(t), IdempotencyOptions{}, log)
+
+		require.NoError(t, handler(ctx, &NewMessage{Topic: "a", Data: event}))
+		assert.ErrorIs(t, nestedErr, ErrMessageInProgress)
+	})
+
+	t.Run("key from metadata", func(t *testing.T) {
+		calls := 0
+		handler := NewIdempotentHandler(func(ctx context.Context, msg *NewMessage) error {
+			calls++
+			return nil
+		}, newTestStateStore(t), IdempotencyOptions{KeyMetadataField: "messageId"}, log)
+
+		require.NoError(t, handler(ctx, &NewMessage{Topic: "a", Data: []byte("raw"), Metadata: map[string]string{"messageId": "1"}}))
+		require.NoError(t, handler(ctx, &NewMessage{Topic: "a", Data: []byte("raw"), Metadata: map[string]string{"messageId": "1"}}))
+		require.NoError(t, handler(ctx, &NewMessage{Topic: "a", Data: []byte("raw"), Metadata: map[string]string{"messageId": "2"}}))
+		// Messages without a key are always processed
+		require.NoError(t, handler(ctx, &NewMessage{Topic: "a", Data: []byte("raw")}))
+		require.NoError(t, handler(ctx, &NewMessage{Topic: "a", Data: []byte("raw")}))
+		assert.Equal(t, 4, calls)
+	})
+}
+
+func TestTransactionalIdempotentHandler(t *testing.T) {
+	log := logger.NewLogger("test")
+	ctx := context.Background()
+	event := []byte(`{"id": "event-1", "specversion": "1.0", "data": "hello"}`)
+
+	t.Run("state changes are committed with the marker", func(t *testing.T) {
+		store := newTestStateStore(t)
+		calls := 0
+		handler, err := NewTransactionalIdempotentHandler(func(ctx context.Context, msg *NewMessage) ([]state.TransactionalStateOperation, error) {
+			calls++
+			return []state.TransactionalStateOperation{{
+				Operation: state.Upsert,
+				Request:   state.SetRequest{Key: "counter", Value: []byte("1")},
+			}}, nil
+		}, store, IdempotencyOptions{}, log)
+		require.NoError(t, err)
+
+		require.NoError(t, handler(ctx, &NewMessage{Topic: "a", Data: event}))
+		require.NoError(t, handler(ctx, &NewMessage{Topic: "a", Data: event}))
+		assert.Equal(t, 1, calls)
+
+		res, err := store.Get(ctx, &state.GetRequest{Key: "counter"})
+		require.NoError(t, err)
+		assert.Equal(t, "1", string(res.Data))
+	})
+
+	t.Run("state changes are discarded when the handler fails", func(t *testing.T) {
+		store := newTestStateStore(t)
+		handler, err := NewTransactionalIdempotentHandler(func(ctx context.Context, msg *NewMessage) ([]state.TransactionalStateOperation, error) {
+			return nil, errors.New("failed")
+		}, store, IdempotencyOptions{}, log)
+		require.NoError(t, err)
+
+		require.Error(t, handler(ctx, &NewMessage{Topic: "a", Data: event}))
+		res, err := store.Get(ctx, &state.GetRequest{Key: defaultIdempotencyKeyPrefix + "a||event-1"})
+		require.NoError(t, err)
+		assert.Nil(t, res.Data)
+	})
+
+	t.Run("duplicates processed concurrently are discarded", func(t *testing.T) {
+		store := newTestStateStore(t)
+		key := defaultIdempotencyKeyPrefix + "a||event-1"
+		handler, err := NewTransactionalIdempotentHandler(func(ctx context.Context, msg *NewMessage) ([]state.TransactionalStateOperation, error) {
+			// The duplicate commits while this message is processed
+			require.NoError(t, store.Set(ctx, &state.SetRequest{Key: key, Value: []byte(idempotencyStatusDone)}))
+			return []state.TransactionalStateOperation{{
+				Operation: state.Upsert,
+				Request:   state.SetRequest{Key: "counter", Value: []byte("2")},
+			}}, nil
+		}, store, IdempotencyOptions{}, log)
+		require.NoError(t, err)
+
+		require.NoError(t, handler(ctx, &NewMessage{Topic: "a", Data: event}))
+		res, err := store.Get(ctx, &state.GetRequest{Key: "counter"})
+		require.NoError(t, err)
+		assert.Nil(t, res.Data)
+	})
+
+	t.Run("conflicts on the state changes are not discarded", func(t *testing.T) {
+		store := newTestStateStore(t)
+		etag := "1"
+		handler, err := NewTransactionalIdempotentHandler(func(ctx context.Context, msg *NewMessage) ([]state.TransactionalStateOperation, error) {
+			return []state.TransactionalStateOperation{{
+				Operation: state.Upsert,
+				Request:   state.SetRequest{Key: "counter", Value: []byte("1"), ETag: &etag},
+			}}, nil
+		}, store, IdempotencyOptions{}, log)
+		require.NoError(t, err)
+
+		err = handler(ctx, &NewMessage{Topic: "a", Data: event})
+		var etagErr *state.ETagError
+		assert.ErrorAs(t, err, &etagErr)
+		res, err := store.Get(ctx, &state.GetRequest{Key: defaultIdempotencyKeyPrefix + "a||event-1"})
+		require.NoError(t, err)
+		assert.Nil(t, res.Data)
+	})
+
+	t.Run("requires a transactional store", func(t *testing.T) {
+		_, err := NewTransactionalIdempotentHandler(nil, nonTransactionalStore{newTestStateStore(t)}, IdempotencyOptions{}, log)
+		assert.Error(t, err)
+	})
+}
+
+// nonTransactionalStore hides the transactional capabilities of a store.
+type nonTransactionalStore struct {
+	state.Store
+}
+
+func (nonTransactionalStore) Features() []state.Feature {
+	return []state.Feature{state.FeatureETag}
+}
@@ -15,21 +15,36 @@ package inmemory
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/JY29/components-contrib/internal/eventbus"
+	contribMetadata "github.com/JY29/components-contrib/metadata"
 	"github.com/JY29/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 )
 
+// subscriptionQueueSize is the number of messages which can wait for the handler of a subscription before publishers are blocked.
+const subscriptionQueueSize = 100
+
 type bus struct {
 	bus eventbus.Bus
 	log logger.Logger
+
+	// Topics and subscriptions known to the TopicAdmin methods.
+	// Topics are also added when they are first published to.
+	adminLock     sync.Mutex
+	topics        map[string]pubsub.TopicDescription
+	subscriptions map[string]map[string]pubsub.SubscriptionDescription
 }
 
 func New(logger logger.Logger) pubsub.PubSub {
 	return &bus{
-		log: logger,
+		log:           logger,
+		topics:        make(map[string]pubsub.TopicDescription),
+		subscriptions: make(map[string]map[string]pubsub.SubscriptionDescription),
 	}
 }
 
@@ -38,7 +53,7 @@ func (a *bus) Close() error {
 }
 
 func (a *bus) Features() []pubsub.Feature {
-	return []pubsub.Feature{pubsub.FeatureSubscribeWildcards}
+	return []pubsub.Feature{pubsub.FeatureSubscribeWildcards, pubsub.FeatureMessagePriority}
 }
 
 func (a *bus) Init(metadata pubsub.Metadata) error {
@@ -48,7 +63,13 @@ func (a *bus) Init(metadata pubsub.Metadata) error {
 }
 
 func (a *bus) Publish(_ context.Context, req *pubsub.PublishRequest) error {
-	a.bus.Publish(req.Topic, req.Data)
+	priority, _, err := contribMetadata.TryGetPriority(req.Metadata)
+	if err != nil {
+		return err
+	}
+
+	a.addTopic(req.Topic)
+	a.bus.Publish(req.Topic, req.Data, priority)
 
 	return nil
 }
@@ -65,7 +86,27 @@ func (a *bus) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handle
 			time.Sleep(100 * time.Millisecond)
 		}
 	}
-	err := a.bus.SubscribeAsync(req.Topic, retryHandler, true)
+
+	// Messages wait in a queue while the handler is busy, and are handled by priority
+	// The bus identifies the handlers by their function pointer, so the same enqueue value must be used to unsubscribe.
+	queue := pubsub.NewPriorityQueue(subscriptionQueueSize)
+	enqueue := func(data []byte, priority uint8) {
+		// Push only fails once the subscription is canceled
+		if err := queue.Push(ctx, priority, data); err != nil {
+			a.log.Warnf("discarding message on topic %s: %v", req.Topic, err)
+		}
+	}
+	go func() {
+		for {
+			data, ok := queue.Pop(ctx)
+			if !ok {
+				return
+			}
+			retryHandler(data.([]byte))
+		}
+	}()
+
+	err := a.bus.SubscribeAsync(req.Topic, enqueue, true)
 	if err != nil {
 		return err
 	}
@@ -73,7 +114,7 @@ func (a *bus) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handle
 	// Unsubscribe when context is done
 	go func() {
 		<-ctx.Done()
-		err := a.bus.Unsubscribe(req.Topic, retryHandler)
+		err := a.bus.Unsubscribe(req.Topic, enqueue)
 		if err != nil {
 			a.log.Errorf("error while unsubscribing from topic %s: %v", req.Topic, err)
 		}
@@ -81,3 +122,127 @@ func (a *bus) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handle
 
 	return nil
 }
+
+func (a *bus) addTopic(topic string) {
+	a.adminLock.Lock()
+	defer a.adminLock.Unlock()
+
+	if _, ok := a.topics[topic]; !ok {
+		a.topics[topic] = pubsub.TopicDescription{Name: topic}
+	}
+}
+
+func (a *bus) CreateTopic(_ context.Context, req pubsub.CreateTopicRequest) error {
+	a.adminLock.Lock()
+	defer a.adminLock.Unlock()
+
+	if _, ok := a.topics[req.Topic]; !ok {
+		a.topics[req.Topic] = pubsub.TopicDescription{
+			Name:       req.Topic,
+			Partitions: req.Partitions,
+			Retention:  req.Retention,
+			Metadata:   req.Metadata,
+		}
+	}
+
+	return nil
+}
+
+func (a *bus) DeleteTopic(_ context.Context, topic string) error {
+	a.adminLock.Lock()
+	defer a.adminLock.Unlock()
+
+	if _, ok := a.topics[topic]; !ok {
+		return fmt.Errorf("%w: %s", pubsub.ErrTopicNotFound, topic)
+	}
+	delete(a.topics, topic)
+	delete(a.subscriptions, topic)
+
+	return nil
+}
+
+func (a *bus) ListTopics(_ context.Context) ([]string, error) {
+	a.adminLock.Lock()
+	defer a.adminLock.Unlock()
+
+	topics := make([]string, 0, len(a.topics))
+	for topic := range a.topics {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	return topics, nil
+}
+
+func (a *bus) DescribeTopic(_ context.Context, topic string) (*pubsub.TopicDescription, error) {
+	a.adminLock.Lock()
+	defer a.adminLock.Unlock()
+
+	desc, ok := a.topics[topic]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", pubsub.ErrTopicNotFound, topic)
+	}
+
+	return &desc, nil
+}
+
+func (a *bus) CreateSubscription(_ context.Context, req pubsub.CreateSubscriptionRequest) error {
+	a.adminLock.Lock()
+	defer a.adminLock.Unlock()
+
+	if _, ok := a.topics[req.Topic]; !ok {
+		return fmt.Errorf("%w: %s", pubsub.ErrTopicNotFound, req.Topic)
+	}
+	if a.subscriptions[req.Topic] == nil {
+		a.subscriptions[req.Topic] = make(map[string]pubsub.SubscriptionDescription)
+	}
+	if _, ok := a.subscriptions[req.Topic][req.Subscription]; !ok {
+		a.subscriptions[req.Topic][req.Subscription] = pubsub.SubscriptionDescription{
+			Name:     req.Subscription,
+			Topic:    req.Topic,
+			Metadata: req.Metadata,
+		}
+	}
+
+	return nil
+}
+
+func (a *bus) DeleteSubscription(_ context.Context, topic string, subscription string) error {
+	a.adminLock.Lock()
+	defer a.adminLock.Unlock()
+
+	if _, ok := a.subscriptions[topic][subscription]; !ok {
+		return fmt.Errorf("%w: %s", pubsub.ErrSubscriptionNotFound, subscription)
+	}
+	delete(a.subscriptions[topic], subscription)
+
+	return nil
+}
+
+func (a *bus) ListSubscriptions(_ context.Context, topic string) ([]string, error) {
+	a.adminLock.Lock()
+	defer a.adminLock.Unlock()
+
+	if _, ok := a.topics[topic]; !ok {
+		return nil, fmt.Errorf("%w: %s", pubsub.ErrTopicNotFound, topic)
+	}
+	subscriptions := make([]string, 0, len(a.subscriptions[topic]))
+	for name := range a.subscriptions[topic] {
+		subscriptions = append(subscriptions, name)
+	}
+	sort.Strings(subscriptions)
+
+	return subscriptions, nil
+}
+
+func (a *bus) DescribeSubscription(_ context.Context, topic string, subscription string) (*pubsub.SubscriptionDescription, error) {
+	a.adminLock.Lock()
+	defer a.adminLock.Unlock()
+
+	desc, ok := a.subscriptions[topic][subscription]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", pubsub.ErrSubscriptionNotFound, subscription)
+	}
+
+	return &desc, nil
+}
@@ -17,6 +17,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -107,3 +108,75 @@ func publish(ch chan []byte, msg *pubsub.NewMessage) error {
 
 	return nil
 }
+
+func TestTopicAdmin(t *testing.T) {
+	bus := New(logger.NewLogger("test"))
+	bus.Init(pubsub.Metadata{})
+	admin := bus.(pubsub.TopicAdmin)
+	ctx := context.Background()
+
+	err := admin.CreateTopic(ctx, pubsub.CreateTopicRequest{Topic: "orders", Partitions: 3, Retention: time.Hour})
+	assert.NoError(t, err)
+	bus.Publish(ctx, &pubsub.PublishRequest{Data: []byte("ABCD"), Topic: "demo"})
+
+	topics, err := admin.ListTopics(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"demo", "orders"}, topics)
+
+	desc, err := admin.DescribeTopic(ctx, "orders")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, desc.Partitions)
+	assert.Equal(t, time.Hour, desc.Retention)
+
+	assert.NoError(t, admin.CreateSubscription(ctx, pubsub.CreateSubscriptionRequest{Topic: "orders", Subscription: "app1"}))
+	assert.ErrorIs(t, admin.CreateSubscription(ctx, pubsub.CreateSubscriptionRequest{Topic: "missing", Subscription: "app1"}), pubsub.ErrTopicNotFound)
+	subscriptions, err := admin.ListSubscriptions(ctx, "orders")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app1"}, subscriptions)
+	sub, err := admin.DescribeSubscription(ctx, "orders", "app1")
+	assert.NoError(t, err)
+	assert.Equal(t, "orders", sub.Topic)
+
+	assert.NoError(t, admin.DeleteSubscription(ctx, "orders", "app1"))
+	assert.ErrorIs(t, admin.DeleteSubscription(ctx, "orders", "app1"), pubsub.ErrSubscriptionNotFound)
+	assert.NoError(t, admin.DeleteTopic(ctx, "orders"))
+	_, err = admin.DescribeTopic(ctx, "orders")
+	assert.ErrorIs(t, err, pubsub.ErrTopicNotFound)
+}
+
+func TestPriority(t *testing.T) {
+	ps := New(logger.NewLogger("test"))
+	ps.Init(pubsub.Metadata{})
+	assert.True(t, pubsub.FeatureMessagePriority.IsPresent(ps.Features()))
+
+	ch := make(chan []byte, 4)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	ps.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "demo"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		if string(msg.Data) == "first" {
+			close(started)
+			<-release
+		}
+		ch <- msg.Data
+		return nil
+	})
+
+	// The messages published while the handler is busy are handled by priority
+	ps.Publish(context.Background(), &pubsub.PublishRequest{Data: []byte("first"), Topic: "demo"})
+	<-started
+	for data, priority := range map[string]string{"low": "1", "none": "", "high": "10"} {
+		err := ps.Publish(context.Background(), &pubsub.PublishRequest{Data: []byte(data), Topic: "demo", Metadata: map[string]string{"priority": priority}})
+		assert.NoError(t, err)
+	}
+	ps.(*bus).bus.WaitAsync()
+	close(release)
+
+	var received []string
+	for i := 0; i < 4; i++ {
+		received = append(received, string(<-ch))
+	}
+	assert.Equal(t, []string{"first", "high", "low", "none"}, received)
+
+	err := ps.Publish(context.Background(), &pubsub.PublishRequest{Data: []byte("x"), Topic: "demo", Metadata: map[string]string{"priority": "high"}})
+	assert.Error(t, err)
+}
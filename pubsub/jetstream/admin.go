@@ -0,0 +1,197 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jetstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/JY29/components-contrib/pubsub"
+)
+
+// Topics are subjects, each captured by a stream, and subscriptions are the durable consumers filtering on them.
+
+const (
+	// Request metadata key with the name of the stream created for a topic. It defaults to the topic, with the characters not allowed in stream names replaced.
+	streamNameKey = "streamName"
+	// Request metadata key with the number of replicas of the stream created for a topic.
+	streamReplicasKey = "replicas"
+)
+
+var streamNameReplacer = strings.NewReplacer(".", "_", "*", "_", ">", "_", " ", "_")
+
+func (js *jetstreamPubSub) CreateTopic(_ context.Context, req pubsub.CreateTopicRequest) error {
+	if req.Partitions > 1 {
+		return fmt.Errorf("jetstream: partitions are %w", pubsub.ErrTopicAdminNotSupported)
+	}
+
+	_, err := js.jsc.StreamNameBySubject(req.Topic)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, nats.ErrNoMatchingStream) {
+		return err
+	}
+
+	cfg := &nats.StreamConfig{
+		Name:     streamNameReplacer.Replace(req.Topic),
+		Subjects: []string{req.Topic},
+		MaxAge:   req.Retention,
+	}
+	if v := req.Metadata[streamNameKey]; v != "" {
+		cfg.Name = v
+	}
+	if v := req.Metadata[streamReplicasKey]; v != "" {
+		if cfg.Replicas, err = strconv.Atoi(v); err != nil {
+			return fmt.Errorf("jetstream: invalid %s: %w", streamReplicasKey, err)
+		}
+	}
+	_, err = js.jsc.AddStream(cfg)
+
+	return err
+}
+
+func (js *jetstreamPubSub) DeleteTopic(_ context.Context, topic string) error {
+	streamName, err := js.streamNameForTopic(topic)
+	if err != nil {
+		return err
+	}
+
+	return js.jsc.DeleteStream(streamName)
+}
+
+// ListTopics returns the subjects of all the streams.
+func (js *jetstreamPubSub) ListTopics(_ context.Context) ([]string, error) {
+	var topics []string
+	for info := range js.jsc.Streams() {
+		topics = append(topics, info.Config.Subjects...)
+	}
+	sort.Strings(topics)
+
+	return topics, nil
+}
+
+func (js *jetstreamPubSub) DescribeTopic(_ context.Context, topic string) (*pubsub.TopicDescription, error) {
+	streamName, err := js.streamNameForTopic(topic)
+	if err != nil {
+		return nil, err
+	}
+	info, err := js.jsc.StreamInfo(streamName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pubsub.TopicDescription{
+		Name:      topic,
+		Retention: info.Config.MaxAge,
+		Metadata: map[string]string{
+			streamNameKey:     streamName,
+			streamReplicasKey: strconv.Itoa(info.Config.Replicas),
+			"messages":        strconv.FormatUint(info.State.Msgs, 10),
+			"bytes":           strconv.FormatUint(info.State.Bytes, 10),
+		},
+	}, nil
+}
+
+// CreateSubscription creates a durable pull consumer, configured from the component metadata like the consumers of Subscribe.
+func (js *jetstreamPubSub) CreateSubscription(_ context.Context, req pubsub.CreateSubscriptionRequest) error {
+	streamName, err := js.streamNameForTopic(req.Topic)
+	if err != nil {
+		return err
+	}
+	if _, err = js.jsc.ConsumerInfo(streamName, req.Subscription); err == nil {
+		return nil
+	}
+
+	consumerConfig := js.consumerConfig(req.Topic)
+	consumerConfig.Durable = req.Subscription
+	// These only apply to push consumers
+	consumerConfig.FlowControl = false
+	consumerConfig.Heartbeat = 0
+	consumerConfig.RateLimit = 0
+	_, err = js.jsc.AddConsumer(streamName, &consumerConfig)
+
+	return err
+}
+
+func (js *jetstreamPubSub) DeleteSubscription(_ context.Context, topic string, subscription string) error {
+	info, err := js.consumerInfo(topic, subscription)
+	if err != nil {
+		return err
+	}
+
+	return js.jsc.DeleteConsumer(info.Stream, subscription)
+}
+
+func (js *jetstreamPubSub) ListSubscriptions(_ context.Context, topic string) ([]string, error) {
+	streamName, err := js.streamNameForTopic(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions := []string{}
+	for info := range js.jsc.Consumers(streamName) {
+		if info.Config.Durable != "" && info.Config.FilterSubject == topic {
+			subscriptions = append(subscriptions, info.Name)
+		}
+	}
+	sort.Strings(subscriptions)
+
+	return subscriptions, nil
+}
+
+func (js *jetstreamPubSub) DescribeSubscription(_ context.Context, topic string, subscription string) (*pubsub.SubscriptionDescription, error) {
+	info, err := js.consumerInfo(topic, subscription)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pubsub.SubscriptionDescription{
+		Name:  subscription,
+		Topic: topic,
+		Metadata: map[string]string{
+			"pending":     strconv.FormatUint(info.NumPending, 10),
+			"ackPending":  strconv.Itoa(info.NumAckPending),
+			"redelivered": strconv.Itoa(info.NumRedelivered),
+		},
+	}, nil
+}
+
+func (js *jetstreamPubSub) consumerInfo(topic string, subscription string) (*nats.ConsumerInfo, error) {
+	streamName, err := js.streamNameForTopic(topic)
+	if err != nil {
+		return nil, err
+	}
+	info, err := js.jsc.ConsumerInfo(streamName, subscription)
+	if errors.Is(err, nats.ErrConsumerNotFound) || (err == nil && info.Config.FilterSubject != topic) {
+		return nil, fmt.Errorf("jetstream: %w: %s", pubsub.ErrSubscriptionNotFound, subscription)
+	}
+
+	return info, err
+}
+
+func (js *jetstreamPubSub) streamNameForTopic(topic string) (string, error) {
+	streamName, err := js.jsc.StreamNameBySubject(topic)
+	if errors.Is(err, nats.ErrNoMatchingStream) {
+		return "", fmt.Errorf("jetstream: %w: %s", pubsub.ErrTopicNotFound, topic)
+	}
+
+	return streamName, err
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jetstream
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/JY29/components-contrib/pubsub"
+)
+
+// Backlog reports the backlog of a durable consumer.
+// The oldest message is the first one of the stream after the ack floor of the consumer: when the stream
+// captures other subjects than the topic, it may not match the filter of the consumer and be older.
+func (js *jetstreamPubSub) Backlog(_ context.Context, topic string, subscription string) (*pubsub.Backlog, error) {
+	if subscription == "" {
+		subscription = js.meta.durableName
+	}
+
+	info, err := js.consumerInfo(topic, subscription)
+	if err != nil {
+		return nil, err
+	}
+	backlog := &pubsub.Backlog{
+		Topic:          topic,
+		Subscription:   subscription,
+		Pending:        int64(info.NumPending),
+		Unacknowledged: int64(info.NumAckPending),
+	}
+
+	if backlog.Pending > 0 || backlog.Unacknowledged > 0 {
+		msg, err := js.jsc.GetMsg(info.Stream, info.AckFloor.Stream+1)
+		switch {
+		case err == nil:
+			backlog.OldestMessageAge = time.Since(msg.Time)
+		case !errors.Is(err, nats.ErrMsgNotFound):
+			return nil, err
+		}
+	}
+
+	return backlog, nil
+}
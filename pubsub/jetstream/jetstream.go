@@ -15,6 +15,7 @@ package jetstream
 
 import (
 	"context"
+	"errors"
 
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nkeys"
@@ -126,52 +127,13 @@ func (js *jetstreamPubSub) Publish(ctx context.Context, req *pubsub.PublishReque
 }
 
 func (js *jetstreamPubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
-	var consumerConfig nats.ConsumerConfig
-
+	consumerConfig := js.consumerConfig(req.Topic)
 	consumerConfig.DeliverSubject = nats.NewInbox()
 
 	if v := js.meta.durableName; v != "" {
 		consumerConfig.Durable = v
 	}
 
-	if v := js.meta.startTime; !v.IsZero() {
-		consumerConfig.OptStartTime = &v
-	}
-	if v := js.meta.startSequence; v > 0 {
-		consumerConfig.OptStartSeq = v
-	}
-	consumerConfig.DeliverPolicy = js.meta.deliverPolicy
-	if js.meta.flowControl {
-		consumerConfig.FlowControl = true
-	}
-
-	if js.meta.ackWait != 0 {
-		consumerConfig.AckWait = js.meta.ackWait
-	}
-	if js.meta.maxDeliver != 0 {
-		consumerConfig.MaxDeliver = js.meta.maxDeliver
-	}
-	if len(js.meta.backOff) != 0 {
-		consumerConfig.BackOff = js.meta.backOff
-	}
-	if js.meta.maxAckPending != 0 {
-		consumerConfig.MaxAckPending = js.meta.maxAckPending
-	}
-	if js.meta.replicas != 0 {
-		consumerConfig.Replicas = js.meta.replicas
-	}
-	if js.meta.memoryStorage {
-		consumerConfig.MemoryStorage = true
-	}
-	if js.meta.rateLimit != 0 {
-		consumerConfig.RateLimit = js.meta.rateLimit
-	}
-	if js.meta.hearbeat != 0 {
-		consumerConfig.Heartbeat = js.meta.hearbeat
-	}
-	consumerConfig.AckPolicy = js.meta.ackPolicy
-	consumerConfig.FilterSubject = req.Topic
-
 	natsHandler := func(m *nats.Msg) {
 		jsm, err := m.Metadata()
 		if err != nil {
@@ -221,6 +183,17 @@ func (js *jetstreamPubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRe
 	}
 	var subscription *nats.Subscription
 
+	// The start position only applies when the consumer is created
+	position, err := pubsub.StartPosition(req)
+	if err != nil {
+		return err
+	}
+	if position != nil && !js.consumerExists(streamName, consumerConfig.Durable) {
+		if err = applyPosition(&consumerConfig, *position); err != nil {
+			return err
+		}
+	}
+
 	consumerInfo, err := js.jsc.AddConsumer(streamName, &consumerConfig)
 	if err != nil {
 		return err
@@ -249,6 +222,61 @@ func (js *jetstreamPubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRe
 	return nil
 }
 
+// consumerExists returns true if the durable consumer exists in the stream.
+func (js *jetstreamPubSub) consumerExists(streamName string, durable string) bool {
+	if durable == "" {
+		return false
+	}
+	_, err := js.jsc.ConsumerInfo(streamName, durable)
+
+	return !errors.Is(err, nats.ErrConsumerNotFound)
+}
+
+// consumerConfig returns the configuration of the consumers of a topic, from the component metadata.
+func (js *jetstreamPubSub) consumerConfig(topic string) nats.ConsumerConfig {
+	var consumerConfig nats.ConsumerConfig
+
+	if v := js.meta.startTime; !v.IsZero() {
+		consumerConfig.OptStartTime = &v
+	}
+	if v := js.meta.startSequence; v > 0 {
+		consumerConfig.OptStartSeq = v
+	}
+	consumerConfig.DeliverPolicy = js.meta.deliverPolicy
+	if js.meta.flowControl {
+		consumerConfig.FlowControl = true
+	}
+
+	if js.meta.ackWait != 0 {
+		consumerConfig.AckWait = js.meta.ackWait
+	}
+	if js.meta.maxDeliver != 0 {
+		consumerConfig.MaxDeliver = js.meta.maxDeliver
+	}
+	if len(js.meta.backOff) != 0 {
+		consumerConfig.BackOff = js.meta.backOff
+	}
+	if js.meta.maxAckPending != 0 {
+		consumerConfig.MaxAckPending = js.meta.maxAckPending
+	}
+	if js.meta.replicas != 0 {
+		consumerConfig.Replicas = js.meta.replicas
+	}
+	if js.meta.memoryStorage {
+		consumerConfig.MemoryStorage = true
+	}
+	if js.meta.rateLimit != 0 {
+		consumerConfig.RateLimit = js.meta.rateLimit
+	}
+	if js.meta.hearbeat != 0 {
+		consumerConfig.Heartbeat = js.meta.hearbeat
+	}
+	consumerConfig.AckPolicy = js.meta.ackPolicy
+	consumerConfig.FilterSubject = topic
+
+	return consumerConfig
+}
+
 func (js *jetstreamPubSub) Close() error {
 	return js.nc.Drain()
 }
@@ -0,0 +1,163 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jetstream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mdata "github.com/JY29/components-contrib/metadata"
+	"github.com/JY29/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+)
+
+func newTestJetStream(t *testing.T) pubsub.PubSub {
+	opts := natsserver.DefaultTestOptions
+	opts.Port = -1
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+	s := natsserver.RunServer(&opts)
+	t.Cleanup(s.Shutdown)
+
+	js := NewJetStream(logger.NewLogger("test"))
+	err := js.Init(pubsub.Metadata{Base: mdata.Base{
+		Properties: map[string]string{
+			"natsURL": s.ClientURL(),
+		},
+	}})
+	require.NoError(t, err)
+	t.Cleanup(func() { js.Close() })
+
+	return js
+}
+
+func TestTopicAdmin(t *testing.T) {
+	js := newTestJetStream(t)
+	admin := js.(pubsub.TopicAdmin)
+	ctx := context.Background()
+
+	require.NoError(t, admin.CreateTopic(ctx, pubsub.CreateTopicRequest{Topic: "orders.created", Retention: time.Hour}))
+	require.NoError(t, admin.CreateTopic(ctx, pubsub.CreateTopicRequest{Topic: "orders.created"}))
+	require.NoError(t, admin.CreateTopic(ctx, pubsub.CreateTopicRequest{Topic: "payments", Metadata: map[string]string{streamNameKey: "PAYMENTS"}}))
+	assert.ErrorIs(t, admin.CreateTopic(ctx, pubsub.CreateTopicRequest{Topic: "other", Partitions: 2}), pubsub.ErrTopicAdminNotSupported)
+	require.NoError(t, js.Publish(ctx, &pubsub.PublishRequest{Topic: "orders.created", Data: []byte("1")}))
+
+	topics, err := admin.ListTopics(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders.created", "payments"}, topics)
+
+	desc, err := admin.DescribeTopic(ctx, "orders.created")
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, desc.Retention)
+	assert.Equal(t, "orders_created", desc.Metadata[streamNameKey])
+	assert.Equal(t, "1", desc.Metadata["messages"])
+	_, err = admin.DescribeTopic(ctx, "missing")
+	assert.ErrorIs(t, err, pubsub.ErrTopicNotFound)
+
+	require.NoError(t, admin.CreateSubscription(ctx, pubsub.CreateSubscriptionRequest{Topic: "orders.created", Subscription: "app1"}))
+	require.NoError(t, admin.CreateSubscription(ctx, pubsub.CreateSubscriptionRequest{Topic: "orders.created", Subscription: "app1"}))
+	subscriptions, err := admin.ListSubscriptions(ctx, "orders.created")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app1"}, subscriptions)
+	sub, err := admin.DescribeSubscription(ctx, "orders.created", "app1")
+	require.NoError(t, err)
+	assert.Equal(t, "1", sub.Metadata["pending"])
+
+	require.NoError(t, admin.DeleteSubscription(ctx, "orders.created", "app1"))
+	assert.ErrorIs(t, admin.DeleteSubscription(ctx, "orders.created", "app1"), pubsub.ErrSubscriptionNotFound)
+	require.NoError(t, admin.DeleteTopic(ctx, "payments"))
+	assert.ErrorIs(t, admin.DeleteTopic(ctx, "payments"), pubsub.ErrTopicNotFound)
+}
+
+func TestBacklog(t *testing.T) {
+	js := newTestJetStream(t)
+	admin := js.(pubsub.TopicAdmin)
+	ctx := context.Background()
+
+	require.NoError(t, admin.CreateTopic(ctx, pubsub.CreateTopicRequest{Topic: "orders"}))
+	require.NoError(t, admin.CreateSubscription(ctx, pubsub.CreateSubscriptionRequest{Topic: "orders", Subscription: "app1"}))
+
+	backlog, err := pubsub.InspectBacklog(ctx, js, "orders", "app1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), backlog.Pending)
+	assert.Equal(t, time.Duration(0), backlog.OldestMessageAge)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, js.Publish(ctx, &pubsub.PublishRequest{Topic: "orders", Data: []byte("1")}))
+	}
+	time.Sleep(10 * time.Millisecond)
+	backlog, err = pubsub.InspectBacklog(ctx, js, "orders", "app1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), backlog.Pending)
+	assert.Greater(t, backlog.OldestMessageAge, time.Duration(0))
+
+	_, err = pubsub.InspectBacklog(ctx, js, "orders", "missing")
+	assert.ErrorIs(t, err, pubsub.ErrSubscriptionNotFound)
+}
+
+func TestSeek(t *testing.T) {
+	js := newTestJetStream(t)
+	ctx := context.Background()
+
+	require.NoError(t, js.(pubsub.TopicAdmin).CreateTopic(ctx, pubsub.CreateTopicRequest{Topic: "orders"}))
+	require.NoError(t, js.Publish(ctx, &pubsub.PublishRequest{Topic: "orders", Data: []byte("1")}))
+	time.Sleep(10 * time.Millisecond)
+	ts := time.Now()
+	require.NoError(t, js.Publish(ctx, &pubsub.PublishRequest{Topic: "orders", Data: []byte("2")}))
+	require.NoError(t, js.Publish(ctx, &pubsub.PublishRequest{Topic: "orders", Data: []byte("3")}))
+	require.NoError(t, js.(pubsub.TopicAdmin).CreateSubscription(ctx, pubsub.CreateSubscriptionRequest{Topic: "orders", Subscription: "app1"}))
+
+	tests := []struct {
+		position pubsub.Position
+		pending  int64
+	}{
+		{pubsub.Position{Type: pubsub.PositionLatest}, 0},
+		{pubsub.Position{Type: pubsub.PositionEarliest}, 3},
+		{pubsub.Position{Type: pubsub.PositionTimestamp, Timestamp: ts}, 2},
+		{pubsub.Position{Type: pubsub.PositionOffset, Offset: "3"}, 1},
+	}
+	for _, tt := range tests {
+		require.NoError(t, pubsub.Seek(ctx, js, "orders", "app1", tt.position))
+		backlog, err := pubsub.InspectBacklog(ctx, js, "orders", "app1")
+		require.NoError(t, err)
+		assert.Equal(t, tt.pending, backlog.Pending, tt.position.Type)
+	}
+
+	assert.Error(t, pubsub.Seek(ctx, js, "orders", "app1", pubsub.Position{Type: pubsub.PositionOffset, Offset: "abc"}))
+	assert.ErrorIs(t, pubsub.Seek(ctx, js, "orders", "missing", pubsub.Position{Type: pubsub.PositionLatest}), pubsub.ErrSubscriptionNotFound)
+
+	t.Run("subscribe with start position", func(t *testing.T) {
+		received := make(chan string, 3)
+		err := js.Subscribe(ctx, pubsub.SubscribeRequest{
+			Topic:    "orders",
+			Metadata: map[string]string{mdata.StartPositionMetadataKey: "3"},
+		}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+			received <- string(msg.Data)
+			return nil
+		})
+		require.NoError(t, err)
+
+		select {
+		case data := <-received:
+			assert.Equal(t, "3", data)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for the message")
+		}
+	})
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jetstream
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/JY29/components-contrib/pubsub"
+)
+
+// Seek recreates a durable consumer with the deliver policy of the position, and otherwise the same configuration.
+// The deliver subject is kept, so the subscriptions bound to a push consumer keep receiving messages.
+// The pending acknowledgements of the consumer are lost.
+func (js *jetstreamPubSub) Seek(_ context.Context, topic string, subscription string, position pubsub.Position) error {
+	if subscription == "" {
+		subscription = js.meta.durableName
+	}
+
+	info, err := js.consumerInfo(topic, subscription)
+	if err != nil {
+		return err
+	}
+	consumerConfig := info.Config
+	if err = applyPosition(&consumerConfig, position); err != nil {
+		return err
+	}
+
+	if err = js.jsc.DeleteConsumer(info.Stream, subscription); err != nil {
+		return fmt.Errorf("jetstream: error deleting consumer %s: %w", subscription, err)
+	}
+	if _, err = js.jsc.AddConsumer(info.Stream, &consumerConfig); err != nil {
+		return fmt.Errorf("jetstream: error creating consumer %s: %w", subscription, err)
+	}
+
+	return nil
+}
+
+// applyPosition sets the deliver policy of a consumer configuration to start at position.
+func applyPosition(consumerConfig *nats.ConsumerConfig, position pubsub.Position) error {
+	consumerConfig.OptStartTime = nil
+	consumerConfig.OptStartSeq = 0
+
+	switch position.Type {
+	case pubsub.PositionEarliest:
+		consumerConfig.DeliverPolicy = nats.DeliverAllPolicy
+	case pubsub.PositionLatest:
+		consumerConfig.DeliverPolicy = nats.DeliverNewPolicy
+	case pubsub.PositionTimestamp:
+		startTime := position.Timestamp
+		consumerConfig.DeliverPolicy = nats.DeliverByStartTimePolicy
+		consumerConfig.OptStartTime = &startTime
+	case pubsub.PositionOffset:
+		seq, err := strconv.ParseUint(position.Offset, 10, 64)
+		if err != nil || seq == 0 {
+			return fmt.Errorf("jetstream: invalid stream sequence %q", position.Offset)
+		}
+		consumerConfig.DeliverPolicy = nats.DeliverByStartSequencePolicy
+		consumerConfig.OptStartSeq = seq
+	default:
+		return fmt.Errorf("jetstream: unsupported position type %q", position.Type)
+	}
+
+	return nil
+}
@@ -41,6 +41,7 @@ func (p *PubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, han
 	handlerConfig := kafka.SubscriptionHandlerConfig{
 		IsBulkSubscribe: false,
 		Handler:         adaptHandler(handler),
+		ValueSchemaType: req.Metadata[kafka.ValueSchemaType],
 	}
 	return p.subscribeUtil(ctx, req, handlerConfig)
 }
@@ -58,11 +59,23 @@ func (p *PubSub) BulkSubscribe(ctx context.Context, req pubsub.SubscribeRequest,
 		IsBulkSubscribe: true,
 		SubscribeConfig: subConfig,
 		BulkHandler:     adaptBulkHandler(handler),
+		ValueSchemaType: req.Metadata[kafka.ValueSchemaType],
 	}
 	return p.subscribeUtil(ctx, req, handlerConfig)
 }
 
 func (p *PubSub) subscribeUtil(ctx context.Context, req pubsub.SubscribeRequest, handlerConfig kafka.SubscriptionHandlerConfig) error {
+	// The start position only applies to the partitions the consumer group has not committed offsets on yet
+	position, err := pubsub.StartPosition(req)
+	if err != nil {
+		return err
+	}
+	if position != nil {
+		if err = p.kafka.ApplyStartPosition(req.Topic, *position); err != nil {
+			return err
+		}
+	}
+
 	p.kafka.AddTopicHandler(req.Topic, handlerConfig)
 
 	go func() {
@@ -150,3 +163,43 @@ func adaptBulkHandler(handler pubsub.BulkHandler) kafka.BulkEventHandler {
 		})
 	}
 }
+
+func (p *PubSub) CreateTopic(ctx context.Context, req pubsub.CreateTopicRequest) error {
+	return p.kafka.CreateTopic(ctx, req)
+}
+
+func (p *PubSub) DeleteTopic(ctx context.Context, topic string) error {
+	return p.kafka.DeleteTopic(ctx, topic)
+}
+
+func (p *PubSub) ListTopics(ctx context.Context) ([]string, error) {
+	return p.kafka.ListTopics(ctx)
+}
+
+func (p *PubSub) DescribeTopic(ctx context.Context, topic string) (*pubsub.TopicDescription, error) {
+	return p.kafka.DescribeTopic(ctx, topic)
+}
+
+func (p *PubSub) CreateSubscription(ctx context.Context, req pubsub.CreateSubscriptionRequest) error {
+	return p.kafka.CreateSubscription(ctx, req)
+}
+
+func (p *PubSub) DeleteSubscription(ctx context.Context, topic string, subscription string) error {
+	return p.kafka.DeleteSubscription(ctx, topic, subscription)
+}
+
+func (p *PubSub) ListSubscriptions(ctx context.Context, topic string) ([]string, error) {
+	return p.kafka.ListSubscriptions(ctx, topic)
+}
+
+func (p *PubSub) DescribeSubscription(ctx context.Context, topic string, subscription string) (*pubsub.SubscriptionDescription, error) {
+	return p.kafka.DescribeSubscription(ctx, topic, subscription)
+}
+
+func (p *PubSub) Backlog(ctx context.Context, topic string, subscription string) (*pubsub.Backlog, error) {
+	return p.kafka.Backlog(ctx, topic, subscription)
+}
+
+func (p *PubSub) Seek(ctx context.Context, topic string, subscription string, position pubsub.Position) error {
+	return p.kafka.Seek(ctx, topic, subscription, position)
+}
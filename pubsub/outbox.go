@@ -0,0 +1,185 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/JY29/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	defaultOutboxPollInterval = time.Second
+	defaultOutboxBatchSize    = 100
+)
+
+// OutboxRelayOptions configures an OutboxRelay.
+type OutboxRelayOptions struct {
+	// PollInterval is how often the outbox is checked for pending messages.
+	PollInterval time.Duration
+	// BatchSize is the maximum number of messages read from the outbox at once. Each poll reads the whole outbox in batches.
+	BatchSize int
+}
+
+// PubSubResolver returns the PubSub component with the given name.
+type PubSubResolver func(name string) (PubSub, bool)
+
+// OutboxRelay publishes the outbox messages committed by the Multi operations of a state.OutboxStore.
+// Messages of the same partition are published in the order they were committed: when one fails,
+// the following ones in its partition are held back until it is retried at the next poll.
+// Messages are deleted from the outbox once published, so delivery is at-least-once.
+type OutboxRelay struct {
+	store    state.OutboxStore
+	resolver PubSubResolver
+	opts     OutboxRelayOptions
+	logger   logger.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewOutboxRelay returns a relay for the outbox of the given state store, which must implement state.OutboxStore.
+func NewOutboxRelay(store state.Store, resolver PubSubResolver, opts OutboxRelayOptions, logger logger.Logger) (*OutboxRelay, error) {
+	outboxStore, ok := store.(state.OutboxStore)
+	if !ok || !state.FeatureTransactional.IsPresent(store.Features()) {
+		return nil, errors.New("the state store does not support the transactional outbox")
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultOutboxPollInterval
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultOutboxBatchSize
+	}
+
+	return &OutboxRelay{
+		store:    outboxStore,
+		resolver: resolver,
+		opts:     opts,
+		logger:   logger,
+	}, nil
+}
+
+// Start starts relaying messages in background until Close is called.
+func (o *OutboxRelay) Start() {
+	o.ctx, o.cancel = context.WithCancel(context.Background())
+	o.wg.Add(1)
+	go o.relayLoop()
+}
+
+// Close stops the relay.
+func (o *OutboxRelay) Close() error {
+	if o.cancel != nil {
+		o.cancel()
+		o.wg.Wait()
+	}
+
+	return nil
+}
+
+func (o *OutboxRelay) relayLoop() {
+	defer o.wg.Done()
+
+	ticker := time.NewTicker(o.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-o.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := o.relay(o.ctx); err != nil && o.ctx.Err() == nil {
+				o.logger.Errorf("error relaying outbox messages: %v", err)
+			}
+		}
+	}
+}
+
+// relay publishes the pending messages, reading the outbox in batches.
+// The batches continue after the keys of the blocked partitions, so that they don't starve the other partitions.
+func (o *OutboxRelay) relay(ctx context.Context) error {
+	blocked := map[string]bool{}
+	after := ""
+	for {
+		entries, err := o.store.ListOutbox(ctx, after, o.opts.BatchSize)
+		if err != nil {
+			return fmt.Errorf("error listing the outbox: %w", err)
+		}
+
+		for _, entry := range entries {
+			partition := entry.Message.Partition
+			if blocked[partition] {
+				continue
+			}
+
+			if err = o.publish(ctx, entry); err != nil {
+				o.logger.Warnf("error relaying outbox message %s, it will be retried: %v", entry.Key, err)
+				blocked[partition] = true
+			}
+		}
+
+		if len(entries) < o.opts.BatchSize {
+			return nil
+		}
+		last := entries[len(entries)-1]
+		after = last.Key
+		if blocked[last.Message.Partition] {
+			after = state.OutboxPartitionEnd(last.Message.Partition)
+		}
+	}
+}
+
+func (o *OutboxRelay) publish(ctx context.Context, entry state.OutboxEntry) error {
+	msg := entry.Message
+	ps, ok := o.resolver(msg.PubsubName)
+	if !ok {
+		return fmt.Errorf("pubsub %s not found", msg.PubsubName)
+	}
+
+	req := &PublishRequest{
+		Data:       msg.Data,
+		PubsubName: msg.PubsubName,
+		Topic:      msg.Topic,
+		Metadata:   msg.Metadata,
+	}
+	if msg.ContentType != "" {
+		req.ContentType = &msg.ContentType
+	}
+	if err := ps.Publish(ctx, req); err != nil {
+		return err
+	}
+
+	err := o.store.Delete(ctx, &state.DeleteRequest{
+		Key:  entry.Key,
+		ETag: entry.ETag,
+		Options: state.DeleteStateOption{
+			Concurrency: state.FirstWrite,
+		},
+	})
+	if err != nil {
+		var etagErr *state.ETagError
+		if errors.As(err, &etagErr) {
+			// Another relay published the message concurrently
+			return nil
+		}
+		return fmt.Errorf("error removing the message from the outbox: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JY29/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+func TestOutboxRelay(t *testing.T) {
+	log := logger.NewLogger("test")
+	ctx := context.Background()
+
+	commit := func(t *testing.T, store state.Store, msgs ...state.OutboxMessage) {
+		err := store.(state.TransactionalStore).Multi(ctx, &state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{{
+				Operation: state.Upsert,
+				Request:   state.SetRequest{Key: "order", Value: []byte("1")},
+			}},
+			Outbox: msgs,
+		})
+		require.NoError(t, err)
+	}
+
+	pending := func(t *testing.T, store state.Store) int {
+		entries, err := store.(state.OutboxStore).ListOutbox(ctx, "", 100)
+		require.NoError(t, err)
+		return len(entries)
+	}
+
+	t.Run("messages are published in order and removed", func(t *testing.T) {
+		store := newTestStateStore(t)
+		ps := &recordingPubSub{}
+		relay, err := NewOutboxRelay(store, func(name string) (PubSub, bool) {
+			return ps, name == "pubsub"
+		}, OutboxRelayOptions{}, log)
+		require.NoError(t, err)
+
+		commit(t, store,
+			state.OutboxMessage{PubsubName: "pubsub", Topic: "orders", Data: []byte("1"), ContentType: "text/plain"},
+			state.OutboxMessage{PubsubName: "pubsub", Topic: "orders", Data: []byte("2"), Metadata: map[string]string{"foo": "bar"}},
+		)
+
+		require.NoError(t, relay.relay(ctx))
+		require.Equal(t, 2, ps.count())
+		assert.Equal(t, "1", string(ps.published[0].Data))
+		assert.Equal(t, "text/plain", *ps.published[0].ContentType)
+		assert.Equal(t, "2", string(ps.published[1].Data))
+		assert.Equal(t, "bar", ps.published[1].Metadata["foo"])
+		assert.Equal(t, 0, pending(t, store))
+	})
+
+	t.Run("a failure holds back the rest of its partition", func(t *testing.T) {
+		store := newTestStateStore(t)
+		ps := &recordingPubSub{failAfter: 1}
+		relay, err := NewOutboxRelay(store, func(name string) (PubSub, bool) {
+			return ps, true
+		}, OutboxRelayOptions{}, log)
+		require.NoError(t, err)
+
+		commit(t, store,
+			state.OutboxMessage{PubsubName: "pubsub", Topic: "orders", Data: []byte("1")},
+			state.OutboxMessage{PubsubName: "pubsub", Topic: "orders", Data: []byte("2")},
+			state.OutboxMessage{PubsubName: "pubsub", Topic: "orders", Data: []byte("3")},
+		)
+
+		require.NoError(t, relay.relay(ctx))
+		assert.Equal(t, 1, ps.count())
+		assert.Equal(t, 2, pending(t, store))
+
+		// The messages are published in order once the pubsub recovers
+		ps.failAfter = 0
+		require.NoError(t, relay.relay(ctx))
+		require.Equal(t, 3, ps.count())
+		assert.Equal(t, "2", string(ps.published[1].Data))
+		assert.Equal(t, "3", string(ps.published[2].Data))
+		assert.Equal(t, 0, pending(t, store))
+	})
+
+	t.Run("partitions are independent", func(t *testing.T) {
+		store := newTestStateStore(t)
+		ps := &recordingPubSub{}
+		relay, err := NewOutboxRelay(store, func(name string) (PubSub, bool) {
+			return ps, name == "pubsub"
+		}, OutboxRelayOptions{}, log)
+		require.NoError(t, err)
+
+		commit(t, store,
+			state.OutboxMessage{PubsubName: "missing", Topic: "orders", Data: []byte("1"), Partition: "a"},
+			state.OutboxMessage{PubsubName: "pubsub", Topic: "orders", Data: []byte("2"), Partition: "a"},
+			state.OutboxMessage{PubsubName: "pubsub", Topic: "orders", Data: []byte("3"), Partition: "b"},
+		)
+
+		require.NoError(t, relay.relay(ctx))
+		require.Equal(t, 1, ps.count())
+		assert.Equal(t, "3", string(ps.published[0].Data))
+		assert.Equal(t, 2, pending(t, store))
+	})
+
+	t.Run("blocked partitions larger than a batch don't starve the others", func(t *testing.T) {
+		store := newTestStateStore(t)
+		ps := &recordingPubSub{}
+		relay, err := NewOutboxRelay(store, func(name string) (PubSub, bool) {
+			return ps, name == "pubsub"
+		}, OutboxRelayOptions{BatchSize: 2}, log)
+		require.NoError(t, err)
+
+		commit(t, store,
+			state.OutboxMessage{PubsubName: "missing", Topic: "orders", Data: []byte("1"), Partition: "a"},
+			state.OutboxMessage{PubsubName: "pubsub", Topic: "orders", Data: []byte("2"), Partition: "a"},
+			state.OutboxMessage{PubsubName: "pubsub", Topic: "orders", Data: []byte("3"), Partition: "a"},
+			state.OutboxMessage{PubsubName: "pubsub", Topic: "orders", Data: []byte("4"), Partition: "b"},
+			state.OutboxMessage{PubsubName: "pubsub", Topic: "orders", Data: []byte("5"), Partition: "c"},
+		)
+
+		require.NoError(t, relay.relay(ctx))
+		require.Equal(t, 2, ps.count())
+		assert.Equal(t, "4", string(ps.published[0].Data))
+		assert.Equal(t, "5", string(ps.published[1].Data))
+		assert.Equal(t, 3, pending(t, store))
+	})
+
+	t.Run("requires an outbox store", func(t *testing.T) {
+		_, err := NewOutboxRelay(nonTransactionalStore{newTestStateStore(t)}, nil, OutboxRelayOptions{}, log)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"math"
+	"sync"
+)
+
+// PriorityQueue is a bounded queue with a lane per priority, from which items are popped by decreasing priority,
+// and in the order they were pushed within a priority.
+// Components without native priorities use it between the goroutines receiving the messages and the workers handling them,
+// so that the messages with a higher priority are handled first among the messages waiting for a worker.
+type PriorityQueue struct {
+	lock  sync.Mutex
+	lanes [math.MaxUint8 + 1][]interface{}
+
+	// slots has an element for each item in the queue, and items is signaled when an item is pushed.
+	slots chan struct{}
+	items chan struct{}
+}
+
+// NewPriorityQueue returns a queue holding up to capacity items.
+func NewPriorityQueue(capacity int) *PriorityQueue {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &PriorityQueue{
+		slots: make(chan struct{}, capacity),
+		items: make(chan struct{}, capacity),
+	}
+}
+
+// Push adds an item to the lane of its priority, blocking while the queue is full.
+func (q *PriorityQueue) Push(ctx context.Context, priority uint8, item interface{}) error {
+	select {
+	case q.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	q.lock.Lock()
+	q.lanes[priority] = append(q.lanes[priority], item)
+	q.lock.Unlock()
+	q.items <- struct{}{}
+
+	return nil
+}
+
+// Pop removes the first item of the lane with the highest priority, blocking while the queue is empty.
+// It returns false if ctx is canceled first.
+func (q *PriorityQueue) Pop(ctx context.Context) (interface{}, bool) {
+	select {
+	case <-q.items:
+	case <-ctx.Done():
+		return nil, false
+	}
+
+	q.lock.Lock()
+	var item interface{}
+	for p := len(q.lanes) - 1; p >= 0; p-- {
+		lane := q.lanes[p]
+		if len(lane) == 0 {
+			continue
+		}
+		item = lane[0]
+		lane[0] = nil
+		q.lanes[p] = lane[1:]
+		if len(q.lanes[p]) == 0 {
+			q.lanes[p] = nil
+		}
+		break
+	}
+	q.lock.Unlock()
+	<-q.slots
+
+	return item, true
+}
+
+// Len returns the number of items in the queue.
+func (q *PriorityQueue) Len() int {
+	return len(q.slots)
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriorityQueue(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("items are popped by priority, then in order", func(t *testing.T) {
+		q := NewPriorityQueue(10)
+		for i, p := range []uint8{0, 5, 0, 255, 5} {
+			require.NoError(t, q.Push(ctx, p, i))
+		}
+		assert.Equal(t, 5, q.Len())
+
+		var popped []interface{}
+		for q.Len() > 0 {
+			item, ok := q.Pop(ctx)
+			require.True(t, ok)
+			popped = append(popped, item)
+		}
+		assert.Equal(t, []interface{}{3, 1, 4, 0, 2}, popped)
+	})
+
+	t.Run("push blocks while the queue is full", func(t *testing.T) {
+		q := NewPriorityQueue(1)
+		require.NoError(t, q.Push(ctx, 0, "a"))
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, q.Push(timeoutCtx, 0, "b"), context.DeadlineExceeded)
+
+		pushed := make(chan error)
+		go func() { pushed <- q.Push(ctx, 1, "c") }()
+		item, ok := q.Pop(ctx)
+		require.True(t, ok)
+		assert.Equal(t, "a", item)
+		require.NoError(t, <-pushed)
+		item, _ = q.Pop(ctx)
+		assert.Equal(t, "c", item)
+	})
+
+	t.Run("pop returns when the context is canceled", func(t *testing.T) {
+		q := NewPriorityQueue(1)
+		canceledCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		_, ok := q.Pop(canceledCtx)
+		assert.False(t, ok)
+	})
+}
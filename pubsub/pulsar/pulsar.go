@@ -268,6 +268,17 @@ func (p *Pulsar) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, han
 		NackRedeliveryDelay: p.metadata.RedeliveryDelay,
 	}
 
+	position, err := pubsub.StartPosition(req)
+	if err != nil {
+		return err
+	}
+	if position != nil {
+		options.SubscriptionInitialPosition, err = initialPosition(*position)
+		if err != nil {
+			return err
+		}
+	}
+
 	consumer, err := p.client.Subscribe(options)
 	if err != nil {
 		p.logger.Debugf("Could not subscribe to %s, full topic name in pulsar is %s", req.Topic, topic)
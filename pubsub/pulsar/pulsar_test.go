@@ -14,9 +14,11 @@ limitations under the License.
 package pulsar
 
 import (
+	"encoding/base64"
 	"testing"
 	"time"
 
+	"github.com/apache/pulsar-client-go/pulsar"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/JY29/components-contrib/pubsub"
@@ -120,3 +122,32 @@ func TestValidTenantAndNS(t *testing.T) {
 		assert.Equal(t, expectNonPersistentResult, res)
 	})
 }
+
+func TestStartPosition(t *testing.T) {
+	t.Run("initial position", func(t *testing.T) {
+		pos, err := initialPosition(pubsub.Position{Type: pubsub.PositionEarliest})
+		assert.NoError(t, err)
+		assert.Equal(t, pulsar.SubscriptionPositionEarliest, pos)
+
+		pos, err = initialPosition(pubsub.Position{Type: pubsub.PositionLatest})
+		assert.NoError(t, err)
+		assert.Equal(t, pulsar.SubscriptionPositionLatest, pos)
+
+		_, err = initialPosition(pubsub.Position{Type: pubsub.PositionTimestamp, Timestamp: time.Now()})
+		assert.Error(t, err)
+	})
+
+	t.Run("seek message ID", func(t *testing.T) {
+		msgID, err := seekMessageID(pubsub.Position{Type: pubsub.PositionEarliest})
+		assert.NoError(t, err)
+		assert.Equal(t, pulsar.EarliestMessageID().Serialize(), msgID.Serialize())
+
+		offset := base64.StdEncoding.EncodeToString(pulsar.LatestMessageID().Serialize())
+		msgID, err = seekMessageID(pubsub.Position{Type: pubsub.PositionOffset, Offset: offset})
+		assert.NoError(t, err)
+		assert.Equal(t, pulsar.LatestMessageID().Serialize(), msgID.Serialize())
+
+		_, err = seekMessageID(pubsub.Position{Type: pubsub.PositionOffset, Offset: "not base64"})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pulsar
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+
+	"github.com/JY29/components-contrib/pubsub"
+)
+
+// Seek resets the cursor of a subscription using a short-lived consumer.
+// The broker disconnects the other consumers of the subscription, which reconnect and receive the messages from the new position.
+// Offsets are base64-encoded serialized message IDs.
+func (p *Pulsar) Seek(_ context.Context, topic string, subscription string, position pubsub.Position) error {
+	if subscription == "" {
+		subscription = p.metadata.ConsumerID
+	}
+
+	consumer, err := p.client.Subscribe(pulsar.ConsumerOptions{
+		Topic:            p.formatTopic(topic),
+		SubscriptionName: subscription,
+		Type:             pulsar.Shared,
+	})
+	if err != nil {
+		return fmt.Errorf("pulsar: error subscribing to %s: %w", topic, err)
+	}
+	defer consumer.Close()
+
+	switch position.Type {
+	case pubsub.PositionTimestamp:
+		err = consumer.SeekByTime(position.Timestamp)
+	default:
+		var msgID pulsar.MessageID
+		msgID, err = seekMessageID(position)
+		if err != nil {
+			return err
+		}
+		err = consumer.Seek(msgID)
+	}
+	if err != nil {
+		return fmt.Errorf("pulsar: error seeking subscription %s: %w", subscription, err)
+	}
+
+	return nil
+}
+
+// seekMessageID returns the message ID of a position which is not a timestamp.
+func seekMessageID(position pubsub.Position) (pulsar.MessageID, error) {
+	switch position.Type {
+	case pubsub.PositionEarliest:
+		return pulsar.EarliestMessageID(), nil
+	case pubsub.PositionLatest:
+		return pulsar.LatestMessageID(), nil
+	case pubsub.PositionOffset:
+		data, err := base64.StdEncoding.DecodeString(position.Offset)
+		if err != nil {
+			return nil, fmt.Errorf("pulsar: invalid message ID %q: %w", position.Offset, err)
+		}
+		msgID, err := pulsar.DeserializeMessageID(data)
+		if err != nil {
+			return nil, fmt.Errorf("pulsar: invalid message ID %q: %w", position.Offset, err)
+		}
+		return msgID, nil
+	default:
+		return nil, fmt.Errorf("pulsar: unsupported position type %q", position.Type)
+	}
+}
+
+// initialPosition returns the position at which a new subscription starts.
+// Timestamps and offsets can't be set at subscription time, as Pulsar doesn't tell if the subscription is new.
+func initialPosition(position pubsub.Position) (pulsar.SubscriptionInitialPosition, error) {
+	switch position.Type {
+	case pubsub.PositionEarliest:
+		return pulsar.SubscriptionPositionEarliest, nil
+	case pubsub.PositionLatest:
+		return pulsar.SubscriptionPositionLatest, nil
+	default:
+		return 0, fmt.Errorf("pulsar: start position %q is not supported at subscription time, use Seek instead", position.Type)
+	}
+}
@@ -0,0 +1,292 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/JY29/components-contrib/pubsub"
+)
+
+// Topics are exchanges, and subscriptions are the queues bound to them, named like the queues of Subscribe.
+// AMQP cannot enumerate exchanges and queues: they are listed with the HTTP API of the management plugin,
+// at the managementURL metadata, and listing is not supported without it.
+
+// managementTimeout bounds the requests to the HTTP API of the management plugin.
+const managementTimeout = 30 * time.Second
+
+var (
+	managementClient = &http.Client{Timeout: managementTimeout}
+
+	errManagementNotFound = errors.New("not found")
+)
+
+// managementExchange is an exchange listed by the HTTP API of the management plugin.
+type managementExchange struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// managementBinding is a binding listed by the HTTP API of the management plugin.
+type managementBinding struct {
+	Destination     string `json:"destination"`
+	DestinationType string `json:"destination_type"`
+}
+
+func subscriptionQueueName(subscription, topic string) string {
+	return fmt.Sprintf("%s-%s", subscription, topic)
+}
+
+func (r *rabbitMQ) CreateTopic(_ context.Context, req pubsub.CreateTopicRequest) error {
+	if req.Partitions > 1 || req.Retention > 0 {
+		return fmt.Errorf("%s partitions and retention are %w", errorMessagePrefix, pubsub.ErrTopicAdminNotSupported)
+	}
+
+	return r.withAdminChannel(func(channel rabbitMQChannelBroker) error {
+		r.channelMutex.Lock()
+		defer r.channelMutex.Unlock()
+
+		return r.ensureTopicExchangeDeclared(channel, req.Topic)
+	})
+}
+
+func (r *rabbitMQ) DeleteTopic(_ context.Context, topic string) error {
+	return r.withAdminChannel(func(channel rabbitMQChannelBroker) error {
+		if err := r.declareTopicExchangePassive(channel, topic); err != nil {
+			return err
+		}
+
+		r.channelMutex.Lock()
+		defer r.channelMutex.Unlock()
+
+		delete(r.declaredExchanges, topic)
+		return channel.ExchangeDelete(topic, false, false)
+	})
+}
+
+// ListTopics returns the exchanges of the kind declared by the component, except the exchanges of the broker and of the filters.
+func (r *rabbitMQ) ListTopics(ctx context.Context) ([]string, error) {
+	var exchanges []managementExchange
+	if err := r.getManagement(ctx, &exchanges, "exchanges"); err != nil {
+		return nil, err
+	}
+
+	kind := r.metadata.exchangeKind
+	if r.metadata.enableDelayedMessages {
+		kind = delayedMessageExchangeKind
+	}
+	filterSuffix := fmt.Sprintf(filterExchangeNameFormat, "")
+	topics := []string{}
+	for _, exchange := range exchanges {
+		if exchange.Name == "" || strings.HasPrefix(exchange.Name, "amq.") || exchange.Type != kind ||
+			(kind == amqp.ExchangeHeaders && strings.HasSuffix(exchange.Name, filterSuffix)) {
+			continue
+		}
+		topics = append(topics, exchange.Name)
+	}
+	sort.Strings(topics)
+
+	return topics, nil
+}
+
+func (r *rabbitMQ) DescribeTopic(_ context.Context, topic string) (*pubsub.TopicDescription, error) {
+	err := r.withAdminChannel(func(channel rabbitMQChannelBroker) error {
+		return r.declareTopicExchangePassive(channel, topic)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pubsub.TopicDescription{Name: topic}, nil
+}
+
+// CreateSubscription declares the queue of the subscription, with its dead letter queue if enabled, and binds it to the topic.
+// The routingKey request metadata is used like in Subscribe.
+func (r *rabbitMQ) CreateSubscription(_ context.Context, req pubsub.CreateSubscriptionRequest) error {
+	return r.withAdminChannel(func(channel rabbitMQChannelBroker) error {
+		r.channelMutex.Lock()
+		defer r.channelMutex.Unlock()
+
+		_, err := r.prepareSubscription(channel, pubsub.SubscribeRequest{Topic: req.Topic, Metadata: req.Metadata}, subscriptionQueueName(req.Subscription, req.Topic))
+		return err
+	})
+}
+
+func (r *rabbitMQ) DeleteSubscription(_ context.Context, topic string, subscription string) error {
+	queueName := subscriptionQueueName(subscription, topic)
+
+	return r.withAdminChannel(func(channel rabbitMQChannelBroker) error {
+		if _, err := r.declareQueuePassive(channel, queueName); err != nil {
+			return err
+		}
+
+		_, err := channel.QueueDelete(queueName, false, false, false)
+		return err
+	})
+}
+
+// ListSubscriptions returns the subscriptions whose queues are bound to the topic, directly or through the exchange of their filter.
+func (r *rabbitMQ) ListSubscriptions(ctx context.Context, topic string) ([]string, error) {
+	var bindings []managementBinding
+	err := r.getManagement(ctx, &bindings, "exchanges", topic, "bindings", "source")
+	if errors.Is(err, errManagementNotFound) {
+		return nil, fmt.Errorf("%s %w: %s", errorMessagePrefix, pubsub.ErrTopicNotFound, topic)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	filterSuffix := fmt.Sprintf(filterExchangeNameFormat, "")
+	queueSuffix := subscriptionQueueName("", topic)
+	seen := map[string]bool{}
+	subscriptions := []string{}
+	for _, binding := range bindings {
+		queueName := binding.Destination
+		if binding.DestinationType == "exchange" {
+			if !strings.HasSuffix(queueName, filterSuffix) {
+				continue
+			}
+			queueName = strings.TrimSuffix(queueName, filterSuffix)
+		}
+		// The queues which are not named like the queues of Subscribe are not subscriptions
+		if !strings.HasSuffix(queueName, queueSuffix) || queueName == queueSuffix {
+			continue
+		}
+		subscription := strings.TrimSuffix(queueName, queueSuffix)
+		if !seen[subscription] {
+			seen[subscription] = true
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	sort.Strings(subscriptions)
+
+	return subscriptions, nil
+}
+
+func (r *rabbitMQ) DescribeSubscription(_ context.Context, topic string, subscription string) (*pubsub.SubscriptionDescription, error) {
+	var q amqp.Queue
+	err := r.withAdminChannel(func(channel rabbitMQChannelBroker) (err error) {
+		q, err = r.declareQueuePassive(channel, subscriptionQueueName(subscription, topic))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pubsub.SubscriptionDescription{
+		Name:  subscription,
+		Topic: topic,
+		Metadata: map[string]string{
+			"queue":     q.Name,
+			"messages":  strconv.Itoa(q.Messages),
+			"consumers": strconv.Itoa(q.Consumers),
+		},
+	}, nil
+}
+
+// getManagement decodes the resource of the virtual host of the connection returned by the HTTP API of the management plugin.
+// The requests are authenticated with the credentials of the management URL, or else with the ones of the connection.
+func (r *rabbitMQ) getManagement(ctx context.Context, v interface{}, resource string, names ...string) error {
+	if r.metadata.managementURL == "" {
+		return fmt.Errorf("%s listing without the %s metadata is %w", errorMessagePrefix, metadataManagementURLKey, pubsub.ErrTopicAdminNotSupported)
+	}
+	uri, err := amqp.ParseURI(r.metadata.connectionURI())
+	if err != nil {
+		return err
+	}
+
+	path := "/api/" + resource + "/" + url.PathEscape(uri.Vhost)
+	for _, name := range names {
+		path += "/" + url.PathEscape(name)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.metadata.managementURL+path, nil)
+	if err != nil {
+		return err
+	}
+	if req.URL.User == nil {
+		req.SetBasicAuth(uri.Username, uri.Password)
+	}
+
+	res, err := managementClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s error calling the management API: %w", errorMessagePrefix, err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return json.NewDecoder(res.Body).Decode(v)
+	case http.StatusNotFound:
+		return errManagementNotFound
+	default:
+		return fmt.Errorf("%s management API returned %s for %s", errorMessagePrefix, res.Status, path)
+	}
+}
+
+// withAdminChannel runs fn on a dedicated channel, as the broker closes the channel on which an operation fails.
+func (r *rabbitMQ) withAdminChannel(fn func(channel rabbitMQChannelBroker) error) error {
+	r.channelMutex.RLock()
+	connection := r.connection
+	r.channelMutex.RUnlock()
+
+	if connection == nil {
+		return errors.New(errorChannelNotInitialized)
+	}
+	channel, err := connection.Channel()
+	if err != nil {
+		return err
+	}
+	defer channel.Close()
+
+	return fn(channel)
+}
+
+func (r *rabbitMQ) declareTopicExchangePassive(channel rabbitMQChannelBroker, topic string) error {
+	kind, args := r.metadata.exchangeKind, amqp.Table(nil)
+	if r.metadata.enableDelayedMessages {
+		kind, args = delayedMessageExchangeKind, amqp.Table{argDelayedType: r.metadata.exchangeKind}
+	}
+
+	err := channel.ExchangeDeclarePassive(topic, kind, true, false, false, false, args)
+	if isNotFound(err) {
+		return fmt.Errorf("%s %w: %s", errorMessagePrefix, pubsub.ErrTopicNotFound, topic)
+	}
+
+	return err
+}
+
+func (r *rabbitMQ) declareQueuePassive(channel rabbitMQChannelBroker, queueName string) (amqp.Queue, error) {
+	q, err := channel.QueueDeclarePassive(queueName, r.metadata.durable, r.metadata.deleteWhenUnused, false, false, nil)
+	if isNotFound(err) {
+		return q, fmt.Errorf("%s %w: %s", errorMessagePrefix, pubsub.ErrSubscriptionNotFound, queueName)
+	}
+
+	return q, err
+}
+
+func isNotFound(err error) bool {
+	var amqpErr *amqp.Error
+	return errors.As(err, &amqpErr) && amqpErr.Code == amqp.NotFound
+}
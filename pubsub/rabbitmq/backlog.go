@@ -0,0 +1,45 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rabbitmq
+
+import (
+	"context"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/JY29/components-contrib/pubsub"
+)
+
+// Backlog reports the messages ready in the queue of the subscription as pending.
+// AMQP does not report the unacknowledged messages nor the age of the messages: they require the management plugin.
+func (r *rabbitMQ) Backlog(_ context.Context, topic string, subscription string) (*pubsub.Backlog, error) {
+	if subscription == "" {
+		subscription = r.metadata.consumerID
+	}
+
+	var q amqp.Queue
+	err := r.withAdminChannel(func(channel rabbitMQChannelBroker) (err error) {
+		q, err = r.declareQueuePassive(channel, subscriptionQueueName(subscription, topic))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pubsub.Backlog{
+		Topic:        topic,
+		Subscription: subscription,
+		Pending:      int64(q.Messages),
+	}, nil
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rabbitmq
+
+import (
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	contribMetadata "github.com/JY29/components-contrib/metadata"
+	"github.com/JY29/components-contrib/pubsub"
+)
+
+const (
+	argHeadersMatch          = "x-match"
+	filterExchangeNameFormat = "%s-filter"
+)
+
+// publishHeaders returns the metadata of a published message to send as headers, which subscribers can filter on.
+func publishHeaders(md map[string]string) amqp.Table {
+	headers := amqp.Table{}
+	for k, v := range md {
+		switch k {
+		case reqMetadataRoutingKey, contribMetadata.TTLMetadataKey, contribMetadata.DelayMetadataKey, contribMetadata.ScheduledTimeMetadataKey, contribMetadata.PriorityMetadataKey:
+			continue
+		}
+		headers[k] = v
+	}
+
+	return headers
+}
+
+// deliveryMetadata returns the headers of a delivered message as metadata.
+func deliveryMetadata(headers amqp.Table) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	md := make(map[string]string, len(headers))
+	for k, v := range headers {
+		if k == headerDelay {
+			continue
+		}
+		if s, ok := v.(string); ok {
+			md[k] = s
+		} else {
+			md[k] = fmt.Sprint(v)
+		}
+	}
+
+	return md
+}
+
+// filterBindingArgs translates a subscription filter to the arguments of a binding to a headers exchange.
+// Only conjunctions of equalities of metadata with string values can be translated.
+func filterBindingArgs(filter *pubsub.Filter) (amqp.Table, bool) {
+	conditions, ok := filter.Conditions()
+	if !ok {
+		return nil, false
+	}
+
+	args := amqp.Table{argHeadersMatch: "all"}
+	for _, c := range conditions {
+		if c.Field.Scope != pubsub.FilterScopeMetadata || c.Operator != pubsub.FilterOpEqual {
+			return nil, false
+		}
+		v, ok := c.Values[0].(string)
+		if !ok {
+			return nil, false
+		}
+		key := c.Field.Path[0]
+		if prev, ok := args[key]; ok && prev != v {
+			return nil, false
+		}
+		args[key] = v
+	}
+
+	return args, true
+}
+
+// bindFilteredQueue routes the messages of the topic to the queue through a headers exchange, which only lets the messages matching the filter through.
+// The queue must already be bound to the topic with the routing keys: the headers exchange of a previous filter is
+// deleted and the new one set up while the messages are routed through these bindings, which are removed last, so that
+// no message is lost in between. The unfiltered messages received meanwhile are dropped by the filter of the handler.
+//
+// this function call should be wrapped by channelMutex.
+func (r *rabbitMQ) bindFilteredQueue(channel rabbitMQChannelBroker, topic string, queueName string, routingKeys []string, args amqp.Table) error {
+	filterExchange := fmt.Sprintf(filterExchangeNameFormat, queueName)
+	// The exchange is recreated, as the bindings of a previous filter can't be listed.
+	// The queue still receives the messages through its bindings to the topic.
+	err := r.deleteFilterExchange(channel, queueName)
+	if err != nil {
+		return err
+	}
+	err = r.ensureExchangeDeclared(channel, filterExchange, amqp.ExchangeHeaders, nil)
+	if err != nil {
+		return err
+	}
+
+	r.logger.Debugf("%s binding queue '%s' to exchange '%s' with arguments %v", logMessagePrefix, queueName, filterExchange, args)
+	err = channel.QueueBind(queueName, "", filterExchange, false, args)
+	if err != nil {
+		return err
+	}
+	for _, routingKey := range routingKeys {
+		err = channel.ExchangeBind(filterExchange, routingKey, topic, false, nil)
+		if err != nil {
+			return err
+		}
+		err = channel.QueueUnbind(queueName, routingKey, topic, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteFilterExchange deletes the headers exchange of the filter of a queue, if any, with its bindings.
+// It is called when the queue is bound to the topic without a filter, so that the messages are not delivered
+// through the exchange of a previous filter too.
+//
+// this function call should be wrapped by channelMutex.
+func (r *rabbitMQ) deleteFilterExchange(channel rabbitMQChannelBroker, queueName string) error {
+	filterExchange := fmt.Sprintf(filterExchangeNameFormat, queueName)
+	// Deleting an exchange which doesn't exist succeeds
+	err := channel.ExchangeDelete(filterExchange, false, false)
+	if err != nil {
+		return err
+	}
+	delete(r.declaredExchanges, filterExchange)
+
+	return nil
+}
@@ -15,8 +15,10 @@ package rabbitmq
 
 import (
 	"fmt"
+	"math"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
@@ -45,10 +47,15 @@ type metadata struct {
 	reconnectWait    time.Duration
 	maxLen           int64
 	maxLenBytes      int64
+	maxPriority      uint8 // Priority queues deactivated if 0
 	exchangeKind     string
 	publisherConfirm bool
 	concurrency      pubsub.ConcurrencyMode
 	defaultQueueTTL  *time.Duration
+	// Requires the rabbitmq_delayed_message_exchange plugin on the broker
+	enableDelayedMessages bool
+	// URL of the HTTP API of the management plugin, which lists the topics and subscriptions
+	managementURL string
 }
 
 const (
@@ -72,9 +79,13 @@ const (
 	metadataReconnectWaitSecondsKey = "reconnectWaitSeconds"
 	metadataMaxLenKey               = "maxLen"
 	metadataMaxLenBytesKey          = "maxLenBytes"
+	metadataMaxPriorityKey          = "maxPriority"
 	metadataExchangeKindKey         = "exchangeKind"
 	metadataPublisherConfirmKey     = "publisherConfirm"
 
+	metadataEnableDelayedMessagesKey = "enableDelayedMessages"
+	metadataManagementURLKey         = "managementURL"
+
 	defaultReconnectWaitSeconds = 3
 
 	protocolAMQP  = "amqp"
@@ -195,6 +206,17 @@ func createMetadata(pubSubMetadata pubsub.Metadata, log logger.Logger) (*metadat
 		}
 	}
 
+	if val, found := pubSubMetadata.Properties[metadataMaxPriorityKey]; found && val != "" {
+		intVal, err := strconv.ParseInt(val, 10, 64)
+		if err != nil || intVal < 0 {
+			return &result, fmt.Errorf("%s invalid RabbitMQ maxPriority %s", errorMessagePrefix, val)
+		}
+		if intVal > math.MaxUint8 {
+			intVal = math.MaxUint8
+		}
+		result.maxPriority = uint8(intVal)
+	}
+
 	if val, found := pubSubMetadata.Properties[metadataExchangeKindKey]; found && val != "" {
 		if exchangeKindValid(val) {
 			result.exchangeKind = val
@@ -209,6 +231,20 @@ func createMetadata(pubSubMetadata pubsub.Metadata, log logger.Logger) (*metadat
 		}
 	}
 
+	if val, found := pubSubMetadata.Properties[metadataEnableDelayedMessagesKey]; found && val != "" {
+		if boolVal, err := strconv.ParseBool(val); err == nil {
+			result.enableDelayedMessages = boolVal
+		}
+	}
+
+	if val, found := pubSubMetadata.Properties[metadataManagementURLKey]; found && val != "" {
+		u, err := url.Parse(val)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return &result, fmt.Errorf("%s invalid RabbitMQ managementURL %s", errorMessagePrefix, val)
+		}
+		result.managementURL = strings.TrimSuffix(val, "/")
+	}
+
 	ttl, ok, err := contribMetadata.TryGetTTL(pubSubMetadata.Properties)
 	if err != nil {
 		return &result, fmt.Errorf("%s parse RabbitMQ ttl metadata with error: %s", errorMessagePrefix, err)
@@ -242,6 +278,9 @@ func (m *metadata) formatQueueDeclareArgs(origin amqp.Table) amqp.Table {
 	if m.maxLenBytes > 0 {
 		origin[argMaxLengthBytes] = m.maxLenBytes
 	}
+	if m.maxPriority > 0 {
+		origin[argMaxPriority] = m.maxPriority
+	}
 
 	return origin
 }
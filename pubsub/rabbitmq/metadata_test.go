@@ -174,6 +174,21 @@ func TestCreateMetadata(t *testing.T) {
 		assert.Error(t, err)
 	})
 
+	t.Run("invalid managementURL", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+
+		fakeMetaData := pubsub.Metadata{
+			Base: mdata.Base{Properties: fakeProperties},
+		}
+		fakeMetaData.Properties[metadataManagementURLKey] = "localhost:15672"
+
+		// act
+		_, err := createMetadata(fakeMetaData, log)
+
+		// assert
+		assert.Error(t, err)
+	})
+
 	t.Run("prefetchCount is set", func(t *testing.T) {
 		fakeProperties := getFakeProperties()
 
@@ -243,6 +258,38 @@ func TestCreateMetadata(t *testing.T) {
 		assert.Equal(t, int64(2000000), m.maxLenBytes)
 	})
 
+	t.Run("maxPriority is set", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+
+		fakeMetaData := pubsub.Metadata{
+			Base: mdata.Base{Properties: fakeProperties},
+		}
+		fakeMetaData.Properties[metadataMaxPriorityKey] = "300"
+
+		// act
+		m, err := createMetadata(fakeMetaData, log)
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, uint8(255), m.maxPriority)
+		assert.Equal(t, uint8(255), m.formatQueueDeclareArgs(nil)[argMaxPriority])
+	})
+
+	t.Run("invalid maxPriority", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+
+		fakeMetaData := pubsub.Metadata{
+			Base: mdata.Base{Properties: fakeProperties},
+		}
+		fakeMetaData.Properties[metadataMaxPriorityKey] = "high"
+
+		// act
+		_, err := createMetadata(fakeMetaData, log)
+
+		// assert
+		assert.ErrorContains(t, err, metadataMaxPriorityKey)
+	})
+
 	for _, tt := range booleanFlagTests {
 		t.Run(fmt.Sprintf("autoAck value=%s", tt.in), func(t *testing.T) {
 			fakeProperties := getFakeProperties()
@@ -33,6 +33,7 @@ import (
 
 const (
 	fanoutExchangeKind              = "fanout"
+	delayedMessageExchangeKind      = "x-delayed-message"
 	logMessagePrefix                = "rabbitmq pub/sub:"
 	errorMessagePrefix              = "rabbitmq pub/sub error:"
 	errorChannelNotInitialized      = "channel not initialized"
@@ -46,7 +47,10 @@ const (
 	argQueueMode          = "x-queue-mode"
 	argMaxLength          = "x-max-length"
 	argMaxLengthBytes     = "x-max-length-bytes"
+	argMaxPriority        = "x-max-priority"
 	argDeadLetterExchange = "x-dead-letter-exchange"
+	argDelayedType        = "x-delayed-type"
+	headerDelay           = "x-delay"
 	queueModeLazy         = "lazy"
 	reqMetadataRoutingKey = "routingKey"
 )
@@ -75,10 +79,16 @@ type rabbitMQChannelBroker interface {
 	PublishWithDeferredConfirmWithContext(ctx context.Context, exchange string, key string, mandatory bool, immediate bool, msg amqp.Publishing) (*amqp.DeferredConfirmation, error)
 	QueueDeclare(name string, durable bool, autoDelete bool, exclusive bool, noWait bool, args amqp.Table) (amqp.Queue, error)
 	QueueBind(name string, key string, exchange string, noWait bool, args amqp.Table) error
+	QueueUnbind(name string, key string, exchange string, args amqp.Table) error
+	ExchangeBind(destination string, key string, source string, noWait bool, args amqp.Table) error
 	Consume(queue string, consumer string, autoAck bool, exclusive bool, noLocal bool, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error)
 	Nack(tag uint64, multiple bool, requeue bool) error
 	Ack(tag uint64, multiple bool) error
 	ExchangeDeclare(name string, kind string, durable bool, autoDelete bool, internal bool, noWait bool, args amqp.Table) error
+	ExchangeDeclarePassive(name string, kind string, durable bool, autoDelete bool, internal bool, noWait bool, args amqp.Table) error
+	ExchangeDelete(name string, ifUnused bool, noWait bool) error
+	QueueDeclarePassive(name string, durable bool, autoDelete bool, exclusive bool, noWait bool, args amqp.Table) (amqp.Queue, error)
+	QueueDelete(name string, ifUnused bool, ifEmpty bool, noWait bool) (int, error)
 	Qos(prefetchCount, prefetchSize int, global bool) error
 	Confirm(noWait bool) error
 	Close() error
@@ -87,9 +97,24 @@ type rabbitMQChannelBroker interface {
 
 // interface used to allow unit testing.
 type rabbitMQConnectionBroker interface {
+	Channel() (rabbitMQChannelBroker, error)
 	Close() error
 }
 
+// amqpConnection adapts amqp.Connection to rabbitMQConnectionBroker.
+type amqpConnection struct {
+	*amqp.Connection
+}
+
+func (c amqpConnection) Channel() (rabbitMQChannelBroker, error) {
+	ch, err := c.Connection.Channel()
+	if err != nil {
+		return nil, err
+	}
+
+	return ch, nil
+}
+
 // NewRabbitMQ creates a new RabbitMQ pub/sub.
 func NewRabbitMQ(logger logger.Logger) pubsub.PubSub {
 	return &rabbitMQ{
@@ -121,7 +146,7 @@ func dial(protocol, uri string, tlsCfg *tls.Config) (rabbitMQConnectionBroker, r
 		return nil, nil, err
 	}
 
-	return conn, ch, nil
+	return amqpConnection{conn}, ch, nil
 }
 
 // Init does metadata parsing and connection creation.
@@ -200,7 +225,7 @@ func (r *rabbitMQ) publishSync(ctx context.Context, req *pubsub.PublishRequest)
 		return r.channel, r.connectionCount, errors.New(errorChannelNotInitialized)
 	}
 
-	if err := r.ensureExchangeDeclared(r.channel, req.Topic, r.metadata.exchangeKind); err != nil {
+	if err := r.ensureTopicExchangeDeclared(r.channel, req.Topic); err != nil {
 		r.logger.Errorf("%s publishing to %s failed in ensureExchangeDeclared: %v", logMessagePrefix, req.Topic, err)
 
 		return r.channel, r.connectionCount, err
@@ -222,11 +247,25 @@ func (r *rabbitMQ) publishSync(ctx context.Context, req *pubsub.PublishRequest)
 		expiration = strconv.FormatInt(r.metadata.defaultQueueTTL.Milliseconds(), 10)
 	}
 
+	// The priority has already been validated in Publish
+	priority, _, _ := contribMetadata.TryGetPriority(req.Metadata)
+
+	headers := publishHeaders(req.Metadata)
+	// The scheduled time has already been validated in Publish
+	if scheduled, ok, _ := contribMetadata.TryGetScheduledTime(req.Metadata); ok {
+		if delay := time.Until(scheduled); delay > 0 {
+			// The delayed message exchange plugin expects the delay in ms
+			headers[headerDelay] = delay.Milliseconds()
+		}
+	}
+
 	confirm, err := r.channel.PublishWithDeferredConfirmWithContext(ctx, req.Topic, routingKey, false, false, amqp.Publishing{
 		ContentType:  "text/plain",
+		Headers:      headers,
 		Body:         req.Data,
 		DeliveryMode: r.metadata.deliveryMode,
 		Expiration:   expiration,
+		Priority:     priority,
 	})
 	if err != nil {
 		r.logger.Errorf("%s publishing to %s failed in channel.Publish: %v", logMessagePrefix, req.Topic, err)
@@ -250,6 +289,17 @@ func (r *rabbitMQ) publishSync(ctx context.Context, req *pubsub.PublishRequest)
 func (r *rabbitMQ) Publish(ctx context.Context, req *pubsub.PublishRequest) error {
 	r.logger.Debugf("%s publishing message to %s", logMessagePrefix, req.Topic)
 
+	_, scheduled, err := contribMetadata.TryGetScheduledTime(req.Metadata)
+	if err != nil {
+		return fmt.Errorf("%s %w", errorMessagePrefix, err)
+	}
+	if scheduled && !r.metadata.enableDelayedMessages {
+		return fmt.Errorf("%s delayed messages require the %s metadata option", errorMessagePrefix, metadataEnableDelayedMessagesKey)
+	}
+	if _, _, err = contribMetadata.TryGetPriority(req.Metadata); err != nil {
+		return fmt.Errorf("%s %w", errorMessagePrefix, err)
+	}
+
 	attempt := 0
 	for {
 		attempt++
@@ -277,7 +327,14 @@ func (r *rabbitMQ) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, h
 		return errors.New("consumerID is required for subscriptions")
 	}
 
-	queueName := fmt.Sprintf("%s-%s", r.metadata.consumerID, req.Topic)
+	// The filter is always evaluated on the received messages, as the headers exchange can only evaluate some filters
+	filter, err := pubsub.SubscriptionFilter(req)
+	if err != nil {
+		return err
+	}
+	handler = pubsub.FilterHandler(filter, handler)
+
+	queueName := subscriptionQueueName(r.metadata.consumerID, req.Topic)
 	r.logger.Infof("%s subscribe to topic/queue '%s/%s'", logMessagePrefix, req.Topic, queueName)
 
 	// Do not set a timeout on the context, as we're just waiting for the first ack; we're using a semaphore instead
@@ -296,7 +353,7 @@ func (r *rabbitMQ) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, h
 
 // this function call should be wrapped by channelMutex.
 func (r *rabbitMQ) prepareSubscription(channel rabbitMQChannelBroker, req pubsub.SubscribeRequest, queueName string) (*amqp.Queue, error) {
-	err := r.ensureExchangeDeclared(channel, req.Topic, r.metadata.exchangeKind)
+	err := r.ensureTopicExchangeDeclared(channel, req.Topic)
 	if err != nil {
 		r.logger.Errorf("%s prepareSubscription for topic/queue '%s/%s' failed in ensureExchangeDeclared: %v", logMessagePrefix, req.Topic, queueName, err)
 
@@ -309,7 +366,7 @@ func (r *rabbitMQ) prepareSubscription(channel rabbitMQChannelBroker, req pubsub
 		// declare dead letter exchange
 		dlxName := fmt.Sprintf(defaultDeadLetterExchangeFormat, queueName)
 		dlqName := fmt.Sprintf(defaultDeadLetterQueueFormat, queueName)
-		err = r.ensureExchangeDeclared(channel, dlxName, fanoutExchangeKind)
+		err = r.ensureExchangeDeclared(channel, dlxName, fanoutExchangeKind, nil)
 		if err != nil {
 			r.logger.Errorf("%s prepareSubscription for topic/queue '%s/%s' failed in ensureExchangeDeclared: %v", logMessagePrefix, req.Topic, dlqName, err)
 
@@ -357,6 +414,20 @@ func (r *rabbitMQ) prepareSubscription(channel rabbitMQChannelBroker, req pubsub
 		metadataRoutingKey = val
 	}
 	routingKeys := strings.Split(metadataRoutingKey, ",")
+
+	// The queue is bound to the topic before being bound to the headers exchange of the filter, if any, so that no message is lost in between
+	filter, err := pubsub.SubscriptionFilter(req)
+	if err != nil {
+		return nil, err
+	}
+	var filterArgs amqp.Table
+	if filter != nil {
+		var ok bool
+		if filterArgs, ok = filterBindingArgs(filter); !ok {
+			r.logger.Debugf("%s filter of topic/queue '%s/%s' can't be translated to a headers exchange binding: evaluating it on the received messages", logMessagePrefix, req.Topic, queueName)
+		}
+	}
+
 	for i := 0; i < len(routingKeys); i++ {
 		routingKey := routingKeys[i]
 		r.logger.Debugf("%s binding queue '%s' to exchange '%s' with routing key '%s'", logMessagePrefix, q.Name, req.Topic, routingKey)
@@ -368,6 +439,22 @@ func (r *rabbitMQ) prepareSubscription(channel rabbitMQChannelBroker, req pubsub
 		}
 	}
 
+	if filterArgs != nil {
+		err = r.bindFilteredQueue(channel, req.Topic, q.Name, routingKeys, filterArgs)
+		if err != nil {
+			r.logger.Errorf("%s prepareSubscription for topic/queue '%s/%s' failed in bindFilteredQueue: %v", logMessagePrefix, req.Topic, queueName, err)
+
+			return nil, err
+		}
+	} else {
+		err = r.deleteFilterExchange(channel, q.Name)
+		if err != nil {
+			r.logger.Errorf("%s prepareSubscription for topic/queue '%s/%s' failed in deleteFilterExchange: %v", logMessagePrefix, req.Topic, queueName, err)
+
+			return nil, err
+		}
+	}
+
 	return &q, nil
 }
 
@@ -482,8 +569,9 @@ func (r *rabbitMQ) listenMessages(ctx context.Context, channel rabbitMQChannelBr
 
 func (r *rabbitMQ) handleMessage(ctx context.Context, d amqp.Delivery, topic string, handler pubsub.Handler) error {
 	pubsubMsg := &pubsub.NewMessage{
-		Data:  d.Body,
-		Topic: topic,
+		Data:     d.Body,
+		Topic:    topic,
+		Metadata: deliveryMetadata(d.Headers),
 	}
 
 	err := handler(ctx, pubsubMsg)
@@ -510,10 +598,21 @@ func (r *rabbitMQ) handleMessage(ctx context.Context, d amqp.Delivery, topic str
 }
 
 // this function call should be wrapped by channelMutex.
-func (r *rabbitMQ) ensureExchangeDeclared(channel rabbitMQChannelBroker, exchange, exchangeKind string) error {
+func (r *rabbitMQ) ensureTopicExchangeDeclared(channel rabbitMQChannelBroker, topic string) error {
+	if r.metadata.enableDelayedMessages {
+		// Topics are declared using the delayed message exchange plugin, which routes like the configured kind once the delay has elapsed.
+		// See https://github.com/rabbitmq/rabbitmq-delayed-message-exchange
+		return r.ensureExchangeDeclared(channel, topic, delayedMessageExchangeKind, amqp.Table{argDelayedType: r.metadata.exchangeKind})
+	}
+
+	return r.ensureExchangeDeclared(channel, topic, r.metadata.exchangeKind, nil)
+}
+
+// this function call should be wrapped by channelMutex.
+func (r *rabbitMQ) ensureExchangeDeclared(channel rabbitMQChannelBroker, exchange, exchangeKind string, args amqp.Table) error {
 	if !r.containsExchange(exchange) {
 		r.logger.Debugf("%s declaring exchange '%s' of kind '%s'", logMessagePrefix, exchange, exchangeKind)
-		err := channel.ExchangeDeclare(exchange, exchangeKind, true, false, false, false, nil)
+		err := channel.ExchangeDeclare(exchange, exchangeKind, true, false, false, false, args)
 		if err != nil {
 			r.logger.Errorf("%s ensureExchangeDeclared: channel.ExchangeDeclare failed: %v", logMessagePrefix, err)
 
@@ -578,7 +677,16 @@ func (r *rabbitMQ) Close() error {
 }
 
 func (r *rabbitMQ) Features() []pubsub.Feature {
-	return []pubsub.Feature{pubsub.FeatureMessageTTL}
+	features := []pubsub.Feature{pubsub.FeatureMessageTTL}
+	if r.metadata != nil && r.metadata.enableDelayedMessages {
+		features = append(features, pubsub.FeatureDelayedPublish)
+	}
+	// The queues are declared with a maximum priority, above which the priorities are handled as the maximum
+	if r.metadata != nil && r.metadata.maxPriority > 0 {
+		features = append(features, pubsub.FeatureMessagePriority)
+	}
+
+	return features
 }
 
 func mustReconnect(channel rabbitMQChannelBroker, err error) bool {
@@ -0,0 +1,205 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	rediscomponent "github.com/JY29/components-contrib/internal/component/redis"
+	"github.com/JY29/components-contrib/pubsub"
+)
+
+// Topics are streams, and subscriptions are the consumer groups of the streams.
+
+const (
+	streamKeyType  = "stream"
+	adminScanCount = 100
+)
+
+func (r *redisStreams) CreateTopic(ctx context.Context, req pubsub.CreateTopicRequest) error {
+	if req.Partitions > 1 || req.Retention > 0 {
+		return fmt.Errorf("redis streams: partitions and retention are %w", pubsub.ErrTopicAdminNotSupported)
+	}
+
+	exists, err := r.streamExists(ctx, req.Topic)
+	if err != nil || exists {
+		return err
+	}
+
+	// Redis creates streams on the first entry, or with a consumer group: a temporary one is used to create an empty stream
+	group := "dapr-admin-" + uuid.New().String()
+	if err = r.client.XGroupCreateMkStream(ctx, req.Topic, group, "$"); err != nil {
+		return fmt.Errorf("redis streams: error creating stream %s: %w", req.Topic, err)
+	}
+	if err = r.client.XGroupDestroy(ctx, req.Topic, group); err != nil {
+		return fmt.Errorf("redis streams: error creating stream %s: %w", req.Topic, err)
+	}
+
+	return nil
+}
+
+func (r *redisStreams) DeleteTopic(ctx context.Context, topic string) error {
+	if err := r.ensureStreamExists(ctx, topic); err != nil {
+		return err
+	}
+	if err := r.client.Del(ctx, topic, topic+delayedSetSuffix, topic+delayedDataSuffix); err != nil {
+		return fmt.Errorf("redis streams: error deleting stream %s: %w", topic, err)
+	}
+
+	return nil
+}
+
+func (r *redisStreams) ListTopics(ctx context.Context) ([]string, error) {
+	var (
+		topics []string
+		cursor uint64
+	)
+	for {
+		keys, next, err := r.client.ScanTypeResult(ctx, cursor, "*", adminScanCount, streamKeyType)
+		if err != nil {
+			return nil, fmt.Errorf("redis streams: error listing streams: %w", err)
+		}
+		topics = append(topics, keys...)
+		if next == 0 {
+			break
+		}
+		cursor = next
+	}
+	sort.Strings(topics)
+
+	return topics, nil
+}
+
+func (r *redisStreams) DescribeTopic(ctx context.Context, topic string) (*pubsub.TopicDescription, error) {
+	if err := r.ensureStreamExists(ctx, topic); err != nil {
+		return nil, err
+	}
+	length, err := r.client.XLenResult(ctx, topic)
+	if err != nil {
+		return nil, fmt.Errorf("redis streams: error describing stream %s: %w", topic, err)
+	}
+
+	return &pubsub.TopicDescription{
+		Name: topic,
+		Metadata: map[string]string{
+			"length": strconv.FormatInt(length, 10),
+		},
+	}, nil
+}
+
+// CreateSubscription creates the consumer group from the start of the stream, and the stream if needed, like Subscribe does.
+func (r *redisStreams) CreateSubscription(ctx context.Context, req pubsub.CreateSubscriptionRequest) error {
+	err := r.client.XGroupCreateMkStream(ctx, req.Topic, req.Subscription, "0")
+	// Ignore BUSYGROUP errors
+	if err != nil && err.Error() != "BUSYGROUP Consumer Group name already exists" {
+		return fmt.Errorf("redis streams: error creating consumer group %s: %w", req.Subscription, err)
+	}
+
+	return nil
+}
+
+func (r *redisStreams) DeleteSubscription(ctx context.Context, topic string, subscription string) error {
+	if _, err := r.describeGroup(ctx, topic, subscription); err != nil {
+		return err
+	}
+	if err := r.client.XGroupDestroy(ctx, topic, subscription); err != nil {
+		return fmt.Errorf("redis streams: error deleting consumer group %s: %w", subscription, err)
+	}
+
+	return nil
+}
+
+func (r *redisStreams) ListSubscriptions(ctx context.Context, topic string) ([]string, error) {
+	groups, err := r.listGroups(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+	subscriptions := make([]string, len(groups))
+	for i, group := range groups {
+		subscriptions[i] = group.Name
+	}
+	sort.Strings(subscriptions)
+
+	return subscriptions, nil
+}
+
+func (r *redisStreams) DescribeSubscription(ctx context.Context, topic string, subscription string) (*pubsub.SubscriptionDescription, error) {
+	return r.describeGroup(ctx, topic, subscription)
+}
+
+func (r *redisStreams) describeGroup(ctx context.Context, topic string, subscription string) (*pubsub.SubscriptionDescription, error) {
+	groups, err := r.listGroups(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		if group.Name == subscription {
+			return &pubsub.SubscriptionDescription{
+				Name:  subscription,
+				Topic: topic,
+				Metadata: map[string]string{
+					"consumers":       strconv.FormatInt(group.Consumers, 10),
+					"pending":         strconv.FormatInt(group.Pending, 10),
+					"lastDeliveredID": group.LastDeliveredID,
+				},
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("redis streams: %w: %s", pubsub.ErrSubscriptionNotFound, subscription)
+}
+
+func (r *redisStreams) listGroups(ctx context.Context, topic string) ([]rediscomponent.RedisXInfoGroup, error) {
+	if err := r.ensureStreamExists(ctx, topic); err != nil {
+		return nil, err
+	}
+	groups, err := r.client.XInfoGroupsResult(ctx, topic)
+	if err != nil {
+		return nil, fmt.Errorf("redis streams: error listing consumer groups of %s: %w", topic, err)
+	}
+
+	return groups, nil
+}
+
+func (r *redisStreams) streamExists(ctx context.Context, topic string) (bool, error) {
+	keyType, err := r.client.TypeResult(ctx, topic)
+	if err != nil {
+		return false, fmt.Errorf("redis streams: error getting the type of %s: %w", topic, err)
+	}
+	switch keyType {
+	case streamKeyType:
+		return true, nil
+	case "none":
+		return false, nil
+	default:
+		return false, fmt.Errorf("redis streams: key %s is a %s and not a stream", topic, keyType)
+	}
+}
+
+func (r *redisStreams) ensureStreamExists(ctx context.Context, topic string) error {
+	exists, err := r.streamExists(ctx, topic)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("redis streams: %w: %s", pubsub.ErrTopicNotFound, topic)
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JY29/components-contrib/pubsub"
+)
+
+const (
+	// backlogCountLimit is the maximum number of entries counted to compute the backlog, beyond which it is unknown.
+	backlogCountLimit = 10000
+	backlogPageSize   = 1000
+)
+
+// Backlog reports the entries pending in the PEL of the consumer group as unacknowledged.
+// The number of entries not delivered to the group yet is reported by Redis 7 and later as the lag of the group.
+// Otherwise the entries after the last delivered one are counted, up to backlogCountLimit, beyond which Pending is -1.
+func (r *redisStreams) Backlog(ctx context.Context, topic string, subscription string) (*pubsub.Backlog, error) {
+	if subscription == "" {
+		subscription = r.metadata.consumerID
+	}
+
+	groups, err := r.listGroups(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+	var lastDeliveredID string
+	found := false
+	backlog := &pubsub.Backlog{
+		Topic:        topic,
+		Subscription: subscription,
+	}
+	for _, group := range groups {
+		if group.Name == subscription {
+			found = true
+			backlog.Pending = group.Lag
+			lastDeliveredID = group.LastDeliveredID
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("redis streams: %w: %s", pubsub.ErrSubscriptionNotFound, subscription)
+	}
+	// A lag of zero is also reported by the v8 client, and by the servers older than Redis 7
+	if backlog.Pending <= 0 {
+		backlog.Pending, err = r.countEntriesAfter(ctx, topic, lastDeliveredID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	pending, err := r.client.XPendingResult(ctx, topic, subscription)
+	if err != nil {
+		return nil, fmt.Errorf("redis streams: error getting the pending entries of %s: %w", subscription, err)
+	}
+	backlog.Unacknowledged = pending.Count
+
+	// The oldest entry is the first pending one, or else the first one not delivered yet
+	oldestID := pending.Lower
+	if pending.Count == 0 {
+		oldestID = ""
+		msgs, err := r.client.XRangeNResult(ctx, topic, lastDeliveredID, "+", 2)
+		if err != nil {
+			return nil, fmt.Errorf("redis streams: error reading stream %s: %w", topic, err)
+		}
+		for _, msg := range msgs {
+			if msg.ID != lastDeliveredID {
+				oldestID = msg.ID
+				break
+			}
+		}
+	}
+	if added, ok := streamIDTime(oldestID); ok {
+		backlog.OldestMessageAge = time.Since(added)
+	}
+
+	return backlog, nil
+}
+
+// countEntriesAfter returns the number of entries of a stream after the given ID,
+// or -1 if there are more than backlogCountLimit.
+func (r *redisStreams) countEntriesAfter(ctx context.Context, stream string, id string) (int64, error) {
+	var count int64
+	// The start of XRANGE is inclusive: the first entry of each page is skipped if it was already seen
+	start := id
+	for {
+		msgs, err := r.client.XRangeNResult(ctx, stream, start, "+", backlogPageSize)
+		if err != nil {
+			return 0, fmt.Errorf("redis streams: error reading stream %s: %w", stream, err)
+		}
+		for _, msg := range msgs {
+			if msg.ID != start {
+				count++
+			}
+		}
+		if count > backlogCountLimit {
+			return -1, nil
+		}
+		if len(msgs) < backlogPageSize {
+			return count, nil
+		}
+		start = msgs[len(msgs)-1].ID
+	}
+}
+
+// streamIDTime returns the time at which an entry was added to a stream, from its ID.
+func streamIDTime(id string) (time.Time, bool) {
+	ms, _, _ := strings.Cut(id, "-")
+	v, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil || v <= 0 {
+		return time.Time{}, false
+	}
+
+	return time.UnixMilli(v), true
+}
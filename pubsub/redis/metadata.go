@@ -31,4 +31,8 @@ type metadata struct {
 
 	// the max len of stream
 	maxLenApprox int64
+	// The number of deliveries after which a message is moved to the dead letter stream (0 redelivers forever)
+	maxDeliveryCount int64
+	// The stream receiving the messages delivered maxDeliveryCount times (messages are dropped when empty)
+	deadLetterStream string
 }
@@ -20,7 +20,10 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/google/uuid"
+
 	rediscomponent "github.com/JY29/components-contrib/internal/component/redis"
+	contribMetadata "github.com/JY29/components-contrib/metadata"
 	"github.com/JY29/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 )
@@ -33,8 +36,40 @@ const (
 	queueDepth        = "queueDepth"
 	concurrency       = "concurrency"
 	maxLenApprox      = "maxLenApprox"
+	maxDeliveryCount  = "maxDeliveryCount"
+	deadLetterStream  = "deadLetterStream"
+
+	// Delayed messages are kept in a sorted set scored by their due time, with their payloads in a hash,
+	// until they are promoted to the stream by the subscribers.
+	delayedSetSuffix            = ":delayed"
+	delayedDataSuffix           = ":delayed:data"
+	delayedMessagesPollInterval = time.Second
+	delayedMessagesBatchSize    = 100
+
+	// The last error of the messages which failed is kept in a hash, until they are acknowledged or dead-lettered.
+	lastErrorsSuffix = ":errors"
 )
 
+// promoteDelayedMessagesScript atomically moves the delayed messages that are due to the stream.
+// KEYS[1] is the sorted set, KEYS[2] the payload hash and KEYS[3] the stream.
+// ARGV[1] is the current time in ms, ARGV[2] the max number of messages to move and ARGV[3] the approximate max length of the stream.
+const promoteDelayedMessagesScript = `
+local ids = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, tonumber(ARGV[2]))
+for _, id in ipairs(ids) do
+	local data = redis.call("HGET", KEYS[2], id)
+	if data then
+		if tonumber(ARGV[3]) > 0 then
+			redis.call("XADD", KEYS[3], "MAXLEN", "~", ARGV[3], "*", "data", data)
+		else
+			redis.call("XADD", KEYS[3], "*", "data", data)
+		end
+	end
+	redis.call("HDEL", KEYS[2], id)
+	redis.call("ZREM", KEYS[1], id)
+end
+return #ids
+`
+
 // redisStreams handles consuming from a Redis stream using
 // `XREADGROUP` for reading new messages and `XPENDING` and
 // `XCLAIM` for redelivering messages that previously failed.
@@ -47,7 +82,7 @@ type redisStreams struct {
 	clientSettings *rediscomponent.Settings
 	logger         logger.Logger
 
-	queue chan redisMessageWrapper
+	queue *pubsub.PriorityQueue
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -60,6 +95,9 @@ type redisMessageWrapper struct {
 	messageID string
 	message   pubsub.NewMessage
 	handler   pubsub.Handler
+	priority  uint8
+	// deliveryCount is the number of times the message has been delivered, including this one.
+	deliveryCount int64
 }
 
 // NewRedisStreams returns a new redis streams pub-sub implementation.
@@ -126,6 +164,21 @@ func parseRedisMetadata(meta pubsub.Metadata) (metadata, error) {
 		m.maxLenApprox = maxLenApprox
 	}
 
+	if val, ok := meta.Properties[maxDeliveryCount]; ok && val != "" {
+		maxDeliveryCount, err := strconv.ParseInt(val, 10, 64)
+		if err != nil || maxDeliveryCount < 0 {
+			return m, fmt.Errorf("redis streams error: invalid maxDeliveryCount %s", val)
+		}
+		m.maxDeliveryCount = maxDeliveryCount
+	}
+
+	if val, ok := meta.Properties[deadLetterStream]; ok && val != "" {
+		if m.maxDeliveryCount == 0 {
+			return m, errors.New("redis streams error: deadLetterStream requires maxDeliveryCount")
+		}
+		m.deadLetterStream = val
+	}
+
 	return m, nil
 }
 
@@ -145,7 +198,8 @@ func (r *redisStreams) Init(metadata pubsub.Metadata) error {
 	if _, err = r.client.PingResult(r.ctx); err != nil {
 		return fmt.Errorf("redis streams: error connecting to redis at %s: %s", r.clientSettings.Host, err)
 	}
-	r.queue = make(chan redisMessageWrapper, int(r.metadata.queueDepth))
+	// Messages waiting for a worker are handled by priority
+	r.queue = pubsub.NewPriorityQueue(int(r.metadata.queueDepth))
 
 	for i := uint(0); i < r.metadata.concurrency; i++ {
 		go r.worker()
@@ -155,7 +209,33 @@ func (r *redisStreams) Init(metadata pubsub.Metadata) error {
 }
 
 func (r *redisStreams) Publish(ctx context.Context, req *pubsub.PublishRequest) error {
-	_, err := r.client.XAdd(ctx, req.Topic, r.metadata.maxLenApprox, map[string]interface{}{"data": req.Data})
+	scheduled, ok, err := contribMetadata.TryGetScheduledTime(req.Metadata)
+	if err != nil {
+		return fmt.Errorf("redis streams: error from publish: %s", err)
+	}
+	if ok && time.Until(scheduled) > 0 {
+		return r.publishDelayed(ctx, req, scheduled)
+	}
+
+	values := map[string]interface{}{"data": req.Data}
+	priority, ok, err := contribMetadata.TryGetPriority(req.Metadata)
+	if err != nil {
+		return fmt.Errorf("redis streams: error from publish: %s", err)
+	}
+	if ok {
+		values[contribMetadata.PriorityMetadataKey] = priority
+	}
+
+	// The stream can be trimmed to a different length than the one of the component
+	maxLen := r.metadata.maxLenApprox
+	if val, ok := req.Metadata[maxLenApprox]; ok && val != "" {
+		maxLen, err = strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return fmt.Errorf("redis streams: error from publish: invalid maxLenApprox %s, %s", val, err)
+		}
+	}
+
+	_, err = r.client.XAdd(ctx, req.Topic, maxLen, values)
 	if err != nil {
 		return fmt.Errorf("redis streams: error from publish: %s", err)
 	}
@@ -163,8 +243,36 @@ func (r *redisStreams) Publish(ctx context.Context, req *pubsub.PublishRequest)
 	return nil
 }
 
+// publishDelayed stores a message until it is due. It is moved to the stream by `promoteDelayedMessagesLoop`.
+// Note that in cluster mode the stream name must use a hash tag so that all keys belong to the same slot.
+func (r *redisStreams) publishDelayed(ctx context.Context, req *pubsub.PublishRequest, scheduled time.Time) error {
+	id := uuid.New().String()
+
+	pipe := r.client.TxPipeline()
+	pipe.Do(ctx, "HSET", req.Topic+delayedDataSuffix, id, req.Data)
+	pipe.Do(ctx, "ZADD", req.Topic+delayedSetSuffix, scheduled.UnixMilli(), id)
+	if err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("redis streams: error from delayed publish: %s", err)
+	}
+
+	return nil
+}
+
 func (r *redisStreams) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
-	err := r.client.XGroupCreateMkStream(ctx, req.Topic, r.metadata.consumerID, "0")
+	// The start position only applies when the consumer group is created
+	startID := "0"
+	position, err := pubsub.StartPosition(req)
+	if err != nil {
+		return fmt.Errorf("redis streams: %w", err)
+	}
+	if position != nil {
+		startID, err = groupStartID(*position)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = r.client.XGroupCreateMkStream(ctx, req.Topic, r.metadata.consumerID, startID)
 	// Ignore BUSYGROUP errors
 	if err != nil && err.Error() != "BUSYGROUP Consumer Group name already exists" {
 		r.logger.Errorf("redis streams: %s", err)
@@ -173,23 +281,61 @@ func (r *redisStreams) Subscribe(ctx context.Context, req pubsub.SubscribeReques
 
 	go r.pollNewMessagesLoop(ctx, req.Topic, handler)
 	go r.reclaimPendingMessagesLoop(ctx, req.Topic, handler)
+	go r.promoteDelayedMessagesLoop(ctx, req.Topic)
 
 	return nil
 }
 
+// promoteDelayedMessagesLoop periodically moves the delayed messages that are due to the stream.
+// Every subscriber runs it, which is safe because the script is atomic.
+func (r *redisStreams) promoteDelayedMessagesLoop(ctx context.Context, stream string) {
+	ticker := time.NewTicker(delayedMessagesPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			r.promoteDelayedMessages(ctx, stream)
+		}
+	}
+}
+
+// promoteDelayedMessages moves the delayed messages that are due to the stream, in batches.
+func (r *redisStreams) promoteDelayedMessages(ctx context.Context, stream string) {
+	for {
+		moved, _, err := r.client.EvalInt(ctx, promoteDelayedMessagesScript,
+			[]string{stream + delayedSetSuffix, stream + delayedDataSuffix, stream},
+			time.Now().UnixMilli(), delayedMessagesBatchSize, r.metadata.maxLenApprox,
+		)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.logger.Errorf("redis streams: error promoting delayed messages for stream %s: %s", stream, err)
+			}
+			return
+		}
+
+		if moved == nil || *moved < delayedMessagesBatchSize {
+			return
+		}
+	}
+}
+
 // enqueueMessages is a shared function that funnels new messages (via polling)
 // and redelivered messages (via reclaiming) to a channel where workers can
 // pick them up for processing.
-func (r *redisStreams) enqueueMessages(ctx context.Context, stream string, handler pubsub.Handler, msgs []rediscomponent.RedisXMessage) {
+func (r *redisStreams) enqueueMessages(ctx context.Context, stream string, handler pubsub.Handler, msgs []rediscomponent.RedisXMessage, deliveryCounts map[string]int64) {
 	for _, msg := range msgs {
 		rmsg := createRedisMessageWrapper(ctx, stream, handler, msg)
+		rmsg.deliveryCount = 1
+		if count, ok := deliveryCounts[msg.ID]; ok {
+			rmsg.deliveryCount = count
+		}
 
-		select {
-		// Might block if the queue is full so we need the ctx.Done below.
-		case r.queue <- rmsg:
-			// Noop
-		// Handle cancelation
-		case <-ctx.Done():
+		// Might block if the queue is full, until ctx is canceled.
+		if err := r.queue.Push(ctx, rmsg.priority, rmsg); err != nil {
 			return
 		}
 	}
@@ -208,7 +354,7 @@ func createRedisMessageWrapper(ctx context.Context, stream string, handler pubsu
 		}
 	}
 
-	return redisMessageWrapper{
+	wrapper := redisMessageWrapper{
 		ctx: ctx,
 		message: pubsub.NewMessage{
 			Topic: stream,
@@ -217,20 +363,27 @@ func createRedisMessageWrapper(ctx context.Context, stream string, handler pubsu
 		messageID: msg.ID,
 		handler:   handler,
 	}
+	if priorityValue, ok := msg.Values[contribMetadata.PriorityMetadataKey].(string); ok {
+		priority, ok, _ := contribMetadata.TryGetPriority(map[string]string{contribMetadata.PriorityMetadataKey: priorityValue})
+		if ok {
+			wrapper.priority = priority
+			wrapper.message.Metadata = map[string]string{contribMetadata.PriorityMetadataKey: priorityValue}
+		}
+	}
+
+	return wrapper
 }
 
 // worker runs in separate goroutine(s) and pull messages from a channel for processing.
 // The number of workers is controlled by the `concurrency` setting.
 func (r *redisStreams) worker() {
 	for {
-		select {
-		// Handle cancelation
-		case <-r.ctx.Done():
+		// Returns false on cancelation
+		msg, ok := r.queue.Pop(r.ctx)
+		if !ok {
 			return
-
-		case msg := <-r.queue:
-			r.processMessage(msg)
 		}
+		r.processMessage(msg.(redisMessageWrapper))
 	}
 }
 
@@ -248,6 +401,12 @@ func (r *redisStreams) processMessage(msg redisMessageWrapper) error {
 	}
 	if err := msg.handler(ctx, &msg.message); err != nil {
 		r.logger.Errorf("Error processing Redis message %s: %v", msg.messageID, err)
+		if r.metadata.maxDeliveryCount > 0 {
+			// Recorded for the dead letter stream
+			if recordErr := r.client.DoWrite(context.Background(), "HSET", msg.message.Topic+lastErrorsSuffix, msg.messageID, err.Error()); recordErr != nil {
+				r.logger.Warnf("Error recording the error of Redis message %s: %v", msg.messageID, recordErr)
+			}
+		}
 
 		return err
 	}
@@ -258,10 +417,58 @@ func (r *redisStreams) processMessage(msg redisMessageWrapper) error {
 
 		return err
 	}
+	if r.metadata.maxDeliveryCount > 0 && msg.deliveryCount > 1 {
+		r.forgetLastError(msg.message.Topic, msg.messageID)
+	}
 
 	return nil
 }
 
+// forgetLastError removes the last error recorded for a message which is no longer pending.
+func (r *redisStreams) forgetLastError(stream string, messageID string) {
+	if err := r.client.DoWrite(context.Background(), "HDEL", stream+lastErrorsSuffix, messageID); err != nil {
+		r.logger.Warnf("Error removing the last error of Redis message %s: %v", messageID, err)
+	}
+}
+
+// deadLetterMessage moves a message delivered maxDeliveryCount times to the dead letter stream, with the stream it comes from,
+// its delivery count and the last error of its handler, and acknowledges it.
+// The message is dropped if there is no dead letter stream.
+// Note that in cluster mode the streams must use the same hash tag, as the message is moved in a transaction.
+func (r *redisStreams) deadLetterMessage(ctx context.Context, stream string, msg rediscomponent.RedisXMessage, deliveryCount int64) error {
+	lastError := ""
+	res, err := r.client.DoRead(ctx, "HGET", stream+lastErrorsSuffix, msg.ID)
+	if err != nil && !errors.Is(err, r.client.GetNilValueError()) {
+		return err
+	}
+	if res != nil {
+		lastError = fmt.Sprint(res)
+	}
+
+	pipe := r.client.TxPipeline()
+	if r.metadata.deadLetterStream != "" {
+		args := []interface{}{"XADD", r.metadata.deadLetterStream}
+		if r.metadata.maxLenApprox > 0 {
+			args = append(args, "MAXLEN", "~", r.metadata.maxLenApprox)
+		}
+		args = append(args, "*",
+			"data", msg.Values["data"],
+			"stream", stream,
+			"messageID", msg.ID,
+			"deliveryCount", deliveryCount,
+			"lastError", lastError,
+		)
+		if priority, ok := msg.Values[contribMetadata.PriorityMetadataKey]; ok {
+			args = append(args, contribMetadata.PriorityMetadataKey, priority)
+		}
+		pipe.Do(ctx, args...)
+	}
+	pipe.Do(ctx, "XACK", stream, r.metadata.consumerID, msg.ID)
+	pipe.Do(ctx, "HDEL", stream+lastErrorsSuffix, msg.ID)
+
+	return pipe.Exec(ctx)
+}
+
 // pollMessagesLoop calls `XReadGroup` for new messages and funnels them to the message channel
 // by calling `enqueueMessages`.
 func (r *redisStreams) pollNewMessagesLoop(ctx context.Context, stream string, handler pubsub.Handler) {
@@ -282,7 +489,7 @@ func (r *redisStreams) pollNewMessagesLoop(ctx context.Context, stream string, h
 
 		// Enqueue messages for the returned streams
 		for _, s := range streams {
-			r.enqueueMessages(ctx, s.Stream, handler, s.Messages)
+			r.enqueueMessages(ctx, s.Stream, handler, s.Messages, nil)
 		}
 	}
 }
@@ -332,9 +539,12 @@ func (r *redisStreams) reclaimPendingMessages(ctx context.Context, stream string
 
 		// Filter out messages that have not timed out yet
 		msgIDs := make([]string, 0, len(pendingResult))
+		// The delivery counts once the messages are claimed
+		deliveryCounts := make(map[string]int64, len(pendingResult))
 		for _, msg := range pendingResult {
 			if msg.Idle >= r.metadata.processingTimeout {
 				msgIDs = append(msgIDs, msg.ID)
+				deliveryCounts[msg.ID] = msg.RetryCount + 1
 			}
 		}
 
@@ -357,8 +567,11 @@ func (r *redisStreams) reclaimPendingMessages(ctx context.Context, stream string
 			break
 		}
 
-		// Enqueue claimed messages
-		r.enqueueMessages(ctx, stream, handler, claimResult)
+		// Dead-letter the messages which have been delivered too many times, and enqueue the other claimed messages
+		if r.metadata.maxDeliveryCount > 0 {
+			claimResult = r.deadLetterExhaustedMessages(ctx, stream, claimResult, deliveryCounts)
+		}
+		r.enqueueMessages(ctx, stream, handler, claimResult, deliveryCounts)
 
 		// If the Redis nil error is returned, it means somes message in the pending
 		// state no longer exist. We need to acknowledge these messages to
@@ -379,6 +592,32 @@ func (r *redisStreams) reclaimPendingMessages(ctx context.Context, stream string
 	}
 }
 
+// deadLetterExhaustedMessages dead-letters the claimed messages which have already been delivered maxDeliveryCount times,
+// and returns the other ones.
+func (r *redisStreams) deadLetterExhaustedMessages(ctx context.Context, stream string, msgs []rediscomponent.RedisXMessage, deliveryCounts map[string]int64) []rediscomponent.RedisXMessage {
+	remaining := msgs[:0]
+	for _, msg := range msgs {
+		// The count includes the delivery which would follow the claim
+		if deliveryCounts[msg.ID] <= r.metadata.maxDeliveryCount {
+			remaining = append(remaining, msg)
+			continue
+		}
+
+		if err := r.deadLetterMessage(ctx, stream, msg, deliveryCounts[msg.ID]-1); err != nil {
+			// The message stays pending, and is dead-lettered by a later reclaim
+			r.logger.Errorf("error moving Redis message %s to the dead letter stream: %v", msg.ID, err)
+			continue
+		}
+		if r.metadata.deadLetterStream != "" {
+			r.logger.Warnf("Redis message %s of stream %s was moved to stream %s after %d deliveries", msg.ID, stream, r.metadata.deadLetterStream, deliveryCounts[msg.ID]-1)
+		} else {
+			r.logger.Warnf("Redis message %s of stream %s was dropped after %d deliveries", msg.ID, stream, deliveryCounts[msg.ID]-1)
+		}
+	}
+
+	return remaining
+}
+
 // removeMessagesThatNoLongerExistFromPending attempts to claim messages individually so that messages in the pending list
 // that no longer exist can be removed from the pending list. This is done by calling `XACK`.
 func (r *redisStreams) removeMessagesThatNoLongerExistFromPending(ctx context.Context, stream string, messageIDs map[string]struct{}, handler pubsub.Handler) {
@@ -402,10 +641,12 @@ func (r *redisStreams) removeMessagesThatNoLongerExistFromPending(ctx context.Co
 			// Use the background context in case subscriptionCtx is already closed
 			if err = r.client.XAck(context.Background(), stream, r.metadata.consumerID, pendingID); err != nil {
 				r.logger.Errorf("error acknowledging Redis message %s after failed claim for %s: %v", pendingID, stream, err)
+			} else if r.metadata.maxDeliveryCount > 0 {
+				r.forgetLastError(stream, pendingID)
 			}
 		} else {
 			// This should not happen but if it does the message should be processed.
-			r.enqueueMessages(ctx, stream, handler, claimResultSingleMsg)
+			r.enqueueMessages(ctx, stream, handler, claimResultSingleMsg, nil)
 		}
 	}
 }
@@ -422,7 +663,7 @@ func (r *redisStreams) Close() error {
 }
 
 func (r *redisStreams) Features() []pubsub.Feature {
-	return nil
+	return []pubsub.Feature{pubsub.FeatureDelayedPublish, pubsub.FeatureMessagePriority}
 }
 
 func (r *redisStreams) Ping() error {
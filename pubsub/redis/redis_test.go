@@ -19,7 +19,9 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
+	miniredis "github.com/alicebob/miniredis/v2"
 	"github.com/stretchr/testify/assert"
 
 	mdata "github.com/JY29/components-contrib/metadata"
@@ -54,6 +56,20 @@ func TestParseRedisMetadata(t *testing.T) {
 		assert.Equal(t, int64(1000), m.maxLenApprox)
 	})
 
+	t.Run("dead letter stream", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+		fakeProperties[deadLetterStream] = "dlq"
+
+		_, err := parseRedisMetadata(pubsub.Metadata{Base: mdata.Base{Properties: fakeProperties}})
+		assert.ErrorContains(t, err, maxDeliveryCount)
+
+		fakeProperties[maxDeliveryCount] = "5"
+		m, err := parseRedisMetadata(pubsub.Metadata{Base: mdata.Base{Properties: fakeProperties}})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), m.maxDeliveryCount)
+		assert.Equal(t, "dlq", m.deadLetterStream)
+	})
+
 	t.Run("consumerID is not given", func(t *testing.T) {
 		fakeProperties := getFakeProperties()
 
@@ -97,9 +113,9 @@ func TestProcessStreams(t *testing.T) {
 	// act
 	testRedisStream := &redisStreams{logger: logger.NewLogger("test")}
 	testRedisStream.ctx, testRedisStream.cancel = context.WithCancel(context.Background())
-	testRedisStream.queue = make(chan redisMessageWrapper, 10)
+	testRedisStream.queue = pubsub.NewPriorityQueue(10)
 	go testRedisStream.worker()
-	testRedisStream.enqueueMessages(context.Background(), fakeConsumerID, fakeHandler, generateRedisStreamTestData(2, 3, expectedData))
+	testRedisStream.enqueueMessages(context.Background(), fakeConsumerID, fakeHandler, generateRedisStreamTestData(2, 3, expectedData), nil)
 
 	// Wait for the handler to finish processing
 	wg.Wait()
@@ -126,3 +142,346 @@ func generateRedisStreamTestData(topicCount, messageCount int, data string) []in
 
 	return xmessageArray
 }
+
+func TestPromoteDelayedMessages(t *testing.T) {
+	s, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer s.Close()
+
+	fakeProperties := getFakeProperties()
+	fakeProperties["redisHost"] = s.Addr()
+	fakeProperties[enableTLS] = "false"
+	fakeProperties[concurrency] = "0"
+
+	testRedisStream := NewRedisStreams(logger.NewLogger("test")).(*redisStreams)
+	err = testRedisStream.Init(pubsub.Metadata{Base: mdata.Base{Properties: fakeProperties}})
+	assert.NoError(t, err)
+	defer testRedisStream.Close()
+
+	ctx := context.Background()
+	err = testRedisStream.publishDelayed(ctx, &pubsub.PublishRequest{Topic: "mytopic", Data: []byte("due")}, time.Now().Add(-time.Second))
+	assert.NoError(t, err)
+	err = testRedisStream.Publish(ctx, &pubsub.PublishRequest{
+		Topic:    "mytopic",
+		Data:     []byte("later"),
+		Metadata: map[string]string{mdata.DelayMetadataKey: "3600"},
+	})
+	assert.NoError(t, err)
+
+	members, err := s.ZMembers("mytopic" + delayedSetSuffix)
+	assert.NoError(t, err)
+	assert.Len(t, members, 2)
+
+	testRedisStream.promoteDelayedMessages(ctx, "mytopic")
+
+	entries, err := s.Stream("mytopic")
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, []string{"data", "due"}, entries[0].Values)
+	}
+	members, err = s.ZMembers("mytopic" + delayedSetSuffix)
+	assert.NoError(t, err)
+	assert.Len(t, members, 1)
+}
+
+func TestTopicAdmin(t *testing.T) {
+	s, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer s.Close()
+
+	fakeProperties := getFakeProperties()
+	fakeProperties["redisHost"] = s.Addr()
+	fakeProperties[enableTLS] = "false"
+	fakeProperties[concurrency] = "0"
+
+	testRedisStream := NewRedisStreams(logger.NewLogger("test")).(*redisStreams)
+	err = testRedisStream.Init(pubsub.Metadata{Base: mdata.Base{Properties: fakeProperties}})
+	assert.NoError(t, err)
+	defer testRedisStream.Close()
+
+	ctx := context.Background()
+	var admin pubsub.TopicAdmin = testRedisStream
+
+	assert.NoError(t, admin.CreateTopic(ctx, pubsub.CreateTopicRequest{Topic: "orders"}))
+	assert.NoError(t, admin.CreateTopic(ctx, pubsub.CreateTopicRequest{Topic: "orders"}))
+	assert.ErrorIs(t, admin.CreateTopic(ctx, pubsub.CreateTopicRequest{Topic: "other", Partitions: 3}), pubsub.ErrTopicAdminNotSupported)
+	assert.NoError(t, testRedisStream.Publish(ctx, &pubsub.PublishRequest{Topic: "payments", Data: []byte("1")}))
+	s.Set("notastream", "value")
+
+	topics, err := admin.ListTopics(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"orders", "payments"}, topics)
+
+	desc, err := admin.DescribeTopic(ctx, "payments")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", desc.Metadata["length"])
+	_, err = admin.DescribeTopic(ctx, "missing")
+	assert.ErrorIs(t, err, pubsub.ErrTopicNotFound)
+
+	assert.NoError(t, admin.CreateSubscription(ctx, pubsub.CreateSubscriptionRequest{Topic: "payments", Subscription: "app1"}))
+	assert.NoError(t, admin.CreateSubscription(ctx, pubsub.CreateSubscriptionRequest{Topic: "payments", Subscription: "app1"}))
+	subscriptions, err := admin.ListSubscriptions(ctx, "payments")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app1"}, subscriptions)
+	sub, err := admin.DescribeSubscription(ctx, "payments", "app1")
+	assert.NoError(t, err)
+	assert.Equal(t, "0", sub.Metadata["pending"])
+
+	assert.NoError(t, admin.DeleteSubscription(ctx, "payments", "app1"))
+	assert.ErrorIs(t, admin.DeleteSubscription(ctx, "payments", "app1"), pubsub.ErrSubscriptionNotFound)
+	assert.NoError(t, admin.DeleteTopic(ctx, "payments"))
+	assert.ErrorIs(t, admin.DeleteTopic(ctx, "payments"), pubsub.ErrTopicNotFound)
+}
+
+func TestBacklog(t *testing.T) {
+	s, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer s.Close()
+
+	fakeProperties := getFakeProperties()
+	fakeProperties["redisHost"] = s.Addr()
+	fakeProperties[enableTLS] = "false"
+	fakeProperties[concurrency] = "0"
+	// The stream is not trimmed, so that the entries can be counted across pages
+	fakeProperties[maxLenApprox] = ""
+
+	testRedisStream := NewRedisStreams(logger.NewLogger("test")).(*redisStreams)
+	err = testRedisStream.Init(pubsub.Metadata{Base: mdata.Base{Properties: fakeProperties}})
+	assert.NoError(t, err)
+	defer testRedisStream.Close()
+
+	ctx := context.Background()
+	s.SetTime(time.Now().Add(-time.Minute))
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, testRedisStream.Publish(ctx, &pubsub.PublishRequest{Topic: "orders", Data: []byte("1")}))
+	}
+	assert.NoError(t, testRedisStream.client.XGroupCreateMkStream(ctx, "orders", "fakeConsumer", "0"))
+
+	backlog, err := pubsub.InspectBacklog(ctx, testRedisStream, "orders", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "fakeConsumer", backlog.Subscription)
+	assert.Equal(t, int64(3), backlog.Pending)
+	assert.Equal(t, int64(0), backlog.Unacknowledged)
+	assert.InDelta(t, time.Minute, backlog.OldestMessageAge, float64(10*time.Second))
+
+	// Deliver one message, without acknowledging it
+	streams, err := testRedisStream.client.XReadGroupResult(ctx, "fakeConsumer", "fakeConsumer", []string{"orders", ">"}, 1, 0)
+	assert.NoError(t, err)
+	assert.Len(t, streams[0].Messages, 1)
+	backlog, err = pubsub.InspectBacklog(ctx, testRedisStream, "orders", "fakeConsumer")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), backlog.Pending)
+	assert.Equal(t, int64(1), backlog.Unacknowledged)
+	assert.InDelta(t, time.Minute, backlog.OldestMessageAge, float64(10*time.Second))
+
+	// The entries are counted by pages
+	for i := 0; i < backlogPageSize; i++ {
+		assert.NoError(t, testRedisStream.Publish(ctx, &pubsub.PublishRequest{Topic: "orders", Data: []byte("1")}))
+	}
+	backlog, err = pubsub.InspectBacklog(ctx, testRedisStream, "orders", "fakeConsumer")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(backlogPageSize+2), backlog.Pending)
+
+	_, err = pubsub.InspectBacklog(ctx, testRedisStream, "orders", "missing")
+	assert.ErrorIs(t, err, pubsub.ErrSubscriptionNotFound)
+}
+
+func TestGroupStartID(t *testing.T) {
+	ts := time.UnixMilli(1678000000000)
+	tests := []struct {
+		position pubsub.Position
+		expected string
+	}{
+		{pubsub.Position{Type: pubsub.PositionEarliest}, "0"},
+		{pubsub.Position{Type: pubsub.PositionLatest}, "$"},
+		{pubsub.Position{Type: pubsub.PositionTimestamp, Timestamp: ts}, "1677999999999-18446744073709551615"},
+		{pubsub.Position{Type: pubsub.PositionOffset, Offset: "1678000000000-5"}, "1678000000000-4"},
+		{pubsub.Position{Type: pubsub.PositionOffset, Offset: "1678000000000"}, "1677999999999-18446744073709551615"},
+		{pubsub.Position{Type: pubsub.PositionOffset, Offset: "0-0"}, "0"},
+	}
+	for _, tt := range tests {
+		id, err := groupStartID(tt.position)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.expected, id)
+	}
+
+	_, err := groupStartID(pubsub.Position{Type: pubsub.PositionOffset, Offset: "invalid"})
+	assert.Error(t, err)
+}
+
+func TestSubscribeStartPosition(t *testing.T) {
+	s, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer s.Close()
+
+	fakeProperties := getFakeProperties()
+	fakeProperties["redisHost"] = s.Addr()
+	fakeProperties[enableTLS] = "false"
+	fakeProperties[concurrency] = "0"
+
+	testRedisStream := NewRedisStreams(logger.NewLogger("test")).(*redisStreams)
+	err = testRedisStream.Init(pubsub.Metadata{Base: mdata.Base{Properties: fakeProperties}})
+	assert.NoError(t, err)
+	defer testRedisStream.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err = s.XAdd("orders", "1000-0", []string{"data", "1"})
+	assert.NoError(t, err)
+	_, err = s.XAdd("orders", "2000-0", []string{"data", "2"})
+	assert.NoError(t, err)
+
+	err = testRedisStream.Subscribe(ctx, pubsub.SubscribeRequest{
+		Topic:    "orders",
+		Metadata: map[string]string{mdata.StartPositionMetadataKey: "2000-0"},
+	}, func(ctx context.Context, msg *pubsub.NewMessage) error { return nil })
+	assert.NoError(t, err)
+
+	// With no workers, the messages delivered to the group stay in the queue
+	popCtx, popCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer popCancel()
+	item, ok := testRedisStream.queue.Pop(popCtx)
+	if !ok {
+		t.Fatal("timeout waiting for the message")
+	}
+	msg := item.(redisMessageWrapper)
+	assert.Equal(t, "2000-0", msg.messageID)
+	assert.Equal(t, "2", string(msg.message.Data))
+
+	err = testRedisStream.Subscribe(ctx, pubsub.SubscribeRequest{
+		Topic:    "payments",
+		Metadata: map[string]string{mdata.StartPositionMetadataKey: "not-an-id"},
+	}, func(ctx context.Context, msg *pubsub.NewMessage) error { return nil })
+	assert.Error(t, err)
+}
+
+func TestPublishPriority(t *testing.T) {
+	s, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer s.Close()
+
+	fakeProperties := getFakeProperties()
+	fakeProperties["redisHost"] = s.Addr()
+	fakeProperties[enableTLS] = "false"
+	fakeProperties[concurrency] = "0"
+
+	testRedisStream := NewRedisStreams(logger.NewLogger("test")).(*redisStreams)
+	err = testRedisStream.Init(pubsub.Metadata{Base: mdata.Base{Properties: fakeProperties}})
+	assert.NoError(t, err)
+	defer testRedisStream.Close()
+	assert.True(t, pubsub.FeatureMessagePriority.IsPresent(testRedisStream.Features()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, priority := range []string{"", "1", "9"} {
+		err = testRedisStream.Publish(ctx, &pubsub.PublishRequest{
+			Topic:    "orders",
+			Data:     []byte("priority " + priority),
+			Metadata: map[string]string{mdata.PriorityMetadataKey: priority},
+		})
+		assert.NoError(t, err)
+	}
+	err = testRedisStream.Publish(ctx, &pubsub.PublishRequest{Topic: "orders", Metadata: map[string]string{mdata.PriorityMetadataKey: "high"}})
+	assert.Error(t, err)
+
+	err = testRedisStream.Subscribe(ctx, pubsub.SubscribeRequest{
+		Topic:    "orders",
+		Metadata: map[string]string{mdata.StartPositionMetadataKey: "earliest"},
+	}, func(ctx context.Context, msg *pubsub.NewMessage) error { return nil })
+	assert.NoError(t, err)
+
+	// With no workers, the messages wait in the queue, from which they are popped by priority
+	assert.Eventually(t, func() bool { return testRedisStream.queue.Len() == 3 }, 5*time.Second, 10*time.Millisecond)
+	var received []string
+	for i := 0; i < 3; i++ {
+		item, ok := testRedisStream.queue.Pop(ctx)
+		assert.True(t, ok)
+		received = append(received, string(item.(redisMessageWrapper).message.Data))
+	}
+	assert.Equal(t, []string{"priority 9", "priority 1", "priority "}, received)
+}
+
+func TestDeadLetterStream(t *testing.T) {
+	s, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer s.Close()
+
+	fakeProperties := getFakeProperties()
+	fakeProperties["redisHost"] = s.Addr()
+	fakeProperties[enableTLS] = "false"
+	fakeProperties[processingTimeout] = "10ms"
+	fakeProperties[redeliverInterval] = "20ms"
+	fakeProperties[maxDeliveryCount] = "3"
+	fakeProperties[deadLetterStream] = "orders-dlq"
+
+	testRedisStream := NewRedisStreams(logger.NewLogger("test")).(*redisStreams)
+	err = testRedisStream.Init(pubsub.Metadata{Base: mdata.Base{Properties: fakeProperties}})
+	assert.NoError(t, err)
+	defer testRedisStream.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var lock sync.Mutex
+	deliveries := 0
+	err = testRedisStream.Subscribe(ctx, pubsub.SubscribeRequest{Topic: "orders"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		lock.Lock()
+		defer lock.Unlock()
+		deliveries++
+		return fmt.Errorf("delivery %d failed", deliveries)
+	})
+	assert.NoError(t, err)
+	err = testRedisStream.Publish(ctx, &pubsub.PublishRequest{Topic: "orders", Data: []byte("poison")})
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		n, _ := testRedisStream.client.XLenResult(ctx, "orders-dlq")
+		return n == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	lock.Lock()
+	assert.Equal(t, 3, deliveries)
+	lock.Unlock()
+	msgs, err := testRedisStream.client.XRangeNResult(ctx, "orders-dlq", "-", "+", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "poison", msgs[0].Values["data"])
+	assert.Equal(t, "orders", msgs[0].Values["stream"])
+	assert.Equal(t, "3", msgs[0].Values["deliveryCount"])
+	assert.Equal(t, "delivery 3 failed", msgs[0].Values["lastError"])
+	pending, err := testRedisStream.client.XPendingResult(ctx, "orders", fakeProperties[consumerID])
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), pending.Count)
+	assert.False(t, s.Exists("orders"+lastErrorsSuffix))
+}
+
+func TestPublishMaxLenApprox(t *testing.T) {
+	s, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer s.Close()
+
+	fakeProperties := getFakeProperties()
+	fakeProperties["redisHost"] = s.Addr()
+	fakeProperties[enableTLS] = "false"
+	fakeProperties[concurrency] = "0"
+
+	testRedisStream := NewRedisStreams(logger.NewLogger("test")).(*redisStreams)
+	err = testRedisStream.Init(pubsub.Metadata{Base: mdata.Base{Properties: fakeProperties}})
+	assert.NoError(t, err)
+	defer testRedisStream.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		err = testRedisStream.Publish(ctx, &pubsub.PublishRequest{
+			Topic:    "orders",
+			Data:     []byte("order"),
+			Metadata: map[string]string{maxLenApprox: "2"},
+		})
+		assert.NoError(t, err)
+	}
+	// Miniredis trims exactly
+	n, err := testRedisStream.client.XLenResult(ctx, "orders")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	err = testRedisStream.Publish(ctx, &pubsub.PublishRequest{Topic: "orders", Metadata: map[string]string{maxLenApprox: "two"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/JY29/components-contrib/pubsub"
+)
+
+// maxStreamIDSeq is the highest sequence number of a stream ID.
+const maxStreamIDSeq = "18446744073709551615"
+
+// Seek moves the last delivered ID of the consumer group with XGROUP SETID.
+// The entries already pending in the PEL of the group are not affected, and are still redelivered when they are reclaimed.
+func (r *redisStreams) Seek(ctx context.Context, topic string, subscription string, position pubsub.Position) error {
+	if subscription == "" {
+		subscription = r.metadata.consumerID
+	}
+
+	id, err := groupStartID(position)
+	if err != nil {
+		return err
+	}
+	if _, err = r.describeGroup(ctx, topic, subscription); err != nil {
+		return err
+	}
+	if err = r.client.XGroupSetID(ctx, topic, subscription, id); err != nil {
+		return fmt.Errorf("redis streams: error setting the ID of consumer group %s: %w", subscription, err)
+	}
+
+	return nil
+}
+
+// groupStartID returns the last delivered ID to set on a consumer group so that it is next delivered the entry at position.
+func groupStartID(position pubsub.Position) (string, error) {
+	switch position.Type {
+	case pubsub.PositionEarliest:
+		return "0", nil
+	case pubsub.PositionLatest:
+		return "$", nil
+	case pubsub.PositionTimestamp:
+		return previousStreamID(strconv.FormatInt(position.Timestamp.UnixMilli(), 10) + "-0")
+	case pubsub.PositionOffset:
+		id := position.Offset
+		if !strings.Contains(id, "-") {
+			id += "-0"
+		}
+		return previousStreamID(id)
+	default:
+		return "", fmt.Errorf("redis streams: unsupported position type %q", position.Type)
+	}
+}
+
+// previousStreamID returns the ID right before id, as the IDs set on consumer groups are exclusive.
+func previousStreamID(id string) (string, error) {
+	msPart, seqPart, _ := strings.Cut(id, "-")
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("redis streams: invalid stream ID %q", id)
+	}
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("redis streams: invalid stream ID %q", id)
+	}
+
+	switch {
+	case seq > 0:
+		return fmt.Sprintf("%d-%d", ms, seq-1), nil
+	case ms > 0:
+		return fmt.Sprintf("%d-%s", ms-1, maxStreamIDSeq), nil
+	default:
+		return "0", nil
+	}
+}
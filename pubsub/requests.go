@@ -13,6 +13,8 @@ limitations under the License.
 
 package pubsub
 
+import "time"
+
 // PublishRequest is the request to publish a message.
 type PublishRequest struct {
 	Data        []byte            `json:"data"`
@@ -66,3 +68,19 @@ type BulkSubscribeConfig struct {
 	MaxBulkSubAwaitDurationMs int `json:"maxBulkSubAwaitDurationMs"`
 	MaxBulkSizeBytes          int `json:"maxBulkSizeBytes"`
 }
+
+// CreateTopicRequest is the request to create a topic.
+// Partitions and Retention are left to the defaults of the broker when zero.
+type CreateTopicRequest struct {
+	Topic      string            `json:"topic"`
+	Partitions int               `json:"partitions,omitempty"`
+	Retention  time.Duration     `json:"retention,omitempty"`
+	Metadata   map[string]string `json:"metadata"`
+}
+
+// CreateSubscriptionRequest is the request to create a subscription to a topic.
+type CreateSubscriptionRequest struct {
+	Topic        string            `json:"topic"`
+	Subscription string            `json:"subscription"`
+	Metadata     map[string]string `json:"metadata"`
+}
@@ -13,6 +13,8 @@ limitations under the License.
 
 package pubsub
 
+import "time"
+
 // AppResponseStatus represents a status of a PubSub response.
 type AppResponseStatus string
 
@@ -82,3 +84,19 @@ func NewBulkPublishResponse(messages []BulkMessageEntry, err error) BulkPublishR
 	}
 	return response
 }
+
+// TopicDescription describes a topic of the broker.
+// Partitions and Retention are zero when they do not apply to the broker, or the retention is unlimited.
+type TopicDescription struct {
+	Name       string            `json:"name"`
+	Partitions int               `json:"partitions,omitempty"`
+	Retention  time.Duration     `json:"retention,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+// SubscriptionDescription describes a subscription to a topic.
+type SubscriptionDescription struct {
+	Name     string            `json:"name"`
+	Topic    string            `json:"topic"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
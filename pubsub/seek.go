@@ -0,0 +1,97 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JY29/components-contrib/metadata"
+)
+
+// PositionType is the kind of position a subscription is started or reset at.
+type PositionType string
+
+const (
+	// PositionEarliest is the oldest message retained by the broker.
+	PositionEarliest PositionType = "earliest"
+	// PositionLatest is the end of the topic: only the messages published afterwards are delivered.
+	PositionLatest PositionType = "latest"
+	// PositionTimestamp is the first message published at or after a point in time.
+	PositionTimestamp PositionType = "timestamp"
+	// PositionOffset is a broker-specific offset, sequence or message ID, which is delivered first.
+	PositionOffset PositionType = "offset"
+)
+
+// Position is a position in a topic.
+type Position struct {
+	Type      PositionType
+	Timestamp time.Time
+	Offset    string
+}
+
+// ParsePosition parses the value of metadata.StartPositionMetadataKey.
+// It is "earliest", "latest", an RFC3339 timestamp, or otherwise an offset.
+func ParsePosition(val string) (Position, error) {
+	val = strings.TrimSpace(val)
+	switch strings.ToLower(val) {
+	case "":
+		return Position{}, fmt.Errorf("invalid %s: empty value", metadata.StartPositionMetadataKey)
+	case string(PositionEarliest), "oldest":
+		return Position{Type: PositionEarliest}, nil
+	case string(PositionLatest), "newest":
+		return Position{Type: PositionLatest}, nil
+	}
+
+	if ts, err := time.Parse(time.RFC3339Nano, val); err == nil {
+		return Position{Type: PositionTimestamp, Timestamp: ts}, nil
+	}
+
+	return Position{Type: PositionOffset, Offset: val}, nil
+}
+
+// StartPosition returns the position set in the subscription metadata with metadata.StartPositionMetadataKey, if any.
+func StartPosition(req SubscribeRequest) (*Position, error) {
+	val, ok := req.Metadata[metadata.StartPositionMetadataKey]
+	if !ok || val == "" {
+		return nil, nil
+	}
+
+	position, err := ParsePosition(val)
+	if err != nil {
+		return nil, err
+	}
+
+	return &position, nil
+}
+
+// Seeker is implemented by the PubSub components that can move a subscription to a position in a topic,
+// for example to reprocess the messages published after a point in time.
+type Seeker interface {
+	// Seek resets a subscription to a topic to the given position.
+	// When subscription is empty, the consumer group, or consumer ID, of the component is used.
+	// Messages before the position are considered processed, and messages from the position onwards are delivered again.
+	Seek(ctx context.Context, topic string, subscription string, position Position) error
+}
+
+// Seek resets a subscription to a topic to the given position, if the pubsub implements Seeker.
+func Seek(ctx context.Context, pubsub PubSub, topic string, subscription string, position Position) error {
+	if seeker, ok := pubsub.(Seeker); ok {
+		return seeker.Seek(ctx, topic, subscription, position)
+	}
+
+	return fmt.Errorf("seek is not implemented by this pubsub")
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/JY29/components-contrib/metadata"
+)
+
+func TestParsePosition(t *testing.T) {
+	ts := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	tests := map[string]Position{
+		"earliest":             {Type: PositionEarliest},
+		"Latest":               {Type: PositionLatest},
+		"oldest":               {Type: PositionEarliest},
+		"2023-03-01T10:00:00Z": {Type: PositionTimestamp, Timestamp: ts},
+		"42":                   {Type: PositionOffset, Offset: "42"},
+		" 1678000000000-0 ":    {Type: PositionOffset, Offset: "1678000000000-0"},
+	}
+	for val, expected := range tests {
+		position, err := ParsePosition(val)
+		assert.NoError(t, err)
+		assert.True(t, expected.Timestamp.Equal(position.Timestamp), val)
+		expected.Timestamp = position.Timestamp
+		assert.Equal(t, expected, position, val)
+	}
+
+	_, err := ParsePosition("")
+	assert.Error(t, err)
+}
+
+func TestStartPosition(t *testing.T) {
+	position, err := StartPosition(SubscribeRequest{Topic: "orders"})
+	assert.NoError(t, err)
+	assert.Nil(t, position)
+
+	position, err = StartPosition(SubscribeRequest{
+		Topic:    "orders",
+		Metadata: map[string]string{metadata.StartPositionMetadataKey: "latest"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &Position{Type: PositionLatest}, position)
+}
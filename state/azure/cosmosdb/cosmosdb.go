@@ -352,6 +352,10 @@ func (c *StateStore) Delete(ctx context.Context, req *state.DeleteRequest) error
 
 // Multi performs a transactional operation. succeeds only if all operations succeed, and fails if one or more operations fail.
 func (c *StateStore) Multi(ctx context.Context, request *state.TransactionalStateRequest) (err error) {
+	if len(request.Outbox) > 0 {
+		return state.ErrOutboxNotSupported
+	}
+
 	if len(request.Operations) == 0 {
 		c.logger.Debugf("No Operations Provided")
 		return nil
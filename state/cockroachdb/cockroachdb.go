@@ -94,6 +94,10 @@ func (c *CockroachDB) BulkSet(ctx context.Context, req []state.SetRequest) error
 
 // Multi handles multiple transactions. Implements TransactionalStore.
 func (c *CockroachDB) Multi(ctx context.Context, request *state.TransactionalStateRequest) error {
+	if len(request.Outbox) > 0 {
+		return state.ErrOutboxNotSupported
+	}
+
 	return c.dbaccess.ExecuteMulti(ctx, request)
 }
 
@@ -92,6 +92,16 @@ func TestInitRunsDBAccessInit(t *testing.T) {
 	assert.True(t, fake.initExecuted)
 }
 
+// Proves that the outbox messages are rejected, as they are not stored.
+func TestMultiRejectsOutbox(t *testing.T) {
+	t.Parallel()
+	pgs, _ := createCockroachDBWithFake(t)
+	err := pgs.Multi(context.Background(), &state.TransactionalStateRequest{
+		Outbox: []state.OutboxMessage{{PubsubName: "pubsub", Topic: "topic"}},
+	})
+	assert.ErrorIs(t, err, state.ErrOutboxNotSupported)
+}
+
 func createCockroachDBWithFake(t *testing.T) (*CockroachDB, *fakeDBaccess) {
 	t.Helper()
 
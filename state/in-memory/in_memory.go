@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -370,12 +371,16 @@ func (store *inMemoryStore) BulkSet(ctx context.Context, req []state.SetRequest)
 }
 
 func (store *inMemoryStore) Multi(ctx context.Context, request *state.TransactionalStateRequest) error {
-	if len(request.Operations) == 0 {
+	operations, err := state.TransactionOperations(request)
+	if err != nil {
+		return err
+	}
+	if len(operations) == 0 {
 		return nil
 	}
 
 	// step1: validate parameters
-	for i, o := range request.Operations {
+	for i, o := range operations {
 		if o.Operation == state.Upsert {
 			s := o.Request.(state.SetRequest)
 			ttlInSeconds, err := store.doSetValidateParameters(&s)
@@ -393,7 +398,7 @@ func (store *inMemoryStore) Multi(ctx context.Context, request *state.Transactio
 				isBinary: isBinary,
 			}
 			// replace with innerSetRequest
-			request.Operations[i].Request = innerSetRequest
+			operations[i].Request = innerSetRequest
 		} else if o.Operation == state.Delete {
 			d := o.Request.(state.DeleteRequest)
 			err := state.CheckRequestOptions(&d)
@@ -408,7 +413,7 @@ func (store *inMemoryStore) Multi(ctx context.Context, request *state.Transactio
 	defer store.lock.Unlock()
 
 	// step2: validate etag if needed
-	for _, o := range request.Operations {
+	for _, o := range operations {
 		if o.Operation == state.Upsert {
 			s := o.Request.(*innerSetRequest)
 			err := store.doValidateEtag(s.req.Key, s.req.ETag, s.req.Options.Concurrency)
@@ -426,7 +431,7 @@ func (store *inMemoryStore) Multi(ctx context.Context, request *state.Transactio
 
 	// step3: do really set
 	// these operations won't fail
-	for _, o := range request.Operations {
+	for _, o := range operations {
 		if o.Operation == state.Upsert {
 			s := o.Request.(*innerSetRequest)
 			store.doSet(ctx, s.req.Key, s.data, s.ttl, s.isBinary)
@@ -438,6 +443,35 @@ func (store *inMemoryStore) Multi(ctx context.Context, request *state.Transactio
 	return nil
 }
 
+// ListOutbox returns the pending outbox entries after the given key, sorted by key.
+func (store *inMemoryStore) ListOutbox(ctx context.Context, after string, limit int) ([]state.OutboxEntry, error) {
+	store.lock.RLock()
+	keys := make([]string, 0)
+	for key, item := range store.items {
+		if state.IsOutboxKey(key) && key > after && !isExpired(item) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+
+	entries := make([]state.OutboxEntry, 0, len(keys))
+	for _, key := range keys {
+		item := store.items[key]
+		entry := state.OutboxEntry{Key: key, ETag: item.etag}
+		if err := json.Unmarshal(item.data, &entry.Message); err != nil {
+			store.lock.RUnlock()
+			return nil, fmt.Errorf("error decoding outbox message %s: %w", key, err)
+		}
+		entries = append(entries, entry)
+	}
+	store.lock.RUnlock()
+
+	return entries, nil
+}
+
 func (store *inMemoryStore) startCleanThread() {
 	for {
 		select {
@@ -126,4 +126,34 @@ func TestReadAndWrite(t *testing.T) {
 		err := store.Delete(context.Background(), req)
 		assert.NoError(t, err)
 	})
+
+	t.Run("Multi with outbox messages", func(t *testing.T) {
+		err := store.(state.TransactionalStore).Multi(context.Background(), &state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{{
+				Operation: state.Upsert,
+				Request:   state.SetRequest{Key: "order", Value: "1"},
+			}},
+			Outbox: []state.OutboxMessage{
+				{PubsubName: "pubsub", Topic: "orders", Data: []byte("first")},
+				{PubsubName: "pubsub", Topic: "orders", Data: []byte("second"), Partition: "other"},
+				{PubsubName: "pubsub", Topic: "orders", Data: []byte("third")},
+			},
+		})
+		assert.NoError(t, err)
+
+		entries, err := store.(state.OutboxStore).ListOutbox(context.Background(), "", 10)
+		assert.NoError(t, err)
+		if assert.Len(t, entries, 3) {
+			assert.Equal(t, "first", string(entries[0].Message.Data))
+			assert.Equal(t, "third", string(entries[1].Message.Data))
+			assert.Equal(t, "other", entries[2].Message.Partition)
+		}
+	})
+
+	t.Run("Multi with invalid outbox message", func(t *testing.T) {
+		err := store.(state.TransactionalStore).Multi(context.Background(), &state.TransactionalStateRequest{
+			Outbox: []state.OutboxMessage{{Topic: "orders"}},
+		})
+		assert.Error(t, err)
+	})
 }
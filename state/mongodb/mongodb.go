@@ -286,6 +286,10 @@ func (m *MongoDB) deleteInternal(ctx context.Context, req *state.DeleteRequest)
 
 // Multi performs a transactional operation. succeeds only if all operations succeed, and fails if one or more operations fail.
 func (m *MongoDB) Multi(ctx context.Context, request *state.TransactionalStateRequest) error {
+	if len(request.Outbox) > 0 {
+		return state.ErrOutboxNotSupported
+	}
+
 	sess, err := m.client.StartSession()
 	txnOpts := options.Transaction().SetReadConcern(readconcern.Snapshot()).
 		SetWriteConcern(writeconcern.New(writeconcern.WMajority()))
@@ -611,12 +611,17 @@ func (m *MySQL) BulkSet(ctx context.Context, req []state.SetRequest) error {
 func (m *MySQL) Multi(ctx context.Context, request *state.TransactionalStateRequest) error {
 	m.logger.Debug("Executing Multi request")
 
+	operations, err := state.TransactionOperations(request)
+	if err != nil {
+		return err
+	}
+
 	tx, err := m.db.Begin()
 	if err != nil {
 		return err
 	}
 
-	for _, req := range request.Operations {
+	for _, req := range operations {
 		switch req.Operation {
 		case state.Upsert:
 			setReq, err := m.getSets(req)
@@ -664,6 +669,46 @@ func (m *MySQL) Multi(ctx context.Context, request *state.TransactionalStateRequ
 	return tx.Commit()
 }
 
+// ListOutbox returns the pending outbox messages after the given key.
+func (m *MySQL) ListOutbox(parentCtx context.Context, after string, limit int) ([]state.OutboxEntry, error) {
+	ctx, cancel := context.WithTimeout(parentCtx, m.timeout)
+	defer cancel()
+	//nolint:gosec
+	query := fmt.Sprintf(
+		`SELECT id, value, eTag FROM %s WHERE id LIKE ? AND id > ? ORDER BY id`,
+		m.tableName, // m.tableName is sanitized
+	)
+	args := []interface{}{state.OutboxKeyPrefix + "%", after}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []state.OutboxEntry{}
+	for rows.Next() {
+		var (
+			entry state.OutboxEntry
+			value []byte
+			eTag  string
+		)
+		if err = rows.Scan(&entry.Key, &value, &eTag); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(value, &entry.Message); err != nil {
+			return nil, fmt.Errorf("failed to decode outbox message %s: %w", entry.Key, err)
+		}
+		entry.ETag = &eTag
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
 // Returns the set requests.
 func (m *MySQL) getSets(req state.TransactionalStateOperation) (state.SetRequest, error) {
 	setReq, ok := req.Request.(state.SetRequest)
@@ -240,6 +240,76 @@ func TestExecuteMultiCommitSetsAndDeletes(t *testing.T) {
 	assert.Nil(t, err, "error returned")
 }
 
+func TestExecuteMultiWritesOutbox(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.mySQL.Close()
+
+	m.mock1.ExpectBegin()
+	m.mock1.ExpectExec("INSERT INTO").WillReturnResult(sqlmock.NewResult(0, 1))
+	m.mock1.ExpectExec("INSERT INTO").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	m.mock1.ExpectCommit()
+
+	request := state.TransactionalStateRequest{
+		Operations: []state.TransactionalStateOperation{{
+			Request:   createSetRequest(),
+			Operation: state.Upsert,
+		}},
+		Outbox: []state.OutboxMessage{{PubsubName: "pubsub", Topic: "orders", Data: []byte("hello")}},
+	}
+
+	// Act
+	err := m.mySQL.Multi(context.Background(), &request)
+
+	// Assert
+	assert.Nil(t, err, "error returned")
+	assert.Nil(t, m.mock1.ExpectationsWereMet())
+}
+
+func TestListOutbox(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.mySQL.Close()
+
+	msg, _ := json.Marshal(state.OutboxMessage{PubsubName: "pubsub", Topic: "orders", Data: []byte("hello")})
+	rows := sqlmock.NewRows([]string{"id", "value", "eTag"}).AddRow(state.OutboxKeyPrefix+"default||1", msg, "etag")
+	m.mock1.ExpectQuery("SELECT id, value, eTag FROM state WHERE id LIKE").
+		WithArgs(state.OutboxKeyPrefix+"%", "", 10).
+		WillReturnRows(rows)
+
+	// Act
+	entries, err := m.mySQL.ListOutbox(context.Background(), "", 10)
+
+	// Assert
+	assert.Nil(t, err, "error returned")
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "etag", *entries[0].ETag)
+		assert.Equal(t, "orders", entries[0].Message.Topic)
+		assert.Equal(t, []byte("hello"), entries[0].Message.Data)
+	}
+}
+
+func TestListOutboxWithoutLimit(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.mySQL.Close()
+
+	after := state.OutboxKeyPrefix + "default||1"
+	m.mock1.ExpectQuery(`SELECT id, value, eTag FROM state WHERE id LIKE \? AND id > \? ORDER BY id$`).
+		WithArgs(state.OutboxKeyPrefix+"%", after).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "value", "eTag"}))
+
+	// Act
+	entries, err := m.mySQL.ListOutbox(context.Background(), after, 0)
+
+	// Assert
+	assert.Nil(t, err, "error returned")
+	assert.Empty(t, entries)
+	assert.Nil(t, m.mock1.ExpectationsWereMet())
+}
+
 func TestSetHandlesOptionsError(t *testing.T) {
 	// Arrange
 	m, _ := mockDatabase(t)
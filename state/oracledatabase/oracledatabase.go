@@ -94,6 +94,10 @@ func (o *OracleDatabase) BulkSet(ctx context.Context, req []state.SetRequest) er
 
 // Multi handles multiple transactions. Implements TransactionalStore.
 func (o *OracleDatabase) Multi(ctx context.Context, request *state.TransactionalStateRequest) error {
+	if len(request.Outbox) > 0 {
+		return state.ErrOutboxNotSupported
+	}
+
 	var deletes []state.DeleteRequest
 	var sets []state.SetRequest
 	for _, req := range request.Operations {
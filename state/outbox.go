@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// OutboxKeyPrefix is the prefix of the keys under which outbox messages are stored.
+	// It is a Redis hash tag, so that the messages are stored in the same slot of a cluster.
+	OutboxKeyPrefix = "{dapr-outbox}||"
+	// DefaultOutboxPartition is the partition used by outbox messages which do not specify one.
+	DefaultOutboxPartition = "default"
+)
+
+// OutboxMessage is a pubsub message which is stored in the same transaction as the state changes
+// of a TransactionalStateRequest, and published by an outbox relay once the transaction is committed.
+type OutboxMessage struct {
+	PubsubName  string            `json:"pubsubname"`
+	Topic       string            `json:"topic"`
+	Data        []byte            `json:"data"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	ContentType string            `json:"contentType,omitempty"`
+	// Partition groups messages which must be published in order.
+	Partition string `json:"partition,omitempty"`
+}
+
+// OutboxEntry is an outbox message pending to be published.
+type OutboxEntry struct {
+	Key     string
+	ETag    *string
+	Message OutboxMessage
+}
+
+// ErrOutboxNotSupported is returned by Multi when the request has outbox messages and the store does not implement OutboxStore.
+var ErrOutboxNotSupported = errors.New("outbox messages are not supported by this state store")
+
+// OutboxStore is implemented by transactional state stores that persist the outbox messages of
+// TransactionalStateRequest and can list the ones pending to be published.
+type OutboxStore interface {
+	Store
+	TransactionalStore
+	// ListOutbox returns at most limit pending outbox entries whose key sorts after the given key, sorted by key.
+	// All the entries after the key are returned when limit is not positive.
+	ListOutbox(ctx context.Context, after string, limit int) ([]OutboxEntry, error)
+}
+
+var (
+	outboxSeqLock sync.Mutex
+	outboxSeqLast int64
+)
+
+// nextOutboxSeq returns a strictly increasing timestamp, so that messages added by this process sort in order.
+func nextOutboxSeq() int64 {
+	outboxSeqLock.Lock()
+	defer outboxSeqLock.Unlock()
+
+	seq := time.Now().UnixNano()
+	if seq <= outboxSeqLast {
+		seq = outboxSeqLast + 1
+	}
+	outboxSeqLast = seq
+
+	return seq
+}
+
+// OutboxMessageKey returns the state key of an outbox message. Keys sort by partition and then by insertion time.
+func OutboxMessageKey(partition string) string {
+	if partition == "" {
+		partition = DefaultOutboxPartition
+	}
+
+	return fmt.Sprintf("%s%s||%020d-%s", OutboxKeyPrefix, partition, nextOutboxSeq(), uuid.New().String())
+}
+
+// OutboxPartitionEnd returns a key which sorts after the keys of all the outbox messages of a partition,
+// and before the ones of the following partitions.
+func OutboxPartitionEnd(partition string) string {
+	if partition == "" {
+		partition = DefaultOutboxPartition
+	}
+
+	// The keys of the messages continue with digits, "-" and lowercase hex digits, which all sort before "~"
+	return OutboxKeyPrefix + partition + "||~"
+}
+
+// IsOutboxKey returns true if the key holds an outbox message.
+func IsOutboxKey(key string) bool {
+	return strings.HasPrefix(key, OutboxKeyPrefix)
+}
+
+// TransactionOperations returns the operations of the request, followed by upserts storing its outbox messages.
+// Stores which implement OutboxStore execute these operations in Multi, so that the outbox messages are
+// committed atomically with the state changes.
+func TransactionOperations(req *TransactionalStateRequest) ([]TransactionalStateOperation, error) {
+	if req == nil {
+		return nil, nil
+	}
+	if len(req.Outbox) == 0 {
+		return req.Operations, nil
+	}
+
+	ops := make([]TransactionalStateOperation, 0, len(req.Operations)+len(req.Outbox))
+	ops = append(ops, req.Operations...)
+	for _, msg := range req.Outbox {
+		if msg.PubsubName == "" || msg.Topic == "" {
+			return nil, errors.New("outbox messages require a pubsub name and a topic")
+		}
+		if msg.Partition == "" {
+			msg.Partition = DefaultOutboxPartition
+		}
+		ops = append(ops, TransactionalStateOperation{
+			Operation: Upsert,
+			Request: SetRequest{
+				Key:   OutboxMessageKey(msg.Partition),
+				Value: msg,
+				Options: SetStateOption{
+					Concurrency: FirstWrite,
+				},
+			},
+		})
+	}
+
+	return ops, nil
+}
@@ -31,6 +31,7 @@ type dbAccess interface {
 	Delete(ctx context.Context, req *state.DeleteRequest) error
 	BulkDelete(ctx context.Context, req []state.DeleteRequest) error
 	ExecuteMulti(ctx context.Context, req *state.TransactionalStateRequest) error
+	ListOutbox(ctx context.Context, after string, limit int) ([]state.OutboxEntry, error)
 	Query(ctx context.Context, req *state.QueryRequest) (*state.QueryResponse, error)
 	Close() error // io.Closer
 }
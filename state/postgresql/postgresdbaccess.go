@@ -376,13 +376,18 @@ func (p *PostgresDBAccess) BulkDelete(parentCtx context.Context, req []state.Del
 }
 
 func (p *PostgresDBAccess) ExecuteMulti(parentCtx context.Context, request *state.TransactionalStateRequest) error {
+	operations, err := state.TransactionOperations(request)
+	if err != nil {
+		return err
+	}
+
 	tx, err := p.beginTx(parentCtx)
 	if err != nil {
 		return err
 	}
 	defer p.rollbackTx(parentCtx, tx, "ExecMulti")
 
-	for _, o := range request.Operations {
+	for _, o := range operations {
 		switch o.Operation {
 		case state.Upsert:
 			var setReq state.SetRequest
@@ -423,6 +428,51 @@ func (p *PostgresDBAccess) ExecuteMulti(parentCtx context.Context, request *stat
 	return nil
 }
 
+// ListOutbox returns the pending outbox entries after the given key, sorted by key.
+func (p *PostgresDBAccess) ListOutbox(parentCtx context.Context, after string, limit int) ([]state.OutboxEntry, error) {
+	query := `SELECT
+			key, value, xmin AS etag
+		FROM %s
+			WHERE
+				key LIKE $1
+				AND key > $2
+				AND (expiredate IS NULL OR expiredate >= CURRENT_TIMESTAMP)
+		ORDER BY key`
+	args := []any{state.OutboxKeyPrefix + "%", after}
+	if limit > 0 {
+		query += ` LIMIT $3`
+		args = append(args, limit)
+	}
+	rows, err := p.db.Query(parentCtx, fmt.Sprintf(query, p.metadata.TableName), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]state.OutboxEntry, 0)
+	for rows.Next() {
+		var (
+			key   string
+			value []byte
+			etag  uint32
+		)
+		if err = rows.Scan(&key, &value, &etag); err != nil {
+			return nil, err
+		}
+
+		entry := state.OutboxEntry{
+			Key:  key,
+			ETag: ptr.Of(strconv.FormatUint(uint64(etag), 10)),
+		}
+		if err = json.Unmarshal(value, &entry.Message); err != nil {
+			return nil, fmt.Errorf("error decoding outbox message %s: %w", key, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
 // Query executes a query against store.
 func (p *PostgresDBAccess) Query(parentCtx context.Context, req *state.QueryRequest) (*state.QueryResponse, error) {
 	q := &Query{
@@ -331,6 +331,64 @@ func TestMultiOperationOrder(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestMultiWithOutbox(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+
+	setReq := createSetRequest()
+	val, _ := json.Marshal(setReq.Value)
+
+	m.db.ExpectBegin()
+	m.db.ExpectExec("INSERT INTO").
+		WithArgs(setReq.Key, string(val), false).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	m.db.ExpectExec("INSERT INTO").
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), false).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	m.db.ExpectCommit()
+	// There's also a rollback called after a commit, which is expected and will not have effect
+	m.db.ExpectRollback()
+
+	// Act
+	err := m.pgDba.ExecuteMulti(context.Background(), &state.TransactionalStateRequest{
+		Operations: []state.TransactionalStateOperation{
+			{Operation: state.Upsert, Request: setReq},
+		},
+		Outbox: []state.OutboxMessage{
+			{PubsubName: "pubsub", Topic: "orders", Data: []byte("hello")},
+		},
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, m.db.ExpectationsWereMet())
+}
+
+func TestListOutbox(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+
+	msg, _ := json.Marshal(state.OutboxMessage{PubsubName: "pubsub", Topic: "orders", Data: []byte("hello")})
+	m.db.ExpectQuery("SELECT").
+		WithArgs(state.OutboxKeyPrefix+"%", "", 10).
+		WillReturnRows(pgxmock.NewRows([]string{"key", "value", "etag"}).
+			AddRow(state.OutboxKeyPrefix+"default||1", msg, uint32(42)))
+
+	// Act
+	entries, err := m.pgDba.ListOutbox(context.Background(), "", 10)
+
+	// Assert
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, state.OutboxKeyPrefix+"default||1", entries[0].Key)
+		assert.Equal(t, "42", *entries[0].ETag)
+		assert.Equal(t, "orders", entries[0].Message.Topic)
+		assert.Equal(t, []byte("hello"), entries[0].Message.Data)
+	}
+}
+
 func TestInvalidBulkSetNoKey(t *testing.T) {
 	// Arrange
 	m, _ := mockDatabase(t)
@@ -90,6 +90,11 @@ func (p *PostgreSQL) Multi(ctx context.Context, request *state.TransactionalStat
 	return p.dbaccess.ExecuteMulti(ctx, request)
 }
 
+// ListOutbox returns the pending outbox entries. Implements OutboxStore.
+func (p *PostgreSQL) ListOutbox(ctx context.Context, after string, limit int) ([]state.OutboxEntry, error) {
+	return p.dbaccess.ListOutbox(ctx, after, limit)
+}
+
 // Query executes a query against store.
 func (p *PostgreSQL) Query(ctx context.Context, req *state.QueryRequest) (*state.QueryResponse, error) {
 	return p.dbaccess.Query(ctx, req)
@@ -74,6 +74,10 @@ func (m *fakeDBaccess) ExecuteMulti(ctx context.Context, req *state.Transactiona
 	return nil
 }
 
+func (m *fakeDBaccess) ListOutbox(ctx context.Context, after string, limit int) ([]state.OutboxEntry, error) {
+	return nil, nil
+}
+
 func (m *fakeDBaccess) Query(ctx context.Context, req *state.QueryRequest) (*state.QueryResponse, error) {
 	return nil, nil
 }
@@ -80,6 +80,10 @@ const (
 	else
 	  return error("failed to delete " .. KEYS[1])
 	end`
+	// outboxIndexKey is the sorted set of the keys of the outbox messages. All the keys have the same score, so that
+	// they are sorted lexicographically. It has the hash tag of the keys of the messages, so that they are stored in
+	// the same slot of a cluster, and it doesn't start with state.OutboxKeyPrefix, so that it isn't an outbox message.
+	outboxIndexKey           = "{dapr-outbox}index"
 	connectedSlavesReplicas  = "connected_slaves:"
 	infoReplicationDelimiter = "\r\n"
 	ttlInSeconds             = "ttlInSeconds"
@@ -217,6 +221,12 @@ func (r *StateStore) Delete(ctx context.Context, req *state.DeleteRequest) error
 	if err != nil {
 		return state.NewETagError(state.ETagMismatch, err)
 	}
+	if state.IsOutboxKey(req.Key) {
+		err = r.client.DoWrite(ctx, "ZREM", outboxIndexKey, req.Key)
+		if err != nil {
+			return fmt.Errorf("failed to remove %s from the outbox index: %w", req.Key, err)
+		}
+	}
 
 	return nil
 }
@@ -402,10 +412,26 @@ func (r *StateStore) Multi(ctx context.Context, request *state.TransactionalStat
 		delQuery = delDefaultQuery
 	}
 
+	operations, err := state.TransactionOperations(request)
+	if err != nil {
+		return err
+	}
+
 	pipe := r.client.TxPipeline()
-	for _, o := range request.Operations {
+	for _, o := range operations {
 		if o.Operation == state.Upsert {
 			req := o.Request.(state.SetRequest)
+			// Outbox messages are always stored as hashes, so that ListOutbox can read them regardless of the content type.
+			// They share the hash slot of the outbox index, which is updated in the same transaction.
+			if state.IsOutboxKey(req.Key) {
+				bt, err := utils.Marshal(req.Value, r.json.Marshal)
+				if err != nil {
+					return fmt.Errorf("failed to marshal outbox message: %w", err)
+				}
+				pipe.Do(ctx, "EVAL", setDefaultQuery, 1, req.Key, 0, bt)
+				pipe.Do(ctx, "ZADD", outboxIndexKey, 0, req.Key)
+				continue
+			}
 			ver, err := r.parseETag(&req)
 			if err != nil {
 				return err
@@ -438,14 +464,69 @@ func (r *StateStore) Multi(ctx context.Context, request *state.TransactionalStat
 				req.ETag = &etag
 			}
 			pipe.Do(ctx, "EVAL", delQuery, 1, req.Key, *req.ETag)
+			if state.IsOutboxKey(req.Key) {
+				pipe.Do(ctx, "ZREM", outboxIndexKey, req.Key)
+			}
 		}
 	}
 
-	err := pipe.Exec(ctx)
+	err = pipe.Exec(ctx)
 
 	return err
 }
 
+// ListOutbox returns the pending outbox messages after the given key.
+// The keys of the messages are read from the outbox index, which is kept on a single node in cluster mode.
+func (r *StateStore) ListOutbox(ctx context.Context, after string, limit int) ([]state.OutboxEntry, error) {
+	args := []interface{}{"ZRANGEBYLEX", outboxIndexKey, "-", "+"}
+	if after != "" {
+		args[2] = "(" + after
+	}
+	if limit > 0 {
+		args = append(args, "LIMIT", 0, limit)
+	}
+	res, err := r.client.DoRead(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the outbox index: %w", err)
+	}
+	members, _ := res.([]interface{})
+	keys := make([]string, 0, len(members))
+	for _, k := range members {
+		keys = append(keys, fmt.Sprintf("%s", k))
+	}
+
+	entries := make([]state.OutboxEntry, 0, len(keys))
+	for _, key := range keys {
+		res, err := r.client.DoRead(ctx, "HGETALL", key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read outbox message %s: %w", key, err)
+		}
+		vals, _ := res.([]interface{})
+		if len(vals) == 0 {
+			// The message was published and deleted in the meantime, or it wasn't removed from the index
+			if err = r.client.DoWrite(ctx, "ZREM", outboxIndexKey, key); err != nil {
+				return nil, fmt.Errorf("failed to remove %s from the outbox index: %w", key, err)
+			}
+			continue
+		}
+		data, version, err := r.getKeyVersion(vals)
+		if err != nil {
+			return nil, err
+		}
+
+		entry := state.OutboxEntry{
+			Key:  key,
+			ETag: version,
+		}
+		if err = r.json.Unmarshal([]byte(data), &entry.Message); err != nil {
+			return nil, fmt.Errorf("failed to decode outbox message %s: %w", key, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
 func (r *StateStore) registerSchemas() error {
 	for name, elem := range r.querySchemas {
 		r.logger.Infof("redis: create query index %s", name)
@@ -532,6 +532,76 @@ func TestGetMetadata(t *testing.T) {
 	assert.Equal(t, metadataInfo["idleCheckFrequency"], "redis.Duration")
 }
 
+func TestTransactionalOutbox(t *testing.T) {
+	s, c := setupMiniredis()
+	defer s.Close()
+
+	ss := &StateStore{
+		client: c,
+		json:   jsoniter.ConfigFastest,
+		logger: logger.NewLogger("test"),
+	}
+	ss.ctx, ss.cancel = context.WithCancel(context.Background())
+
+	err := ss.Multi(context.Background(), &state.TransactionalStateRequest{
+		Operations: []state.TransactionalStateOperation{
+			{
+				Operation: state.Upsert,
+				Request: state.SetRequest{
+					Key:   "weapon",
+					Value: "deathstar",
+				},
+			},
+		},
+		Outbox: []state.OutboxMessage{
+			{PubsubName: "pubsub", Topic: "weapons", Data: []byte("1")},
+			{PubsubName: "pubsub", Topic: "weapons", Data: []byte("2")},
+		},
+	})
+	assert.NoError(t, err)
+
+	entries, err := ss.ListOutbox(context.Background(), "", 10)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, []byte("1"), entries[0].Message.Data)
+	assert.Equal(t, []byte("2"), entries[1].Message.Data)
+	assert.Equal(t, ptr.Of("1"), entries[0].ETag)
+
+	next, err := ss.ListOutbox(context.Background(), entries[0].Key, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, next, 1) {
+		assert.Equal(t, []byte("2"), next[0].Message.Data)
+	}
+
+	entries, err = ss.ListOutbox(context.Background(), "", 1)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// The published messages are removed from the index
+	err = ss.Delete(context.Background(), &state.DeleteRequest{Key: entries[0].Key, ETag: entries[0].ETag})
+	assert.NoError(t, err)
+	members, err := s.ZMembers(outboxIndexKey)
+	assert.NoError(t, err)
+	assert.Len(t, members, 1)
+
+	// The messages missing from the store are removed from the index
+	s.Del(members[0])
+	entries, err = ss.ListOutbox(context.Background(), "", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+	assert.False(t, s.Exists(outboxIndexKey))
+
+	t.Run("invalid message", func(t *testing.T) {
+		err := ss.Multi(context.Background(), &state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{{
+				Operation: state.Upsert,
+				Request:   state.SetRequest{Key: state.OutboxMessageKey(""), Value: func() {}},
+			}},
+		})
+		assert.ErrorContains(t, err, "marshal")
+	})
+}
+
 func setupMiniredis() (*miniredis.Miniredis, rediscomponent.RedisClient) {
 	s, err := miniredis.Run()
 	if err != nil {
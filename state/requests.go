@@ -88,9 +88,11 @@ const Delete OperationType = "delete"
 
 // TransactionalStateRequest describes a transactional operation against a state store that comprises multiple types of operations
 // The Request field is either a DeleteRequest or SetRequest.
+// Outbox messages are stored with the operations by stores implementing OutboxStore, and published by an outbox relay.
 type TransactionalStateRequest struct {
 	Operations []TransactionalStateOperation `json:"operations"`
 	Metadata   map[string]string             `json:"metadata,omitempty"`
+	Outbox     []OutboxMessage               `json:"outbox,omitempty"`
 }
 
 // TransactionalStateOperation describes operation type, key, and value for transactional operation.
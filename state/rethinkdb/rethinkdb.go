@@ -293,6 +293,10 @@ func (s *RethinkDB) BulkDelete(ctx context.Context, req []state.DeleteRequest) e
 
 // Multi performs multiple operations.
 func (s *RethinkDB) Multi(ctx context.Context, req *state.TransactionalStateRequest) error {
+	if len(req.Outbox) > 0 {
+		return state.ErrOutboxNotSupported
+	}
+
 	upserts := make([]state.SetRequest, 0)
 	deletes := make([]state.DeleteRequest, 0)
 
@@ -348,6 +348,10 @@ func (s *SQLServer) Features() []state.Feature {
 
 // Multi performs multiple updates on a Sql server store.
 func (s *SQLServer) Multi(ctx context.Context, request *state.TransactionalStateRequest) error {
+	if len(request.Outbox) > 0 {
+		return state.ErrOutboxNotSupported
+	}
+
 	tx, err := s.db.Begin()
 	if err != nil {
 		return err
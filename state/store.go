@@ -100,3 +100,6 @@ func (b *DefaultBulkStore) BulkDelete(ctx context.Context, req []DeleteRequest)
 type Querier interface {
 	Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error)
 }
+
+// StoreResolver returns the state store with the given name.
+type StoreResolver func(name string) (Store, bool)
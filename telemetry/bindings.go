@@ -0,0 +1,78 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/JY29/components-contrib/bindings"
+	"github.com/JY29/components-contrib/health"
+)
+
+type instrumentedOutputBinding struct {
+	bindings.OutputBinding
+	inst *instrumentation
+}
+
+// bindingPinger implements health.Pinger for the decorators of the bindings implementing it.
+type bindingPinger struct{ b *instrumentedOutputBinding }
+
+// OutputBinding returns an OutputBinding which records a span and the duration of each invocation.
+// The decorator implements health.Pinger if the binding does. The binding is returned by its Unwrap method.
+func OutputBinding(binding bindings.OutputBinding, opts Options) (bindings.OutputBinding, error) {
+	inst, err := newInstrumentation(opts, "dapr.component.bindings.duration")
+	if err != nil {
+		return nil, err
+	}
+
+	b := &instrumentedOutputBinding{
+		OutputBinding: binding,
+		inst:          inst,
+	}
+	if _, ok := binding.(health.Pinger); ok {
+		return &struct {
+			*instrumentedOutputBinding
+			bindingPinger
+		}{b, bindingPinger{b}}, nil
+	}
+
+	return b, nil
+}
+
+func (b *instrumentedOutputBinding) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	start := time.Now()
+	operation := OperationKey.String(string(req.Operation))
+	ctx, span := b.inst.start(ctx, "bindings/"+string(req.Operation), trace.SpanKindClient, operation)
+
+	res, err := b.OutputBinding.Invoke(ctx, req)
+	b.inst.end(ctx, span, start, err, operation)
+
+	return res, err
+}
+
+func (b *instrumentedOutputBinding) Close() error {
+	return closeComponent(b.OutputBinding)
+}
+
+// Unwrap returns the decorated binding.
+func (b *instrumentedOutputBinding) Unwrap() bindings.OutputBinding {
+	return b.OutputBinding
+}
+
+func (p bindingPinger) Ping() error {
+	return p.b.OutputBinding.(health.Pinger).Ping()
+}
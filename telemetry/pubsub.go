@@ -0,0 +1,198 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/JY29/components-contrib/health"
+	"github.com/JY29/components-contrib/pubsub"
+)
+
+type instrumentedPubSub struct {
+	pubsub.PubSub
+	inst *instrumentation
+}
+
+// The optional interfaces of the pubsubs are implemented by these types, which are only embedded in the decorators of
+// the pubsubs implementing them.
+type (
+	pubsubPinger         struct{ p *instrumentedPubSub }
+	pubsubBulkPublisher  struct{ p *instrumentedPubSub }
+	pubsubBulkSubscriber struct{ p *instrumentedPubSub }
+)
+
+// PubSub returns a PubSub which records a span and the duration of each published and processed message.
+// The trace context is injected in the metadata of published messages, which most brokers carry as message
+// headers, and extracted from the metadata of the received messages.
+// The decorator implements health.Pinger, pubsub.BulkPublisher and pubsub.BulkSubscriber if the pubsub does.
+// The pubsub is returned by its Unwrap method.
+func PubSub(ps pubsub.PubSub, opts Options) (pubsub.PubSub, error) {
+	inst, err := newInstrumentation(opts, "dapr.component.pubsub.duration")
+	if err != nil {
+		return nil, err
+	}
+
+	p := &instrumentedPubSub{
+		PubSub: ps,
+		inst:   inst,
+	}
+	pp, bp, bs := pubsubPinger{p}, pubsubBulkPublisher{p}, pubsubBulkSubscriber{p}
+	_, pinger := ps.(health.Pinger)
+	_, bulkPublisher := ps.(pubsub.BulkPublisher)
+	_, bulkSubscriber := ps.(pubsub.BulkSubscriber)
+
+	switch {
+	case pinger && bulkPublisher && bulkSubscriber:
+		return &struct {
+			*instrumentedPubSub
+			pubsubPinger
+			pubsubBulkPublisher
+			pubsubBulkSubscriber
+		}{p, pp, bp, bs}, nil
+	case pinger && bulkPublisher:
+		return &struct {
+			*instrumentedPubSub
+			pubsubPinger
+			pubsubBulkPublisher
+		}{p, pp, bp}, nil
+	case pinger && bulkSubscriber:
+		return &struct {
+			*instrumentedPubSub
+			pubsubPinger
+			pubsubBulkSubscriber
+		}{p, pp, bs}, nil
+	case bulkPublisher && bulkSubscriber:
+		return &struct {
+			*instrumentedPubSub
+			pubsubBulkPublisher
+			pubsubBulkSubscriber
+		}{p, bp, bs}, nil
+	case pinger:
+		return &struct {
+			*instrumentedPubSub
+			pubsubPinger
+		}{p, pp}, nil
+	case bulkPublisher:
+		return &struct {
+			*instrumentedPubSub
+			pubsubBulkPublisher
+		}{p, bp}, nil
+	case bulkSubscriber:
+		return &struct {
+			*instrumentedPubSub
+			pubsubBulkSubscriber
+		}{p, bs}, nil
+	default:
+		return p, nil
+	}
+}
+
+func (p *instrumentedPubSub) Publish(ctx context.Context, req *pubsub.PublishRequest) error {
+	start := time.Now()
+	ctx, span := p.inst.start(ctx, req.Topic+" publish", trace.SpanKindProducer,
+		OperationKey.String("publish"),
+		MessagingDestination.String(req.Topic),
+	)
+
+	// Copy the request so that the caller's metadata is not modified
+	r := *req
+	r.Metadata = make(map[string]string, len(req.Metadata)+2)
+	for k, v := range req.Metadata {
+		r.Metadata[k] = v
+	}
+	p.inst.propagator.Inject(ctx, propagation.MapCarrier(r.Metadata))
+
+	err := p.PubSub.Publish(ctx, &r)
+	p.inst.end(ctx, span, start, err, OperationKey.String("publish"), MessagingDestination.String(req.Topic))
+
+	return err
+}
+
+func (p *instrumentedPubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	return p.PubSub.Subscribe(ctx, req, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		start := time.Now()
+		// The span of the producer, if any, is the parent of the span processing the message
+		ctx = p.inst.propagator.Extract(ctx, propagation.MapCarrier(msg.Metadata))
+		ctx, span := p.inst.start(ctx, msg.Topic+" process", trace.SpanKindConsumer,
+			OperationKey.String("process"),
+			MessagingDestination.String(msg.Topic),
+		)
+
+		err := handler(ctx, msg)
+		p.inst.end(ctx, span, start, err, OperationKey.String("process"), MessagingDestination.String(msg.Topic))
+
+		return err
+	})
+}
+
+// Unwrap returns the decorated pubsub.
+func (p *instrumentedPubSub) Unwrap() pubsub.PubSub {
+	return p.PubSub
+}
+
+func (pp pubsubPinger) Ping() error {
+	return pp.p.PubSub.(health.Pinger).Ping()
+}
+
+func (bp pubsubBulkPublisher) BulkPublish(ctx context.Context, req *pubsub.BulkPublishRequest) (pubsub.BulkPublishResponse, error) {
+	p := bp.p
+	start := time.Now()
+	ctx, span := p.inst.start(ctx, req.Topic+" publish", trace.SpanKindProducer,
+		OperationKey.String("bulkpublish"),
+		MessagingDestination.String(req.Topic),
+		attribute.Int("messaging.batch.message_count", len(req.Entries)),
+	)
+
+	// Copy the request and its entries so that the caller's metadata is not modified
+	r := *req
+	r.Entries = make([]pubsub.BulkMessageEntry, len(req.Entries))
+	for i, entry := range req.Entries {
+		entry.Metadata = make(map[string]string, len(req.Entries[i].Metadata)+2)
+		for k, v := range req.Entries[i].Metadata {
+			entry.Metadata[k] = v
+		}
+		p.inst.propagator.Inject(ctx, propagation.MapCarrier(entry.Metadata))
+		r.Entries[i] = entry
+	}
+
+	res, err := p.PubSub.(pubsub.BulkPublisher).BulkPublish(ctx, &r)
+	p.inst.end(ctx, span, start, err, OperationKey.String("bulkpublish"), MessagingDestination.String(req.Topic))
+
+	return res, err
+}
+
+func (bs pubsubBulkSubscriber) BulkSubscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.BulkHandler) error {
+	p := bs.p
+
+	return p.PubSub.(pubsub.BulkSubscriber).BulkSubscribe(ctx, req, func(ctx context.Context, msg *pubsub.BulkMessage) ([]pubsub.BulkSubscribeResponseEntry, error) {
+		start := time.Now()
+		ctx = p.inst.propagator.Extract(ctx, propagation.MapCarrier(msg.Metadata))
+		ctx, span := p.inst.start(ctx, msg.Topic+" process", trace.SpanKindConsumer,
+			OperationKey.String("bulkprocess"),
+			MessagingDestination.String(msg.Topic),
+			attribute.Int("messaging.batch.message_count", len(msg.Entries)),
+		)
+
+		res, err := handler(ctx, msg)
+		p.inst.end(ctx, span, start, err, OperationKey.String("bulkprocess"), MessagingDestination.String(msg.Topic))
+
+		return res, err
+	})
+}
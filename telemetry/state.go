@@ -0,0 +1,225 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/JY29/components-contrib/health"
+	"github.com/JY29/components-contrib/state"
+)
+
+type instrumentedStore struct {
+	state.Store
+	inst *instrumentation
+}
+
+// The optional interfaces of the stores are implemented by these types, which are only embedded in the decorators of
+// the stores implementing them, so that the type assertions on a decorator behave as on the store itself.
+type (
+	storePinger        struct{ s *instrumentedStore }
+	storeTransactional struct{ s *instrumentedStore }
+	storeOutbox        struct{ s *instrumentedStore }
+	storeQuerier       struct{ s *instrumentedStore }
+)
+
+// StateStore returns a state.Store which records a span and the duration of each operation.
+// The decorator implements health.Pinger, state.TransactionalStore, state.OutboxStore and state.Querier if the
+// store does, and instruments the transactions, the outbox listings and the queries.
+// The store is returned by its Unwrap method.
+func StateStore(store state.Store, opts Options) (state.Store, error) {
+	inst, err := newInstrumentation(opts, "dapr.component.state.duration")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &instrumentedStore{
+		Store: store,
+		inst:  inst,
+	}
+	p, t, o, q := storePinger{s}, storeTransactional{s}, storeOutbox{s}, storeQuerier{s}
+	_, pinger := store.(health.Pinger)
+	_, transactional := store.(state.TransactionalStore)
+	_, outbox := store.(state.OutboxStore)
+	_, querier := store.(state.Querier)
+
+	switch {
+	case outbox && pinger && querier:
+		return &struct {
+			*instrumentedStore
+			storePinger
+			storeTransactional
+			storeOutbox
+			storeQuerier
+		}{s, p, t, o, q}, nil
+	case outbox && pinger:
+		return &struct {
+			*instrumentedStore
+			storePinger
+			storeTransactional
+			storeOutbox
+		}{s, p, t, o}, nil
+	case outbox && querier:
+		return &struct {
+			*instrumentedStore
+			storeTransactional
+			storeOutbox
+			storeQuerier
+		}{s, t, o, q}, nil
+	case outbox:
+		return &struct {
+			*instrumentedStore
+			storeTransactional
+			storeOutbox
+		}{s, t, o}, nil
+	case transactional && pinger && querier:
+		return &struct {
+			*instrumentedStore
+			storePinger
+			storeTransactional
+			storeQuerier
+		}{s, p, t, q}, nil
+	case transactional && pinger:
+		return &struct {
+			*instrumentedStore
+			storePinger
+			storeTransactional
+		}{s, p, t}, nil
+	case transactional && querier:
+		return &struct {
+			*instrumentedStore
+			storeTransactional
+			storeQuerier
+		}{s, t, q}, nil
+	case transactional:
+		return &struct {
+			*instrumentedStore
+			storeTransactional
+		}{s, t}, nil
+	case pinger && querier:
+		return &struct {
+			*instrumentedStore
+			storePinger
+			storeQuerier
+		}{s, p, q}, nil
+	case pinger:
+		return &struct {
+			*instrumentedStore
+			storePinger
+		}{s, p}, nil
+	case querier:
+		return &struct {
+			*instrumentedStore
+			storeQuerier
+		}{s, q}, nil
+	default:
+		return s, nil
+	}
+}
+
+// do runs an operation in a span.
+func (s *instrumentedStore) do(ctx context.Context, operation string, fn func(ctx context.Context) error) error {
+	start := time.Now()
+	attr := OperationKey.String(operation)
+	ctx, span := s.inst.start(ctx, "state/"+operation, trace.SpanKindClient, attr)
+
+	err := fn(ctx)
+	s.inst.end(ctx, span, start, err, attr)
+
+	return err
+}
+
+func (s *instrumentedStore) Get(ctx context.Context, req *state.GetRequest) (res *state.GetResponse, err error) {
+	err = s.do(ctx, "get", func(ctx context.Context) error {
+		res, err = s.Store.Get(ctx, req)
+		return err
+	})
+
+	return res, err
+}
+
+func (s *instrumentedStore) Set(ctx context.Context, req *state.SetRequest) error {
+	return s.do(ctx, "set", func(ctx context.Context) error {
+		return s.Store.Set(ctx, req)
+	})
+}
+
+func (s *instrumentedStore) Delete(ctx context.Context, req *state.DeleteRequest) error {
+	return s.do(ctx, "delete", func(ctx context.Context) error {
+		return s.Store.Delete(ctx, req)
+	})
+}
+
+func (s *instrumentedStore) BulkGet(ctx context.Context, req []state.GetRequest) (supported bool, res []state.BulkGetResponse, err error) {
+	err = s.do(ctx, "bulkget", func(ctx context.Context) error {
+		supported, res, err = s.Store.BulkGet(ctx, req)
+		return err
+	})
+
+	return supported, res, err
+}
+
+func (s *instrumentedStore) BulkSet(ctx context.Context, req []state.SetRequest) error {
+	return s.do(ctx, "bulkset", func(ctx context.Context) error {
+		return s.Store.BulkSet(ctx, req)
+	})
+}
+
+func (s *instrumentedStore) BulkDelete(ctx context.Context, req []state.DeleteRequest) error {
+	return s.do(ctx, "bulkdelete", func(ctx context.Context) error {
+		return s.Store.BulkDelete(ctx, req)
+	})
+}
+
+func (s *instrumentedStore) Close() error {
+	return closeComponent(s.Store)
+}
+
+// Unwrap returns the decorated store.
+func (s *instrumentedStore) Unwrap() state.Store {
+	return s.Store
+}
+
+func (p storePinger) Ping() error {
+	return p.s.Store.(health.Pinger).Ping()
+}
+
+func (t storeTransactional) Multi(ctx context.Context, req *state.TransactionalStateRequest) error {
+	return t.s.do(ctx, "multi", func(ctx context.Context) error {
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Int("dapr.state.operations", len(req.Operations)))
+		return t.s.Store.(state.TransactionalStore).Multi(ctx, req)
+	})
+}
+
+func (o storeOutbox) ListOutbox(ctx context.Context, after string, limit int) (entries []state.OutboxEntry, err error) {
+	err = o.s.do(ctx, "listoutbox", func(ctx context.Context) error {
+		entries, err = o.s.Store.(state.OutboxStore).ListOutbox(ctx, after, limit)
+		return err
+	})
+
+	return entries, err
+}
+
+func (q storeQuerier) Query(ctx context.Context, req *state.QueryRequest) (res *state.QueryResponse, err error) {
+	err = q.s.do(ctx, "query", func(ctx context.Context) error {
+		res, err = q.s.Store.(state.Querier).Query(ctx, req)
+		return err
+	})
+
+	return res, err
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package telemetry contains decorators adding OpenTelemetry spans and metrics to components.
+// A decorator only implements the optional interfaces it instruments, and health.Pinger, when the decorated
+// component implements them. The other optional interfaces are available on the component returned by its
+// Unwrap method.
+package telemetry
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/JY29/components-contrib/telemetry"
+
+// Attribute keys set on spans and metrics.
+const (
+	ComponentNameKey     = attribute.Key("dapr.component.name")
+	ComponentTypeKey     = attribute.Key("dapr.component.type")
+	OperationKey         = attribute.Key("dapr.operation")
+	SuccessKey           = attribute.Key("dapr.success")
+	MessagingDestination = attribute.Key("messaging.destination.name")
+)
+
+// Options configures the instrumentation of a component.
+type Options struct {
+	// ComponentName is the name of the instrumented component.
+	ComponentName string
+	// ComponentType is the type of the instrumented component, such as "pubsub.kafka".
+	ComponentType string
+	// TracerProvider defaults to the global tracer provider.
+	TracerProvider trace.TracerProvider
+	// MeterProvider defaults to the global meter provider.
+	MeterProvider metric.MeterProvider
+	// Propagator is used to propagate the trace context through message metadata.
+	// It defaults to the global propagator.
+	Propagator propagation.TextMapPropagator
+}
+
+// instrumentation holds the tracer and the instruments shared by the decorators.
+type instrumentation struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	duration   instrument.Float64Histogram
+	attrs      []attribute.KeyValue
+}
+
+func newInstrumentation(opts Options, metricName string) (*instrumentation, error) {
+	if opts.TracerProvider == nil {
+		opts.TracerProvider = otel.GetTracerProvider()
+	}
+	if opts.MeterProvider == nil {
+		opts.MeterProvider = global.MeterProvider()
+	}
+	if opts.Propagator == nil {
+		opts.Propagator = otel.GetTextMapPropagator()
+	}
+
+	duration, err := opts.MeterProvider.Meter(instrumentationName).Float64Histogram(metricName,
+		instrument.WithUnit("ms"),
+		instrument.WithDescription("Duration of the component operations."),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &instrumentation{
+		tracer:     opts.TracerProvider.Tracer(instrumentationName),
+		propagator: opts.Propagator,
+		duration:   duration,
+		attrs: []attribute.KeyValue{
+			ComponentNameKey.String(opts.ComponentName),
+			ComponentTypeKey.String(opts.ComponentType),
+		},
+	}, nil
+}
+
+// start starts a span for an operation.
+func (i *instrumentation) start(ctx context.Context, name string, kind trace.SpanKind, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return i.tracer.Start(ctx, name,
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(i.attrs...),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// end ends the span of an operation and records its duration.
+func (i *instrumentation) end(ctx context.Context, span trace.Span, start time.Time, err error, attrs ...attribute.KeyValue) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+
+	metricAttrs := make([]attribute.KeyValue, 0, len(i.attrs)+len(attrs)+1)
+	metricAttrs = append(metricAttrs, i.attrs...)
+	metricAttrs = append(metricAttrs, attrs...)
+	metricAttrs = append(metricAttrs, SuccessKey.Bool(err == nil))
+	i.duration.Record(ctx, float64(time.Since(start))/float64(time.Millisecond), metricAttrs...)
+}
+
+// closeComponent closes a component, if it implements io.Closer.
+func closeComponent(component any) error {
+	if closer, ok := component.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
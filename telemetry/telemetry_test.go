@@ -0,0 +1,310 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/JY29/components-contrib/bindings"
+	"github.com/JY29/components-contrib/health"
+	"github.com/JY29/components-contrib/pubsub"
+	"github.com/JY29/components-contrib/state"
+	inmemory "github.com/JY29/components-contrib/state/in-memory"
+	"github.com/dapr/kit/logger"
+)
+
+type testTelemetry struct {
+	exporter *tracetest.InMemoryExporter
+	reader   sdkmetric.Reader
+	opts     Options
+}
+
+func newTestTelemetry(t *testing.T) *testTelemetry {
+	exporter := tracetest.NewInMemoryExporter()
+	reader := sdkmetric.NewManualReader()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	t.Cleanup(func() {
+		tp.Shutdown(context.Background())
+		mp.Shutdown(context.Background())
+	})
+
+	return &testTelemetry{
+		exporter: exporter,
+		reader:   reader,
+		opts: Options{
+			ComponentName:  "mycomponent",
+			ComponentType:  "test",
+			TracerProvider: tp,
+			MeterProvider:  mp,
+			Propagator:     propagation.TraceContext{},
+		},
+	}
+}
+
+// durationCount returns the number of durations recorded in the histogram with the given name.
+func (tt *testTelemetry) durationCount(t *testing.T, name string) uint64 {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, tt.reader.Collect(context.Background(), &rm))
+
+	var count uint64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Histogram).DataPoints {
+				count += dp.Count
+			}
+		}
+	}
+
+	return count
+}
+
+// loopbackPubSub delivers the published messages, with their metadata, to the subscribed handler.
+type loopbackPubSub struct {
+	handler pubsub.Handler
+}
+
+func (l *loopbackPubSub) Init(pubsub.Metadata) error { return nil }
+func (l *loopbackPubSub) Features() []pubsub.Feature { return nil }
+func (l *loopbackPubSub) Close() error               { return nil }
+func (l *loopbackPubSub) Subscribe(_ context.Context, _ pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	l.handler = handler
+	return nil
+}
+
+func (l *loopbackPubSub) Publish(ctx context.Context, req *pubsub.PublishRequest) error {
+	// Deliver on a new context, as brokers do
+	return l.handler(context.Background(), &pubsub.NewMessage{Topic: req.Topic, Data: req.Data, Metadata: req.Metadata})
+}
+
+func TestPubSub(t *testing.T) {
+	tt := newTestTelemetry(t)
+	ps, err := PubSub(&loopbackPubSub{}, tt.opts)
+	require.NoError(t, err)
+
+	var handlerSpan trace.SpanContext
+	err = ps.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "orders"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		handlerSpan = trace.SpanContextFromContext(ctx)
+		return errors.New("failed")
+	})
+	require.NoError(t, err)
+
+	metadata := map[string]string{"foo": "bar"}
+	err = ps.Publish(context.Background(), &pubsub.PublishRequest{Topic: "orders", Metadata: metadata})
+	require.Error(t, err)
+	assert.Equal(t, map[string]string{"foo": "bar"}, metadata, "the metadata of the request must not be modified")
+
+	spans := tt.exporter.GetSpans()
+	require.Len(t, spans, 2)
+	process, publish := spans[0], spans[1]
+	assert.Equal(t, "orders publish", publish.Name)
+	assert.Equal(t, trace.SpanKindProducer, publish.SpanKind)
+	assert.Equal(t, "orders process", process.Name)
+	assert.Equal(t, trace.SpanKindConsumer, process.SpanKind)
+	assert.Equal(t, codes.Error, process.Status.Code)
+
+	// The trace context was propagated through the message metadata
+	assert.Equal(t, publish.SpanContext.TraceID(), process.SpanContext.TraceID())
+	assert.Equal(t, publish.SpanContext.SpanID(), process.Parent.SpanID())
+	assert.Equal(t, process.SpanContext.SpanID(), handlerSpan.SpanID())
+	assert.Contains(t, publish.Attributes, ComponentNameKey.String("mycomponent"))
+	assert.Contains(t, publish.Attributes, MessagingDestination.String("orders"))
+
+	assert.Equal(t, uint64(2), tt.durationCount(t, "dapr.component.pubsub.duration"))
+}
+
+// bulkLoopbackPubSub is a loopbackPubSub which implements health.Pinger and pubsub.BulkPublisher too.
+type bulkLoopbackPubSub struct {
+	loopbackPubSub
+}
+
+func (l *bulkLoopbackPubSub) Ping() error { return nil }
+func (l *bulkLoopbackPubSub) BulkPublish(ctx context.Context, req *pubsub.BulkPublishRequest) (pubsub.BulkPublishResponse, error) {
+	for _, entry := range req.Entries {
+		if err := l.handler(context.Background(), &pubsub.NewMessage{Topic: req.Topic, Data: entry.Event, Metadata: entry.Metadata}); err != nil {
+			return pubsub.NewBulkPublishResponse(req.Entries, err), err
+		}
+	}
+
+	return pubsub.BulkPublishResponse{}, nil
+}
+
+func TestPubSubInterfaces(t *testing.T) {
+	tt := newTestTelemetry(t)
+
+	t.Run("only the interfaces of the pubsub are implemented", func(t *testing.T) {
+		inner := &loopbackPubSub{}
+		ps, err := PubSub(inner, tt.opts)
+		require.NoError(t, err)
+		_, isPinger := ps.(health.Pinger)
+		assert.False(t, isPinger)
+		_, isBulkPublisher := ps.(pubsub.BulkPublisher)
+		assert.False(t, isBulkPublisher)
+		_, isBulkSubscriber := ps.(pubsub.BulkSubscriber)
+		assert.False(t, isBulkSubscriber)
+		assert.Same(t, inner, ps.(interface{ Unwrap() pubsub.PubSub }).Unwrap())
+	})
+
+	t.Run("bulk publish is instrumented", func(t *testing.T) {
+		ps, err := PubSub(&bulkLoopbackPubSub{}, tt.opts)
+		require.NoError(t, err)
+		require.NoError(t, pubsub.Ping(ps))
+		_, isBulkSubscriber := ps.(pubsub.BulkSubscriber)
+		assert.False(t, isBulkSubscriber)
+
+		var traceParents []string
+		err = ps.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "orders"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+			traceParents = append(traceParents, msg.Metadata["traceparent"])
+			return nil
+		})
+		require.NoError(t, err)
+
+		entries := []pubsub.BulkMessageEntry{{EntryId: "1"}, {EntryId: "2", Metadata: map[string]string{"foo": "bar"}}}
+		_, err = ps.(pubsub.BulkPublisher).BulkPublish(context.Background(), &pubsub.BulkPublishRequest{Topic: "orders", Entries: entries})
+		require.NoError(t, err)
+		assert.Nil(t, entries[0].Metadata, "the metadata of the request must not be modified")
+		assert.Equal(t, map[string]string{"foo": "bar"}, entries[1].Metadata, "the metadata of the request must not be modified")
+		require.Len(t, traceParents, 2)
+		assert.NotEmpty(t, traceParents[0])
+		assert.Equal(t, traceParents[0], traceParents[1])
+	})
+}
+
+func TestStateStore(t *testing.T) {
+	tt := newTestTelemetry(t)
+	inner := inmemory.NewInMemoryStateStore(logger.NewLogger("test"))
+	require.NoError(t, inner.Init(state.Metadata{}))
+	store, err := StateStore(inner, tt.opts)
+	require.NoError(t, err)
+	defer store.(interface{ Close() error }).Close()
+
+	ctx := context.Background()
+	require.NoError(t, store.Set(ctx, &state.SetRequest{Key: "a", Value: "1"}))
+	res, err := store.Get(ctx, &state.GetRequest{Key: "a"})
+	require.NoError(t, err)
+	assert.Equal(t, `"1"`, string(res.Data))
+	require.NoError(t, store.(state.TransactionalStore).Multi(ctx, &state.TransactionalStateRequest{
+		Operations: []state.TransactionalStateOperation{{
+			Operation: state.Delete,
+			Request:   state.DeleteRequest{Key: "a"},
+		}},
+	}))
+	_, isQuerier := store.(state.Querier)
+	assert.False(t, isQuerier)
+
+	spans := tt.exporter.GetSpans()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "state/set", spans[0].Name)
+	assert.Equal(t, "state/get", spans[1].Name)
+	assert.Equal(t, "state/multi", spans[2].Name)
+	assert.Contains(t, spans[2].Attributes, OperationKey.String("multi"))
+
+	assert.Equal(t, uint64(3), tt.durationCount(t, "dapr.component.state.duration"))
+}
+
+// querierStore is an in-memory store which implements health.Pinger and state.Querier too.
+type querierStore struct {
+	state.Store
+}
+
+func (q *querierStore) Ping() error { return nil }
+func (q *querierStore) Query(context.Context, *state.QueryRequest) (*state.QueryResponse, error) {
+	return &state.QueryResponse{}, nil
+}
+
+func TestStateStoreInterfaces(t *testing.T) {
+	tt := newTestTelemetry(t)
+	inner := inmemory.NewInMemoryStateStore(logger.NewLogger("test"))
+	require.NoError(t, inner.Init(state.Metadata{}))
+	defer inner.(interface{ Close() error }).Close()
+
+	t.Run("only the interfaces of the store are implemented", func(t *testing.T) {
+		// Embedding state.Store hides the optional interfaces of the in-memory store
+		basic := struct{ state.Store }{inner}
+		store, err := StateStore(basic, tt.opts)
+		require.NoError(t, err)
+		assert.EqualError(t, state.Ping(store), state.Ping(basic).Error())
+		_, isTransactional := store.(state.TransactionalStore)
+		assert.False(t, isTransactional)
+		_, isQuerier := store.(state.Querier)
+		assert.False(t, isQuerier)
+		assert.Equal(t, basic, store.(interface{ Unwrap() state.Store }).Unwrap())
+	})
+
+	t.Run("outbox store", func(t *testing.T) {
+		store, err := StateStore(inner, tt.opts)
+		require.NoError(t, err)
+		outbox, ok := store.(state.OutboxStore)
+		require.True(t, ok)
+		_, err = outbox.ListOutbox(context.Background(), "", 10)
+		require.NoError(t, err)
+	})
+
+	t.Run("pinger and querier", func(t *testing.T) {
+		store, err := StateStore(&querierStore{inner}, tt.opts)
+		require.NoError(t, err)
+		require.NoError(t, state.Ping(store))
+		_, err = store.(state.Querier).Query(context.Background(), &state.QueryRequest{})
+		require.NoError(t, err)
+		_, isTransactional := store.(state.TransactionalStore)
+		assert.False(t, isTransactional)
+	})
+}
+
+type fakeOutputBinding struct {
+	err error
+}
+
+func (f *fakeOutputBinding) Init(bindings.Metadata) error { return nil }
+func (f *fakeOutputBinding) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{bindings.CreateOperation}
+}
+
+func (f *fakeOutputBinding) Invoke(context.Context, *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	return &bindings.InvokeResponse{Data: []byte("ok")}, f.err
+}
+
+func TestOutputBinding(t *testing.T) {
+	tt := newTestTelemetry(t)
+	binding, err := OutputBinding(&fakeOutputBinding{err: errors.New("failed")}, tt.opts)
+	require.NoError(t, err)
+
+	_, err = binding.Invoke(context.Background(), &bindings.InvokeRequest{Operation: bindings.CreateOperation})
+	require.Error(t, err)
+	_, isPinger := binding.(health.Pinger)
+	assert.False(t, isPinger)
+
+	spans := tt.exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "bindings/create", spans[0].Name)
+	assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+
+	assert.Equal(t, uint64(1), tt.durationCount(t, "dapr.component.bindings.duration"))
+}
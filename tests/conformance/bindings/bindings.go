@@ -15,6 +15,7 @@ package bindings
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"strconv"
@@ -23,6 +24,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/JY29/components-contrib/bindings"
 	"github.com/JY29/components-contrib/metadata"
@@ -34,6 +36,8 @@ import (
 const (
 	defaultTimeoutDuration = 60 * time.Second
 	defaultWaitDuration    = time.Second
+	// The maximum number of pages requested by the list test.
+	maxListPages = 20
 
 	// Use CloudEvent as default data because it is required by Azure's EventGrid.
 	defaultOutputData = "[{\"eventType\":\"test\",\"eventTime\": \"2018-01-25T22:12:19.4556811Z\",\"subject\":\"dapr-conf-tests\",\"id\":\"A234-1234-1234\",\"data\":\"root/>\"}]"
@@ -245,10 +249,38 @@ func ConformanceTests(t *testing.T, props map[string]string, inputBinding bindin
 	if config.HasOperation(string(bindings.ListOperation)) {
 		t.Run("list", func(t *testing.T) {
 			testLogger.Info("List test running ...")
-			req := config.createInvokeRequest()
-			req.Operation = bindings.ListOperation
-			_, err := outputBinding.Invoke(context.Background(), &req)
-			assert.NoError(t, err, "expected no error invoking output binding")
+			// The pages of a single item are listed until the last one, or until the maximum number of pages of the test
+			listReq := bindings.ListRequest{PageSize: 1}
+			items := 0
+			for page := 0; page < maxListPages; page++ {
+				data, err := json.Marshal(listReq)
+				require.NoError(t, err)
+				req := bindings.InvokeRequest{
+					Operation: bindings.ListOperation,
+					Data:      data,
+					Metadata:  map[string]string{},
+				}
+				resp, err := outputBinding.Invoke(context.Background(), &req)
+				require.NoError(t, err, "expected no error invoking output binding")
+				require.NotNil(t, resp)
+
+				var listResp bindings.ListResponse
+				require.NoError(t, json.Unmarshal(resp.Data, &listResp), "expected a list response")
+				assert.LessOrEqual(t, len(listResp.Items)+len(listResp.Prefixes), 1, "expected the page size to be honored")
+				for _, item := range listResp.Items {
+					assert.NotEmpty(t, item.Name)
+				}
+				items += len(listResp.Items)
+
+				if listResp.ContinuationToken == "" {
+					break
+				}
+				assert.NotEqual(t, listReq.ContinuationToken, listResp.ContinuationToken, "expected the continuation token to advance")
+				listReq.ContinuationToken = listResp.ContinuationToken
+			}
+			if createPerformed {
+				assert.Greater(t, items, 0, "expected the created object to be listed")
+			}
 			testLogger.Info("List test done.")
 		})
 	}
@@ -52,6 +52,7 @@ import (
 	b_influx "github.com/JY29/components-contrib/bindings/influx"
 	b_kafka "github.com/JY29/components-contrib/bindings/kafka"
 	b_kubemq "github.com/JY29/components-contrib/bindings/kubemq"
+	b_localstorage "github.com/JY29/components-contrib/bindings/localstorage"
 	b_mqtt "github.com/JY29/components-contrib/bindings/mqtt"
 	b_postgres "github.com/JY29/components-contrib/bindings/postgres"
 	b_rabbitmq "github.com/JY29/components-contrib/bindings/rabbitmq"
@@ -564,6 +565,8 @@ func loadOutputBindings(tc TestComponent) bindings.OutputBinding {
 		binding = b_kubemq.NewKubeMQ(testLogger)
 	case "postgres":
 		binding = b_postgres.NewPostgres(testLogger)
+	case "localstorage":
+		binding = b_localstorage.NewLocalStorage(testLogger)
 	default:
 		return nil
 	}
@@ -593,6 +596,8 @@ func loadInputBindings(tc TestComponent) bindings.InputBinding {
 		binding = b_rabbitmq.NewRabbitMQ(testLogger)
 	case "kubemq":
 		binding = b_kubemq.NewKubeMQ(testLogger)
+	case "localstorage":
+		binding = b_localstorage.NewLocalStorage(testLogger)
 	default:
 		return nil
 	}